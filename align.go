@@ -0,0 +1,159 @@
+package textsimilarity
+
+import "context"
+
+// alignOccurrences expands the two occurrences in occs downward across gaps of inserted or deleted lines, if
+// Options.MaxGapLines > 0. When expandOccurrences stops at lines that differ, the lines following the occurrences
+// are aligned using their longest common subsequence of similar lines, and the occurrences are expanded to the
+// last aligned pair of lines, as long as no gap exceeds Options.MaxGapLines lines and more lines are aligned than
+// skipped. Expansion then continues using expandOccurrences. The returned level is at most SimilarSimilarityLevel
+// if any gap was bridged. Similarities with more than two occurrences are not aligned.
+func alignOccurrences(ctx context.Context, occs []*FileOccurrence, level SimilarityLevel, opts *Options) SimilarityLevel {
+	if opts.MaxGapLines <= 0 || len(occs) != 2 {
+		return level
+	}
+
+	for !contextDone(ctx) {
+		bridgeLevel, ok := bridgeGap(occs, opts)
+		if !ok {
+			break
+		}
+
+		level = min(level, bridgeLevel, SimilarSimilarityLevel)
+		level = expandOccurrences(ctx, occs, level, opts)
+	}
+
+	return level
+}
+
+// bridgeGap expands the two occurrences in occs to the last pair of lines of an alignment of the lines following
+// them, as described in alignOccurrences. It returns the lowest level of the aligned lines, and whether the
+// occurrences were expanded.
+func bridgeGap(occs []*FileOccurrence, opts *Options) (SimilarityLevel, bool) {
+	window := 4 * opts.MaxGapLines
+
+	lines1 := alignmentWindow(occs[0], occs, window, opts)
+	lines2 := alignmentWindow(occs[1], occs, window, opts)
+
+	if len(lines1) == 0 || len(lines2) == 0 {
+		return differentSimilarityLevel, false
+	}
+
+	levels := make([][]SimilarityLevel, len(lines1))
+	for idx1, lineIdx1 := range lines1 {
+		levels[idx1] = make([]SimilarityLevel, len(lines2))
+
+		for idx2, lineIdx2 := range lines2 {
+			levels[idx1][idx2] = linesSimilarity(occs[0].fileToCheck.f.lines[lineIdx1], occs[1].fileToCheck.f.lines[lineIdx2], opts)
+		}
+	}
+
+	prev1 := -1
+	prev2 := -1
+	aligned := 0
+	skipped := 0
+	level := EqualSimilarityLevel
+	bridged := false
+	end1 := 0
+	end2 := 0
+	endLevel := level
+
+	for _, pair := range longestCommonSubsequence(levels) {
+		gap1 := pair[0] - prev1 - 1
+		gap2 := pair[1] - prev2 - 1
+
+		if gap1 > opts.MaxGapLines || gap2 > opts.MaxGapLines {
+			break
+		}
+
+		prev1 = pair[0]
+		prev2 = pair[1]
+		aligned++
+		skipped += max(gap1, gap2)
+		level = min(level, levels[pair[0]][pair[1]])
+
+		if aligned > skipped {
+			bridged = true
+			end1 = lines1[pair[0]] + 1
+			end2 = lines2[pair[1]] + 1
+			endLevel = level
+		}
+	}
+
+	if !bridged {
+		return differentSimilarityLevel, false
+	}
+
+	for idx, end := range []int{end1, end2} {
+		occ := occs[idx]
+
+		for l := occ.End; l < end; l++ {
+			occ.fileToCheck.linesDone.set(l, true)
+		}
+
+		occ.End = end
+	}
+
+	return endLevel, true
+}
+
+// alignmentWindow returns the indexes of up to size lines following occ that are considered for similarities,
+// according to opts. It stops at lines that are done, out of occ's scope, or part of any occurrence in occs.
+func alignmentWindow(occ *FileOccurrence, occs []*FileOccurrence, size int, opts *Options) []int {
+	lines := []int{}
+
+	for lineIdx := occ.End; lineIdx < len(occ.fileToCheck.f.lines) && len(lines) < size; lineIdx++ {
+		if occ.fileToCheck.linesDone.isSet(lineIdx) || !occ.fileToCheck.f.sameScope(occ.Start, lineIdx) ||
+			lineInOccurrences(occ.fileToCheck.f, lineIdx, occs) {
+			break
+		}
+
+		if acceptLine(occ.fileToCheck.f.lines[lineIdx], opts) {
+			lines = append(lines, lineIdx)
+		}
+	}
+
+	return lines
+}
+
+// longestCommonSubsequence returns the pairs of indexes of the longest common subsequence of two sequences, in
+// order, where levels holds the similarity levels of all pairs of their elements. Earlier pairs are preferred.
+func longestCommonSubsequence(levels [][]SimilarityLevel) [][2]int {
+	len1 := len(levels)
+	len2 := len(levels[0])
+
+	// lengths[idx1][idx2] is the length of the longest common subsequence of the suffixes starting at idx1 and idx2
+	lengths := make([][]int, len1+1)
+	for idx1 := range lengths {
+		lengths[idx1] = make([]int, len2+1)
+	}
+
+	for idx1 := len1 - 1; idx1 >= 0; idx1-- {
+		for idx2 := len2 - 1; idx2 >= 0; idx2-- {
+			if levels[idx1][idx2] != differentSimilarityLevel {
+				lengths[idx1][idx2] = lengths[idx1+1][idx2+1] + 1
+			} else {
+				lengths[idx1][idx2] = max(lengths[idx1+1][idx2], lengths[idx1][idx2+1])
+			}
+		}
+	}
+
+	pairs := [][2]int{}
+
+	for idx1, idx2 := 0, 0; idx1 < len1 && idx2 < len2; {
+		switch {
+		case levels[idx1][idx2] != differentSimilarityLevel:
+			pairs = append(pairs, [2]int{idx1, idx2})
+			idx1++
+			idx2++
+
+		case lengths[idx1+1][idx2] >= lengths[idx1][idx2+1]:
+			idx1++
+
+		default:
+			idx2++
+		}
+	}
+
+	return pairs
+}
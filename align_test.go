@@ -0,0 +1,76 @@
+package textsimilarity
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestSimilarities_MaxGapLines(t *testing.T) {
+	lines := make([]string, 14)
+	for idx := range lines {
+		lines[idx] = fmt.Sprintf("%x", sha256.Sum256([]byte(fmt.Sprintf("line %d", idx))))
+	}
+
+	text := func(lines []string) string {
+		return strings.Join(lines, "\n") + "\n"
+	}
+
+	// b.txt has a line inserted after line 4, and lines 8-9 deleted
+	linesB := append(append(append([]string{}, lines[:5]...), "inserted"), lines[5:8]...)
+	linesB = append(linesB, lines[10:]...)
+
+	newFiles := func() []*File {
+		return []*File{
+			newFile("a.txt", text(lines)),
+			newFile("b.txt", text(linesB)),
+		}
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		is := is.New(t)
+
+		sims := similaritiesWithOptions(t, newFiles(), &Options{MinSimilarLines: 8})
+		is.Equal(len(sims), 0)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		is := is.New(t)
+
+		sims := similaritiesWithOptions(t, newFiles(), &Options{MinSimilarLines: 8, MaxGapLines: 2})
+		is.Equal(len(sims), 1)
+		is.Equal(sims[0].Level, SimilarSimilarityLevel)
+		is.Equal(sims[0].Occurrences[0].Start, 0)
+		is.Equal(sims[0].Occurrences[0].End, 14)
+		is.Equal(sims[0].Occurrences[1].Start, 0)
+		is.Equal(sims[0].Occurrences[1].End, 13)
+		is.Equal(sims[0].LineLevels, nil)
+	})
+
+	t.Run("gap too large", func(t *testing.T) {
+		is := is.New(t)
+
+		sims := similaritiesWithOptions(t, newFiles(), &Options{MinSimilarLines: 5, MaxGapLines: 1})
+		is.Equal(len(sims), 1)
+		is.Equal(sims[0].Occurrences[0].End, 8)
+		is.Equal(sims[0].Occurrences[1].End, 9)
+	})
+}
+
+func TestLongestCommonSubsequence(t *testing.T) {
+	is := is.New(t)
+
+	d := differentSimilarityLevel
+	e := EqualSimilarityLevel
+
+	levels := [][]SimilarityLevel{
+		{d, e, d, d},
+		{d, d, d, e},
+		{e, d, e, d},
+	}
+
+	is.Equal(longestCommonSubsequence(levels), [][2]int{{0, 1}, {2, 2}})
+}
@@ -0,0 +1,249 @@
+// Package analyzer provides an analysis.Analyzer that reports similar blocks of lines in the Go files of a
+// package, so that similarities can be found using go vet-style drivers and golangci-lint.
+package analyzer
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/blizzy78/textsimilarity"
+	"golang.org/x/tools/go/analysis"
+)
+
+// doc is the documentation of the analyzer.
+const doc = `report similar blocks of lines
+
+The textsimilarity analyzer finds blocks of lines that are exactly equal or similar within the Go files
+of a package. A diagnostic is reported at the first line of each occurrence of a block. Similarities
+across packages are not found.`
+
+// defaultMinSimilarLines is the minimum number of similar lines used by default, matching the default of
+// the command line tool.
+const defaultMinSimilarLines = 10
+
+// Analyzer reports similar blocks of lines, using the defaults of the command line tool unless changed
+// using its flags.
+var Analyzer = New()
+
+// config is the configuration of an analyzer, set using its flags.
+type config struct {
+	minLineLength   int
+	minSimilarLines int
+	maxEditDistance int
+	ignoreWS        bool
+	ignoreBlank     bool
+	goFunctions     bool
+	skipGenerated   bool
+}
+
+// New returns a new analyzer that reports similar blocks of lines, using the defaults of the command line tool
+// unless changed using its flags. Each analyzer has its own flags.
+func New() *analysis.Analyzer {
+	cfg := config{
+		minSimilarLines: defaultMinSimilarLines,
+		maxEditDistance: textsimilarity.DefaultMaxEditDistance,
+		skipGenerated:   true,
+	}
+
+	flags := flag.NewFlagSet("textsimilarity", flag.ExitOnError)
+	flags.IntVar(&cfg.minLineLength, "minLen", cfg.minLineLength, "minimum line length")
+	flags.IntVar(&cfg.minSimilarLines, "minLines", cfg.minSimilarLines, "minimum similar lines")
+	flags.IntVar(&cfg.maxEditDistance, "maxDist", cfg.maxEditDistance, "maximum edit distance")
+	flags.BoolVar(&cfg.ignoreWS, "ignoreWS", cfg.ignoreWS, "ignore whitespace")
+	flags.BoolVar(&cfg.ignoreBlank, "ignoreBlank", cfg.ignoreBlank, "ignore blank lines")
+	flags.BoolVar(&cfg.goFunctions, "go-functions", cfg.goFunctions, "only find similarities within bodies of functions")
+	flags.BoolVar(&cfg.skipGenerated, "skip-generated", cfg.skipGenerated, "skip generated files")
+
+	return &analysis.Analyzer{
+		Name:  "textsimilarity",
+		Doc:   doc,
+		Flags: *flags,
+		Run:   cfg.run,
+	}
+}
+
+// run implements analysis.Analyzer.Run.
+func (c *config) run(pass *analysis.Pass) (any, error) {
+	files, tokenFiles, err := c.files(pass)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(files) == 0 {
+		return nil, nil //nolint:nilnil // the analyzer has no result
+	}
+
+	sims, err := similarities(files, c.options())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sim := range sims {
+		for idx := range sim.Occurrences {
+			report(pass, sim, idx, tokenFiles)
+		}
+	}
+
+	return nil, nil //nolint:nilnil // the analyzer has no result
+}
+
+// files returns files for the Go files of pass, as well as the token files for them.
+func (c *config) files(pass *analysis.Pass) ([]*textsimilarity.File, map[*textsimilarity.File]*token.File, error) {
+	readFile := pass.ReadFile
+	if readFile == nil {
+		readFile = os.ReadFile
+	}
+
+	files := make([]*textsimilarity.File, 0, len(pass.Files))
+	tokenFiles := make(map[*textsimilarity.File]*token.File, len(pass.Files))
+
+	for _, astFile := range pass.Files {
+		if c.skipGenerated && ast.IsGenerated(astFile) {
+			continue
+		}
+
+		tokenFile := pass.Fset.File(astFile.Pos())
+		if tokenFile == nil {
+			continue
+		}
+
+		content, err := readFile(tokenFile.Name())
+		if err != nil {
+			return nil, nil, fmt.Errorf("read %s: %w", tokenFile.Name(), err)
+		}
+
+		file := textsimilarity.File{
+			Name: tokenFile.Name(),
+			R:    bytes.NewReader(content),
+		}
+
+		files = append(files, &file)
+		tokenFiles[&file] = tokenFile
+	}
+
+	return files, tokenFiles, nil
+}
+
+// options returns similarity options according to c.
+func (c *config) options() *textsimilarity.Options {
+	opts := textsimilarity.Options{
+		MinLineLength:   c.minLineLength,
+		MinSimilarLines: c.minSimilarLines,
+		MaxEditDistance: c.maxEditDistance,
+	}
+
+	if c.ignoreWS {
+		opts.Flags |= textsimilarity.IgnoreWhitespaceFlag
+	}
+
+	if c.ignoreBlank {
+		opts.Flags |= textsimilarity.IgnoreBlankLinesFlag
+	}
+
+	if c.goFunctions {
+		opts.Flags |= textsimilarity.GoFunctionsFlag
+	}
+
+	return &opts
+}
+
+// similarities returns all similarities in files according to opts.
+func similarities(files []*textsimilarity.File, opts *textsimilarity.Options) ([]*textsimilarity.Similarity, error) {
+	simsCh, progressCh, err := textsimilarity.Similarities(context.Background(), files, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	grp := sync.WaitGroup{}
+	grp.Add(2)
+
+	var progressErr error
+
+	go func() {
+		defer grp.Done()
+
+		for p := range progressCh {
+			if p.Err != nil && progressErr == nil {
+				progressErr = fmt.Errorf("scan %s: %w", p.File.Name, p.Err)
+			}
+		}
+	}()
+
+	sims := []*textsimilarity.Similarity{}
+
+	go func() {
+		defer grp.Done()
+
+		for sim := range simsCh {
+			sims = append(sims, sim)
+		}
+	}()
+
+	grp.Wait()
+
+	if progressErr != nil {
+		return nil, progressErr
+	}
+
+	return sims, nil
+}
+
+// report reports a diagnostic at the first line of the occurrence of sim at index occIdx. The other
+// occurrences of sim are reported as related information.
+func report(pass *analysis.Pass, sim *textsimilarity.Similarity, occIdx int, tokenFiles map[*textsimilarity.File]*token.File) {
+	occ := sim.Occurrences[occIdx]
+
+	pos, ok := linePos(tokenFiles[occ.File], occ.Start)
+	if !ok {
+		return
+	}
+
+	others := make([]string, 0, len(sim.Occurrences)-1)
+	related := make([]analysis.RelatedInformation, 0, len(sim.Occurrences)-1)
+
+	for idx, other := range sim.Occurrences {
+		if idx == occIdx {
+			continue
+		}
+
+		others = append(others, filepath.Base(other.File.Name)+":"+lineRange(other))
+
+		if otherPos, ok := linePos(tokenFiles[other.File], other.Start); ok {
+			related = append(related, analysis.RelatedInformation{Pos: otherPos, Message: "other occurrence"})
+		}
+	}
+
+	pass.Report(analysis.Diagnostic{
+		Pos:      pos,
+		Category: "similarity",
+		Message: fmt.Sprintf("%d lines %s to %s",
+			occ.End-occ.Start, sim.Level.String(), strings.Join(others, ", ")),
+		Related: related,
+	})
+}
+
+// linePos returns the position of the start of line lineIdx (zero-based) in tokenFile.
+func linePos(tokenFile *token.File, lineIdx int) (token.Pos, bool) {
+	if tokenFile == nil || lineIdx >= tokenFile.LineCount() {
+		return token.NoPos, false
+	}
+
+	return tokenFile.LineStart(lineIdx + 1), true
+}
+
+// lineRange returns a human-readable line range of occ, with one-based line numbers.
+func lineRange(occ *textsimilarity.FileOccurrence) string {
+	if occ.End == occ.Start+1 {
+		return fmt.Sprintf("%d", occ.Start+1)
+	}
+
+	return fmt.Sprintf("%d-%d", occ.Start+1, occ.End)
+}
@@ -0,0 +1,122 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	"golang.org/x/tools/go/analysis"
+)
+
+func TestAnalyzer(t *testing.T) {
+	is := is.New(t)
+
+	body := strings.Repeat("\tx++\n\tx *= 2\n", 5)
+
+	diags := analyze(t, New(), map[string]string{
+		"a.go": "package p\n\nfunc a(x int) int {\n" + body + "\treturn x\n}\n",
+		"b.go": "package p\n\n// b does things.\nfunc b(x int) int {\n" + body + "\treturn x\n}\n",
+	})
+
+	is.Equal(len(diags), 2)
+
+	is.Equal(diags[0].Position.Line, 3)
+	is.True(strings.HasSuffix(diags[0].Position.Filename, "a.go"))
+	is.Equal(diags[0].Message, "13 lines similar to b.go:4-16")
+
+	is.Equal(diags[1].Position.Line, 4)
+	is.True(strings.HasSuffix(diags[1].Position.Filename, "b.go"))
+	is.Equal(diags[1].Message, "13 lines similar to a.go:3-15")
+
+	is.Equal(diags[0].Related, []token.Position{diags[1].Position})
+}
+
+func TestAnalyzer_Generated(t *testing.T) {
+	is := is.New(t)
+
+	body := strings.Repeat("\tx++\n\tx *= 2\n", 5)
+
+	diags := analyze(t, New(), map[string]string{
+		"a.go": "package p\n\nfunc a(x int) int {\n" + body + "\treturn x\n}\n",
+		"b.go": "// Code generated by foo. DO NOT EDIT.\n\npackage p\n\nfunc b(x int) int {\n" + body + "\treturn x\n}\n",
+	})
+
+	is.Equal(len(diags), 0)
+}
+
+// A diagnostic is a reported analysis.Diagnostic, with resolved positions.
+type diagnostic struct {
+	analysis.Diagnostic
+
+	Position token.Position
+	Related  []token.Position
+}
+
+// analyze runs analyzer on a package consisting of files, in order of file names, and returns all
+// diagnostics reported, sorted by position.
+func analyze(t *testing.T, analyzer *analysis.Analyzer, files map[string]string) []*diagnostic {
+	t.Helper()
+
+	dir := t.TempDir()
+	fset := token.NewFileSet()
+	astFiles := []*ast.File{}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+
+		if err := os.WriteFile(path, []byte(files[name]), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		astFile, err := parser.ParseFile(fset, path, files[name], parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		astFiles = append(astFiles, astFile)
+	}
+
+	diags := []*diagnostic{}
+
+	pass := analysis.Pass{
+		Analyzer: analyzer,
+		Fset:     fset,
+		Files:    astFiles,
+		ReadFile: os.ReadFile,
+		Report: func(d analysis.Diagnostic) {
+			diag := diagnostic{
+				Diagnostic: d,
+				Position:   fset.Position(d.Pos),
+			}
+
+			for _, rel := range d.Related {
+				diag.Related = append(diag.Related, fset.Position(rel.Pos))
+			}
+
+			diags = append(diags, &diag)
+		},
+	}
+
+	if _, err := analyzer.Run(&pass); err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Slice(diags, func(a int, b int) bool {
+		return diags[a].Position.String() < diags[b].Position.String()
+	})
+
+	return diags
+}
@@ -0,0 +1,13 @@
+// Command textsimilarity-vet reports similar blocks of lines in Go packages. It is meant to be run by go vet:
+//
+//	go vet -vettool=$(which textsimilarity-vet) ./...
+package main
+
+import (
+	"github.com/blizzy78/textsimilarity/analyzer"
+	"golang.org/x/tools/go/analysis/unitchecker"
+)
+
+func main() {
+	unitchecker.Main(analyzer.Analyzer)
+}
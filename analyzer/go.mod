@@ -0,0 +1,16 @@
+module github.com/blizzy78/textsimilarity/analyzer
+
+go 1.22.0
+
+require (
+	github.com/blizzy78/textsimilarity v0.0.0
+	github.com/matryer/is v1.4.1
+	golang.org/x/tools v0.28.0
+)
+
+require (
+	github.com/agext/levenshtein v1.2.3 // indirect
+	github.com/dropbox/godropbox v0.0.0-20230623171840-436d2007a9fd // indirect
+)
+
+replace github.com/blizzy78/textsimilarity => ../
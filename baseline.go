@@ -0,0 +1,146 @@
+package textsimilarity
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// baselineVersion is the version of the baseline file format.
+const baselineVersion = 1
+
+// errBaselineVersion is returned when reading a baseline with an unsupported version.
+var errBaselineVersion = errors.New("unsupported baseline version")
+
+// A Baseline is a set of known similarities, identified by their IDs. It can be used to only report
+// similarities that have been introduced after the baseline was created.
+type Baseline struct {
+	// counts maps similarity IDs to the number of known similarities with that ID.
+	counts map[string]int
+
+	// entries are the known similarities, for reference.
+	entries []*baselineEntry
+}
+
+// baselineFile is the persisted form of a Baseline.
+type baselineFile struct {
+	Version      int              `json:"version"`
+	Similarities []*baselineEntry `json:"similarities"`
+}
+
+// baselineEntry is a single known similarity in a baselineFile. Only ID is used for matching, all other fields
+// are informational.
+type baselineEntry struct {
+	ID    string   `json:"id"`
+	Level string   `json:"level"`
+	Lines int      `json:"lines"`
+	Files []string `json:"files"`
+}
+
+// NewBaseline returns a new Baseline containing sims. sims must have been returned by Similarities.
+func NewBaseline(sims []*Similarity) *Baseline {
+	base := Baseline{
+		counts:  map[string]int{},
+		entries: make([]*baselineEntry, 0, len(sims)),
+	}
+
+	for _, sim := range sims {
+		entry := baselineEntry{
+			ID:    sim.ID(),
+			Level: "equal",
+			Lines: sim.Occurrences[0].End - sim.Occurrences[0].Start,
+			Files: make([]string, len(sim.Occurrences)),
+		}
+
+		switch sim.Level {
+		case SimilarSimilarityLevel:
+			entry.Level = "similar"
+		case ReorderedSimilarityLevel:
+			entry.Level = "reordered"
+		}
+
+		for idx, occ := range sim.Occurrences {
+			entry.Files[idx] = occ.File.Name
+		}
+
+		base.add(&entry)
+	}
+
+	sort.SliceStable(base.entries, func(a int, b int) bool {
+		return base.entries[a].ID < base.entries[b].ID
+	})
+
+	return &base
+}
+
+// ReadBaseline reads a Baseline from r, which has previously been written using Baseline.Write.
+func ReadBaseline(r io.Reader) (*Baseline, error) {
+	file := baselineFile{}
+
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("decode baseline: %w", err)
+	}
+
+	if file.Version != baselineVersion {
+		return nil, fmt.Errorf("%w: %d", errBaselineVersion, file.Version)
+	}
+
+	base := Baseline{
+		counts: map[string]int{},
+	}
+
+	for _, entry := range file.Similarities {
+		base.add(entry)
+	}
+
+	return &base, nil
+}
+
+// Write writes b to w.
+func (b *Baseline) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	err := enc.Encode(&baselineFile{
+		Version:      baselineVersion,
+		Similarities: b.entries,
+	})
+
+	if err != nil {
+		return fmt.Errorf("encode baseline: %w", err)
+	}
+
+	return nil
+}
+
+// Len returns the number of similarities in b.
+func (b *Baseline) Len() int {
+	return len(b.entries)
+}
+
+// New returns all similarities in sims that are not contained in b. If b contains a similarity ID fewer times
+// than sims, the surplus similarities with that ID are returned. sims must have been returned by Similarities.
+func (b *Baseline) New(sims []*Similarity) []*Similarity {
+	seen := map[string]int{}
+	newSims := []*Similarity{}
+
+	for _, sim := range sims {
+		id := sim.ID()
+
+		seen[id]++
+
+		if seen[id] > b.counts[id] {
+			newSims = append(newSims, sim)
+		}
+	}
+
+	return newSims
+}
+
+// add adds entry to b.
+func (b *Baseline) add(entry *baselineEntry) {
+	b.counts[entry.ID]++
+	b.entries = append(b.entries, entry)
+}
@@ -0,0 +1,88 @@
+package textsimilarity
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestSimilarity_ID(t *testing.T) {
+	is := is.New(t)
+
+	sims := similarities(t, newFile("1.txt", "aaaaaaaaaa\nbbbbbbbbbb\n"), newFile("2.txt", "aaaaaaaaaa\nbbbbbbbbbb\n"))
+	is.Equal(len(sims), 1)
+	is.True(sims[0].ID() != "")
+
+	// moved within file
+	sims2 := similarities(t, newFile("1.txt", "xxxxxxxxxx\naaaaaaaaaa\nbbbbbbbbbb\n"), newFile("2.txt", "aaaaaaaaaa\nbbbbbbbbbb\n"))
+	is.Equal(len(sims2), 1)
+	is.Equal(sims2[0].ID(), sims[0].ID())
+
+	// different file
+	sims3 := similarities(t, newFile("1.txt", "aaaaaaaaaa\nbbbbbbbbbb\n"), newFile("3.txt", "aaaaaaaaaa\nbbbbbbbbbb\n"))
+	is.Equal(len(sims3), 1)
+	is.True(sims3[0].ID() != sims[0].ID())
+}
+
+func TestSimilarity_SetID(t *testing.T) {
+	is := is.New(t)
+
+	sims := similarities(t, newFile("1.txt", "aaaaaaaaaa\nbbbbbbbbbb\n"), newFile("2.txt", "aaaaaaaaaa\nbbbbbbbbbb\n"))
+	is.Equal(len(sims), 1)
+
+	restored := Similarity{Occurrences: sims[0].Occurrences, Level: sims[0].Level}
+	is.Equal(restored.ID(), "")
+
+	restored.SetID(sims[0].ID())
+	is.Equal(restored.ID(), sims[0].ID())
+}
+
+func TestBaseline(t *testing.T) {
+	is := is.New(t)
+
+	sims := similarities(t, newFile("1.txt", "aaaaaaaaaa\nbbbbbbbbbb\n"), newFile("2.txt", "aaaaaaaaaa\nbbbbbbbbbb\n"))
+
+	buf := bytes.Buffer{}
+	is.NoErr(NewBaseline(sims).Write(&buf))
+
+	base, err := ReadBaseline(&buf)
+	is.NoErr(err)
+	is.Equal(base.Len(), 1)
+
+	is.Equal(len(base.New(sims)), 0)
+
+	sims = similarities(t,
+		newFile("1.txt", "aaaaaaaaaa\nbbbbbbbbbb\nxxxxxxxxxx\ncccccccccc\ndddddddddd\n"),
+		newFile("2.txt", "aaaaaaaaaa\nbbbbbbbbbb\nyyyyyyyyyy\ncccccccccc\ndddddddddd\n"),
+	)
+
+	newSims := base.New(sims)
+	is.Equal(len(newSims), 1)
+	is.Equal(newSims[0].Occurrences[0].Start, 3)
+}
+
+func TestReadBaseline_Version(t *testing.T) {
+	is := is.New(t)
+
+	_, err := ReadBaseline(bytes.NewBufferString(`{"version":0}`))
+	is.True(err != nil)
+}
+
+func similarities(t *testing.T, files ...*File) []*Similarity {
+	t.Helper()
+
+	simsCh, progressCh, err := Similarities(context.Background(), files, &Options{MaxEditDistance: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sims []*Similarity
+
+	waitForAll(func() {
+		sims = readSimilaritiesChan(simsCh)
+	}, drainProgressChan(progressCh))
+
+	return sims
+}
@@ -0,0 +1,107 @@
+package textsimilarity
+
+const (
+	// bloomBitsPerElement is the number of bits used per element in a bloomFilter.
+	bloomBitsPerElement = 10
+
+	// bloomHashes is the number of hash functions used in a bloomFilter.
+	bloomHashes = 7
+
+	// fnvOffset64 is the offset basis of the 64-bit FNV-1a hash.
+	fnvOffset64 = 14695981039346656037
+
+	// fnvPrime64 is the prime of the 64-bit FNV-1a hash.
+	fnvPrime64 = 1099511628211
+)
+
+// A bloomFilter is a probabilistic set of hashes. It may report false positives, but never false negatives.
+type bloomFilter struct {
+	// bits is the bit array of the filter.
+	bits []uint64
+
+	// size is the number of bits in bits.
+	size uint64
+}
+
+// newBloomFilter returns a new empty bloomFilter suitable to hold n elements.
+func newBloomFilter(n int) *bloomFilter {
+	words := max(1, (n*bloomBitsPerElement+63)/64)
+
+	return &bloomFilter{
+		bits: make([]uint64, words),
+		size: uint64(words) * 64,
+	}
+}
+
+// add adds hash to b.
+func (b *bloomFilter) add(hash uint64) {
+	h1, h2 := hash&0xffffffff, hash>>32
+
+	for i := uint64(0); i < bloomHashes; i++ {
+		pos := (h1 + i*h2) % b.size
+		b.bits[pos/64] |= 1 << (pos % 64)
+	}
+}
+
+// mayContain returns whether b may contain hash. If it returns false, b definitely does not contain hash.
+func (b *bloomFilter) mayContain(hash uint64) bool {
+	h1, h2 := hash&0xffffffff, hash>>32
+
+	for i := uint64(0); i < bloomHashes; i++ {
+		pos := (h1 + i*h2) % b.size
+		if b.bits[pos/64]&(1<<(pos%64)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// buildLinesFilter sets up f.lineHashes and f.linesFilter with the hashes of all lines that are considered
+// for similarities, according to opts.
+func (f *File) buildLinesFilter(opts *Options) {
+	f.lineHashes = make([]uint64, 0, len(f.lines))
+
+	for idx := 0; idx < len(f.lines); idx++ {
+		line := f.lines[idx]
+		if !acceptLine(line, opts) {
+			continue
+		}
+
+		f.lineHashes = append(f.lineHashes, line.hash)
+	}
+
+	f.linesFilter = newBloomFilter(len(f.lineHashes))
+
+	for _, hash := range f.lineHashes {
+		f.linesFilter.add(hash)
+	}
+}
+
+// disjointFiles returns whether file1 and file2 definitely do not share any exactly equal lines.
+// Both files must have been set up using buildLinesFilter.
+func disjointFiles(file1 *File, file2 *File) bool {
+	if len(file1.lineHashes) > len(file2.lineHashes) {
+		file1, file2 = file2, file1
+	}
+
+	for _, hash := range file1.lineHashes {
+		if file2.linesFilter.mayContain(hash) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// hashString returns the 64-bit FNV-1a hash of s.
+func hashString(s string) uint64 {
+	hash := uint64(fnvOffset64)
+
+	for i := 0; i < len(s); i++ {
+		hash ^= uint64(s[i])
+		hash *= fnvPrime64
+	}
+
+	return hash
+}
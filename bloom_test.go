@@ -0,0 +1,76 @@
+package textsimilarity
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestBloomFilter(t *testing.T) {
+	is := is.New(t)
+
+	filter := newBloomFilter(100)
+
+	for i := 0; i < 100; i++ {
+		filter.add(hashString(strconv.Itoa(i)))
+	}
+
+	for i := 0; i < 100; i++ {
+		is.True(filter.mayContain(hashString(strconv.Itoa(i))))
+	}
+
+	falsePositives := 0
+
+	for i := 100; i < 1100; i++ {
+		if filter.mayContain(hashString(strconv.Itoa(i))) {
+			falsePositives++
+		}
+	}
+
+	is.True(falsePositives < 50)
+}
+
+func TestSimilarities_SkipDisjointFiles(t *testing.T) {
+	is := is.New(t)
+
+	newFiles := func() []*File {
+		return []*File{
+			newFile("1.txt", "aaaaaaaaaa\nbbbbbbbbbb\n"),
+			newFile("2.txt", "aaaaxaaaaa\nbbbbxbbbbb\n"),
+			newFile("3.txt", "aaaaaaaaaa\nbbbbxbbbbb\n"),
+		}
+	}
+
+	sims := similaritiesWithOptions(t, newFiles(), &Options{MaxEditDistance: 2})
+	is.Equal(len(sims), 1)
+	is.Equal(len(sims[0].Occurrences), 3)
+
+	// 1.txt and 2.txt do not share any equal lines, so they are not compared with each other
+	sims = similaritiesWithOptions(t, newFiles(), &Options{Flags: SkipDisjointFilesFlag, MaxEditDistance: 2})
+	is.Equal(len(sims), 2)
+	is.Equal(len(sims[0].Occurrences), 2)
+	is.Equal(sims[0].Occurrences[0].File.Name, "1.txt")
+	is.Equal(sims[0].Occurrences[1].File.Name, "3.txt")
+	is.Equal(len(sims[1].Occurrences), 2)
+	is.Equal(sims[1].Occurrences[0].File.Name, "2.txt")
+	is.Equal(sims[1].Occurrences[1].File.Name, "3.txt")
+}
+
+func similaritiesWithOptions(t *testing.T, files []*File, opts *Options) []*Similarity {
+	t.Helper()
+
+	simsCh, progressCh, err := Similarities(context.Background(), files, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sims []*Similarity
+
+	waitForAll(func() {
+		sims = readSimilaritiesChan(simsCh)
+	}, drainProgressChan(progressCh))
+
+	return sims
+}
@@ -0,0 +1,23 @@
+package textsimilarity
+
+import "math/bits"
+
+// A charSet is a set of characters. Characters are mapped to 64 buckets, so a charSet may contain false positives.
+type charSet uint64
+
+// newCharSet returns the set of characters in s.
+func newCharSet(s string) charSet {
+	set := charSet(0)
+
+	for _, r := range s {
+		set |= 1 << (uint32(r) % 64)
+	}
+
+	return set
+}
+
+// minDistance returns a lower bound of the Levenshtein distance between texts having character sets c and other.
+// Every character bucket that is only contained in one of the sets requires at least one edit.
+func (c charSet) minDistance(other charSet) int {
+	return max(bits.OnesCount64(uint64(c&^other)), bits.OnesCount64(uint64(other&^c)))
+}
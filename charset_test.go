@@ -0,0 +1,17 @@
+package textsimilarity
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestCharSet_MinDistance(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(newCharSet("abc").minDistance(newCharSet("cba")), 0)
+	is.Equal(newCharSet("abc").minDistance(newCharSet("abx")), 1)
+	is.Equal(newCharSet("abcdef").minDistance(newCharSet("ab")), 4)
+	is.Equal(newCharSet("abcd").minDistance(newCharSet("wxyz")), 4)
+	is.Equal(newCharSet("").minDistance(newCharSet("")), 0)
+}
@@ -0,0 +1,114 @@
+package textsimilarity
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// errCheckpointMismatch is returned when a checkpoint to resume from does not match the files being scanned.
+var errCheckpointMismatch = errors.New("checkpoint does not match files")
+
+// A Checkpoint is the state of a scan, which can be used to resume the scan later using Options.ResumeFrom.
+// A checkpoint is only valid for the same files, in the same order and with the same contents, and for the
+// same options.
+type Checkpoint struct {
+	// TasksDone is the number of units of work that have been completed.
+	TasksDone int
+
+	// Similarities are all similarities found by the completed units of work, including those that have not
+	// been reported because of Options.MinOccurrences or Options.MaxOccurrences.
+	Similarities []*Similarity
+}
+
+// A checkpointer calls Options.OnCheckpoint with checkpoints of a similarityEmitter.
+type checkpointer struct {
+	// opts specifies the callback and interval.
+	opts *Options
+
+	// lastTime is the time of the last checkpoint.
+	lastTime time.Time
+
+	// lastTasksDone is the number of tasks completed at the last checkpoint.
+	lastTasksDone int
+}
+
+// newCheckpointer returns a new checkpointer according to opts. If opts.OnCheckpoint is nil, nil is returned.
+func newCheckpointer(opts *Options, tasksDone int) *checkpointer {
+	if opts.OnCheckpoint == nil {
+		return nil
+	}
+
+	return &checkpointer{
+		opts:          opts,
+		lastTime:      time.Now(),
+		lastTasksDone: tasksDone,
+	}
+}
+
+// update calls Options.OnCheckpoint with a checkpoint of e if more tasks have been completed since the last
+// checkpoint, and if Options.CheckpointInterval has passed, or if force is true.
+func (c *checkpointer) update(e *similarityEmitter, force bool) {
+	if c == nil || e.tasksDone == c.lastTasksDone {
+		return
+	}
+
+	if !force && (c.opts.CheckpointInterval <= 0 || time.Since(c.lastTime) < c.opts.CheckpointInterval) {
+		return
+	}
+
+	c.opts.OnCheckpoint(e.checkpoint())
+
+	c.lastTime = time.Now()
+	c.lastTasksDone = e.tasksDone
+}
+
+// checkpoint returns a checkpoint of all tasks completed so far.
+func (e *similarityEmitter) checkpoint() *Checkpoint {
+	sims := make([]*Similarity, e.tasksDoneSims)
+	copy(sims, e.found)
+
+	return &Checkpoint{
+		TasksDone:    e.tasksDone,
+		Similarities: sims,
+	}
+}
+
+// resume sets up e to continue after cp, emitting cp's similarities to emit.
+func (e *similarityEmitter) resume(cp *Checkpoint, emit func(*Similarity)) {
+	e.next = cp.TasksDone
+
+	e.emitResult(taskResult{
+		sims:     cp.Similarities,
+		complete: true,
+	}, emit)
+
+	e.tasksDone = cp.TasksDone
+	e.tasksDoneSims = len(e.found)
+}
+
+// validateCheckpoint returns an error if cp does not match files or tasks.
+func validateCheckpoint(cp *Checkpoint, files []*File, tasks []*task) error {
+	if cp.TasksDone < 0 || cp.TasksDone > len(tasks) {
+		return fmt.Errorf("%w: %d tasks done, but only %d tasks", errCheckpointMismatch, cp.TasksDone, len(tasks))
+	}
+
+	known := make(map[*File]struct{}, len(files))
+	for _, f := range files {
+		known[f] = struct{}{}
+	}
+
+	for _, sim := range cp.Similarities {
+		for _, occ := range sim.Occurrences {
+			if _, ok := known[occ.File]; !ok {
+				return fmt.Errorf("%w: unknown file", errCheckpointMismatch)
+			}
+
+			if occ.Start < 0 || occ.End > occ.File.lineCount || occ.Start >= occ.End {
+				return fmt.Errorf("%w: lines %d-%d out of range in %s", errCheckpointMismatch, occ.Start, occ.End, occ.File.Name)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+package textsimilarity
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestSimilarities_Resume(t *testing.T) {
+	is := is.New(t)
+
+	newFiles := func() []*File {
+		return []*File{
+			newFile("1.txt", "aaaaaaaaaa\nbbbbbbbbbb\ncccccccccc\nxxxxxxxxxx\n"),
+			newFile("2.txt", "yyyyyyyyyy\naaaaaaaaaa\nbbbbbbbbbb\ncccccccccc\n"),
+			newFile("3.txt", "zzzzzzzzzz\nbbbbbbbbbb\ncccccccccc\nxxxxxxxxxx\n"),
+			newFile("4.txt", "zzzzzzzzzz\nvvvvvvvvvv\nwwwwwwwwww\nxxxxxxxxxx\n"),
+			newFile("5.txt", "vvvvvvvvvv\nwwwwwwwwww\nqqqqqqqqqq\n"),
+		}
+	}
+
+	checkpoints := []*Checkpoint{}
+
+	opts := Options{
+		MinSimilarLines:    2,
+		Parallelism:        1, // run tasks in order
+		CheckpointInterval: time.Nanosecond,
+		OnCheckpoint: func(cp *Checkpoint) {
+			checkpoints = append(checkpoints, cp)
+		},
+	}
+
+	origFiles := newFiles()
+	want := similaritiesKeys(similaritiesWithOptions(t, origFiles, &opts))
+	is.True(len(want) > 1)
+
+	is.Equal(len(checkpoints), 5)
+	is.Equal(checkpoints[len(checkpoints)-1].TasksDone, 5)
+
+	for _, cp := range checkpoints {
+		files := newFiles()
+
+		opts := Options{
+			MinSimilarLines: 2,
+			ResumeFrom:      checkpointForFiles(cp, origFiles, files),
+		}
+
+		sims := similaritiesWithOptions(t, files, &opts)
+		is.Equal(similaritiesKeys(sims), want)
+
+		for _, sim := range sims {
+			is.True(sim.ID() != "")
+		}
+	}
+}
+
+func TestSimilarities_Resume_Mismatch(t *testing.T) {
+	is := is.New(t)
+
+	opts := Options{
+		ResumeFrom: &Checkpoint{TasksDone: 2},
+	}
+
+	_, _, err := Similarities(context.Background(), []*File{newFile("1.txt", "aaaaaaaaaa\n")}, &opts)
+	is.True(err != nil)
+}
+
+func TestSimilarityEmitter_Checkpoint_Incomplete(t *testing.T) {
+	is := is.New(t)
+
+	file := &File{lineCount: 10}
+
+	newSim := func(start int) *Similarity {
+		return &Similarity{
+			Occurrences: []*FileOccurrence{
+				{File: file, Start: start, End: start + 1},
+				{File: file, Start: start + 5, End: start + 6},
+			},
+		}
+	}
+
+	emitter := newSimilarityEmitter(&Options{OnCheckpoint: func(*Checkpoint) {}})
+	emit := func(*Similarity) {}
+
+	emitter.add(taskResult{taskIdx: 0, sims: []*Similarity{newSim(0)}, complete: true}, emit)
+	emitter.add(taskResult{taskIdx: 1, sims: []*Similarity{newSim(1)}, complete: false}, emit)
+	emitter.add(taskResult{taskIdx: 2, sims: []*Similarity{newSim(2)}, complete: true}, emit)
+
+	cp := emitter.checkpoint()
+	is.Equal(cp.TasksDone, 1)
+	is.Equal(len(cp.Similarities), 1)
+	is.Equal(cp.Similarities[0].Occurrences[0].Start, 0)
+}
+
+func TestSimilarityEmitter_WithoutCheckpoints(t *testing.T) {
+	is := is.New(t)
+
+	file := &File{lineCount: 10}
+
+	emitter := newSimilarityEmitter(&Options{})
+
+	emitted := 0
+	emit := func(*Similarity) {
+		emitted++
+	}
+
+	emitter.add(taskResult{taskIdx: 0, sims: []*Similarity{{
+		Occurrences: []*FileOccurrence{
+			{File: file, Start: 0, End: 1},
+			{File: file, Start: 5, End: 6},
+		},
+	}}, complete: true}, emit)
+
+	// emitted similarities are not kept alive
+	is.Equal(emitted, 1)
+	is.Equal(len(emitter.found), 0)
+}
+
+// checkpointForFiles returns a copy of cp that refers to files instead of origFiles.
+func checkpointForFiles(cp *Checkpoint, origFiles []*File, files []*File) *Checkpoint {
+	fileMap := map[*File]*File{}
+	for idx, f := range origFiles {
+		fileMap[f] = files[idx]
+	}
+
+	newCP := Checkpoint{
+		TasksDone: cp.TasksDone,
+	}
+
+	for _, sim := range cp.Similarities {
+		newSim := Similarity{
+			Level: sim.Level,
+		}
+
+		for _, occ := range sim.Occurrences {
+			newSim.Occurrences = append(newSim.Occurrences, &FileOccurrence{
+				File:  fileMap[occ.File],
+				Start: occ.Start,
+				End:   occ.End,
+			})
+		}
+
+		newCP.Similarities = append(newCP.Similarities, &newSim)
+	}
+
+	return &newCP
+}
+
+// similaritiesKeys returns keys of sims that can be compared regardless of the actual Files.
+func similaritiesKeys(sims []*Similarity) []string {
+	keys := make([]string, len(sims))
+
+	for idx, sim := range sims {
+		key := fmt.Sprintf("%d", sim.Level)
+		for _, occ := range sim.Occurrences {
+			key += fmt.Sprintf(" %s:%d-%d", occ.File.Name, occ.Start, occ.End)
+		}
+
+		keys[idx] = key
+	}
+
+	return keys
+}
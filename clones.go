@@ -0,0 +1,161 @@
+package textsimilarity
+
+import "sort"
+
+// A CloneClass is a group of similarities that transitively share occurrences, that is, all regions of files
+// that are mutually similar. For example, a block of text duplicated in 8 files forms a single clone class,
+// even though it may have been reported as several similarities.
+type CloneClass struct {
+	// Regions are the regions of files in the class, sorted by file name and line. Overlapping occurrences of
+	// the class's similarities are merged into a single region.
+	Regions []*FileOccurrence
+
+	// Similarities are the similarities in the class, in the order they were passed to CloneClasses.
+	Similarities []*Similarity
+}
+
+// A cloneOccurrence is an occurrence of a similarity, for grouping into clone classes.
+type cloneOccurrence struct {
+	occ *FileOccurrence
+
+	// simIdx is the index of the occurrence's similarity.
+	simIdx int
+}
+
+// CloneClasses groups sims into clone classes. Similarities whose occurrences overlap in the same file are put
+// into the same class, transitively. Classes are sorted by number of regions, then by total number of lines
+// (both descending), and then by their first region. sims must have been returned by Similarities.
+func CloneClasses(sims []*Similarity) []*CloneClass {
+	occs := []cloneOccurrence{}
+
+	for simIdx, sim := range sims {
+		for _, occ := range sim.Occurrences {
+			occs = append(occs, cloneOccurrence{
+				occ:    occ,
+				simIdx: simIdx,
+			})
+		}
+	}
+
+	sort.SliceStable(occs, func(a int, b int) bool {
+		occ1 := occs[a].occ
+		occ2 := occs[b].occ
+
+		if occ1.File.Name != occ2.File.Name {
+			return occ1.File.Name < occ2.File.Name
+		}
+
+		return occ1.Start < occ2.Start
+	})
+
+	parents := make([]int, len(sims))
+	for idx := range parents {
+		parents[idx] = idx
+	}
+
+	regions := []*FileOccurrence{}
+
+	// regionSims are the indexes of a similarity of each region
+	regionSims := []int{}
+
+	for _, cOcc := range occs {
+		if len(regions) > 0 {
+			region := regions[len(regions)-1]
+
+			if region.File == cOcc.occ.File && cOcc.occ.Start < region.End {
+				region.End = max(region.End, cOcc.occ.End)
+				unionClasses(parents, regionSims[len(regionSims)-1], cOcc.simIdx)
+
+				continue
+			}
+		}
+
+		regions = append(regions, &FileOccurrence{
+			File:  cOcc.occ.File,
+			Start: cOcc.occ.Start,
+			End:   cOcc.occ.End,
+		})
+
+		regionSims = append(regionSims, cOcc.simIdx)
+	}
+
+	classesByRoot := map[int]*CloneClass{}
+	classes := []*CloneClass{}
+
+	classOf := func(simIdx int) *CloneClass {
+		root := findClass(parents, simIdx)
+
+		class, ok := classesByRoot[root]
+		if !ok {
+			class = &CloneClass{}
+			classesByRoot[root] = class
+			classes = append(classes, class)
+		}
+
+		return class
+	}
+
+	for simIdx, sim := range sims {
+		class := classOf(simIdx)
+		class.Similarities = append(class.Similarities, sim)
+	}
+
+	for idx, region := range regions {
+		class := classOf(regionSims[idx])
+		class.Regions = append(class.Regions, region)
+	}
+
+	sort.SliceStable(classes, func(a int, b int) bool {
+		class1 := classes[a]
+		class2 := classes[b]
+
+		if len(class1.Regions) != len(class2.Regions) {
+			return len(class1.Regions) > len(class2.Regions)
+		}
+
+		if class1.Lines() != class2.Lines() {
+			return class1.Lines() > class2.Lines()
+		}
+
+		region1 := class1.Regions[0]
+		region2 := class2.Regions[0]
+
+		if region1.File.Name != region2.File.Name {
+			return region1.File.Name < region2.File.Name
+		}
+
+		return region1.Start < region2.Start
+	})
+
+	return classes
+}
+
+// Lines returns the total number of lines of all regions of c.
+func (c *CloneClass) Lines() int {
+	lines := 0
+	for _, region := range c.Regions {
+		lines += region.End - region.Start
+	}
+
+	return lines
+}
+
+// findClass returns the root of the class of simIdx in parents, compressing the path along the way.
+func findClass(parents []int, simIdx int) int {
+	for parents[simIdx] != simIdx {
+		parents[simIdx] = parents[parents[simIdx]]
+		simIdx = parents[simIdx]
+	}
+
+	return simIdx
+}
+
+// unionClasses merges the classes of simIdx1 and simIdx2 in parents.
+func unionClasses(parents []int, simIdx1 int, simIdx2 int) {
+	root1 := findClass(parents, simIdx1)
+	root2 := findClass(parents, simIdx2)
+
+	if root1 != root2 {
+		parents[max(root1, root2)] = min(root1, root2)
+	}
+}
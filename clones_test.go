@@ -0,0 +1,38 @@
+package textsimilarity
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestCloneClasses(t *testing.T) {
+	is := is.New(t)
+
+	fileA := &File{Name: "a.go"}
+	fileB := &File{Name: "b.go"}
+	fileC := &File{Name: "c.go"}
+	fileD := &File{Name: "d.go"}
+
+	sims := []*Similarity{
+		{Occurrences: []*FileOccurrence{{File: fileA, Start: 0, End: 10}, {File: fileB, Start: 0, End: 10}}},
+		{Occurrences: []*FileOccurrence{{File: fileB, Start: 5, End: 12}, {File: fileC, Start: 20, End: 27}}},
+		{Occurrences: []*FileOccurrence{{File: fileA, Start: 10, End: 12}, {File: fileD, Start: 0, End: 2}}},
+	}
+
+	classes := CloneClasses(sims)
+	is.Equal(len(classes), 2)
+
+	is.Equal(len(classes[0].Similarities), 2)
+	is.Equal(classes[0].Similarities[0], sims[0])
+	is.Equal(classes[0].Similarities[1], sims[1])
+	is.Equal(len(classes[0].Regions), 3)
+	is.Equal(*classes[0].Regions[0], FileOccurrence{File: fileA, Start: 0, End: 10})
+	is.Equal(*classes[0].Regions[1], FileOccurrence{File: fileB, Start: 0, End: 12})
+	is.Equal(*classes[0].Regions[2], FileOccurrence{File: fileC, Start: 20, End: 27})
+	is.Equal(classes[0].Lines(), 29)
+
+	is.Equal(len(classes[1].Similarities), 1)
+	is.Equal(classes[1].Similarities[0], sims[2])
+	is.Equal(len(classes[1].Regions), 2)
+}
@@ -0,0 +1,281 @@
+//go:build js && wasm
+
+package main //nolint:revive // no need for package documentation here
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"syscall/js"
+
+	"github.com/blizzy78/textsimilarity"
+)
+
+// defaultMinSimilarLines is the minimum number of similar lines used if options do not specify it,
+// matching the default of the command line tool.
+const defaultMinSimilarLines = 10
+
+var (
+	// errFilesNotArray is returned when files are not given as an array.
+	errFilesNotArray = errors.New("files must be an array")
+
+	// errDuplicateFileName is returned when multiple files have the same name.
+	errDuplicateFileName = errors.New("duplicate file name")
+)
+
+func main() {
+	js.Global().Set("textsimilarity", js.ValueOf(map[string]any{
+		"analyze": js.FuncOf(analyze),
+	}))
+
+	// keep running to serve calls from JavaScript
+	select {}
+}
+
+// analyze implements textsimilarity.analyze(files, options, onProgress) in JavaScript. It returns a Promise
+// that resolves to an array of similarities, or is rejected with an Error.
+func analyze(_ js.Value, args []js.Value) any {
+	arg := func(idx int) js.Value {
+		if idx >= len(args) {
+			return js.Undefined()
+		}
+
+		return args[idx]
+	}
+
+	files, filesErr := newFiles(arg(0))
+	opts, optsErr := newOptions(arg(1))
+	onProgress := arg(2)
+
+	handler := js.FuncOf(func(_ js.Value, promiseArgs []js.Value) any {
+		resolve := promiseArgs[0]
+		reject := promiseArgs[1]
+
+		go func() {
+			if err := errors.Join(filesErr, optsErr); err != nil {
+				reject.Invoke(js.Global().Get("Error").New(err.Error()))
+				return
+			}
+
+			sims, err := similarities(files, opts, onProgress)
+			if err != nil {
+				reject.Invoke(js.Global().Get("Error").New(err.Error()))
+				return
+			}
+
+			resolve.Invoke(js.ValueOf(sims))
+		}()
+
+		return nil
+	})
+
+	promise := js.Global().Get("Promise").New(handler)
+	handler.Release()
+
+	return promise
+}
+
+// similarities returns the similarities between files according to opts, converted to JavaScript values.
+// If onProgress is a function, it is called with progress objects.
+func similarities(files []*textsimilarity.File, opts *textsimilarity.Options, onProgress js.Value) ([]any, error) {
+	simsCh, progressCh, err := textsimilarity.Similarities(context.Background(), files, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var progressErr error
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for prog := range progressCh {
+			if prog.Err != nil {
+				if progressErr == nil {
+					progressErr = fmt.Errorf("scan %s: %w", prog.File.Name, prog.Err)
+				}
+
+				continue
+			}
+
+			if onProgress.Type() != js.TypeFunction {
+				continue
+			}
+
+			onProgress.Invoke(js.ValueOf(map[string]any{
+				"file": prog.File.Name,
+				"done": prog.Done,
+			}))
+		}
+	}()
+
+	sims := []any{}
+	for sim := range simsCh {
+		sims = append(sims, newJSSimilarity(sim))
+	}
+
+	<-done
+
+	if progressErr != nil {
+		return nil, progressErr
+	}
+
+	return sims, nil
+}
+
+// newFiles returns files for an array of objects with the properties name, content, and referenceOnly.
+func newFiles(value js.Value) ([]*textsimilarity.File, error) {
+	if value.Type() != js.TypeObject || !js.Global().Get("Array").Call("isArray", value).Bool() {
+		return nil, errFilesNotArray
+	}
+
+	files := make([]*textsimilarity.File, value.Length())
+	names := make(map[string]struct{}, len(files))
+
+	for idx := range files {
+		file := value.Index(idx)
+
+		name := stringProperty(file, "name")
+		if _, ok := names[name]; ok {
+			return nil, fmt.Errorf("%w: %s", errDuplicateFileName, name)
+		}
+
+		names[name] = struct{}{}
+
+		files[idx] = &textsimilarity.File{
+			Name:          name,
+			R:             strings.NewReader(stringProperty(file, "content")),
+			ReferenceOnly: boolProperty(file, "referenceOnly"),
+		}
+	}
+
+	return files, nil
+}
+
+// newOptions returns options for an object with properties named like the fields of textsimilarity.Options,
+// in camel case. Unset properties use the defaults of the command line tool.
+func newOptions(value js.Value) (*textsimilarity.Options, error) {
+	opts := textsimilarity.Options{
+		MinLineLength:   intProperty(value, "minLineLength"),
+		MinSimilarLines: intProperty(value, "minSimilarLines"),
+		MaxEditDistance: intProperty(value, "maxEditDistance"),
+		MinOccurrences:  intProperty(value, "minOccurrences"),
+		MaxOccurrences:  intProperty(value, "maxOccurrences"),
+		CaptureText:     boolProperty(value, "captureText"),
+
+		// there is only a single thread
+		Parallelism: 1,
+	}
+
+	if opts.MinSimilarLines <= 0 {
+		opts.MinSimilarLines = defaultMinSimilarLines
+	}
+
+	if opts.MaxEditDistance <= 0 {
+		opts.MaxEditDistance = textsimilarity.DefaultMaxEditDistance
+	}
+
+	if boolProperty(value, "ignoreWhitespace") {
+		opts.Flags |= textsimilarity.IgnoreWhitespaceFlag
+	}
+
+	if boolProperty(value, "ignoreBlankLines") {
+		opts.Flags |= textsimilarity.IgnoreBlankLinesFlag
+	}
+
+	if expr := stringProperty(value, "ignoreLineRegex"); expr != "" {
+		regex, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("ignore line regex: %w", err)
+		}
+
+		opts.IgnoreLineRegex = regex
+	}
+
+	return &opts, nil
+}
+
+// newJSSimilarity returns sim as a value that can be converted using js.ValueOf.
+func newJSSimilarity(sim *textsimilarity.Similarity) map[string]any {
+	level := "equal"
+	if sim.Level == textsimilarity.SimilarSimilarityLevel {
+		level = "similar"
+	}
+
+	occs := make([]any, len(sim.Occurrences))
+
+	for idx, occ := range sim.Occurrences {
+		jsOcc := map[string]any{
+			"file":  occ.File.Name,
+			"start": occ.Start,
+			"end":   occ.End,
+		}
+
+		if occ.StartColumns != nil {
+			jsOcc["startColumns"] = newJSColumnRange(occ.StartColumns)
+		}
+
+		if occ.EndColumns != nil {
+			jsOcc["endColumns"] = newJSColumnRange(occ.EndColumns)
+		}
+
+		if occ.Text != "" {
+			jsOcc["text"] = occ.Text
+		}
+
+		occs[idx] = jsOcc
+	}
+
+	return map[string]any{
+		"id":          sim.ID(),
+		"level":       level,
+		"occurrences": occs,
+	}
+}
+
+// newJSColumnRange returns r as a value that can be converted using js.ValueOf.
+func newJSColumnRange(r *textsimilarity.ColumnRange) map[string]any {
+	return map[string]any{
+		"start": r.Start,
+		"end":   r.End,
+	}
+}
+
+// stringProperty returns the string property name of value, or "" if value is not an object or the property
+// is not a string.
+func stringProperty(value js.Value, name string) string {
+	prop := property(value, name)
+	if prop.Type() != js.TypeString {
+		return ""
+	}
+
+	return prop.String()
+}
+
+// intProperty returns the number property name of value as an int, or 0 if value is not an object or the property
+// is not a number.
+func intProperty(value js.Value, name string) int {
+	prop := property(value, name)
+	if prop.Type() != js.TypeNumber {
+		return 0
+	}
+
+	return prop.Int()
+}
+
+// boolProperty returns whether the property name of value is truthy. It returns false if value is not an object.
+func boolProperty(value js.Value, name string) bool {
+	return property(value, name).Truthy()
+}
+
+// property returns the property name of value, or undefined if value is not an object.
+func property(value js.Value, name string) js.Value {
+	if value.Type() != js.TypeObject {
+		return js.Undefined()
+	}
+
+	return value.Get(name)
+}
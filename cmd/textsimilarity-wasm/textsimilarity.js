@@ -0,0 +1,35 @@
+// JavaScript bindings for textsimilarity compiled to WebAssembly.
+//
+// The Go runtime support script wasm_exec.js (found in the Go installation) must be loaded before this module,
+// so that the global Go class is available.
+
+let api;
+
+// load loads the WebAssembly module from url and returns the textsimilarity API. Subsequent calls return
+// the same API without loading the module again.
+export async function load(url = "textsimilarity.wasm") {
+	if (api) {
+		return api;
+	}
+
+	const go = new Go();
+	const { instance } = await WebAssembly.instantiateStreaming(fetch(url), go.importObject);
+
+	// the Go program keeps running to serve calls
+	go.run(instance);
+
+	api = globalThis.textsimilarity;
+	return api;
+}
+
+// analyze scans files for similarities between them. files is an array of objects with the properties name,
+// content, and referenceOnly (optional.) options is an object with the optional properties ignoreWhitespace,
+// ignoreBlankLines, minLineLength, minSimilarLines, maxEditDistance, ignoreLineRegex, minOccurrences,
+// maxOccurrences, and captureText. onProgress, if given, is called with objects with the properties file and
+// done (0 to 100.) It returns a Promise that resolves to an array of similarities, each with the properties id,
+// level ("equal" or "similar"), and occurrences. Each occurrence has the properties file, start, end (zero-based
+// line numbers, end exclusive), and optionally startColumns, endColumns, and text.
+export async function analyze(files, options = {}, onProgress = undefined, url = undefined) {
+	const textsimilarity = await load(url);
+	return textsimilarity.analyze(files, options, onProgress);
+}
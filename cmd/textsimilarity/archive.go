@@ -0,0 +1,252 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/blizzy78/textsimilarity"
+)
+
+// binarySniffBytes is the number of bytes at the start of an archive entry that are checked for NUL bytes
+// to detect binary files, which are not extracted.
+const binarySniffBytes = 8000
+
+// errUnsafeArchiveEntry is returned when an archive contains an entry whose path would escape the directory
+// the archive is extracted into.
+var errUnsafeArchiveEntry = errors.New("unsafe path in archive")
+
+// extractedArchives are archives that have been extracted into a temporary directory for scanning.
+type extractedArchives struct {
+	// dir is the temporary directory, or empty if no archives have been extracted.
+	dir string
+
+	// paths maps the directories archives have been extracted into to the paths of the archives.
+	paths map[string]string
+}
+
+// isArchive returns whether path is an archive whose files can be scanned, according to its file extension.
+func isArchive(path string) bool {
+	lowerPath := strings.ToLower(path)
+
+	for _, ext := range []string{".zip", ".tar", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(lowerPath, ext) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// expandArchives returns paths with all archives replaced by the text files they contain, which are extracted
+// into a temporary directory. If useIgnoreFiles is true, ignore files found in archives are honored.
+// The returned extractedArchives must be removed by the caller, even if an error is returned.
+func expandArchives(ctx context.Context, paths []string, useIgnoreFiles bool) ([]string, *extractedArchives, error) {
+	archives := extractedArchives{
+		paths: map[string]string{},
+	}
+
+	files := make([]string, 0, len(paths))
+
+	for _, path := range paths {
+		if !isArchive(path) || isObjectURL(path) {
+			files = append(files, path)
+			continue
+		}
+
+		if contextDone(ctx) {
+			return nil, &archives, errCanceled
+		}
+
+		if archives.dir == "" {
+			dir, err := os.MkdirTemp("", "textsimilarity-archives-*")
+			if err != nil {
+				return nil, &archives, fmt.Errorf("create directory for archives: %w", err)
+			}
+
+			archives.dir = dir
+		}
+
+		dir := filepath.Join(archives.dir, strconv.Itoa(len(archives.paths)))
+		archives.paths[dir] = path
+
+		if err := extractArchive(path, dir); err != nil {
+			return nil, &archives, err
+		}
+
+		archiveFiles, err := walkDir(ctx, dir, useIgnoreFiles)
+		if err != nil {
+			return nil, &archives, err
+		}
+
+		files = append(files, archiveFiles...)
+	}
+
+	return files, &archives, nil
+}
+
+// rename changes the names of all files extracted from archives to the paths of the archives, followed by
+// the paths of the files within.
+func (a *extractedArchives) rename(files []*textsimilarity.File) {
+	if a.dir == "" {
+		return
+	}
+
+	for _, file := range files {
+		rel, err := filepath.Rel(a.dir, file.Name)
+		if err != nil || !filepath.IsLocal(rel) {
+			continue
+		}
+
+		idx, entryPath, _ := strings.Cut(rel, string(filepath.Separator))
+
+		file.Name = filepath.Join(a.paths[filepath.Join(a.dir, idx)], entryPath)
+	}
+}
+
+// remove removes the temporary directory archives have been extracted into.
+func (a *extractedArchives) remove() {
+	if a == nil || a.dir == "" {
+		return
+	}
+
+	_ = os.RemoveAll(a.dir)
+}
+
+// extractArchive extracts the text files contained in the archive at path into dir.
+func extractArchive(path string, dir string) error {
+	lowerPath := strings.ToLower(path)
+
+	if strings.HasSuffix(lowerPath, ".zip") {
+		return extractZip(path, dir)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", path, err)
+	}
+
+	defer file.Close() //nolint:errcheck // file is being read
+
+	reader := io.Reader(file)
+
+	if !strings.HasSuffix(lowerPath, ".tar") {
+		gzipReader, err := gzip.NewReader(file)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", path, err)
+		}
+
+		defer gzipReader.Close() //nolint:errcheck // reader is being read
+
+		reader = gzipReader
+	}
+
+	return extractTar(path, reader, dir)
+}
+
+// extractZip extracts the text files contained in the zip archive at path into dir.
+func extractZip(path string, dir string) error {
+	zipReader, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", path, err)
+	}
+
+	defer zipReader.Close() //nolint:errcheck // archive is being read
+
+	for _, entry := range zipReader.File {
+		if !entry.Mode().IsRegular() {
+			continue
+		}
+
+		entryReader, err := entry.Open()
+		if err != nil {
+			return fmt.Errorf("read %s: %s: %w", path, entry.Name, err)
+		}
+
+		err = extractArchiveEntry(path, entry.Name, entryReader, dir)
+
+		_ = entryReader.Close()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// extractTar extracts the text files contained in the tar archive at path, read from r, into dir.
+func extractTar(path string, r io.Reader, dir string) error {
+	tarReader := tar.NewReader(r)
+
+	for {
+		header, err := tarReader.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return fmt.Errorf("read %s: %w", path, err)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		if err := extractArchiveEntry(path, header.Name, tarReader, dir); err != nil {
+			return err
+		}
+	}
+}
+
+// extractArchiveEntry extracts the entry with name of the archive at path, read from r, into dir, unless it is
+// a binary file.
+func extractArchiveEntry(path string, name string, r io.Reader, dir string) error {
+	name = filepath.FromSlash(strings.TrimPrefix(name, "./"))
+	if !filepath.IsLocal(name) {
+		return fmt.Errorf("%w: %s: %s", errUnsafeArchiveEntry, path, name)
+	}
+
+	head := make([]byte, binarySniffBytes)
+
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("read %s: %s: %w", path, name, err)
+	}
+
+	if bytes.IndexByte(head[:n], 0) >= 0 {
+		return nil
+	}
+
+	entryPath := filepath.Join(dir, name)
+
+	if err := os.MkdirAll(filepath.Dir(entryPath), 0o755); err != nil { //nolint:gosec // extracted files are not sensitive
+		return fmt.Errorf("create directory for %s: %s: %w", path, name, err)
+	}
+
+	file, err := os.Create(entryPath)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", entryPath, err)
+	}
+
+	_, err = io.Copy(file, io.MultiReader(bytes.NewReader(head[:n]), r))
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("extract %s: %s: %w", path, name, err)
+	}
+
+	return nil
+}
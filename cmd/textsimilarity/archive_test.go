@@ -0,0 +1,250 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/blizzy78/textsimilarity"
+	"github.com/matryer/is"
+)
+
+func TestIsArchive(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"a.zip", true},
+		{"a.ZIP", true},
+		{"a.tar", true},
+		{"a.tar.gz", true},
+		{"a.tgz", true},
+		{"a.gz", false},
+		{"a.txt", false},
+		{"zip", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			is := is.New(t)
+			is.Equal(isArchive(test.path), test.want)
+		})
+	}
+}
+
+func TestExtractArchiveEntry(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		wantPath string
+		wantErr  error
+	}{
+		{"a.txt", "a\n", "a.txt", nil},
+		{"./dir/b.txt", "b\n", filepath.Join("dir", "b.txt"), nil},
+		{"binary.bin", "b\x00b", "", nil},
+		{"../escape.txt", "e\n", "", errUnsafeArchiveEntry},
+		{"dir/../../escape.txt", "e\n", "", errUnsafeArchiveEntry},
+		{"/abs.txt", "e\n", "", errUnsafeArchiveEntry},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			is := is.New(t)
+
+			dir := t.TempDir()
+
+			err := extractArchiveEntry("test.zip", test.name, strings.NewReader(test.text), dir)
+			if test.wantErr != nil {
+				is.True(errors.Is(err, test.wantErr))
+				return
+			}
+
+			is.NoErr(err)
+
+			paths := extractedPaths(t, dir)
+
+			if test.wantPath == "" {
+				is.Equal(len(paths), 0)
+				return
+			}
+
+			is.Equal(paths, []string{test.wantPath})
+
+			data, err := os.ReadFile(filepath.Join(dir, test.wantPath))
+			is.NoErr(err)
+			is.Equal(string(data), test.text)
+		})
+	}
+}
+
+func TestExpandArchives(t *testing.T) {
+	is := is.New(t)
+
+	dir := t.TempDir()
+
+	entries := map[string]string{
+		"a.txt":       "a\n",
+		"dir/b.txt":   "b\n",
+		"dir/c.bin":   "c\x00c",
+		".gitignore":  "ignored.txt\n",
+		"ignored.txt": "i\n",
+	}
+
+	zipPath := filepath.Join(dir, "test.zip")
+	writeZip(t, zipPath, entries)
+
+	tarPath := filepath.Join(dir, "test.tar.gz")
+	writeTarGz(t, tarPath, entries)
+
+	textPath := filepath.Join(dir, "plain.txt")
+	is.NoErr(os.WriteFile(textPath, []byte("plain\n"), 0o600))
+
+	paths, archives, err := expandArchives(context.Background(), []string{textPath, zipPath, tarPath}, true)
+	defer archives.remove()
+
+	is.NoErr(err)
+
+	files := make([]*textsimilarity.File, len(paths))
+	for idx, path := range paths {
+		files[idx] = &textsimilarity.File{Name: path}
+	}
+
+	archives.rename(files)
+
+	names := make([]string, len(files))
+	for idx, file := range files {
+		names[idx] = file.Name
+	}
+
+	sort.Strings(names)
+
+	is.Equal(names, []string{
+		textPath,
+		filepath.Join(tarPath, ".gitignore"),
+		filepath.Join(tarPath, "a.txt"),
+		filepath.Join(tarPath, "dir", "b.txt"),
+		filepath.Join(zipPath, ".gitignore"),
+		filepath.Join(zipPath, "a.txt"),
+		filepath.Join(zipPath, "dir", "b.txt"),
+	})
+
+	extractDir := archives.dir
+	archives.remove()
+
+	_, err = os.Stat(extractDir)
+	is.True(os.IsNotExist(err))
+}
+
+func TestExpandArchives_Unsafe(t *testing.T) {
+	is := is.New(t)
+
+	zipPath := filepath.Join(t.TempDir(), "test.zip")
+	writeZip(t, zipPath, map[string]string{"../escape.txt": "e\n"})
+
+	_, archives, err := expandArchives(context.Background(), []string{zipPath}, false)
+	defer archives.remove()
+
+	is.True(errors.Is(err, errUnsafeArchiveEntry))
+}
+
+// extractedPaths returns the relative paths of all files in dir, sorted.
+func extractedPaths(t *testing.T, dir string) []string {
+	t.Helper()
+
+	paths := []string{}
+
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		paths = append(paths, rel)
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(paths)
+
+	return paths
+}
+
+// writeZip writes a zip archive with entries, mapping names to texts, to path.
+func writeZip(t *testing.T, path string, entries map[string]string) {
+	t.Helper()
+
+	buf := bytes.Buffer{}
+	zipWriter := zip.NewWriter(&buf)
+
+	for name, text := range entries {
+		w, err := zipWriter.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := w.Write([]byte(text)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeTarGz writes a gzipped tar archive with entries, mapping names to texts, to path.
+func writeTarGz(t *testing.T, path string, entries map[string]string) {
+	t.Helper()
+
+	buf := bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	for name, text := range entries {
+		header := tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     "./" + name,
+			Mode:     0o600,
+			Size:     int64(len(text)),
+		}
+
+		if err := tarWriter.WriteHeader(&header); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := tarWriter.Write([]byte(text)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := gzipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
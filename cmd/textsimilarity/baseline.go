@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/blizzy78/textsimilarity"
+)
+
+// defaultBaselinePath is the path of the baseline file if no other path is given.
+const defaultBaselinePath = ".textsimilarity-baseline.json"
+
+// writeBaseline writes a new baseline containing sims to path.
+func writeBaseline(path string, sims []*textsimilarity.Similarity) error {
+	base := textsimilarity.NewBaseline(sims)
+
+	if err := writeFileAtomic(path, base.Write); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "wrote baseline with %d similarities to %s\n", base.Len(), path)
+
+	return nil
+}
+
+// newSimilarities returns all similarities in sims that are not contained in the baseline at path.
+func newSimilarities(path string, sims []*textsimilarity.Similarity) ([]*textsimilarity.Similarity, error) {
+	base, err := readBaseline(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return base.New(sims), nil
+}
+
+// readBaseline reads the baseline at path.
+func readBaseline(path string) (*textsimilarity.Baseline, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	defer file.Close() //nolint:errcheck // file is being read
+
+	base, err := textsimilarity.ReadBaseline(file)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+
+	return base, nil
+}
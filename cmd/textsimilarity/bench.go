@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"text/tabwriter"
+	"time"
+
+	"github.com/blizzy78/textsimilarity"
+)
+
+// benchMinCosineSimilarity is the minimum cosine similarity used by the cosine engine of the bench subcommand,
+// if -min-cosine is not set.
+const benchMinCosineSimilarity = 0.7
+
+// A benchEngine is a detection engine or line metric compared by the bench subcommand.
+type benchEngine struct {
+	// name is the name of the engine.
+	name string
+
+	// apply modifies opts to use the engine.
+	apply func(opts *textsimilarity.Options)
+}
+
+// A benchResult is the result of running a benchEngine over a corpus.
+type benchResult struct {
+	// engine is the name of the engine.
+	engine string
+
+	// duration is the time the scan took.
+	duration time.Duration
+
+	// allocated is the number of bytes allocated during the scan.
+	allocated uint64
+
+	// similarities is the number of similarities found.
+	similarities int
+
+	// lines is the number of lines covered by any similarity.
+	lines int
+}
+
+// benchEngines are the engines compared by the bench subcommand, in order.
+var benchEngines = []benchEngine{
+	{
+		name: "levenshtein",
+		apply: func(opts *textsimilarity.Options) {
+			opts.LineMetric = textsimilarity.LevenshteinLineMetric
+		},
+	},
+	{
+		name: "exact-seeds",
+		apply: func(opts *textsimilarity.Options) {
+			opts.LineMetric = textsimilarity.LevenshteinLineMetric
+			opts.Flags |= textsimilarity.ExactSeedingFlag
+		},
+	},
+	{
+		name: "word-distance",
+		apply: func(opts *textsimilarity.Options) {
+			opts.LineMetric = textsimilarity.LevenshteinLineMetric
+			opts.Flags |= textsimilarity.WordDistanceFlag
+		},
+	},
+	{
+		name: "jaro-winkler",
+		apply: func(opts *textsimilarity.Options) {
+			opts.LineMetric = textsimilarity.JaroWinklerLineMetric
+		},
+	},
+	{
+		name: "reordered",
+		apply: func(opts *textsimilarity.Options) {
+			opts.Flags |= textsimilarity.ReorderedBlocksFlag
+		},
+	},
+	{
+		name: "window",
+		apply: func(opts *textsimilarity.Options) {
+			if opts.WindowLines <= 0 {
+				opts.WindowLines = max(opts.MinSimilarLines, 1)
+			}
+		},
+	},
+	{
+		name: "cosine",
+		apply: func(opts *textsimilarity.Options) {
+			if opts.MinCosineSimilarity <= 0 {
+				opts.MinCosineSimilarity = benchMinCosineSimilarity
+			}
+		},
+	},
+}
+
+// bench runs all benchEngines over the files in paths, and writes their timings, memory allocations, and numbers
+// of findings to stdout. Files are read only once, and then scanned by each engine in turn, according to opts.
+// If changedFiles is not nil, all other files are reference-only. It returns the exit code.
+func bench(ctx context.Context, paths []string, changedFiles map[string]struct{}, objects *objectStore, index *gitIndex,
+	opts cmdOptions,
+) (int, error) {
+	loaded, err := loadFiles(ctx, paths, changedFiles, objects, index)
+	if err != nil {
+		return -1, err
+	}
+
+	results := make([]*benchResult, 0, len(benchEngines))
+
+	for _, engine := range benchEngines {
+		if contextDone(ctx) {
+			return -1, errCanceled
+		}
+
+		simOpts := opts.simOpts
+		engine.apply(&simOpts)
+
+		res, err := benchEngineResult(ctx, engine.name, loaded, simOpts)
+		if err != nil {
+			return -1, err
+		}
+
+		results = append(results, res)
+	}
+
+	if contextDone(ctx) {
+		return -1, errCanceled
+	}
+
+	if err := writeBenchResults(os.Stdout, results); err != nil {
+		return -1, err
+	}
+
+	return 0, nil
+}
+
+// loadFiles opens and loads the files in paths, so that they can be scanned multiple times.
+// If changedFiles is not nil, all other files are reference-only.
+func loadFiles(ctx context.Context, paths []string, changedFiles map[string]struct{}, objects *objectStore, index *gitIndex,
+) ([]*textsimilarity.LoadedFile, error) {
+	var osFiles []*os.File
+
+	defer func() {
+		for _, f := range osFiles {
+			_ = f.Close()
+		}
+	}()
+
+	files, osFiles, err := openFiles(ctx, paths, objects, index)
+	if err != nil {
+		return nil, err
+	}
+
+	if changedFiles != nil {
+		if err := markReferenceOnly(files, changedFiles); err != nil {
+			return nil, err
+		}
+	}
+
+	loaded := make([]*textsimilarity.LoadedFile, len(files))
+
+	for idx, file := range files {
+		if contextDone(ctx) {
+			return nil, errCanceled
+		}
+
+		loaded[idx], err = textsimilarity.Load(file)
+		if err != nil {
+			return nil, err //nolint:wrapcheck // LoadError includes the file name
+		}
+	}
+
+	return loaded, nil
+}
+
+// benchEngineResult scans loaded according to opts, and returns the result for engine.
+func benchEngineResult(ctx context.Context, engine string, loaded []*textsimilarity.LoadedFile, opts textsimilarity.Options,
+) (*benchResult, error) {
+	files := make([]*textsimilarity.File, len(loaded))
+	for idx, l := range loaded {
+		files[idx] = l.File()
+	}
+
+	runtime.GC()
+
+	memStats := runtime.MemStats{}
+	runtime.ReadMemStats(&memStats)
+	allocatedBefore := memStats.TotalAlloc
+
+	start := time.Now()
+
+	sims, err := findSimilarities(ctx, files, opts, func(textsimilarity.Progress) {})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", engine, err)
+	}
+
+	duration := time.Since(start)
+
+	runtime.ReadMemStats(&memStats)
+
+	lines := 0
+
+	for _, cov := range textsimilarity.FilesCoverage(sims) {
+		for _, n := range cov.Lines {
+			if n > 0 {
+				lines++
+			}
+		}
+	}
+
+	return &benchResult{
+		engine:       engine,
+		duration:     duration,
+		allocated:    memStats.TotalAlloc - allocatedBefore,
+		similarities: len(sims),
+		lines:        lines,
+	}, nil
+}
+
+// writeBenchResults writes results to w as a table.
+func writeBenchResults(w io.Writer, results []*benchResult) error {
+	tabW := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintln(tabW, "engine\ttime\tallocated\tsimilarities\tlines\t")
+
+	for _, res := range results {
+		fmt.Fprintf(tabW, "%s\t%s\t%.1f MB\t%d\t%d\t\n",
+			res.engine, res.duration.Round(time.Millisecond), float64(res.allocated)/1024/1024, res.similarities, res.lines)
+	}
+
+	if err := tabW.Flush(); err != nil {
+		return fmt.Errorf("write bench results: %w", err)
+	}
+
+	return nil
+}
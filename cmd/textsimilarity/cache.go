@@ -0,0 +1,468 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/blizzy78/textsimilarity"
+)
+
+// cacheVersion is the version of the cache file format. Cache files of other versions are ignored.
+const cacheVersion = 4
+
+// A resultCache holds the similarities found in a previous scan, along with the content hashes of the files
+// scanned, so that similarities between unchanged files can be reused.
+type resultCache struct {
+	Version int `json:"version"`
+
+	// Files maps absolute file paths to content hashes.
+	Files map[string]string `json:"files"`
+
+	Similarities []*cachedSimilarity `json:"similarities"`
+}
+
+// A cachedSimilarity is a single similarity in a resultCache.
+type cachedSimilarity struct {
+	// ID is the identifier of the similarity, as returned by Similarity.ID.
+	ID string `json:"id,omitempty"`
+
+	Level       textsimilarity.SimilarityLevel   `json:"level"`
+	Tier        string                           `json:"tier,omitempty"`
+	LineLevels  []textsimilarity.SimilarityLevel `json:"lineLevels,omitempty"`
+	Occurrences []*cachedOccurrence              `json:"occurrences"`
+
+	// OmittedOccurrences is the number of occurrences omitted because of a maximum number of occurrences.
+	OmittedOccurrences int `json:"omittedOccurrences,omitempty"`
+}
+
+// A cachedOccurrence is a single occurrence of a cachedSimilarity. Line numbers are zero-based, End is exclusive.
+type cachedOccurrence struct {
+	File         string                      `json:"file"`
+	Start        int                         `json:"start"`
+	End          int                         `json:"end"`
+	StartUnit    int                         `json:"startUnit,omitempty"`
+	EndUnit      int                         `json:"endUnit,omitempty"`
+	StartColumns *textsimilarity.ColumnRange `json:"startColumns,omitempty"`
+	EndColumns   *textsimilarity.ColumnRange `json:"endColumns,omitempty"`
+	SimHash      uint64                      `json:"simHash,omitempty"`
+	FuzzyHash    string                      `json:"fuzzyHash,omitempty"`
+	Text         string                      `json:"text,omitempty"`
+}
+
+// cachePath returns the path of the cache file in dir to use for opts.
+func cachePath(dir string, opts *textsimilarity.Options) string {
+	return filepath.Join(dir, optionsKey(cacheVersion, opts)+".json")
+}
+
+// optionsKey returns a key of all options in opts that affect the similarities found, for a file format version.
+func optionsKey(version int, opts *textsimilarity.Options) string {
+	ignoreLineRegex := ""
+	if opts.IgnoreLineRegex != nil {
+		ignoreLineRegex = opts.IgnoreLineRegex.String()
+	}
+
+	key := fmt.Sprintf("%d\x00%d\x00%d\x00%d\x00%d\x00%d\x00%d\x00%s", version, opts.Flags,
+		opts.MinLineLength, opts.MinSimilarLines, opts.MaxEditDistance, opts.MinOccurrences, opts.MaxOccurrences, ignoreLineRegex)
+
+	if opts.MaxOccurrencesPerSimilarity > 0 {
+		key += fmt.Sprintf("\x00maxOccs=%d", opts.MaxOccurrencesPerSimilarity)
+	}
+
+	if opts.MaxGapLines > 0 {
+		key += fmt.Sprintf("\x00maxGap=%d", opts.MaxGapLines)
+	}
+
+	if opts.WindowLines > 0 {
+		key += fmt.Sprintf("\x00window=%d\x00%g", opts.WindowLines, opts.MinWindowSimilarity)
+	}
+
+	if opts.LineMetric != textsimilarity.LevenshteinLineMetric {
+		key += fmt.Sprintf("\x00metric=%d\x00%g", opts.LineMetric, opts.MinJaroWinklerSimilarity)
+	}
+
+	if opts.OverlapPolicy != textsimilarity.DropOverlapPolicy {
+		key += fmt.Sprintf("\x00overlap=%d", opts.OverlapPolicy)
+	}
+
+	if opts.MinCosineSimilarity > 0 {
+		key += fmt.Sprintf("\x00cosine=%g", opts.MinCosineSimilarity)
+	}
+
+	if opts.ComputeSimHashes {
+		key += "\x00simhash"
+	}
+
+	if opts.ComputeFuzzyHashes {
+		key += "\x00fuzzyhash"
+	}
+
+	if opts.MaxSimHashDistance > 0 {
+		key += fmt.Sprintf("\x00simhashDist=%d", opts.MaxSimHashDistance)
+	}
+
+	if opts.MinSimilarChars > 0 {
+		key += fmt.Sprintf("\x00chars=%d", opts.MinSimilarChars)
+	}
+
+	for _, transform := range opts.LineTransforms {
+		key += "\x00" + transform.Regex.String() + "\x00" + transform.Replacement
+	}
+
+	for _, marker := range opts.TrailingCommentMarkers {
+		key += "\x00" + marker
+	}
+
+	if opts.LineWeights != nil {
+		key += fmt.Sprintf("\x00weightLength=%d", opts.LineWeights.FullLength)
+
+		for _, rule := range opts.LineWeights.Rules {
+			key += fmt.Sprintf("\x00weight=%s\x00%g", rule.Regex.String(), rule.Weight)
+		}
+	}
+
+	for _, tier := range opts.Tiers {
+		key += fmt.Sprintf("\x00tier=%s\x00%d", tier.Name, tier.MaxEditDistance)
+	}
+
+	for _, pair := range opts.ExcludedFilePairs {
+		key += "\x00pair=" + pair.Name1.String() + "\x00" + pair.Name2.String()
+	}
+
+	hash := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(hash[:8])
+}
+
+// readResultCache reads the cache file at path. If the file does not exist or is of another version,
+// it returns nil.
+func readResultCache(path string) (*resultCache, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("read cache %s: %w", path, err)
+	}
+
+	cache := resultCache{}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, fmt.Errorf("decode cache %s: %w", path, err)
+	}
+
+	if cache.Version != cacheVersion {
+		return nil, nil
+	}
+
+	return &cache, nil
+}
+
+// writeResultCache writes hashes and sims to the cache file at path.
+func writeResultCache(path string, hashes map[string]string, sims []*textsimilarity.Similarity) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create cache directory: %w", err)
+	}
+
+	cachedSims, err := newCachedSimilarities(sims)
+	if err != nil {
+		return err
+	}
+
+	cache := resultCache{
+		Version:      cacheVersion,
+		Files:        hashes,
+		Similarities: cachedSims,
+	}
+
+	return writeFileAtomic(path, func(w io.Writer) error {
+		if err := json.NewEncoder(w).Encode(&cache); err != nil {
+			return fmt.Errorf("encode cache: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// newCachedSimilarities returns cached similarities for sims.
+func newCachedSimilarities(sims []*textsimilarity.Similarity) ([]*cachedSimilarity, error) {
+	cachedSims := make([]*cachedSimilarity, 0, len(sims))
+
+	for _, sim := range sims {
+		cachedSim := cachedSimilarity{
+			ID:                 sim.ID(),
+			Level:              sim.Level,
+			Tier:               sim.Tier,
+			LineLevels:         sim.LineLevels,
+			Occurrences:        make([]*cachedOccurrence, len(sim.Occurrences)),
+			OmittedOccurrences: sim.OmittedOccurrences,
+		}
+
+		for idx, occ := range sim.Occurrences {
+			absPath, err := filepath.Abs(occ.File.Name)
+			if err != nil {
+				return nil, fmt.Errorf("absolute path of %s: %w", occ.File.Name, err)
+			}
+
+			cachedSim.Occurrences[idx] = &cachedOccurrence{
+				File:         absPath,
+				Start:        occ.Start,
+				End:          occ.End,
+				StartUnit:    occ.StartUnit,
+				EndUnit:      occ.EndUnit,
+				StartColumns: occ.StartColumns,
+				EndColumns:   occ.EndColumns,
+				SimHash:      occ.SimHash,
+				FuzzyHash:    occ.FuzzyHash,
+				Text:         occ.Text,
+			}
+		}
+
+		cachedSims = append(cachedSims, &cachedSim)
+	}
+
+	return cachedSims, nil
+}
+
+// hashFiles returns the content hashes of all files in paths, keyed by absolute path.
+func hashFiles(paths []string) (map[string]string, error) {
+	hashes := make(map[string]string, len(paths))
+
+	for _, path := range paths {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("absolute path of %s: %w", path, err)
+		}
+
+		hash, err := hashFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		hashes[absPath] = hash
+	}
+
+	return hashes, nil
+}
+
+// hashFile returns the content hash of the file at path.
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("open %s: %w", path, err)
+	}
+	defer file.Close() //nolint:errcheck // file is being read
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("read %s: %w", path, err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// plan determines which files need to be scanned again, given the current content hashes, and which cached
+// similarities can be reused. A file needs to be scanned again if its content has changed, if it is new,
+// or if it shares a similarity with such a file. If c is nil, all files need to be scanned again.
+func (c *resultCache) plan(hashes map[string]string) (map[string]struct{}, []*cachedSimilarity) {
+	rescan := map[string]struct{}{}
+
+	if c == nil {
+		for path := range hashes {
+			rescan[path] = struct{}{}
+		}
+
+		return rescan, nil
+	}
+
+	for path, hash := range hashes {
+		if c.Files[path] != hash {
+			rescan[path] = struct{}{}
+		}
+	}
+
+	reuse := make([]*cachedSimilarity, 0, len(c.Similarities))
+
+	for _, sim := range c.Similarities {
+		if sim.unchanged(c.Files, hashes) {
+			reuse = append(reuse, sim)
+			continue
+		}
+
+		for _, occ := range sim.Occurrences {
+			if _, ok := hashes[occ.File]; ok {
+				rescan[occ.File] = struct{}{}
+			}
+		}
+	}
+
+	filtered := reuse[:0]
+
+	for _, sim := range reuse {
+		if !sim.anyFileIn(rescan) {
+			filtered = append(filtered, sim)
+		}
+	}
+
+	return rescan, filtered
+}
+
+// unchanged returns whether all files of s still exist and have the same content hashes.
+func (s *cachedSimilarity) unchanged(oldHashes map[string]string, hashes map[string]string) bool {
+	for _, occ := range s.Occurrences {
+		hash, ok := hashes[occ.File]
+		if !ok || hash != oldHashes[occ.File] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// anyFileIn returns whether any occurrence of s is in a file contained in paths.
+func (s *cachedSimilarity) anyFileIn(paths map[string]struct{}) bool {
+	for _, occ := range s.Occurrences {
+		if _, ok := paths[occ.File]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// occurrenceKey identifies an occurrence by file and line range.
+type occurrenceKey struct {
+	file  *textsimilarity.File
+	start int
+	end   int
+}
+
+// cachedSimilarities returns similarities for cachedSims, using files. Similarities whose occurrences are all
+// contained in any of sims are skipped.
+func cachedSimilarities(cachedSims []*cachedSimilarity, files []*textsimilarity.File,
+	sims []*textsimilarity.Similarity,
+) ([]*textsimilarity.Similarity, error) {
+	filesByPath := make(map[string]*textsimilarity.File, len(files))
+
+	for _, file := range files {
+		absPath, err := filepath.Abs(file.Name)
+		if err != nil {
+			return nil, fmt.Errorf("absolute path of %s: %w", file.Name, err)
+		}
+
+		filesByPath[absPath] = file
+	}
+
+	occurrences := map[occurrenceKey]struct{}{}
+
+	for _, sim := range sims {
+		for _, occ := range sim.Occurrences {
+			occurrences[occurrenceKey{file: occ.File, start: occ.Start, end: occ.End}] = struct{}{}
+		}
+	}
+
+	result := make([]*textsimilarity.Similarity, 0, len(cachedSims))
+
+	for _, cachedSim := range cachedSims {
+		sim := textsimilarity.Similarity{
+			Level:              cachedSim.Level,
+			Tier:               cachedSim.Tier,
+			LineLevels:         cachedSim.LineLevels,
+			Occurrences:        make([]*textsimilarity.FileOccurrence, len(cachedSim.Occurrences)),
+			OmittedOccurrences: cachedSim.OmittedOccurrences,
+		}
+
+		sim.SetID(cachedSim.ID)
+
+		contained := true
+
+		for idx, occ := range cachedSim.Occurrences {
+			file := filesByPath[occ.File]
+
+			sim.Occurrences[idx] = &textsimilarity.FileOccurrence{
+				File:         file,
+				Start:        occ.Start,
+				End:          occ.End,
+				StartUnit:    occ.StartUnit,
+				EndUnit:      occ.EndUnit,
+				StartColumns: occ.StartColumns,
+				EndColumns:   occ.EndColumns,
+				SimHash:      occ.SimHash,
+				FuzzyHash:    occ.FuzzyHash,
+				Text:         occ.Text,
+			}
+
+			if _, ok := occurrences[occurrenceKey{file: file, start: occ.Start, end: occ.End}]; !ok {
+				contained = false
+			}
+		}
+
+		if !contained {
+			result = append(result, &sim)
+		}
+	}
+
+	return result, nil
+}
+
+// A cachePlan is the plan of a scan using a resultCache.
+type cachePlan struct {
+	// path is the path of the cache file.
+	path string
+
+	// hashes maps absolute file paths to current content hashes.
+	hashes map[string]string
+
+	// rescan are the absolute paths of files that need to be scanned again.
+	rescan map[string]struct{}
+
+	// reuse are the cached similarities that can be reused.
+	reuse []*cachedSimilarity
+}
+
+// newCachePlan returns a plan to scan files with current content hashes, keyed by absolute path, using the cache
+// in dir for opts.
+func newCachePlan(dir string, hashes map[string]string, opts *textsimilarity.Options) (*cachePlan, error) {
+	path := cachePath(dir, opts)
+
+	cache, err := readResultCache(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rescan, reuse := cache.plan(hashes)
+
+	return &cachePlan{
+		path:   path,
+		hashes: hashes,
+		rescan: rescan,
+		reuse:  reuse,
+	}, nil
+}
+
+// complete returns sims, found by scanning files according to p, along with the reused cached similarities.
+// If write is true, it also writes all similarities to the cache file.
+func (p *cachePlan) complete(files []*textsimilarity.File, sims []*textsimilarity.Similarity, write bool) ([]*textsimilarity.Similarity, error) {
+	cached, err := cachedSimilarities(p.reuse, files, sims)
+	if err != nil {
+		return nil, err
+	}
+
+	sims = append(sims, cached...)
+
+	if !write {
+		return sims, nil
+	}
+
+	if err := writeResultCache(p.path, p.hashes, sims); err != nil {
+		return nil, err
+	}
+
+	return sims, nil
+}
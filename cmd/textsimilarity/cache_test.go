@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/blizzy78/textsimilarity"
+	"github.com/blizzy78/textsimilarity/report"
+	"github.com/matryer/is"
+)
+
+func TestOptionsKey(t *testing.T) {
+	base := textsimilarity.Options{MinSimilarLines: 5, MaxEditDistance: 2}
+
+	tests := []struct {
+		name     string
+		version  int
+		opts     func(opts *textsimilarity.Options)
+		wantSame bool
+	}{
+		{"same", cacheVersion, func(*textsimilarity.Options) {}, true},
+		{"parallelism", cacheVersion, func(opts *textsimilarity.Options) { opts.Parallelism = 8 }, true},
+		{"version", cacheVersion + 1, func(*textsimilarity.Options) {}, false},
+		{"flags", cacheVersion, func(opts *textsimilarity.Options) { opts.Flags = textsimilarity.IgnoreWhitespaceFlag }, false},
+		{"minLines", cacheVersion, func(opts *textsimilarity.Options) { opts.MinSimilarLines = 6 }, false},
+		{"maxEditDistance", cacheVersion, func(opts *textsimilarity.Options) { opts.MaxEditDistance = 3 }, false},
+		{"ignoreLineRegex", cacheVersion, func(opts *textsimilarity.Options) { opts.IgnoreLineRegex = regexp.MustCompile("^#") }, false},
+		{"overlap", cacheVersion, func(opts *textsimilarity.Options) { opts.OverlapPolicy = textsimilarity.SplitOverlapPolicy }, false},
+		{"fuzzyHashes", cacheVersion, func(opts *textsimilarity.Options) { opts.ComputeFuzzyHashes = true }, false},
+		{"transforms", cacheVersion, func(opts *textsimilarity.Options) {
+			opts.LineTransforms = []textsimilarity.LineTransform{{Regex: regexp.MustCompile("[0-9]+"), Replacement: "N"}}
+		}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			is := is.New(t)
+
+			opts := base
+			test.opts(&opts)
+
+			is.Equal(optionsKey(test.version, &opts) == optionsKey(cacheVersion, &base), test.wantSame)
+		})
+	}
+}
+
+func TestResultCache_Plan(t *testing.T) {
+	cache := resultCache{
+		Version: cacheVersion,
+		Files: map[string]string{
+			"/a": "a1",
+			"/b": "b1",
+			"/c": "c1",
+			"/d": "d1",
+		},
+		Similarities: []*cachedSimilarity{
+			cachedSimilarityOf("/a", "/b"),
+			cachedSimilarityOf("/c", "/d"),
+		},
+	}
+
+	unchanged := map[string]string{"/a": "a1", "/b": "b1", "/c": "c1", "/d": "d1"}
+
+	tests := []struct {
+		name       string
+		cache      *resultCache
+		hashes     map[string]string
+		wantRescan []string
+		wantReuse  []int
+	}{
+		{"no cache", nil, unchanged, []string{"/a", "/b", "/c", "/d"}, nil},
+		{"unchanged", &cache, unchanged, []string{}, []int{0, 1}},
+		{"changed", &cache, map[string]string{"/a": "a2", "/b": "b1", "/c": "c1", "/d": "d1"}, []string{"/a", "/b"}, []int{1}},
+		{"new", &cache, map[string]string{"/a": "a1", "/b": "b1", "/c": "c1", "/d": "d1", "/e": "e1"}, []string{"/e"}, []int{0, 1}},
+		{"deleted", &cache, map[string]string{"/a": "a1", "/b": "b1", "/d": "d1"}, []string{"/d"}, []int{0}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			is := is.New(t)
+
+			rescan, reuse := test.cache.plan(test.hashes)
+
+			rescanPaths := []string{}
+			for path := range rescan {
+				rescanPaths = append(rescanPaths, path)
+			}
+
+			sort.Strings(rescanPaths)
+
+			is.Equal(rescanPaths, test.wantRescan)
+
+			is.Equal(len(reuse), len(test.wantReuse))
+
+			for idx, simIdx := range test.wantReuse {
+				is.Equal(reuse[idx], cache.Similarities[simIdx])
+			}
+		})
+	}
+}
+
+func TestCachedSimilarities(t *testing.T) {
+	is := is.New(t)
+
+	files, sims := scanTexts(t, t.TempDir(), nil)
+	is.True(len(sims) > 0)
+
+	cachedSims, err := newCachedSimilarities(sims)
+	is.NoErr(err)
+
+	restored, err := cachedSimilarities(cachedSims, files, nil)
+	is.NoErr(err)
+	is.Equal(len(restored), len(sims))
+
+	for simIdx, sim := range restored {
+		is.True(sim.ID() != "")
+		is.Equal(sim.ID(), sims[simIdx].ID())
+		is.Equal(sim.Level, sims[simIdx].Level)
+		is.Equal(len(sim.Occurrences), len(sims[simIdx].Occurrences))
+
+		for occIdx, occ := range sim.Occurrences {
+			want := sims[simIdx].Occurrences[occIdx]
+
+			is.Equal(occ.File, want.File)
+			is.Equal(occ.Start, want.Start)
+			is.Equal(occ.End, want.End)
+			is.Equal(occ.StartUnit, want.StartUnit)
+			is.Equal(occ.EndUnit, want.EndUnit)
+			is.Equal(occ.Text, want.Text)
+		}
+	}
+
+	// similarities contained in the similarities found are skipped
+	restored, err = cachedSimilarities(cachedSims, files, sims)
+	is.NoErr(err)
+	is.Equal(len(restored), 0)
+}
+
+func TestCachePlan_Reuse(t *testing.T) {
+	is := is.New(t)
+
+	dir := t.TempDir()
+	cacheDir := t.TempDir()
+
+	uncachedFiles, uncachedSims := scanTexts(t, dir, nil)
+
+	// the first run fills the cache, the second one reuses all similarities
+	for run := 0; run < 2; run++ {
+		files, sims := scanTexts(t, dir, &cacheDir)
+		is.True(len(sims) > 0)
+
+		for _, format := range []string{"json", "codeclimate"} {
+			is.Equal(reportOutput(t, format, sims, files), reportOutput(t, format, uncachedSims, uncachedFiles))
+		}
+
+		for simIdx, sim := range sims {
+			for occIdx, occ := range sim.Occurrences {
+				is.Equal(occ.Text, uncachedSims[simIdx].Occurrences[occIdx].Text)
+			}
+		}
+	}
+}
+
+// cachedSimilarityOf returns a cached similarity with occurrences in files.
+func cachedSimilarityOf(files ...string) *cachedSimilarity {
+	sim := cachedSimilarity{}
+
+	for _, file := range files {
+		sim.Occurrences = append(sim.Occurrences, &cachedOccurrence{
+			File: file,
+			End:  5,
+		})
+	}
+
+	return &sim
+}
+
+// scanTexts writes test files to dir if they do not exist, and returns the similarities found in them. If cacheDir
+// is not nil, the cache in it is used like in main.
+func scanTexts(t *testing.T, dir string, cacheDir *string) ([]*textsimilarity.File, []*textsimilarity.Similarity) {
+	t.Helper()
+
+	block := make([]string, 8)
+	for idx := range block {
+		block[idx] = fmt.Sprintf("%x", sha256.Sum256([]byte(fmt.Sprintf("block %d", idx))))
+	}
+
+	texts := map[string]string{
+		"a.txt": "first\n" + strings.Join(block, "\n") + "\n",
+		"b.txt": strings.Join(block[:6], "\n") + "\nsecond\n",
+		"c.txt": "third\nfourth\n" + strings.Join(block[2:], "\n") + "\n",
+	}
+
+	paths := make([]string, 0, len(texts))
+
+	for name, text := range texts {
+		path := filepath.Join(dir, name)
+		paths = append(paths, path)
+
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+
+		if err := os.WriteFile(path, []byte(text), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sort.Strings(paths)
+
+	opts := scanTextsOptions()
+
+	var (
+		plan         *cachePlan
+		changedFiles map[string]struct{}
+	)
+
+	if cacheDir != nil {
+		hashes, err := hashFiles(paths)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		plan, err = newCachePlan(*cacheDir, hashes, &opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		changedFiles = plan.rescan
+	}
+
+	sims, files, err := similarities(context.Background(), paths, nil, nil, changedFiles, opts, func(textsimilarity.Progress) {}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if plan != nil {
+		sims, err = plan.complete(files, sims, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sortSimilarities(sims, filesSortOrder, nil)
+
+	return files, sims
+}
+
+// scanTextsOptions returns the options used by scanTexts.
+func scanTextsOptions() textsimilarity.Options {
+	return textsimilarity.Options{MinSimilarLines: 3, MaxEditDistance: 2, CaptureText: true}
+}
+
+// reportOutput returns the output of a report of sims in files in format.
+func reportOutput(t *testing.T, format string, sims []*textsimilarity.Similarity, files []*textsimilarity.File) string {
+	t.Helper()
+
+	reporter, err := report.New(format, &report.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := bytes.Buffer{}
+
+	if summaryReporter, ok := reporter.(report.SummaryReporter); ok {
+		err = summaryReporter.ReportWithSummary(context.Background(), &buf, sims, files)
+	} else {
+		err = reporter.Report(context.Background(), &buf, sims)
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/blizzy78/textsimilarity"
+)
+
+// checkpointVersion is the version of the checkpoint file format. Checkpoint files of other versions are ignored.
+const checkpointVersion = 1
+
+// A scanCheckpoint is the state of a scan written to a checkpoint file.
+type scanCheckpoint struct {
+	Version int `json:"version"`
+
+	// Options is the key of the options used for the scan.
+	Options string `json:"options"`
+
+	// Files are the files scanned, in order.
+	Files []*checkpointedFile `json:"files"`
+
+	// TasksDone is the number of units of work that have been completed.
+	TasksDone int `json:"tasksDone"`
+
+	Similarities []*cachedSimilarity `json:"similarities"`
+}
+
+// A checkpointedFile is a single file in a scanCheckpoint.
+type checkpointedFile struct {
+	// Path is the absolute path of the file.
+	Path string `json:"path"`
+
+	// Hash is the content hash of the file.
+	Hash string `json:"hash"`
+
+	ReferenceOnly bool `json:"referenceOnly,omitempty"`
+}
+
+// A checkpointer periodically writes checkpoints of a scan to a file, and reads them to resume the scan.
+type checkpointer struct {
+	// path is the path of the checkpoint file.
+	path string
+
+	// interval is the interval between checkpoints.
+	interval time.Duration
+
+	// resume indicates whether the scan should be resumed from the checkpoint file, if it exists.
+	resume bool
+
+	// err is the first error that occurred while writing a checkpoint.
+	err error
+}
+
+// setup sets up opts to write checkpoints of a scan of files, and to resume the scan if c.resume is set and
+// the checkpoint file matches files and opts.
+func (c *checkpointer) setup(files []*textsimilarity.File, opts *textsimilarity.Options) error {
+	key := optionsKey(checkpointVersion, opts)
+
+	checkpointedFiles, err := newCheckpointedFiles(files)
+	if err != nil {
+		return err
+	}
+
+	if c.resume {
+		state, err := readCheckpoint(c.path)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case state == nil:
+		case state.matches(key, checkpointedFiles):
+			opts.ResumeFrom, err = state.checkpoint(files)
+			if err != nil {
+				return err
+			}
+
+		default:
+			fmt.Fprintln(os.Stderr, "Checkpoint does not match files or options, starting over.")
+		}
+	}
+
+	opts.CheckpointInterval = c.interval
+
+	opts.OnCheckpoint = func(cp *textsimilarity.Checkpoint) {
+		if c.err != nil {
+			return
+		}
+
+		c.err = writeCheckpoint(c.path, key, checkpointedFiles, cp)
+	}
+
+	return nil
+}
+
+// finish returns the first error that occurred while writing checkpoints. If complete is true, the scan
+// has been completed, and the checkpoint file is removed.
+func (c *checkpointer) finish(complete bool) error {
+	if c.err != nil {
+		return c.err
+	}
+
+	if !complete {
+		return nil
+	}
+
+	if err := os.Remove(c.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("remove checkpoint %s: %w", c.path, err)
+	}
+
+	return nil
+}
+
+// newCheckpointedFiles returns checkpointed files for files.
+func newCheckpointedFiles(files []*textsimilarity.File) ([]*checkpointedFile, error) {
+	checkpointedFiles := make([]*checkpointedFile, len(files))
+
+	for idx, file := range files {
+		absPath, err := filepath.Abs(file.Name)
+		if err != nil {
+			return nil, fmt.Errorf("absolute path of %s: %w", file.Name, err)
+		}
+
+		hash, err := hashFile(file.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		checkpointedFiles[idx] = &checkpointedFile{
+			Path:          absPath,
+			Hash:          hash,
+			ReferenceOnly: file.ReferenceOnly,
+		}
+	}
+
+	return checkpointedFiles, nil
+}
+
+// readCheckpoint reads the checkpoint file at path. If the file does not exist or is of another version,
+// it returns nil.
+func readCheckpoint(path string) (*scanCheckpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("read checkpoint %s: %w", path, err)
+	}
+
+	state := scanCheckpoint{}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("decode checkpoint %s: %w", path, err)
+	}
+
+	if state.Version != checkpointVersion {
+		return nil, nil
+	}
+
+	return &state, nil
+}
+
+// writeCheckpoint writes cp of a scan of files with options key to the checkpoint file at path.
+func writeCheckpoint(path string, key string, files []*checkpointedFile, cp *textsimilarity.Checkpoint) error {
+	cachedSims, err := newCachedSimilarities(cp.Similarities)
+	if err != nil {
+		return err
+	}
+
+	state := scanCheckpoint{
+		Version:      checkpointVersion,
+		Options:      key,
+		Files:        files,
+		TasksDone:    cp.TasksDone,
+		Similarities: cachedSims,
+	}
+
+	return writeFileAtomic(path, func(w io.Writer) error {
+		if err := json.NewEncoder(w).Encode(&state); err != nil {
+			return fmt.Errorf("encode checkpoint: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// matches returns whether s is a checkpoint of a scan of files with options key.
+func (s *scanCheckpoint) matches(key string, files []*checkpointedFile) bool {
+	if s.Options != key || len(s.Files) != len(files) {
+		return false
+	}
+
+	for idx, file := range files {
+		if *s.Files[idx] != *file {
+			return false
+		}
+	}
+
+	return true
+}
+
+// checkpoint returns the checkpoint of s, using files.
+func (s *scanCheckpoint) checkpoint(files []*textsimilarity.File) (*textsimilarity.Checkpoint, error) {
+	sims, err := cachedSimilarities(s.Similarities, files, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &textsimilarity.Checkpoint{
+		TasksDone:    s.TasksDone,
+		Similarities: sims,
+	}, nil
+}
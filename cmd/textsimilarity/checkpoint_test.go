@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/blizzy78/textsimilarity"
+	"github.com/matryer/is"
+)
+
+func TestScanCheckpoint_Matches(t *testing.T) {
+	files := []*checkpointedFile{
+		{Path: "/a.txt", Hash: "1111"},
+		{Path: "/b.txt", Hash: "2222"},
+	}
+
+	state := scanCheckpoint{
+		Version: checkpointVersion,
+		Options: "key",
+		Files:   files,
+	}
+
+	tests := []struct {
+		name  string
+		key   string
+		files []*checkpointedFile
+		want  bool
+	}{
+		{"same", "key", []*checkpointedFile{{Path: "/a.txt", Hash: "1111"}, {Path: "/b.txt", Hash: "2222"}}, true},
+		{"options", "other", files, false},
+		{"hash", "key", []*checkpointedFile{{Path: "/a.txt", Hash: "1111"}, {Path: "/b.txt", Hash: "3333"}}, false},
+		{"order", "key", []*checkpointedFile{{Path: "/b.txt", Hash: "2222"}, {Path: "/a.txt", Hash: "1111"}}, false},
+		{"missing", "key", files[:1], false},
+		{"referenceOnly", "key", []*checkpointedFile{{Path: "/a.txt", Hash: "1111", ReferenceOnly: true}, {Path: "/b.txt", Hash: "2222"}}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			is := is.New(t)
+			is.Equal(state.matches(test.key, test.files), test.want)
+		})
+	}
+}
+
+func TestCheckpointer_Resume(t *testing.T) {
+	dir := t.TempDir()
+
+	files, wantSims := scanTexts(t, dir, nil)
+	wantReport := reportOutput(t, "json", wantSims, files)
+
+	tests := []struct {
+		name     string
+		edit     func(state *scanCheckpoint)
+		wantSims int
+	}{
+		{"complete", func(*scanCheckpoint) {}, len(wantSims)},
+		// similarities are not found again for tasks that are done
+		{"removed similarity", func(state *scanCheckpoint) { state.Similarities = state.Similarities[1:] }, len(wantSims) - 1},
+		{"no tasks done", func(state *scanCheckpoint) { state.TasksDone, state.Similarities = 0, nil }, len(wantSims)},
+		{"mismatch", func(state *scanCheckpoint) { state.Options = "other" }, len(wantSims)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			is := is.New(t)
+
+			path := filepath.Join(t.TempDir(), "checkpoint.json")
+			paths := textPaths(t, dir)
+
+			writeScanCheckpoint(t, path, paths)
+
+			state, err := readCheckpoint(path)
+			is.NoErr(err)
+			is.True(state != nil)
+			is.True(state.TasksDone > 0)
+			is.Equal(len(state.Similarities), len(wantSims))
+
+			test.edit(state)
+
+			data, err := json.Marshal(state)
+			is.NoErr(err)
+			is.NoErr(os.WriteFile(path, data, 0o600))
+
+			checkpoints := checkpointer{
+				path:   path,
+				resume: true,
+			}
+
+			sims, files, err := similarities(context.Background(), paths, nil, nil, nil, scanTextsOptions(),
+				func(textsimilarity.Progress) {}, &checkpoints)
+			is.NoErr(err)
+
+			sortSimilarities(sims, filesSortOrder, nil)
+
+			is.Equal(len(sims), test.wantSims)
+
+			if test.wantSims == len(wantSims) {
+				is.Equal(reportOutput(t, "json", sims, files), wantReport)
+			}
+
+			// the checkpoint is removed when the scan is complete
+			_, err = os.Stat(path)
+			is.True(os.IsNotExist(err))
+		})
+	}
+}
+
+// writeScanCheckpoint scans the files at paths and writes the final checkpoint of the scan to path.
+func writeScanCheckpoint(t *testing.T, path string, paths []string) {
+	t.Helper()
+
+	files, osFiles, err := openFiles(context.Background(), paths, nil, nil)
+
+	defer func() {
+		for _, f := range osFiles {
+			_ = f.Close()
+		}
+	}()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkpoints := checkpointer{
+		path: path,
+	}
+
+	opts := scanTextsOptions()
+
+	if err := checkpoints.setup(files, &opts); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := findSimilarities(context.Background(), files, opts, func(textsimilarity.Progress) {}); err != nil {
+		t.Fatal(err)
+	}
+
+	if checkpoints.err != nil {
+		t.Fatal(checkpoints.err)
+	}
+}
+
+// textPaths returns the paths of the files written by scanTexts to dir, sorted.
+func textPaths(t *testing.T, dir string) []string {
+	t.Helper()
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(paths)
+
+	return paths
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/blizzy78/textsimilarity"
+)
+
+// linkages maps linkage names to linkages.
+var linkages = map[string]textsimilarity.Linkage{
+	"single":   textsimilarity.SingleLinkage,
+	"complete": textsimilarity.CompleteLinkage,
+	"average":  textsimilarity.AverageLinkage,
+}
+
+// errUnknownLinkage is returned when an unknown linkage is requested.
+var errUnknownLinkage = errors.New("unknown linkage")
+
+// clustering specifies how files are grouped into clusters.
+type clustering struct {
+	// linkage determines the similarity of two clusters.
+	linkage textsimilarity.Linkage
+
+	// minSimilarity is the minimum similarity of two clusters to be merged (0-1.)
+	minSimilarity float64
+}
+
+// linkageNames returns the names of all linkages, sorted.
+func linkageNames() []string {
+	names := make([]string, 0, len(linkages))
+	for name := range linkages {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+const (
+	// scanCommand scans files for similarities and reports them.
+	scanCommand = command(iota)
+
+	// baselineWriteCommand scans files for similarities and writes them to a baseline file.
+	baselineWriteCommand
+
+	// baselineCheckCommand scans files for similarities and only reports those not contained in a baseline file.
+	baselineCheckCommand
+
+	// watchCommand repeatedly scans files for similarities and reports them.
+	watchCommand
+
+	// trendCommand compares two saved reports.
+	trendCommand
+
+	// lspCommand serves the Language Server Protocol, publishing similarities as diagnostics.
+	lspCommand
+
+	// benchCommand compares the timings, memory allocations, and findings of detection engines over files.
+	benchCommand
+)
+
+// A command is a subcommand of the command line utility.
+type command int
+
+// errUnknownCommand is returned when an unknown subcommand is given.
+var errUnknownCommand = errors.New("unknown command")
+
+// parseCommand returns the subcommand given in args, as well as the remaining args.
+func parseCommand(args []string) (command, []string, error) {
+	if len(args) != 0 && args[0] == "watch" {
+		return watchCommand, args[1:], nil
+	}
+
+	if len(args) != 0 && args[0] == "trend" {
+		return trendCommand, args[1:], nil
+	}
+
+	if len(args) != 0 && args[0] == "lsp" {
+		return lspCommand, args[1:], nil
+	}
+
+	if len(args) != 0 && args[0] == "bench" {
+		return benchCommand, args[1:], nil
+	}
+
+	if len(args) == 0 || args[0] != "baseline" {
+		return scanCommand, args, nil
+	}
+
+	if len(args) < 2 {
+		return scanCommand, nil, fmt.Errorf("%w: baseline: expected write or check", errUnknownCommand)
+	}
+
+	switch args[1] {
+	case "write":
+		return baselineWriteCommand, args[2:], nil
+	case "check":
+		return baselineCheckCommand, args[2:], nil
+	default:
+		return scanCommand, nil, fmt.Errorf("%w: baseline %s", errUnknownCommand, args[1])
+	}
+}
+
+// usage prints usage information to stderr.
+func usage() {
+	fmt.Fprintf(os.Stderr, `Usage:
+  %[1]s [flags] PATH...
+        scan files for similarities and report them
+  %[1]s baseline write [flags] PATH...
+        scan files for similarities and write them to a baseline file
+  %[1]s baseline check [flags] PATH...
+        scan files for similarities and only report those not contained in a baseline file
+  %[1]s watch [flags] PATH...
+        repeatedly scan files for similarities and report them, optionally exposing metrics
+  %[1]s trend OLD.json NEW.json
+        compare two reports written in the json format and report changes
+  %[1]s lsp [flags] [PATH...]
+        serve the Language Server Protocol on stdin and stdout, publishing similarities
+        between open documents and files as diagnostics
+  %[1]s bench [flags] PATH...
+        scan files with each detection engine and line metric, and compare their timings,
+        memory allocations, and findings
+
+Flags:
+`, os.Args[0])
+
+	flag.PrintDefaults()
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/blizzy78/textsimilarity"
+	"github.com/blizzy78/textsimilarity/report"
+)
+
+// A duplicateEngine finds pairs of files that are near-duplicates of each other as a whole.
+type duplicateEngine func(ctx context.Context, files []*textsimilarity.File, minSimilarity float64,
+	opts *textsimilarity.Options) ([]*textsimilarity.NearDuplicate, error)
+
+// duplicateEngines maps names of engines to find near-duplicate files to the engines.
+var duplicateEngines = map[string]duplicateEngine{
+	"lines": textsimilarity.NearDuplicates,
+	"ctph":  textsimilarity.FuzzyDuplicates,
+}
+
+// errUnknownDuplicateEngine is returned when an unknown engine to find near-duplicate files is requested.
+var errUnknownDuplicateEngine = errors.New("unknown duplicate engine")
+
+// duplicateEngineNames returns the names of all engines to find near-duplicate files, sorted.
+func duplicateEngineNames() []string {
+	names := make([]string, 0, len(duplicateEngines))
+	for name := range duplicateEngines {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// nearDuplicates finds files in paths that are near-duplicates of each other as a whole, according to opts,
+// using opts.duplicateEngine, and reports them to opts.outputs, using the respective reporters. Objects in object stores are read using
+// objects, and staged contents of files are read using index. If changedFiles is not nil, only files with
+// absolute paths contained in it are compared against all files. Files extracted from archives are renamed
+// before reporting. It returns the exit code, which is non-zero if any near-duplicates have been found.
+func nearDuplicates(ctx context.Context, paths []string, changedFiles map[string]struct{}, objects *objectStore, index *gitIndex,
+	archives *extractedArchives, opts cmdOptions, reporters []report.Reporter,
+) (int, error) {
+	var osFiles []*os.File
+
+	defer func() {
+		for _, f := range osFiles {
+			_ = f.Close()
+		}
+	}()
+
+	files, osFiles, err := openFiles(ctx, paths, objects, index)
+	if err != nil {
+		return -1, err
+	}
+
+	if changedFiles != nil {
+		if err := markReferenceOnly(files, changedFiles); err != nil {
+			return -1, err
+		}
+	}
+
+	if contextDone(ctx) {
+		return -1, errCanceled
+	}
+
+	dupes, err := duplicateEngines[opts.duplicateEngine](ctx, files, opts.duplicateSimilarity, &opts.simOpts)
+	if err != nil {
+		if contextDone(ctx) {
+			return -1, errCanceled
+		}
+
+		return -1, err
+	}
+
+	archives.rename(files)
+
+	for idx, out := range opts.outputs {
+		reporter := reporters[idx].(report.DuplicatesReporter) //nolint:forcetypeassert // checked in outputReporters
+
+		err := writeOutput(out, func(w io.Writer) error {
+			return reporter.ReportDuplicates(ctx, w, dupes)
+		})
+
+		if err != nil {
+			return -1, err
+		}
+	}
+
+	if len(dupes) != 0 {
+		return 1, nil
+	}
+
+	return 0, nil
+}
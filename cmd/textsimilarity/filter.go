@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/blizzy78/textsimilarity"
+	"github.com/blizzy78/textsimilarity/internal/ignore"
+)
+
+// A pathFilter filters similarities by the paths of the files they occur in.
+type pathFilter struct {
+	// only are the globs of which at least one must match any occurrence of a similarity.
+	only []*regexp.Regexp
+
+	// not are the globs of which none must match any occurrence of a similarity.
+	not []*regexp.Regexp
+}
+
+// newPathFilter returns a new pathFilter using the globs in only and not.
+func newPathFilter(only []string, not []string) (*pathFilter, error) {
+	onlyExprs, err := compileGlobs(only)
+	if err != nil {
+		return nil, err
+	}
+
+	notExprs, err := compileGlobs(not)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pathFilter{
+		only: onlyExprs,
+		not:  notExprs,
+	}, nil
+}
+
+// errInvalidFilePair is returned when a pair of globs does not consist of exactly two globs.
+var errInvalidFilePair = errors.New("file pair must consist of two globs separated by whitespace")
+
+// parseFilePairs returns file pairs for specs, each consisting of two globs separated by whitespace.
+func parseFilePairs(specs []string) ([]textsimilarity.FilePair, error) {
+	pairs := make([]textsimilarity.FilePair, len(specs))
+
+	for idx, spec := range specs {
+		globs := strings.Fields(spec)
+		if len(globs) != 2 {
+			return nil, fmt.Errorf("%w: %s", errInvalidFilePair, spec)
+		}
+
+		exprs, err := compileGlobs(globs)
+		if err != nil {
+			return nil, err
+		}
+
+		pairs[idx] = textsimilarity.FilePair{
+			Name1: exprs[0],
+			Name2: exprs[1],
+		}
+	}
+
+	return pairs, nil
+}
+
+// compileGlobs compiles all globs into regular expressions.
+func compileGlobs(globs []string) ([]*regexp.Regexp, error) {
+	exprs := make([]*regexp.Regexp, len(globs))
+
+	for idx, glob := range globs {
+		expr, err := ignore.Glob(filepath.ToSlash(glob))
+		if err != nil {
+			return nil, err //nolint:wrapcheck // already wrapped
+		}
+
+		exprs[idx] = expr
+	}
+
+	return exprs, nil
+}
+
+// filter returns those similarities of sims that have at least one occurrence matching any of f.only
+// (if any), and none matching any of f.not.
+func (f *pathFilter) filter(sims []*textsimilarity.Similarity) []*textsimilarity.Similarity {
+	if len(f.only) == 0 && len(f.not) == 0 {
+		return sims
+	}
+
+	filtered := make([]*textsimilarity.Similarity, 0, len(sims))
+
+	for _, sim := range sims {
+		if len(f.only) != 0 && !anyOccurrenceMatches(sim, f.only) {
+			continue
+		}
+
+		if anyOccurrenceMatches(sim, f.not) {
+			continue
+		}
+
+		filtered = append(filtered, sim)
+	}
+
+	return filtered
+}
+
+// anyOccurrenceMatches returns whether the file path of any occurrence of sim matches any of exprs.
+func anyOccurrenceMatches(sim *textsimilarity.Similarity, exprs []*regexp.Regexp) bool {
+	for _, occ := range sim.Occurrences {
+		name := filepath.ToSlash(filepath.Clean(occ.File.Name))
+
+		for _, expr := range exprs {
+			if expr.MatchString(name) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,55 @@
+package main
+
+import "strings"
+
+// stringsFlag is a command line flag that can be specified multiple times, collecting all values.
+type stringsFlag []string
+
+// String implements flag.Value.
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ", ")
+}
+
+// Set implements flag.Value.
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// optionalStringFlag is a command line flag that can be specified with or without a value, such as "-flag" or "-flag=value".
+type optionalStringFlag struct {
+	// set indicates whether the flag has been specified.
+	set bool
+
+	// value is the value of the flag. If the flag has been specified without a value, value is left untouched.
+	value string
+}
+
+// String implements flag.Value.
+func (f *optionalStringFlag) String() string {
+	if f == nil {
+		return ""
+	}
+
+	return f.value
+}
+
+// Set implements flag.Value.
+func (f *optionalStringFlag) Set(value string) error {
+	switch value {
+	case "true":
+		f.set = true
+	case "false":
+		f.set = false
+	default:
+		f.set = true
+		f.value = value
+	}
+
+	return nil
+}
+
+// IsBoolFlag implements flag.boolFlag, allowing the flag to be specified without a value.
+func (f *optionalStringFlag) IsBoolFlag() bool {
+	return true
+}
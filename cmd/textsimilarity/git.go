@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// defaultGitBase is the git ref that changed files are determined against if no other ref is given.
+const defaultGitBase = "HEAD"
+
+// gitChangedFiles returns the absolute paths of all files that have been changed in the working tree
+// relative to git ref base, including untracked files that are not ignored.
+func gitChangedFiles(ctx context.Context, base string) (map[string]struct{}, error) {
+	changed, err := gitFileList(ctx, "diff", "--name-only", "--relative", "--diff-filter=ACMR", "-z", base, "--")
+	if err != nil {
+		return nil, err
+	}
+
+	untracked, err := gitFileList(ctx, "ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]struct{}{}
+
+	for _, path := range append(changed, untracked...) {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("absolute path of %s: %w", path, err)
+		}
+
+		files[absPath] = struct{}{}
+	}
+
+	return files, nil
+}
+
+// gitFileList runs git with args and returns its output as a list of NUL-separated paths.
+func gitFileList(ctx context.Context, args ...string) ([]string, error) {
+	out, err := gitOutput(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := []string{}
+
+	for _, path := range strings.Split(string(out), "\x00") {
+		if path == "" {
+			continue
+		}
+
+		paths = append(paths, filepath.FromSlash(path))
+	}
+
+	return paths, nil
+}
+
+// gitOutput runs git with args and returns its output.
+func gitOutput(ctx context.Context, args ...string) ([]byte, error) {
+	stdout := bytes.Buffer{}
+	stderr := bytes.Buffer{}
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
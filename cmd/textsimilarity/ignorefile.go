@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	// ignoreFileGlobPrefix is the prefix of lines in an ignore file that specify globs of files to ignore.
+	ignoreFileGlobPrefix = "file:"
+
+	// ignoreFilePairPrefix is the prefix of lines in an ignore file that specify pairs of globs of files
+	// that should not be compared with each other.
+	ignoreFilePairPrefix = "pair:"
+)
+
+// ignoreFile holds the patterns read from an ignore file.
+type ignoreFile struct {
+	// lineExprs are the regular expressions of lines to ignore.
+	lineExprs []string
+
+	// fileGlobs are the globs of files to ignore.
+	fileGlobs []string
+
+	// filePairs are the pairs of globs of files that should not be compared with each other,
+	// separated by whitespace.
+	filePairs []string
+}
+
+// readIgnoreFile reads the ignore file at path. Each line of the file is either a regular expression of lines
+// to ignore, a glob of files to ignore if prefixed by ignoreFileGlobPrefix, or a pair of globs of files that
+// should not be compared with each other if prefixed by ignoreFilePairPrefix. Blank lines and lines starting
+// with "#" are skipped.
+func readIgnoreFile(path string) (*ignoreFile, error) {
+	lines, err := readPathList(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ignore := ignoreFile{}
+
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "#"):
+			continue
+
+		case strings.HasPrefix(line, ignoreFileGlobPrefix):
+			ignore.fileGlobs = append(ignore.fileGlobs, strings.TrimSpace(strings.TrimPrefix(line, ignoreFileGlobPrefix)))
+
+		case strings.HasPrefix(line, ignoreFilePairPrefix):
+			ignore.filePairs = append(ignore.filePairs, strings.TrimSpace(strings.TrimPrefix(line, ignoreFilePairPrefix)))
+
+		default:
+			if _, err := regexp.Compile(line); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+
+			ignore.lineExprs = append(ignore.lineExprs, line)
+		}
+	}
+
+	return &ignore, nil
+}
+
+// combineRegexes returns a single regular expression that matches if any of exprs matches.
+func combineRegexes(exprs []string) string {
+	if len(exprs) == 1 {
+		return exprs[0]
+	}
+
+	parts := make([]string, len(exprs))
+	for idx, expr := range exprs {
+		parts[idx] = "(?:" + expr + ")"
+	}
+
+	return strings.Join(parts, "|")
+}
+
+// excludePaths returns those paths that do not match any of globs.
+func excludePaths(paths []string, globs []string) ([]string, error) {
+	if len(globs) == 0 {
+		return paths, nil
+	}
+
+	exprs, err := compileGlobs(globs)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]string, 0, len(paths))
+
+paths:
+	for _, path := range paths {
+		slashPath := filepath.ToSlash(filepath.Clean(path))
+
+		for _, expr := range exprs {
+			if expr.MatchString(slashPath) {
+				continue paths
+			}
+		}
+
+		filtered = append(filtered, path)
+	}
+
+	return filtered, nil
+}
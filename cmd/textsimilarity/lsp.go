@@ -0,0 +1,390 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blizzy78/textsimilarity"
+	tsio "github.com/blizzy78/textsimilarity/internal/io"
+	"github.com/blizzy78/textsimilarity/internal/lsp"
+)
+
+// lspDebounce is the time to wait after a document has been changed before computing diagnostics again.
+const lspDebounce = 300 * time.Millisecond
+
+// A lspWorkspace finds similarities between documents open in an editor and files in a workspace, and converts
+// them to diagnostics. Each open document is scanned against all other documents and files, so that it only
+// needs to be scanned again when it, or a document it shares similarities with, has changed.
+type lspWorkspace struct {
+	opts textsimilarity.Options
+
+	// files maps absolute paths of files in the workspace to their lines. Open documents take precedence.
+	files map[string][]string
+
+	// results maps the URIs of open documents to the similarities found when last scanning them.
+	results map[lsp.DocumentURI][]*textsimilarity.Similarity
+}
+
+// serveLSP serves the Language Server Protocol on stdin and stdout, publishing similarities between documents open
+// in the editor and files in paths as diagnostics, according to opts. If paths is empty, the current directory
+// is used.
+func serveLSP(ctx context.Context, paths []string, opts cmdOptions) (int, error) {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	workspace, err := newLSPWorkspace(ctx, paths, opts)
+	if err != nil {
+		return -1, err
+	}
+
+	server := lsp.Server{
+		Name:     "textsimilarity",
+		Diagnose: workspace.diagnose,
+		Debounce: lspDebounce,
+	}
+
+	if err := server.Serve(ctx, os.Stdin, os.Stdout); err != nil {
+		if contextDone(ctx) {
+			return -1, errCanceled
+		}
+
+		return -1, err //nolint:wrapcheck // already wrapped
+	}
+
+	return 0, nil
+}
+
+// newLSPWorkspace returns a new workspace containing the files in paths, according to opts.
+func newLSPWorkspace(ctx context.Context, paths []string, opts cmdOptions) (*lspWorkspace, error) {
+	objects := newObjectStore()
+
+	paths, err := expandPaths(ctx, paths, opts.useIgnoreFiles, objects)
+	if err != nil {
+		return nil, err
+	}
+
+	if !objects.empty() {
+		return nil, errObjectsUnsupported
+	}
+
+	paths, err = excludePaths(paths, opts.ignoreFileGlobs)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.skipGenerated {
+		paths, err = skipGenerated(ctx, paths)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	files := make(map[string][]string, len(paths))
+
+	for _, path := range paths {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("absolute path of %s: %w", path, err)
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+
+		files[absPath], err = splitLines(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+	}
+
+	return &lspWorkspace{
+		opts:    opts.simOpts,
+		files:   files,
+		results: map[lsp.DocumentURI][]*textsimilarity.Similarity{},
+	}, nil
+}
+
+// diagnose implements lsp.Server.Diagnose. Changed documents are scanned again, as well as documents whose
+// similarities involved changed documents, or which share similarities with documents scanned again.
+func (w *lspWorkspace) diagnose(ctx context.Context, docs map[lsp.DocumentURI]string,
+	changed []lsp.DocumentURI,
+) (map[lsp.DocumentURI][]lsp.Diagnostic, error) {
+	docURIs := make(map[string]lsp.DocumentURI, len(docs))
+	for uri := range docs {
+		docURIs[documentName(uri)] = uri
+	}
+
+	changedNames := map[string]struct{}{}
+	rescan := map[lsp.DocumentURI]struct{}{}
+
+	for _, uri := range changed {
+		changedNames[documentName(uri)] = struct{}{}
+
+		if _, ok := docs[uri]; ok {
+			rescan[uri] = struct{}{}
+		} else {
+			delete(w.results, uri)
+			w.reload(uri)
+		}
+	}
+
+	for uri, sims := range w.results {
+		if involvesAny(sims, changedNames) {
+			rescan[uri] = struct{}{}
+		}
+	}
+
+	diags := map[lsp.DocumentURI][]lsp.Diagnostic{}
+
+	// documents sharing new similarities with documents scanned in the first round are scanned in a second round
+	for round := 0; round < 2 && len(rescan) != 0; round++ {
+		scanned := sortedURIs(rescan)
+		rescan = map[lsp.DocumentURI]struct{}{}
+
+		for _, uri := range scanned {
+			sims, err := w.scan(ctx, uri, docs, docURIs)
+			if err != nil {
+				return nil, err
+			}
+
+			w.results[uri] = sims
+			diags[uri] = documentDiagnostics(uri, sims)
+		}
+
+		for _, uri := range scanned {
+			for _, sim := range w.results[uri] {
+				for _, occ := range sim.Occurrences {
+					otherURI, ok := docURIs[occ.File.Name]
+					if !ok {
+						continue
+					}
+
+					if _, ok := diags[otherURI]; !ok {
+						rescan[otherURI] = struct{}{}
+					}
+				}
+			}
+		}
+	}
+
+	return diags, nil
+}
+
+// reload reads the file of the closed document with uri again if it is part of w, since it may have been saved.
+// If it cannot be read, it is removed from w.
+func (w *lspWorkspace) reload(uri lsp.DocumentURI) {
+	path := documentName(uri)
+	if _, ok := w.files[path]; !ok {
+		return
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		delete(w.files, path)
+		return
+	}
+
+	lines, err := splitLines(string(content))
+	if err != nil {
+		delete(w.files, path)
+		return
+	}
+
+	w.files[path] = lines
+}
+
+// scan returns the similarities found by scanning the open document with uri against all other open documents
+// docs and files in w. docURIs maps names of open documents to their URIs.
+func (w *lspWorkspace) scan(ctx context.Context, uri lsp.DocumentURI, docs map[lsp.DocumentURI]string,
+	docURIs map[string]lsp.DocumentURI,
+) ([]*textsimilarity.Similarity, error) {
+	files := make([]*textsimilarity.File, 0, len(docs)+len(w.files))
+
+	for _, docURI := range sortedURIs(docs) {
+		lines, err := splitLines(docs[docURI])
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", docURI, err)
+		}
+
+		files = append(files, &textsimilarity.File{
+			Name:          documentName(docURI),
+			Lines:         lines,
+			ReferenceOnly: docURI != uri,
+		})
+	}
+
+	paths := make([]string, 0, len(w.files))
+	for path := range w.files {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if _, ok := docURIs[path]; ok {
+			continue
+		}
+
+		files = append(files, &textsimilarity.File{
+			Name:          path,
+			Lines:         w.files[path],
+			ReferenceOnly: true,
+		})
+	}
+
+	return findSimilarities(ctx, files, w.opts, func(textsimilarity.Progress) {})
+}
+
+// documentDiagnostics returns diagnostics for the occurrences of sims in the document with uri, with the other
+// occurrences as related information.
+func documentDiagnostics(uri lsp.DocumentURI, sims []*textsimilarity.Similarity) []lsp.Diagnostic {
+	name := documentName(uri)
+	diags := []lsp.Diagnostic{}
+
+	for _, sim := range sims {
+		for idx, occ := range sim.Occurrences {
+			if occ.File.Name != name {
+				continue
+			}
+
+			others := make([]string, 0, len(sim.Occurrences)-1)
+			related := make([]lsp.RelatedInformation, 0, len(sim.Occurrences)-1)
+
+			for otherIdx, other := range sim.Occurrences {
+				if otherIdx == idx {
+					continue
+				}
+
+				others = append(others, displayName(other.File.Name)+":"+occurrenceLines(other))
+
+				related = append(related, lsp.RelatedInformation{
+					Location: lsp.Location{URI: nameURI(other.File.Name), Range: occurrenceRange(other)},
+					Message:  "other occurrence",
+				})
+			}
+
+			diags = append(diags, lsp.Diagnostic{
+				Range:              occurrenceRange(occ),
+				Severity:           lsp.SeverityInformation,
+				Source:             "textsimilarity",
+				Message:            fmt.Sprintf("%d lines %s to %s", occ.End-occ.Start, sim.Level.String(), strings.Join(others, ", ")),
+				RelatedInformation: related,
+			})
+		}
+	}
+
+	return diags
+}
+
+// occurrenceRange returns the range of the lines of occ.
+func occurrenceRange(occ *textsimilarity.FileOccurrence) lsp.Range {
+	return lsp.Range{
+		Start: lsp.Position{Line: occ.Start},
+		End:   lsp.Position{Line: occ.End},
+	}
+}
+
+// occurrenceLines returns a human-readable line range of occ, with one-based line numbers.
+func occurrenceLines(occ *textsimilarity.FileOccurrence) string {
+	if occ.End == occ.Start+1 {
+		return fmt.Sprintf("%d", occ.Start+1)
+	}
+
+	return fmt.Sprintf("%d-%d", occ.Start+1, occ.End)
+}
+
+// involvesAny returns whether any occurrence of sims is in a file with a name contained in names.
+func involvesAny(sims []*textsimilarity.Similarity, names map[string]struct{}) bool {
+	for _, sim := range sims {
+		for _, occ := range sim.Occurrences {
+			if _, ok := names[occ.File.Name]; ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// documentName returns the name of the file of the document with uri: its absolute path if it is a file URI,
+// or the URI itself otherwise.
+func documentName(uri lsp.DocumentURI) string {
+	if path, ok := uri.Path(); ok {
+		return path
+	}
+
+	return string(uri)
+}
+
+// nameURI returns the URI of the document with file name.
+func nameURI(name string) lsp.DocumentURI {
+	if filepath.IsAbs(name) {
+		return lsp.FileURI(name)
+	}
+
+	return lsp.DocumentURI(name)
+}
+
+// displayName returns name relative to the current directory if possible.
+func displayName(name string) string {
+	if !filepath.IsAbs(name) {
+		return name
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return name
+	}
+
+	rel, err := filepath.Rel(wd, name)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return name
+	}
+
+	return rel
+}
+
+// sortedURIs returns the keys of m, sorted.
+func sortedURIs[T any](m map[lsp.DocumentURI]T) []lsp.DocumentURI {
+	uris := make([]lsp.DocumentURI, 0, len(m))
+	for uri := range m {
+		uris = append(uris, uri)
+	}
+
+	sort.Slice(uris, func(a int, b int) bool {
+		return uris[a] < uris[b]
+	})
+
+	return uris
+}
+
+// splitLines returns the lines of text, without line terminators.
+func splitLines(text string) ([]string, error) {
+	reader := bufio.NewReader(strings.NewReader(text))
+	buf := bytes.Buffer{}
+	lines := []string{}
+
+	for {
+		line, err := tsio.ReadLine(reader, &buf)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return lines, nil
+			}
+
+			return nil, err //nolint:wrapcheck // wrapped by callers
+		}
+
+		lines = append(lines, line)
+	}
+}
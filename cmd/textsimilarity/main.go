@@ -1,19 +1,15 @@
 package main //nolint:revive // no need for package documentation here
 
 import (
-	"bufio"
-	"bytes"
 	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"os/signal"
+	"path/filepath"
 	"regexp"
-	"sort"
-	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -21,50 +17,172 @@ import (
 	"time"
 
 	"github.com/blizzy78/textsimilarity"
-	tsio "github.com/blizzy78/textsimilarity/internal/io"
-)
-
-const (
-	// clearLine is the ANSI escape sequence to clear the current line.
-	clearLine = "\033[2K"
-
-	// moveUp is the ANSI escape sequence to move cursor to the beginning of the previous line.
-	moveUp = "\033[F"
+	"github.com/blizzy78/textsimilarity/prose"
+	"github.com/blizzy78/textsimilarity/report"
 )
 
 // cmdOptions holds command line options.
 type cmdOptions struct {
+	// command is the subcommand to run.
+	command command
+
+	// watchInterval is the interval between scans of the watch subcommand.
+	watchInterval time.Duration
+
+	// metricsAddr is the address to serve metrics on in the watch subcommand, or empty to not serve metrics.
+	metricsAddr string
+
+	// baselinePath is the path of the baseline file used by baseline subcommands.
+	baselinePath string
+
+	// timeout is the maximum duration of a scan, or 0 for no limit. When exceeded, similarities found so far
+	// are reported.
+	timeout time.Duration
+
+	// checkpointPath, if set, is the path of a file to periodically write checkpoints of a scan to.
+	checkpointPath string
+
+	// checkpointInterval is the interval between checkpoints.
+	checkpointInterval time.Duration
+
+	// resume indicates whether a scan should be resumed from the checkpoint file, if it exists.
+	resume bool
+
 	// showProgress indicates whether progress should be written to stderr.
 	showProgress bool
 
-	// printEqual indicates whether exactly equal similarities should be printed.
-	printEqual bool
+	// progressFormat is the format to write progress in.
+	progressFormat progressFormat
+
+	// outputs are the destinations to write reports to.
+	outputs []output
+
+	// reportMode is the kind of report to write.
+	reportMode reportMode
+
+	// clustering specifies how files are grouped into clusters.
+	clustering clustering
+
+	// duplicateSimilarity is the minimum similarity of files to be reported as near-duplicates (0-1.)
+	duplicateSimilarity float64
+
+	// duplicateEngine is the name of the engine used to find near-duplicate files.
+	duplicateEngine string
+
+	// top is the maximum number of similarities to report, or 0 to report all.
+	top int
+
+	// pruneSubsumed indicates whether similarities contained in larger similarities should be dropped.
+	pruneSubsumed bool
+
+	// sortOrder is the order in which to report similarities.
+	sortOrder sortOrder
 
-	// diffTool is a command line template for a diff tool to print similar, but not exactly equal, similarities.
-	diffTool *template.Template
+	// ranking is the ranking used to compute the scores of similarities.
+	ranking *textsimilarity.Ranking
 
-	// ignoreDiffToolRC indicates whether the return code of running diffTool should be ignored.
-	ignoreDiffToolRC bool
+	// pathFilter filters similarities by the paths of the files they occur in.
+	pathFilter *pathFilter
+
+	// reportOpts specifies options for the reporter.
+	reportOpts report.Options
+
+	// ignoreFileGlobs are the globs of input files to ignore.
+	ignoreFileGlobs []string
+
+	// filesFrom is the path of a file to read newline-separated input paths from, or "-" for stdin.
+	filesFrom string
+
+	// skipGenerated indicates whether generated, minified, and vendored files should be skipped.
+	skipGenerated bool
+
+	// cacheDir, if set, is the directory to cache results in, to reuse similarities between unchanged files.
+	cacheDir string
+
+	// gitBase, if set, is the git ref to determine changed files against. Only changed files will be
+	// scanned for similarities, but they will be compared against all files.
+	gitBase string
+
+	// staged indicates whether the staged contents of files in the git index should be scanned instead of the
+	// contents in the working tree. Only staged files will be scanned for similarities, but they will be
+	// compared against all files in the index.
+	staged bool
+
+	// useIgnoreFiles indicates whether .gitignore/.ignore files should be honored when walking directories.
+	useIgnoreFiles bool
+
+	// thresholds holds limits that cause a non-zero exit code when exceeded.
+	thresholds thresholds
+
+	// profiles holds the paths of files to write profiles of the run to.
+	profiles profiles
+
+	// stats indicates whether the time spent in each phase of a scan, and per file, should be written to stderr.
+	stats bool
+
+	// prose indicates whether files are scanned as prose documents, using a prose.SentenceSegmenter, and the
+	// percentage of matched sentences of each document should be written to stderr.
+	prose bool
 
 	// simOpts specifies options for similarity calculations.
 	simOpts textsimilarity.Options
 }
 
+// timeoutExitCode is the exit code used when a scan times out without exceeding any limit in its partial results.
+const timeoutExitCode = 2
+
 var (
 	// errCanceled is returned when the context is canceled.
 	errCanceled = errors.New("")
 
 	// errNoFiles is returned when no files are specified.
 	errNoFiles = errors.New("no files given")
+
+	// errTimeout is returned when a scan times out and its partial results cannot be used.
+	errTimeout = errors.New("scan timed out")
+
+	// errResumeWithoutCheckpoint is returned when a scan should be resumed without a checkpoint file.
+	errResumeWithoutCheckpoint = errors.New("-resume requires -checkpoint")
+
+	// errTrendReports is returned when the trend subcommand is not given exactly two reports.
+	errTrendReports = errors.New("trend requires exactly two reports")
+
+	// errInvalidDuplicateSimilarity is returned when the minimum similarity of near-duplicate files is out of range.
+	errInvalidDuplicateSimilarity = errors.New("-duplicate-similarity must be greater than 0 and at most 1")
+
+	// errInvalidJaroWinkler is returned when the minimum Jaro-Winkler similarity of similar lines is out of range.
+	errInvalidJaroWinkler = errors.New("-min-jaro-winkler must be greater than 0 and at most 1")
+
+	// errInvalidCosine is returned when the minimum cosine similarity of blocks is out of range.
+	errInvalidCosine = errors.New("-min-cosine must be at least 0 and at most 1")
+
+	// errInvalidWindowSimilarity is returned when the minimum fraction of similar lines of windows is out of range.
+	errInvalidWindowSimilarity = errors.New("-min-window-similarity must be greater than 0 and at most 1")
+
+	// errStagedUnsupported is returned when staged files should be scanned with options that require files
+	// in the working tree.
+	errStagedUnsupported = errors.New("-staged is not supported with -git-changed and -checkpoint")
+
+	// errHintsWithoutClasses is returned when -hints is used without -report classes.
+	errHintsWithoutClasses = errors.New("-hints requires -report classes")
 )
 
 func main() {
-	opts, err := options()
+	flag.Usage = usage
+
+	cmd, args, err := parseCommand(os.Args[1:])
+	if err != nil {
+		panic(err)
+	}
+
+	opts, err := options(cmd, args)
 	if err != nil {
 		panic(err)
 	}
 
-	ret, err := run(flag.Args(), opts)
+	ret, err := opts.profiles.run(func() (int, error) {
+		return run(flag.Args(), opts)
+	})
 	if err != nil {
 		if errors.Is(err, errCanceled) {
 			if opts.showProgress {
@@ -80,38 +198,213 @@ func main() {
 	os.Exit(ret)
 }
 
-// options parses and returns the command line options.
-func options() (cmdOptions, error) {
+// options parses args and returns the command line options for cmd.
+func options(cmd command, args []string) (cmdOptions, error) {
+	baselinePath := defaultBaselinePath
+	watchInterval := defaultWatchInterval
+	metricsAddr := ""
+
+	timeout := time.Duration(0)
+	checkpointPath := ""
+	checkpointInterval := time.Minute
+	resume := false
 	showProgress := false
+	progressFormatName := string(autoProgressFormat)
 	printEqual := false
 	diffTool := ""
 	ignoreDiffToolRC := false
+	linkFormat := ""
+	builtinDiff := false
+	format := "text"
+	outputPaths := stringsFlag{}
+	onlyGlobs := stringsFlag{}
+	notGlobs := stringsFlag{}
+	excludePairs := stringsFlag{}
+	reportModeName := string(similaritiesReportMode)
+	noIgnoreFiles := false
+	filesFrom := ""
+	cacheDir := ""
+	skipGenerated := true
+	gitChanged := optionalStringFlag{value: defaultGitBase}
+	staged := false
+	maxSimilarities := -1
+	maxDuplicatedLines := -1
+	maxDuplicationPct := -1.0
 
 	ignoreWhitespace := false
 	ignoreBlankLines := false
+	skipDisjoint := false
+	exactSeeds := false
+	maskLiterals := false
+	reordered := false
+	goFunctions := false
+	wordDistance := false
+	proseMode := false
+	stem := false
+	stripComments := optionalStringFlag{value: defaultCommentMarkers}
 	minLineLength := 0
 	minSimilarLines := 10
+	minSimilarChars := 0
+	lineWeightRules := stringsFlag{}
+	tierSpecs := stringsFlag{}
+	weightLength := 0
 	maxEditDistance := textsimilarity.DefaultMaxEditDistance
+	maxGapLines := 0
+	lineMetricName := "levenshtein"
+	overlapPolicyName := "drop"
+	minJaroWinkler := textsimilarity.DefaultMinJaroWinklerSimilarity
+	windowLines := 0
+	minWindowSimilarity := textsimilarity.DefaultMinWindowSimilarity
+	minCosine := 0.0
+	maxSimHashDistance := 0
+	simHashes := false
 	ignoreLineRegex := ""
-
+	ignoreFrom := ""
+	transforms := stringsFlag{}
+	parallelism := 0
+	maxMemoryMB := 0
+	spillDir := ""
+	lineCacheSize := 0
+	minOccurrences := 0
+	maxOccurrences := 0
+	maxOccurrencesPerSimilarity := 0
+	top := 0
+	pruneSubsumed := false
+	previewLines := 0
+	coverage := false
+	hints := false
+	sortOrderName := string(scoreSortOrder)
+	rankingSpec := ""
+	linkageName := "average"
+	clusterSimilarity := 0.5
+	duplicateSimilarity := 0.9
+	duplicateEngine := "lines"
+	fuzzyHashes := false
+	cpuProfile := ""
+	memProfile := ""
+	tracePath := ""
+	stats := false
+
+	flag.DurationVar(&timeout, "timeout", timeout, "maximum duration of a scan, reporting similarities found so far when exceeded (0 for no limit)")
 	flag.BoolVar(&showProgress, "progress", showProgress, "write progress to stderr")
+	flag.StringVar(&progressFormatName, "progress-format", progressFormatName, "progress format (auto, bar, plain, or json), auto uses bar on terminals and plain otherwise (implies -progress)")
 	flag.BoolVar(&printEqual, "printEqual", printEqual, "print equal similarities")
 	flag.StringVar(&diffTool, "diffTool", diffTool, "diff tool command line template")
 	flag.BoolVar(&ignoreDiffToolRC, "ignoreDiffToolRC", ignoreDiffToolRC, "ignore diff tool return code")
+	flag.StringVar(&linkFormat, "link-format", linkFormat, "template for links to occurrences, using {{.Path}}, {{.AbsPath}}, {{.Line}}, {{.EndLine}}")
+	flag.BoolVar(&builtinDiff, "diff", builtinDiff, "print differences of similar similarities using built-in diff renderer")
+	flag.IntVar(&previewLines, "preview", previewLines, "print the first N lines of each occurrence (text format only)")
+	flag.BoolVar(&simHashes, "simhash", simHashes, "include SimHash fingerprints of occurrences (json format only)")
+	flag.BoolVar(&fuzzyHashes, "fuzzy-hash", fuzzyHashes, "include ssdeep-style fuzzy hashes of occurrences (json format only)")
+	flag.BoolVar(&coverage, "coverage", coverage, "include the number of similarities covering each line of each file (json and html formats only)")
+	flag.BoolVar(&hints, "hints", hints, "include a canonical representative and refactoring hints for each clone class (requires -report classes)")
+	flag.StringVar(&format, "format", format, "report format ("+strings.Join(report.Names(), ", ")+")")
+	flag.StringVar(&reportModeName, "report", reportModeName, "kind of report ("+string(similaritiesReportMode)+", "+string(filesReportMode)+", "+string(classesReportMode)+", "+string(matrixReportMode)+", "+string(clustersReportMode)+", "+string(duplicatesReportMode)+")")
+	flag.StringVar(&linkageName, "linkage", linkageName, "linkage of clusters of files ("+strings.Join(linkageNames(), ", ")+")")
+	flag.Float64Var(&clusterSimilarity, "cluster-similarity", clusterSimilarity, "minimum similarity of clusters of files to be merged (0-1)")
+	flag.Float64Var(&duplicateSimilarity, "duplicate-similarity", duplicateSimilarity, "minimum fraction of identical lines of near-duplicate files (0-1)")
+	flag.StringVar(&duplicateEngine, "duplicate-engine", duplicateEngine, "engine to find near-duplicate files ("+strings.Join(duplicateEngineNames(), ", ")+"), ctph compares ssdeep-style fuzzy hashes")
+	flag.IntVar(&top, "top", top, "only report the N highest-ranked similarities (see -ranking; 0 to report all)")
+	flag.BoolVar(&pruneSubsumed, "prune-subsumed", pruneSubsumed, "drop similarities whose occurrences are contained in a larger similarity")
+	flag.StringVar(&sortOrderName, "sort", sortOrderName, "order of similarities ("+strings.Join(sortOrderNames(), ", ")+")")
+	flag.StringVar(&rankingSpec, "ranking", rankingSpec, "ranking of similarities by score, as comma-separated key=value pairs (lines, occurrences, equal, similar, reordered)")
+	flag.Var(&onlyGlobs, "only", "only report similarities that occur in files matching glob (may be repeated)")
+	flag.Var(&notGlobs, "not", "do not report similarities that occur in files matching glob (may be repeated)")
+	flag.Var(&excludePairs, "exclude-pair", "do not compare files matching the first glob with files matching the second, as \"glob1 glob2\" (may be repeated)")
+	flag.Var(&outputPaths, "output", "write report to file instead of stdout, format derived from file extension (may be repeated)")
+	flag.StringVar(&filesFrom, "files-from", filesFrom, "read newline-separated input paths from file (\"-\" for stdin)")
+	flag.Var(&gitChanged, "git-changed", "only scan files changed relative to git ref (default "+defaultGitBase+"), comparing them against all files")
+	flag.BoolVar(&staged, "staged", staged, "only scan files staged in the git index, using their staged contents, comparing them against all files (default path .)")
+	flag.StringVar(&cacheDir, "cache-dir", cacheDir, "cache results in directory to reuse similarities between unchanged files (not with -git-changed)")
+	flag.BoolVar(&skipGenerated, "skip-generated", skipGenerated, "skip generated, minified, and vendored files")
+	flag.BoolVar(&noIgnoreFiles, "no-ignore-files", noIgnoreFiles, "do not honor .gitignore/.ignore files when walking directories")
+	flag.IntVar(&maxSimilarities, "max-similarities", maxSimilarities, "exit with non-zero code if number of similarities exceeds this (-1 to disable)")
+	flag.IntVar(&maxDuplicatedLines, "max-duplicated-lines", maxDuplicatedLines, "exit with non-zero code if number of duplicated lines exceeds this (-1 to disable)")
+	flag.Float64Var(&maxDuplicationPct, "max-duplication-pct", maxDuplicationPct, "exit with non-zero code if percentage of duplicated lines exceeds this (-1 to disable)")
 
 	flag.BoolVar(&ignoreWhitespace, "ignoreWS", ignoreWhitespace, "ignore whitespace")
 	flag.BoolVar(&ignoreBlankLines, "ignoreBlank", ignoreBlankLines, "ignore blank lines")
+	flag.BoolVar(&exactSeeds, "exact-seeds", exactSeeds, "only start similarities from exactly equal lines (faster, but may miss similarities)")
+	flag.BoolVar(&skipDisjoint, "skip-disjoint", skipDisjoint, "do not compare files that do not share any exactly equal lines (faster, but may miss similarities)")
+	flag.BoolVar(&reordered, "reordered", reordered, "also report blocks containing the same lines in a different order")
+	flag.BoolVar(&wordDistance, "word-distance", wordDistance, "measure edit distance in words instead of characters (for prose)")
+	flag.BoolVar(&proseMode, "prose", proseMode, "compare sentences instead of lines, ignoring case, punctuation, and stopwords, and report the percentage of matched sentences per document")
+	flag.BoolVar(&stem, "stem", stem, "reduce words to their stems before comparing sentences (requires -prose)")
+	flag.BoolVar(&goFunctions, "go-functions", goFunctions, "only find similarities within bodies of functions in .go files, reporting function names")
+	flag.BoolVar(&maskLiterals, "mask-literals", maskLiterals, "replace numeric and string literals with placeholders before comparing lines")
+	flag.Var(&stripComments, "strip-comments", "remove trailing comments before comparing lines, using comma-separated markers (default \""+defaultCommentMarkers+"\")")
 	flag.IntVar(&minLineLength, "minLen", minLineLength, "minimum line length")
 	flag.IntVar(&minSimilarLines, "minLines", minSimilarLines, "minimum similar lines")
+	flag.IntVar(&minSimilarChars, "min-chars", minSimilarChars, "minimum characters of similar lines, in addition to -minLines (0 for no minimum)")
+	flag.Var(&lineWeightRules, "line-weight", "weight of lines matching regex towards -minLines, as \"regex=weight\" (may be repeated)")
+	flag.IntVar(&weightLength, "weight-length", weightLength, "weigh lines shorter than N characters proportionally less towards -minLines (0 to disable)")
 	flag.IntVar(&maxEditDistance, "maxDist", maxEditDistance, "maximum edit distance")
+	flag.IntVar(&maxGapLines, "max-gap", maxGapLines, "maximum consecutive lines inserted into or deleted from one of two occurrences of a similarity (0 to disable)")
+	flag.IntVar(&windowLines, "window", windowLines, "slide a window of N lines across files instead of expanding similar lines into blocks, for noisy inputs (0 to disable)")
+	flag.Float64Var(&minWindowSimilarity, "min-window-similarity", minWindowSimilarity, "minimum fraction of similar lines of windows with -window (0-1)")
+	flag.Var(&tierSpecs, "tier", "tier of similarities by largest edit distance between their lines, as \"name=maxDist\" (may be repeated)")
+	flag.StringVar(&lineMetricName, "line-metric", lineMetricName, "metric of similar lines ("+strings.Join(lineMetricNames(), ", ")+")")
+	flag.StringVar(&overlapPolicyName, "overlap", overlapPolicyName, "policy for similarities overlapping earlier ones ("+strings.Join(overlapPolicyNames(), ", ")+")")
+	flag.Float64Var(&minJaroWinkler, "min-jaro-winkler", minJaroWinkler, "minimum Jaro-Winkler similarity of similar lines with -line-metric jaro-winkler (0-1)")
+	flag.Float64Var(&minCosine, "min-cosine", minCosine, "also report blocks of -minLines lines whose words have a TF-IDF cosine similarity of at least this, to find paraphrased prose (0-1, 0 to disable)")
+	flag.IntVar(&maxSimHashDistance, "simhash-distance", maxSimHashDistance, "only compare blocks for -min-cosine whose SimHashes differ in at most N bits (faster for very large corpora, 0 to disable)")
 	flag.StringVar(&ignoreLineRegex, "ignoreRE", ignoreLineRegex, "ignore lines matching regex")
+	flag.StringVar(&ignoreFrom, "ignore-from", ignoreFrom, "read regexes of lines to ignore from file, one per line (\""+ignoreFileGlobPrefix+"glob\" to ignore files, \""+ignoreFilePairPrefix+"glob1 glob2\" to exclude pairs)")
+	flag.Var(&transforms, "transform", "replace matches of regex in lines before comparing them, as \"regex"+transformSeparator+"replacement\" (may be repeated)")
+	flag.IntVar(&minOccurrences, "min-occurrences", minOccurrences, "minimum number of occurrences of a similarity (0 for no minimum)")
+	flag.IntVar(&maxOccurrences, "max-occurrences", maxOccurrences, "maximum number of occurrences of a similarity (0 for no maximum)")
+	flag.IntVar(&maxOccurrencesPerSimilarity, "max-occurrences-per-similarity", maxOccurrencesPerSimilarity,
+		"report at most N occurrences of each similarity, with a count of the omitted ones (0 for no maximum)")
+	flag.IntVar(&parallelism, "j", parallelism, "maximum number of files, or ranges of lines of large files, to process concurrently (0 to derive from CPUs, file sizes, and memory)")
+	flag.IntVar(&maxMemoryMB, "max-memory", maxMemoryMB, "soft limit of memory used for loaded lines in MB, unloading lines of other files when exceeded (0 for no limit)")
+	flag.StringVar(&spillDir, "spill-dir", spillDir, "directory to spill lines to when exceeding -max-memory (default is the system's temporary directory)")
+	flag.IntVar(&lineCacheSize, "line-cache", lineCacheSize, "maximum number of pairs of different lines whose similarity is cached (0 to disable)")
+	flag.StringVar(&cpuProfile, "cpuprofile", cpuProfile, "write CPU profile to file")
+	flag.StringVar(&memProfile, "memprofile", memProfile, "write memory profile to file when finished")
+	flag.StringVar(&tracePath, "trace", tracePath, "write execution trace to file")
+	flag.BoolVar(&stats, "stats", stats, "write time spent per phase and per file to stderr")
+
+	switch cmd {
+	case baselineWriteCommand, baselineCheckCommand:
+		flag.StringVar(&baselinePath, "baseline", baselinePath, "path of baseline file")
+
+	case watchCommand:
+		flag.DurationVar(&watchInterval, "interval", watchInterval, "interval between scans")
+		flag.StringVar(&metricsAddr, "metrics-addr", metricsAddr, "serve Prometheus metrics at /metrics on address (e.g. \":9090\")")
+	}
 
-	flag.Parse()
+	if cmd != watchCommand {
+		flag.StringVar(&checkpointPath, "checkpoint", checkpointPath, "periodically write the state of the scan to file, to be able to resume it with -resume")
+		flag.DurationVar(&checkpointInterval, "checkpoint-interval", checkpointInterval, "interval between checkpoints")
+		flag.BoolVar(&resume, "resume", resume, "resume the scan from the -checkpoint file, if it exists")
+	}
+
+	_ = flag.CommandLine.Parse(args) // exits on error
 
 	simOpts := textsimilarity.Options{
 		MinLineLength:   minLineLength,
 		MinSimilarLines: minSimilarLines,
+		MinSimilarChars: minSimilarChars,
 		MaxEditDistance: maxEditDistance,
+		MaxGapLines:     maxGapLines,
+		WindowLines:     windowLines,
+		LineMetric:      lineMetrics[lineMetricName],
+		OverlapPolicy:   overlapPolicies[overlapPolicyName],
+		Parallelism:     parallelism,
+		MinOccurrences:  minOccurrences,
+		MaxOccurrences:  maxOccurrences,
+		CaptureText:     true,
+		MaxLinesMemory:  int64(maxMemoryMB) * 1024 * 1024,
+		SpillDir:        spillDir,
+		LineCacheSize:   lineCacheSize,
+
+		MaxOccurrencesPerSimilarity: maxOccurrencesPerSimilarity,
+		MinJaroWinklerSimilarity:    minJaroWinkler,
+		MinCosineSimilarity:         minCosine,
+		MinWindowSimilarity:         minWindowSimilarity,
+		MaxSimHashDistance:          maxSimHashDistance,
+		ComputeSimHashes:            simHashes,
+		ComputeFuzzyHashes:          fuzzyHashes,
 	}
 
 	if ignoreWhitespace {
@@ -122,295 +415,579 @@ func options() (cmdOptions, error) {
 		simOpts.Flags |= textsimilarity.IgnoreBlankLinesFlag
 	}
 
+	if skipDisjoint {
+		simOpts.Flags |= textsimilarity.SkipDisjointFilesFlag
+	}
+
+	if exactSeeds {
+		simOpts.Flags |= textsimilarity.ExactSeedingFlag
+	}
+
+	if maskLiterals {
+		simOpts.Flags |= textsimilarity.MaskLiteralsFlag
+	}
+
+	if reordered {
+		simOpts.Flags |= textsimilarity.ReorderedBlocksFlag
+	}
+
+	if goFunctions {
+		simOpts.Flags |= textsimilarity.GoFunctionsFlag
+	}
+
+	if wordDistance {
+		simOpts.Flags |= textsimilarity.WordDistanceFlag
+	}
+
+	lineExprs := []string{}
 	if ignoreLineRegex != "" {
-		simOpts.IgnoreLineRegex = regexp.MustCompile(ignoreLineRegex)
+		lineExprs = append(lineExprs, ignoreLineRegex)
+	}
+
+	var ignoreFileGlobs []string
+
+	if ignoreFrom != "" {
+		ignore, err := readIgnoreFile(ignoreFrom)
+		if err != nil {
+			return cmdOptions{}, err
+		}
+
+		lineExprs = append(lineExprs, ignore.lineExprs...)
+		ignoreFileGlobs = ignore.fileGlobs
+		excludePairs = append(excludePairs, ignore.filePairs...)
+	}
+
+	if len(lineExprs) != 0 {
+		simOpts.IgnoreLineRegex = regexp.MustCompile(combineRegexes(lineExprs))
+	}
+
+	filePairs, err := parseFilePairs(excludePairs)
+	if err != nil {
+		return cmdOptions{}, err
+	}
+
+	simOpts.ExcludedFilePairs = filePairs
+
+	lineWeights, err := newLineWeights(lineWeightRules, weightLength)
+	if err != nil {
+		return cmdOptions{}, err
+	}
+
+	simOpts.LineWeights = lineWeights
+
+	tiers, err := newTiers(tierSpecs)
+	if err != nil {
+		return cmdOptions{}, err
+	}
+
+	simOpts.Tiers = tiers
+
+	ranking, err := parseRanking(rankingSpec)
+	if err != nil {
+		return cmdOptions{}, err
+	}
+
+	lineTransforms, err := parseLineTransforms(transforms)
+	if err != nil {
+		return cmdOptions{}, err
+	}
+
+	simOpts.LineTransforms = lineTransforms
+
+	if stripComments.set {
+		simOpts.TrailingCommentMarkers = strings.Split(stripComments.value, ",")
 	}
 
 	cmdOpts := cmdOptions{
-		showProgress:     showProgress,
-		printEqual:       printEqual,
-		ignoreDiffToolRC: ignoreDiffToolRC,
+		command:             cmd,
+		baselinePath:        baselinePath,
+		watchInterval:       watchInterval,
+		metricsAddr:         metricsAddr,
+		timeout:             timeout,
+		checkpointPath:      checkpointPath,
+		checkpointInterval:  checkpointInterval,
+		resume:              resume,
+		showProgress:        showProgress || progressFormatName != string(autoProgressFormat),
+		progressFormat:      progressFormat(progressFormatName),
+		outputs:             []output{{format: format}},
+		reportMode:          reportMode(reportModeName),
+		clustering:          clustering{linkage: linkages[linkageName], minSimilarity: clusterSimilarity},
+		duplicateSimilarity: duplicateSimilarity,
+		duplicateEngine:     duplicateEngine,
+		top:                 top,
+		pruneSubsumed:       pruneSubsumed,
+		sortOrder:           sortOrder(sortOrderName),
+		ranking:             ranking,
+		filesFrom:           filesFrom,
+		cacheDir:            cacheDir,
+		staged:              staged,
+		skipGenerated:       skipGenerated,
+		ignoreFileGlobs:     ignoreFileGlobs,
+		useIgnoreFiles:      !noIgnoreFiles,
+
+		reportOpts: report.Options{
+			PrintEqual:       printEqual,
+			IgnoreDiffToolRC: ignoreDiffToolRC,
+			Diff:             builtinDiff,
+			PreviewLines:     previewLines,
+			Coverage:         coverage,
+			Hints:            hints,
+		},
+
+		thresholds: thresholds{
+			maxSimilarities:    maxSimilarities,
+			maxDuplicatedLines: maxDuplicatedLines,
+			maxDuplicationPct:  maxDuplicationPct,
+		},
+
+		stats: stats,
+
+		profiles: profiles{
+			cpu:   cpuProfile,
+			mem:   memProfile,
+			trace: tracePath,
+		},
 
 		simOpts: simOpts,
 	}
 
 	if diffTool != "" {
 		var err error
-		cmdOpts.diffTool, err = template.New("diffTool").Parse(diffTool)
+		cmdOpts.reportOpts.DiffTool, err = template.New("diffTool").Parse(diffTool)
 
 		if err != nil {
 			return cmdOptions{}, fmt.Errorf("parse diff tool template: %w", err)
 		}
 	}
 
-	if flag.NArg() == 0 {
-		return cmdOptions{}, errNoFiles
+	if linkFormat != "" {
+		var err error
+		cmdOpts.reportOpts.LinkFormat, err = template.New("linkFormat").Parse(linkFormat)
+
+		if err != nil {
+			return cmdOptions{}, fmt.Errorf("parse link format template: %w", err)
+		}
 	}
 
-	return cmdOpts, nil
-}
+	switch cmdOpts.reportMode {
+	case similaritiesReportMode, filesReportMode, classesReportMode, matrixReportMode, clustersReportMode:
+	case duplicatesReportMode:
+		if cmd == baselineWriteCommand || cmd == baselineCheckCommand {
+			return cmdOptions{}, fmt.Errorf("%w: baseline: %s", errUnsupportedReportMode, cmdOpts.reportMode)
+		}
 
-func run(paths []string, opts cmdOptions) (int, error) {
-	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
-	defer cancel()
+		if duplicateSimilarity <= 0 || duplicateSimilarity > 1 {
+			return cmdOptions{}, fmt.Errorf("%w: %g", errInvalidDuplicateSimilarity, duplicateSimilarity)
+		}
 
-	progress := func(prog textsimilarity.Progress) {
-		if !opts.showProgress {
-			return
+		if _, ok := duplicateEngines[duplicateEngine]; !ok {
+			return cmdOptions{}, fmt.Errorf("%w: %s", errUnknownDuplicateEngine, duplicateEngine)
 		}
 
-		fmt.Fprintf(os.Stderr, "\n"+clearLine+"%s"+moveUp+clearLine+"%.1f%%, ETA: %s   ", prog.File.Name, prog.Done, prog.ETA.Local().Format(time.Kitchen))
+	default:
+		return cmdOptions{}, fmt.Errorf("%w: %s", errUnsupportedReportMode, cmdOpts.reportMode)
 	}
 
-	sims, err := similarities(ctx, paths, opts.simOpts, progress)
+	pathFilter, err := newPathFilter(onlyGlobs, notGlobs)
 	if err != nil {
-		return -1, err
+		return cmdOptions{}, err
 	}
 
-	if opts.showProgress {
-		fmt.Fprint(os.Stderr, clearLine+"\n"+clearLine+moveUp)
+	cmdOpts.pathFilter = pathFilter
+
+	if _, ok := linkages[linkageName]; !ok {
+		return cmdOptions{}, fmt.Errorf("%w: %s", errUnknownLinkage, linkageName)
 	}
 
-	if contextDone(ctx) {
-		return -1, errCanceled
+	if _, ok := lineMetrics[lineMetricName]; !ok {
+		return cmdOptions{}, fmt.Errorf("%w: %s", errUnknownLineMetric, lineMetricName)
 	}
 
-	sortSimilaritiesLines(sims)
+	if _, ok := overlapPolicies[overlapPolicyName]; !ok {
+		return cmdOptions{}, fmt.Errorf("%w: %s", errUnknownOverlapPolicy, overlapPolicyName)
+	}
 
-	if err := printSimilarities(ctx, sims, opts); err != nil {
-		return -1, err
+	if minJaroWinkler <= 0 || minJaroWinkler > 1 {
+		return cmdOptions{}, fmt.Errorf("%w: %g", errInvalidJaroWinkler, minJaroWinkler)
 	}
 
-	rc := 0
-	if len(sims) != 0 {
-		rc = 1
+	if minCosine < 0 || minCosine > 1 {
+		return cmdOptions{}, fmt.Errorf("%w: %g", errInvalidCosine, minCosine)
 	}
 
-	return rc, nil
-}
+	if minWindowSimilarity <= 0 || minWindowSimilarity > 1 {
+		return cmdOptions{}, fmt.Errorf("%w: %g", errInvalidWindowSimilarity, minWindowSimilarity)
+	}
 
-// printSimilarities prints occurrences in sims. If opts.diffTool is set, it will run it to show differences.
-func printSimilarities(ctx context.Context, sims []*textsimilarity.Similarity, opts cmdOptions) error {
-	for idx, sim := range sims {
-		if contextDone(ctx) {
-			return errCanceled
-		}
+	if _, ok := sortOrders[cmdOpts.sortOrder]; !ok {
+		return cmdOptions{}, fmt.Errorf("%w: %s", errUnknownSortOrder, cmdOpts.sortOrder)
+	}
 
-		level := "exactly equal"
-		if sim.Level == textsimilarity.SimilarSimilarityLevel {
-			level = "similar"
-		}
+	if _, ok := progressFormats[cmdOpts.progressFormat]; !ok {
+		return cmdOptions{}, fmt.Errorf("%w: %s", errUnknownProgressFormat, cmdOpts.progressFormat)
+	}
 
-		if idx > 0 {
-			fmt.Println()
+	if gitChanged.set {
+		cmdOpts.gitBase = gitChanged.value
+	}
+
+	if len(outputPaths) != 0 {
+		cmdOpts.outputs = make([]output, len(outputPaths))
+
+		for idx, path := range outputPaths {
+			cmdOpts.outputs[idx] = output{
+				path:   path,
+				format: outputFormat(path, format),
+			}
 		}
+	}
 
-		fmt.Printf("similarity #%d - %d lines, %s\n", idx+1, sim.Occurrences[0].End-sim.Occurrences[0].Start, level)
+	if cmd == trendCommand && flag.NArg() != 2 {
+		return cmdOptions{}, errTrendReports
+	}
 
-		for _, occ := range sim.Occurrences {
-			fmt.Printf("- %s: ", occ.File.Name)
+	if flag.NArg() == 0 && filesFrom == "" && !staged && cmd != lspCommand {
+		return cmdOptions{}, errNoFiles
+	}
 
-			if occ.End == occ.Start+1 {
-				fmt.Print(strconv.Itoa(occ.Start + 1))
-			} else {
-				fmt.Printf("%d-%d", occ.Start+1, occ.End)
-			}
+	if staged && (gitChanged.set || checkpointPath != "") {
+		return cmdOptions{}, errStagedUnsupported
+	}
 
-			fmt.Println()
-		}
+	if resume && checkpointPath == "" {
+		return cmdOptions{}, errResumeWithoutCheckpoint
+	}
 
-		if err := dumpOrDiff(ctx, sim, opts); err != nil {
-			return err
+	if hints && cmdOpts.reportMode != classesReportMode {
+		return cmdOptions{}, errHintsWithoutClasses
+	}
+
+	if stem && !proseMode {
+		return cmdOptions{}, errStemWithoutProse
+	}
+
+	if proseMode {
+		if cacheDir != "" || checkpointPath != "" || cmdOpts.reportMode == duplicatesReportMode || cmd == benchCommand || cmd == lspCommand {
+			return cmdOptions{}, errProseUnsupported
 		}
+
+		cmdOpts.prose = true
+		cmdOpts.simOpts.Segmenter = prose.SentenceSegmenter{Options: prose.Options{Stem: stem}}
 	}
 
-	return nil
+	return cmdOpts, nil
 }
 
-// dumpOrDiff prints sim's text:
-// If sim.Level==textsimilarity.EqualSimilarityLevel and opts.printEqual==true, it will dump the first occurrence's text.
-// If sim.Level==textsimilarity.SimilarSimilarityLevel and opts.diffTool!=nil, it will run opts.diffTool to print differences.
-func dumpOrDiff(ctx context.Context, sim *textsimilarity.Similarity, opts cmdOptions) error {
-	switch {
-	case sim.Level == textsimilarity.EqualSimilarityLevel && opts.printEqual:
-		fmt.Println("\n------------------------------")
+func run(paths []string, opts cmdOptions) (int, error) {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
 
-		if err := dump(sim.Occurrences[0]); err != nil {
-			return err
-		}
+	switch opts.command {
+	case watchCommand:
+		return watch(ctx, paths, opts)
+	case trendCommand:
+		return trend(paths[0], paths[1])
+	case lspCommand:
+		return serveLSP(ctx, paths, opts)
+	}
+
+	return scan(ctx, paths, opts, nil)
+}
+
+// scan scans files in paths for similarities and reports them, according to opts. It returns the exit code.
+// If metrics is not nil, it will be updated during the scan. If opts.timeout is exceeded, similarities found
+// so far are reported, and the exit code will be non-zero.
+func scan(ctx context.Context, paths []string, opts cmdOptions, metrics *scanMetrics) (int, error) { //nolint:gocognit,cyclop // it's complicated
+	scanCtx := ctx
+
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		scanCtx, cancel = context.WithTimeout(ctx, opts.timeout)
 
-		fmt.Println("------------------------------")
+		defer cancel()
+	}
 
-	case sim.Level == textsimilarity.SimilarSimilarityLevel && opts.diffTool != nil:
-		fmt.Println("\n------------------------------")
+	reporters, err := outputReporters(opts)
+	if err != nil {
+		return -1, err
+	}
 
-		if err := diff(ctx, sim, opts); err != nil {
-			return err
+	if opts.filesFrom != "" {
+		listPaths, err := readPathList(opts.filesFrom)
+		if err != nil {
+			return -1, err
 		}
 
-		fmt.Println("------------------------------")
+		paths = append(paths, listPaths...)
 	}
 
-	return nil
-}
+	if opts.staged && len(paths) == 0 {
+		paths = []string{"."}
+	}
 
-// dump prints the text of occ.
-func dump(occ *textsimilarity.FileOccurrence) error {
-	text, err := fileText(occ.File.Name, occ.Start, occ.End)
+	objects := newObjectStore()
+
+	paths, err = expandPaths(scanCtx, paths, opts.useIgnoreFiles, objects)
 	if err != nil {
-		return err
+		return -1, err
 	}
 
-	fmt.Print(text)
+	if !objects.empty() && (opts.cacheDir != "" || opts.checkpointPath != "") {
+		return -1, errObjectsUnsupported
+	}
 
-	return nil
-}
+	paths, archives, err := expandArchives(scanCtx, paths, opts.useIgnoreFiles)
+	defer archives.remove()
 
-// diff uses opts.diffTool to print differences between occurrences in sim.
-func diff(ctx context.Context, sim *textsimilarity.Similarity, opts cmdOptions) error {
-	text1, err := fileText(sim.Occurrences[0].File.Name, sim.Occurrences[0].Start, sim.Occurrences[0].End)
 	if err != nil {
-		return err
+		return -1, err
 	}
 
-	path1, err := writeTempFile(text1)
+	paths, err = excludePaths(paths, opts.ignoreFileGlobs)
 	if err != nil {
-		return err
+		return -1, err
 	}
 
-	defer func() {
-		if err := os.Remove(path1); err != nil {
-			fmt.Fprintln(os.Stderr, fmt.Errorf("remove temporary file %s: %w", path1, err).Error())
+	var index *gitIndex
+
+	if opts.staged {
+		index, err = readGitIndex(scanCtx)
+		if err != nil {
+			return -1, err
 		}
-	}()
 
-	var text2 string
+		paths, err = index.tracked(paths)
+		if err != nil {
+			return -1, err
+		}
+	}
 
-	// get text of an occurrence that is not exactly equal to sim.Occurrences[0]
-	for _, occ := range sim.Occurrences[1:] {
-		text2, err = fileText(occ.File.Name, occ.Start, occ.End)
+	if opts.skipGenerated {
+		paths, err = skipGenerated(scanCtx, paths)
 		if err != nil {
-			return err
+			return -1, err
 		}
+	}
 
-		if text2 == text1 {
-			continue
+	if opts.simOpts.Parallelism <= 0 {
+		opts.simOpts.Parallelism, err = adaptiveParallelism(paths, objects)
+		if err != nil {
+			return -1, err
 		}
+	}
+
+	var changedFiles map[string]struct{}
 
-		break
+	if opts.gitBase != "" {
+		changedFiles, err = gitChangedFiles(scanCtx, opts.gitBase)
+		if err != nil {
+			return -1, err
+		}
 	}
 
-	path2, err := writeTempFile(text2)
-	if err != nil {
-		return err
+	if index != nil {
+		changedFiles = index.staged
 	}
 
-	defer func() {
-		if err := os.Remove(path2); err != nil {
-			fmt.Fprintln(os.Stderr, fmt.Errorf("remove temporary file %s: %w", path2, err).Error())
-		}
-	}()
+	if opts.command == benchCommand {
+		return bench(scanCtx, paths, changedFiles, objects, index, opts)
+	}
 
-	return runDiffTool(ctx, path1, path2, opts)
-}
+	if opts.reportMode == duplicatesReportMode {
+		return nearDuplicates(scanCtx, paths, changedFiles, objects, index, archives, opts, reporters)
+	}
 
-// runDiffTool runs opts.diffTool to print differences between files path1 and path2.
-func runDiffTool(ctx context.Context, path1 string, path2 string, opts cmdOptions) error {
-	buf := strings.Builder{}
+	progressBar := newProgressBar(os.Stderr, opts.progressFormat)
 
-	err := opts.diffTool.Execute(&buf, struct {
-		File1 string
-		File2 string
-	}{
-		File1: path1,
-		File2: path2,
-	})
+	progress := func(prog textsimilarity.Progress) {
+		if metrics != nil {
+			metrics.fileScanned(prog.File)
+		}
 
-	if err != nil {
-		return fmt.Errorf("construct diff tool command line: %w", err)
+		if !opts.showProgress {
+			return
+		}
+
+		progressBar.update(prog)
 	}
 
-	cmdLine := buf.String()
-	parts := strings.Split(cmdLine, " ")
+	var plan *cachePlan
+
+	if opts.cacheDir != "" && (changedFiles == nil || index != nil) && opts.command != baselineWriteCommand && opts.command != baselineCheckCommand {
+		var hashes map[string]string
 
-	cmd := exec.CommandContext(ctx, parts[0], parts[1:]...) //nolint:gosec // okay
+		// with -staged, the IDs of the files' blobs are used, so that files do not need to be read for hashing
+		if index != nil {
+			hashes, err = index.hashes(paths)
+		} else {
+			hashes, err = hashFiles(paths)
+		}
 
-	output, err := cmd.CombinedOutput()
-	fmt.Print(string(output))
+		if err != nil {
+			return -1, err
+		}
 
-	if err != nil && !opts.ignoreDiffToolRC {
-		return fmt.Errorf("%s: %w", cmdLine, err)
+		plan, err = newCachePlan(opts.cacheDir, hashes, &opts.simOpts)
+		if err != nil {
+			return -1, err
+		}
+
+		changedFiles = plan.rescan
 	}
 
-	return nil
-}
+	var timings *textsimilarity.Timings
 
-// writeTempFile writes text to a temporary file and returns its path.
-func writeTempFile(text string) (string, error) {
-	file, err := os.CreateTemp("", "similarity")
-	if err != nil {
-		return "", fmt.Errorf("create: %w", err)
+	if opts.stats {
+		timings = &textsimilarity.Timings{}
+		opts.simOpts.Timings = timings
 	}
 
-	closed := false
+	var checkpoints *checkpointer
 
-	defer func() {
-		if closed {
-			return
+	if opts.checkpointPath != "" {
+		checkpoints = &checkpointer{
+			path:     opts.checkpointPath,
+			interval: opts.checkpointInterval,
+			resume:   opts.resume,
 		}
+	}
 
-		_ = file.Close()
-	}()
+	sims, files, err := similarities(scanCtx, paths, objects, index, changedFiles, opts.simOpts, progress, checkpoints)
+	if err != nil {
+		return -1, err
+	}
+
+	var matches []*prose.Match
 
-	if _, err = file.WriteString(text); err != nil {
-		return "", fmt.Errorf("write: %w", err)
+	if opts.prose {
+		matches = prose.Matches(sims, files)
 	}
 
-	closed = true
+	if opts.showProgress {
+		progressBar.finish()
+	}
 
-	if err = file.Close(); err != nil {
-		return "", fmt.Errorf("close: %w", err)
+	if contextDone(ctx) {
+		return -1, errCanceled
 	}
 
-	return file.Name(), nil
-}
+	timedOut := contextDone(scanCtx)
+	if timedOut {
+		fmt.Fprintf(os.Stderr, "Timed out after %s, reporting partial results.\n", opts.timeout)
+	}
 
-// fileText returns the text of file path, starting from startLine (zero-based), up to endLine (zero-based, exclusive.)
-func fileText(path string, startLine int, endLine int) (string, error) {
-	file, err := os.Open(path)
-	if err != nil {
-		return "", fmt.Errorf("open: %w", err)
+	if plan != nil {
+		sims, err = plan.complete(files, sims, !timedOut)
+		if err != nil {
+			return -1, err
+		}
 	}
-	defer file.Close() //nolint:errcheck // file is being read
 
-	textBuf := strings.Builder{}
+	if index != nil {
+		// cached similarities may not involve any staged files
+		sims, err = index.stagedSimilarities(sims)
+		if err != nil {
+			return -1, err
+		}
+	}
 
-	reader := bufio.NewReader(file)
-	buf := bytes.Buffer{}
+	archives.rename(files)
 
-	for lineIdx := 0; lineIdx < endLine; lineIdx++ {
-		line, err := tsio.ReadLine(reader, &buf)
+	sortSimilarities(sims, scoreSortOrder, opts.ranking)
+
+	if opts.pruneSubsumed {
+		sims = textsimilarity.PruneSubsumed(sims)
+	}
+
+	switch opts.command {
+	case baselineWriteCommand:
+		if timedOut {
+			return -1, errTimeout
+		}
+
+		if err := writeBaseline(opts.baselinePath, sims); err != nil {
+			return -1, err
+		}
+
+		return 0, nil
+
+	case baselineCheckCommand:
+		sims, err = newSimilarities(opts.baselinePath, sims)
 		if err != nil {
-			if errors.Is(err, io.EOF) {
-				break
-			}
+			return -1, err
+		}
+	}
+
+	sims = opts.pathFilter.filter(sims)
 
-			return "", fmt.Errorf("read line: %w", err)
+	if metrics != nil {
+		metrics.similaritiesFound(len(sims))
+	}
+
+	reportSims := topSimilarities(sims, opts.top)
+	sortSimilarities(reportSims, opts.sortOrder, opts.ranking)
+
+	outputStart := time.Now()
+
+	if err := writeReports(ctx, opts.outputs, reporters, opts.reportMode, opts.clustering, reportSims, files); err != nil {
+		if contextDone(ctx) {
+			return -1, errCanceled
 		}
 
-		if lineIdx < startLine {
-			continue
+		return -1, err
+	}
+
+	if timings != nil {
+		if err := writeStats(os.Stderr, timings, time.Since(outputStart)); err != nil {
+			return -1, err
 		}
+	}
 
-		textBuf.WriteString(line)
-		textBuf.WriteString("\n")
+	if matches != nil {
+		if err := writeProseMatches(os.Stderr, matches); err != nil {
+			return -1, err
+		}
 	}
 
-	return textBuf.String(), nil
+	code := exitCode(sims, files, opts)
+	if timedOut && code == 0 {
+		code = timeoutExitCode
+	}
+
+	return code, nil
 }
 
-// similarities calculates similarities between files in paths, according to opts. Progress is reported to progress.
-func similarities(ctx context.Context, paths []string, opts textsimilarity.Options, progress func(textsimilarity.Progress)) ([]*textsimilarity.Similarity, error) {
+// exitCode returns the exit code for sims, found in files. If opts.thresholds is enabled, the exit code
+// will be non-zero only if any limit is exceeded. Otherwise, it will be non-zero if there are any similarities.
+func exitCode(sims []*textsimilarity.Similarity, files []*textsimilarity.File, opts cmdOptions) int {
+	if !opts.thresholds.enabled() {
+		if len(sims) != 0 {
+			return 1
+		}
+
+		return 0
+	}
+
+	msgs := opts.thresholds.exceeded(sims, files)
+	for _, msg := range msgs {
+		fmt.Fprintf(os.Stderr, "threshold exceeded: %s\n", msg)
+	}
+
+	if len(msgs) != 0 {
+		return 1
+	}
+
+	return 0
+}
+
+// similarities calculates similarities between files in paths, according to opts. Objects in object stores are
+// read using objects. If index is not nil, staged contents of files are read from it. Progress is reported to progress.
+// If changedFiles is not nil, only files with absolute paths contained in it are scanned, but they are compared
+// against all files. If checkpoints is not nil, checkpoints of the scan are written, and the scan may be resumed
+// from an earlier checkpoint. It also returns the files that have been scanned.
+func similarities(ctx context.Context, paths []string, objects *objectStore, index *gitIndex, changedFiles map[string]struct{},
+	opts textsimilarity.Options, progress func(textsimilarity.Progress), checkpoints *checkpointer,
+) ([]*textsimilarity.Similarity, []*textsimilarity.File, error) {
 	var osFiles []*os.File
 
 	defer func() {
@@ -419,15 +996,45 @@ func similarities(ctx context.Context, paths []string, opts textsimilarity.Optio
 		}
 	}()
 
-	files, osFiles, err := openFiles(ctx, paths)
+	files, osFiles, err := openFiles(ctx, paths, objects, index)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	if changedFiles != nil {
+		if err := markReferenceOnly(files, changedFiles); err != nil {
+			return nil, nil, err
+		}
 	}
 
 	if contextDone(ctx) {
-		return nil, nil
+		return nil, nil, nil
 	}
 
+	if checkpoints != nil {
+		if err := checkpoints.setup(files, &opts); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	sims, err := findSimilarities(ctx, files, opts, progress)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if checkpoints != nil {
+		if err := checkpoints.finish(!contextDone(ctx)); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return sims, files, nil
+}
+
+// findSimilarities calculates similarities between files, according to opts. Progress is reported to progress.
+func findSimilarities(ctx context.Context, files []*textsimilarity.File, opts textsimilarity.Options,
+	progress func(textsimilarity.Progress),
+) ([]*textsimilarity.Similarity, error) {
 	simsCh, progressCh, err := textsimilarity.Similarities(ctx, files, &opts)
 	if err != nil {
 		return nil, err
@@ -436,10 +1043,20 @@ func similarities(ctx context.Context, paths []string, opts textsimilarity.Optio
 	grp := sync.WaitGroup{}
 	grp.Add(2)
 
+	var progressErr error
+
 	go func() {
 		defer grp.Done()
 
 		for p := range progressCh {
+			if p.Err != nil {
+				if progressErr == nil {
+					progressErr = fmt.Errorf("scan %s: %w", p.File.Name, p.Err)
+				}
+
+				continue
+			}
+
 			progress(p)
 		}
 	}()
@@ -456,13 +1073,19 @@ func similarities(ctx context.Context, paths []string, opts textsimilarity.Optio
 
 	grp.Wait()
 
+	if progressErr != nil {
+		return nil, progressErr
+	}
+
 	return sims, nil
 }
 
 // openFiles opens files in paths and returns corresponding slices of textsimilarity.File and os.File.
-// The returned os.Files must be closed by the caller. If an error occurs, the os.Files opened so far
-// will be returned and must be closed by the caller.
-func openFiles(ctx context.Context, paths []string) ([]*textsimilarity.File, []*os.File, error) {
+// Objects in object stores are read using objects, and have no corresponding os.File. If index is not nil,
+// files whose staged contents differ from the working tree are read from it, and have no corresponding os.File.
+// The returned os.Files must be closed by the caller. If an error occurs, the os.Files opened so far will be
+// returned and must be closed by the caller.
+func openFiles(ctx context.Context, paths []string, objects *objectStore, index *gitIndex) ([]*textsimilarity.File, []*os.File, error) {
 	files := []*textsimilarity.File{}
 	osFiles := []*os.File{}
 
@@ -471,6 +1094,34 @@ func openFiles(ctx context.Context, paths []string) ([]*textsimilarity.File, []*
 			return nil, osFiles, nil
 		}
 
+		if isObjectURL(path) {
+			reader, err := objects.open(ctx, path)
+			if err != nil {
+				return nil, osFiles, err
+			}
+
+			files = append(files, &textsimilarity.File{
+				Name: path,
+				R:    reader,
+			})
+
+			continue
+		}
+
+		reader, ok, err := index.open(ctx, path)
+		if err != nil {
+			return nil, osFiles, err
+		}
+
+		if ok {
+			files = append(files, &textsimilarity.File{
+				Name: path,
+				R:    reader,
+			})
+
+			continue
+		}
+
 		osFile, err := os.Open(path)
 		if err != nil {
 			return nil, osFiles, fmt.Errorf("open %s: %w", path, err)
@@ -481,31 +1132,34 @@ func openFiles(ctx context.Context, paths []string) ([]*textsimilarity.File, []*
 		files = append(files, &textsimilarity.File{
 			Name: path,
 			R:    osFile,
+			Open: openFunc(path),
 		})
 	}
 
 	return files, osFiles, nil
 }
 
-// sortSimilaritiesLines sorts sims by number of lines, in reverse order.
-func sortSimilaritiesLines(sims []*textsimilarity.Similarity) {
-	sort.SliceStable(sims, func(a int, b int) bool {
-		lines1 := similarityLines(sims[a])
-		lines2 := similarityLines(sims[b])
-
-		// reverse
-		return lines1 > lines2
-	})
+// openFunc returns a function that opens the file at path again, to be used as textsimilarity.File.Open.
+func openFunc(path string) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		return os.Open(path) //nolint:wrapcheck // wrapped by callers
+	}
 }
 
-// similarityLines returns the number of lines of all occurrences in sim.
-func similarityLines(sim *textsimilarity.Similarity) int {
-	lines := 0
-	for _, occ := range sim.Occurrences {
-		lines += occ.End - occ.Start
+// markReferenceOnly marks all files as reference-only whose absolute paths are not contained in changedFiles.
+func markReferenceOnly(files []*textsimilarity.File, changedFiles map[string]struct{}) error {
+	for _, file := range files {
+		absPath, err := filepath.Abs(file.Name)
+		if err != nil {
+			return fmt.Errorf("absolute path of %s: %w", file.Name, err)
+		}
+
+		if _, ok := changedFiles[absPath]; !ok {
+			file.ReferenceOnly = true
+		}
 	}
 
-	return lines
+	return nil
 }
 
 // contextDone returns whether ctx is done.
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// availableMemory returns the number of bytes of memory available to the process. It takes into account
+// the system's available memory as well as cgroup memory limits.
+func availableMemory() (int64, bool) {
+	available, ok := memInfoAvailable()
+	if !ok {
+		return 0, false
+	}
+
+	if limit, ok := cgroupMemoryLimit(); ok && limit < available {
+		available = limit
+	}
+
+	return available, true
+}
+
+// memInfoAvailable returns the system's available memory according to /proc/meminfo.
+func memInfoAvailable() (int64, bool) {
+	file, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, false
+	}
+	defer file.Close() //nolint:errcheck // file is being read
+
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "MemAvailable:" {
+			continue
+		}
+
+		kb, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return 0, false
+		}
+
+		return kb * 1024, true
+	}
+
+	return 0, false
+}
+
+// cgroupMemoryLimit returns the remaining memory according to the cgroup v2 memory limit of the process.
+func cgroupMemoryLimit() (int64, bool) {
+	limit, ok := readIntFile("/sys/fs/cgroup/memory.max")
+	if !ok {
+		return 0, false
+	}
+
+	current, ok := readIntFile("/sys/fs/cgroup/memory.current")
+	if !ok {
+		return limit, true
+	}
+
+	return limit - current, true
+}
+
+// readIntFile returns the integer contained in the file at path.
+func readIntFile(path string) (int64, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+
+	val, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return val, true
+}
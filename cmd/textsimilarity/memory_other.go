@@ -0,0 +1,9 @@
+//go:build !linux
+
+package main
+
+// availableMemory returns the number of bytes of memory available to the process. It is not supported
+// on this platform.
+func availableMemory() (int64, bool) {
+	return 0, false
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/blizzy78/textsimilarity"
+)
+
+// lineMetrics maps line metric names to line metrics.
+var lineMetrics = map[string]textsimilarity.LineMetric{
+	"levenshtein":  textsimilarity.LevenshteinLineMetric,
+	"jaro-winkler": textsimilarity.JaroWinklerLineMetric,
+}
+
+// errUnknownLineMetric is returned when an unknown line metric is requested.
+var errUnknownLineMetric = errors.New("unknown line metric")
+
+// lineMetricNames returns the names of all line metrics, sorted.
+func lineMetricNames() []string {
+	names := make([]string, 0, len(lineMetrics))
+	for name := range lineMetrics {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/blizzy78/textsimilarity/internal/s3"
+)
+
+// errObjectsUnsupported is returned when objects in object stores are scanned with options that require
+// local files.
+var errObjectsUnsupported = errors.New("-cache-dir and -checkpoint are not supported for objects in object stores")
+
+// An objectStore lists and reads objects in S3-compatible object stores, given as s3://bucket/prefix URLs.
+type objectStore struct {
+	// client is created when it is first needed.
+	client *s3.Client
+
+	// objects maps the URLs of all listed objects to the objects.
+	objects map[string]*s3.Object
+}
+
+// An objectReader reads the contents of an object. The object is only requested when it is first read from,
+// and closed when it has been read completely, or when an error occurs.
+type objectReader struct {
+	ctx    context.Context //nolint:containedctx // needed to request the object lazily
+	client *s3.Client
+	bucket string
+	key    string
+
+	// body is the contents of the object, once requested.
+	body io.ReadCloser
+
+	// err is the error that ended reading, if any.
+	err error
+}
+
+// newObjectStore returns a new objectStore.
+func newObjectStore() *objectStore {
+	return &objectStore{
+		objects: map[string]*s3.Object{},
+	}
+}
+
+// isObjectURL returns whether path is the URL of objects in an object store, rather than a local path.
+func isObjectURL(path string) bool {
+	_, _, ok := s3.ParseURL(path)
+	return ok
+}
+
+// list returns the URLs of all objects whose URLs start with prefixURL, sorted by key. Directory markers,
+// that is, keys ending with a slash, are skipped.
+func (s *objectStore) list(ctx context.Context, prefixURL string) ([]string, error) {
+	client, err := s.s3Client()
+	if err != nil {
+		return nil, err
+	}
+
+	bucket, prefix, _ := s3.ParseURL(prefixURL)
+
+	objects, err := client.List(ctx, bucket, prefix)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // already wrapped
+	}
+
+	urls := make([]string, 0, len(objects))
+
+	for _, object := range objects {
+		if strings.HasSuffix(object.Key, "/") {
+			continue
+		}
+
+		url := s3.URL(bucket, object.Key)
+		s.objects[url] = object
+		urls = append(urls, url)
+	}
+
+	return urls, nil
+}
+
+// empty returns whether no objects have been listed.
+func (s *objectStore) empty() bool {
+	return len(s.objects) == 0
+}
+
+// size returns the size in bytes of the listed object with url.
+func (s *objectStore) size(url string) int64 {
+	if object, ok := s.objects[url]; ok {
+		return object.Size
+	}
+
+	return 0
+}
+
+// open returns a reader of the contents of the object with url. The object is requested when the reader
+// is first read from, using ctx.
+func (s *objectStore) open(ctx context.Context, url string) (io.Reader, error) {
+	client, err := s.s3Client()
+	if err != nil {
+		return nil, err
+	}
+
+	bucket, key, _ := s3.ParseURL(url)
+
+	return &objectReader{
+		ctx:    ctx,
+		client: client,
+		bucket: bucket,
+		key:    key,
+	}, nil
+}
+
+// s3Client returns the client of s, creating it if necessary.
+func (s *objectStore) s3Client() (*s3.Client, error) {
+	if s.client != nil {
+		return s.client, nil
+	}
+
+	client, err := s3.NewClientFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("create object store client: %w", err)
+	}
+
+	s.client = client
+
+	return client, nil
+}
+
+// Read implements io.Reader.
+func (r *objectReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	if r.body == nil {
+		body, err := r.client.Open(r.ctx, r.bucket, r.key)
+		if err != nil {
+			r.err = err
+			return 0, err //nolint:wrapcheck // already wrapped
+		}
+
+		r.body = body
+	}
+
+	n, err := r.body.Read(p)
+	if err != nil {
+		r.err = err
+		_ = r.body.Close()
+	}
+
+	return n, err //nolint:wrapcheck // passed through
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/blizzy78/textsimilarity"
+	"github.com/blizzy78/textsimilarity/report"
+)
+
+const (
+	// similaritiesReportMode reports similarities.
+	similaritiesReportMode = reportMode("similarities")
+
+	// filesReportMode reports per-file statistics.
+	filesReportMode = reportMode("files")
+
+	// classesReportMode reports clone classes.
+	classesReportMode = reportMode("classes")
+
+	// matrixReportMode reports the similarity matrix of files.
+	matrixReportMode = reportMode("matrix")
+
+	// clustersReportMode reports clusters of similar files.
+	clustersReportMode = reportMode("clusters")
+
+	// duplicatesReportMode reports files that are near-duplicates of each other as a whole.
+	duplicatesReportMode = reportMode("duplicates")
+)
+
+// A reportMode is the kind of report to write.
+type reportMode string
+
+// errUnsupportedReportMode is returned when a report format does not support the requested report mode.
+var errUnsupportedReportMode = errors.New("report mode not supported")
+
+// An output is a destination for a report.
+type output struct {
+	// path is the path of the file to write the report to. If empty, the report is written to stdout.
+	path string
+
+	// format is the name of the report format.
+	format string
+}
+
+// outputFormat returns the name of the report format to use for path. The format is derived from path's
+// file extension if possible, otherwise defaultFormat is returned.
+func outputFormat(path string, defaultFormat string) string {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+
+	if ext == "txt" {
+		return "text"
+	}
+
+	if slices.Contains(report.Names(), ext) {
+		return ext
+	}
+
+	return defaultFormat
+}
+
+// outputReporters returns a new Reporter for each of opts.outputs, in the same order.
+func outputReporters(opts cmdOptions) ([]report.Reporter, error) {
+	reporters := make([]report.Reporter, len(opts.outputs))
+
+	for idx, out := range opts.outputs {
+		reportOpts := opts.reportOpts
+		reportOpts.Color = out.path == "" && colorStdout()
+
+		reporter, err := report.New(out.format, &reportOpts)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := reporter.(report.FilesReporter); opts.reportMode == filesReportMode && !ok {
+			return nil, fmt.Errorf("%w: %s: %s", errUnsupportedReportMode, out.format, opts.reportMode)
+		}
+
+		if _, ok := reporter.(report.ClassesReporter); opts.reportMode == classesReportMode && !ok {
+			return nil, fmt.Errorf("%w: %s: %s", errUnsupportedReportMode, out.format, opts.reportMode)
+		}
+
+		if _, ok := reporter.(report.MatrixReporter); opts.reportMode == matrixReportMode && !ok {
+			return nil, fmt.Errorf("%w: %s: %s", errUnsupportedReportMode, out.format, opts.reportMode)
+		}
+
+		if _, ok := reporter.(report.ClustersReporter); opts.reportMode == clustersReportMode && !ok {
+			return nil, fmt.Errorf("%w: %s: %s", errUnsupportedReportMode, out.format, opts.reportMode)
+		}
+
+		if _, ok := reporter.(report.DuplicatesReporter); opts.reportMode == duplicatesReportMode && !ok {
+			return nil, fmt.Errorf("%w: %s: %s", errUnsupportedReportMode, out.format, opts.reportMode)
+		}
+
+		reporters[idx] = reporter
+	}
+
+	return reporters, nil
+}
+
+// writeReports writes reports about sims, found in files, to all outs, using the respective reporters.
+// The kind of report is determined by mode. Files are grouped into clusters according to clust.
+func writeReports(ctx context.Context, outs []output, reporters []report.Reporter, mode reportMode, clust clustering,
+	sims []*textsimilarity.Similarity, files []*textsimilarity.File,
+) error {
+	var (
+		stats    []*textsimilarity.FileStats
+		classes  []*textsimilarity.CloneClass
+		matrix   *textsimilarity.SimilarityMatrix
+		clusters []*textsimilarity.FileCluster
+	)
+
+	switch mode {
+	case filesReportMode:
+		stats = textsimilarity.FilesStats(files, sims)
+	case classesReportMode:
+		classes = textsimilarity.CloneClasses(sims)
+	case matrixReportMode:
+		matrix = textsimilarity.NewSimilarityMatrix(files, sims)
+	case clustersReportMode:
+		clusters = textsimilarity.NewSimilarityMatrix(files, sims).Clusters(clust.linkage, clust.minSimilarity)
+	}
+
+	for idx, out := range outs {
+		reporter := reporters[idx]
+
+		write := func(w io.Writer) error {
+			switch mode {
+			case filesReportMode:
+				return reporter.(report.FilesReporter).ReportFiles(ctx, w, stats) //nolint:forcetypeassert // checked in outputReporters
+			case classesReportMode:
+				return reporter.(report.ClassesReporter).ReportClasses(ctx, w, classes) //nolint:forcetypeassert // checked in outputReporters
+			case matrixReportMode:
+				return reporter.(report.MatrixReporter).ReportMatrix(ctx, w, matrix) //nolint:forcetypeassert // checked in outputReporters
+			case clustersReportMode:
+				return reporter.(report.ClustersReporter).ReportClusters(ctx, w, clusters) //nolint:forcetypeassert // checked in outputReporters
+			}
+
+			if summaryReporter, ok := reporter.(report.SummaryReporter); ok {
+				return summaryReporter.ReportWithSummary(ctx, w, sims, files)
+			}
+
+			return reporter.Report(ctx, w, sims)
+		}
+
+		if err := writeOutput(out, write); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeOutput calls write to write a report to out.
+func writeOutput(out output, write func(w io.Writer) error) error {
+	if out.path == "" {
+		return write(os.Stdout)
+	}
+
+	return writeFileAtomic(out.path, write)
+}
+
+// colorStdout returns whether ANSI colors should be used when writing to stdout. Colors are used if
+// stdout is a terminal, unless disabled via the NO_COLOR environment variable.
+func colorStdout() bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+
+	return isTerminal(os.Stdout)
+}
+
+// isTerminal returns whether file is a terminal.
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// writeFileAtomic calls write to write to a temporary file, then renames the temporary file to path.
+// If write fails, path is left untouched.
+func writeFileAtomic(path string, write func(w io.Writer) error) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temporary file for %s: %w", path, err)
+	}
+
+	success := false
+
+	defer func() {
+		if success {
+			return
+		}
+
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}()
+
+	if err := write(file); err != nil {
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("sync %s: %w", file.Name(), err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("close %s: %w", file.Name(), err)
+	}
+
+	if err := os.Chmod(file.Name(), 0o644); err != nil { //nolint:gosec // report files are not sensitive
+		return fmt.Errorf("chmod %s: %w", file.Name(), err)
+	}
+
+	if err := os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("rename %s to %s: %w", file.Name(), path, err)
+	}
+
+	success = true
+
+	return nil
+}
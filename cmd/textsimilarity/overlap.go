@@ -0,0 +1,31 @@
+package main
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/blizzy78/textsimilarity"
+)
+
+// overlapPolicies maps overlap policy names to overlap policies.
+var overlapPolicies = map[string]textsimilarity.OverlapPolicy{
+	"drop":         textsimilarity.DropOverlapPolicy,
+	"keep-all":     textsimilarity.KeepAllOverlapPolicy,
+	"keep-largest": textsimilarity.KeepLargestOverlapPolicy,
+	"split":        textsimilarity.SplitOverlapPolicy,
+}
+
+// errUnknownOverlapPolicy is returned when an unknown overlap policy is requested.
+var errUnknownOverlapPolicy = errors.New("unknown overlap policy")
+
+// overlapPolicyNames returns the names of all overlap policies, sorted.
+func overlapPolicyNames() []string {
+	names := make([]string, 0, len(overlapPolicies))
+	for name := range overlapPolicies {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
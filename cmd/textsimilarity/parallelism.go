@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+const (
+	// loadedBytesFactor is the estimated factor of memory used for loaded lines in relation to file size.
+	// Lines are kept as strings and rune slices, both original and trimmed.
+	loadedBytesFactor = 12
+
+	// workerStackBytes is the estimated stack memory used by a single goroutine.
+	workerStackBytes = 8 * 1024
+
+	// lineIndexChunkSize is the number of lines searched by a single goroutine when looking up a line.
+	lineIndexChunkSize = 10
+)
+
+// adaptiveParallelism returns the maximum number of files (or ranges of lines) to process concurrently, based on
+// the number of CPUs, the sizes of the files at paths, and the available memory. The sizes of objects in object
+// stores are taken from objects. If the available memory cannot be determined, the number of CPUs is used.
+func adaptiveParallelism(paths []string, objects *objectStore) (int, error) {
+	cpus := runtime.NumCPU() + 2
+
+	available, ok := availableMemory()
+	if !ok {
+		return cpus, nil
+	}
+
+	totalBytes := int64(0)
+	largestBytes := int64(0)
+
+	for _, path := range paths {
+		size := objects.size(path)
+
+		if !isObjectURL(path) {
+			info, err := os.Stat(path)
+			if err != nil {
+				return 0, fmt.Errorf("stat %s: %w", path, err)
+			}
+
+			size = info.Size()
+		}
+
+		totalBytes += size
+		largestBytes = max(largestBytes, size)
+	}
+
+	// estimate lines from bytes, assuming an average line length of 40 bytes
+	totalLines := totalBytes / 40
+	largestLines := largestBytes / 40
+
+	available -= totalBytes * loadedBytesFactor
+
+	// each worker holds done-markers for all lines of all files, and searches its peers concurrently in chunks
+	perWorker := totalLines/8 + (largestLines/lineIndexChunkSize+1)*workerStackBytes
+
+	return parallelismForMemory(available, perWorker, cpus), nil
+}
+
+// parallelismForMemory returns the number of workers that fit into available bytes of memory, using perWorker
+// bytes each, but no more than maxWorkers, and at least 1.
+func parallelismForMemory(available int64, perWorker int64, maxWorkers int) int {
+	if available <= 0 || perWorker <= 0 {
+		return 1
+	}
+
+	return int(max(1, min(int64(maxWorkers), available/perWorker)))
+}
@@ -0,0 +1,15 @@
+package main
+
+// Additional report formats can be provided by packages in other Go modules that register them using
+// report.Register in their init functions. To make such formats available in the command line utility
+// without changing any other files, add a file to this directory that imports the package, guarded by
+// a build tag:
+//
+//	//go:build myformat
+//
+//	package main
+//
+//	import _ "example.com/textsimilarity-myformat"
+//
+// Then build with that tag, for example "go build -tags myformat ./cmd/textsimilarity/". Registered formats
+// can be selected using -format, and are derived from file extensions when using -output.
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+)
+
+// profiles holds the paths of files to write profiles of a run to. Empty paths disable a profile.
+type profiles struct {
+	// cpu is the path of the CPU profile.
+	cpu string
+
+	// mem is the path of the heap profile, which is written when the run is finished.
+	mem string
+
+	// trace is the path of the execution trace.
+	trace string
+}
+
+// run calls fn, writing the profiles in p while it is running, and returns its results.
+func (p profiles) run(fn func() (int, error)) (int, error) {
+	if p.cpu != "" {
+		file, err := os.Create(p.cpu)
+		if err != nil {
+			return -1, fmt.Errorf("create CPU profile: %w", err)
+		}
+
+		defer file.Close() //nolint:errcheck // profile has already been written
+
+		if err := pprof.StartCPUProfile(file); err != nil {
+			return -1, fmt.Errorf("start CPU profile: %w", err)
+		}
+
+		defer pprof.StopCPUProfile()
+	}
+
+	if p.trace != "" {
+		file, err := os.Create(p.trace)
+		if err != nil {
+			return -1, fmt.Errorf("create trace: %w", err)
+		}
+
+		defer file.Close() //nolint:errcheck // trace has already been written
+
+		if err := trace.Start(file); err != nil {
+			return -1, fmt.Errorf("start trace: %w", err)
+		}
+
+		defer trace.Stop()
+	}
+
+	ret, err := fn()
+	if err != nil {
+		return ret, err
+	}
+
+	if p.mem != "" {
+		if err := writeHeapProfile(p.mem); err != nil {
+			return -1, err
+		}
+	}
+
+	return ret, nil
+}
+
+// writeHeapProfile writes a heap profile to the file at path.
+func writeHeapProfile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create memory profile: %w", err)
+	}
+
+	defer file.Close() //nolint:errcheck // error is checked below
+
+	// get up-to-date statistics
+	runtime.GC()
+
+	if err := pprof.WriteHeapProfile(file); err != nil {
+		return fmt.Errorf("write memory profile: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("write memory profile: %w", err)
+	}
+
+	return nil
+}
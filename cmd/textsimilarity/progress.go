@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/blizzy78/textsimilarity"
+)
+
+const (
+	// clearLine is the ANSI escape sequence to clear the current line.
+	clearLine = "\033[2K"
+
+	// moveUp is the ANSI escape sequence to move cursor to the beginning of the previous line.
+	moveUp = "\033[F"
+
+	// progressBarWidth is the number of characters used for the bar of a progressBar.
+	progressBarWidth = 30
+
+	// progressNameWidth is the maximum number of characters used for the current file name of a progressBar.
+	progressNameWidth = 70
+
+	// plainProgressInterval is the minimum interval between progress lines if not writing a progress bar.
+	plainProgressInterval = 5 * time.Second
+
+	// etaSmoothing is the weight of a new remaining time estimate in the smoothed ETA (0-1.)
+	etaSmoothing = 0.2
+)
+
+const (
+	// autoProgressFormat writes a progress bar if writing to a terminal, and plain text lines otherwise.
+	autoProgressFormat = progressFormat("auto")
+
+	// barProgressFormat writes a progress bar that is continuously updated in place using ANSI escape sequences.
+	barProgressFormat = progressFormat("bar")
+
+	// plainProgressFormat periodically writes plain text lines.
+	plainProgressFormat = progressFormat("plain")
+
+	// jsonProgressFormat periodically writes lines containing JSON objects.
+	jsonProgressFormat = progressFormat("json")
+)
+
+// A progressFormat is the format progress is written in.
+type progressFormat string
+
+// errUnknownProgressFormat is returned when an unknown progress format is requested.
+var errUnknownProgressFormat = errors.New("unknown progress format")
+
+// progressFormats are all known progress formats.
+var progressFormats = map[progressFormat]struct{}{
+	autoProgressFormat:  {},
+	barProgressFormat:   {},
+	plainProgressFormat: {},
+	jsonProgressFormat:  {},
+}
+
+// A jsonProgress is a single line of progress written in jsonProgressFormat.
+type jsonProgress struct {
+	// Percent is the percentage done (0-100.)
+	Percent float64 `json:"percent"`
+
+	// File is the name of the file processed last.
+	File string `json:"file"`
+
+	// Files is the number of files processed.
+	Files int `json:"files"`
+
+	// Lines is the number of lines processed.
+	Lines int `json:"lines"`
+
+	// ETA is the estimated time of completion.
+	ETA time.Time `json:"eta"`
+
+	// RemainingSeconds is the estimated remaining time, in seconds.
+	RemainingSeconds float64 `json:"remainingSeconds"`
+}
+
+// A progressBar writes progress to a writer. Depending on its format, it continuously updates a progress bar
+// in place, or periodically writes lines of plain text or JSON.
+type progressBar struct {
+	// w is the writer to write progress to.
+	w io.Writer
+
+	// format is the format to write progress in. It is never autoProgressFormat.
+	format progressFormat
+
+	// start is the time the progressBar has been created.
+	start time.Time
+
+	// lastPlain is the time the last plain text line has been written.
+	lastPlain time.Time
+
+	// files is the number of files processed.
+	files int
+
+	// lines is the number of lines processed.
+	lines int
+
+	// remaining is the smoothed estimate of remaining time.
+	remaining time.Duration
+}
+
+// newProgressBar returns a new progressBar that writes to file in format. Throughput is measured starting now.
+func newProgressBar(file *os.File, format progressFormat) *progressBar {
+	if format == autoProgressFormat {
+		format = plainProgressFormat
+		if isTerminal(file) {
+			format = barProgressFormat
+		}
+	}
+
+	return &progressBar{
+		w:      file,
+		format: format,
+		start:  time.Now(),
+	}
+}
+
+// update writes progress prog.
+func (b *progressBar) update(prog textsimilarity.Progress) {
+	now := time.Now()
+
+	if b.files == 0 {
+		b.remaining = prog.ETA.Sub(now)
+	} else {
+		b.remaining = time.Duration(etaSmoothing*float64(prog.ETA.Sub(now)) + (1-etaSmoothing)*float64(b.remaining))
+	}
+
+	b.files++
+	b.lines += prog.File.LineCount()
+
+	if b.format == barProgressFormat {
+		fmt.Fprintf(b.w, "\n"+clearLine+"%s"+moveUp+clearLine+"%s %s",
+			truncateLeft(prog.File.Name, progressNameWidth), bar(prog.Done), b.status(prog.Done, now))
+
+		return
+	}
+
+	if prog.Done < 100.0 && now.Sub(b.lastPlain) < plainProgressInterval {
+		return
+	}
+
+	b.lastPlain = now
+
+	if b.format == jsonProgressFormat {
+		remaining := max(b.remaining, 0)
+
+		data, _ := json.Marshal(&jsonProgress{ //nolint:errchkjson // cannot fail
+			Percent:          prog.Done,
+			File:             prog.File.Name,
+			Files:            b.files,
+			Lines:            b.lines,
+			ETA:              now.Add(remaining).Round(time.Second),
+			RemainingSeconds: remaining.Round(time.Second).Seconds(),
+		})
+
+		fmt.Fprintf(b.w, "%s\n", data)
+
+		return
+	}
+
+	fmt.Fprintf(b.w, "progress: %s, %s\n", b.status(prog.Done, now), prog.File.Name)
+}
+
+// status returns a description of the current progress, with done being the percentage done (0-100.)
+func (b *progressBar) status(done float64, now time.Time) string {
+	secs := now.Sub(b.start).Seconds()
+
+	filesPerSec := 0.0
+	linesPerSec := 0.0
+
+	if secs > 0 {
+		filesPerSec = float64(b.files) / secs
+		linesPerSec = float64(b.lines) / secs
+	}
+
+	return fmt.Sprintf("%5.1f%%, %.1f files/s, %.0f lines/s, ETA: %s",
+		done, filesPerSec, linesPerSec, max(b.remaining, 0).Round(time.Second))
+}
+
+// finish removes the progress bar from the terminal, if any.
+func (b *progressBar) finish() {
+	if b.format != barProgressFormat {
+		return
+	}
+
+	fmt.Fprint(b.w, clearLine+"\n"+clearLine+moveUp)
+}
+
+// bar returns a progress bar representing done (0-100.)
+func bar(done float64) string {
+	filled := min(max(int(done*progressBarWidth/100.0), 0), progressBarWidth)
+	return "[" + strings.Repeat("#", filled) + strings.Repeat("-", progressBarWidth-filled) + "]"
+}
+
+// truncateLeft truncates s to at most width runes, replacing leading runes with "..." if necessary.
+func truncateLeft(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+
+	return "..." + string(runes[len(runes)-width+3:])
+}
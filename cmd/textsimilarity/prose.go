@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/blizzy78/textsimilarity/prose"
+)
+
+var (
+	// errProseUnsupported is returned when -prose is used with options that it is not supported with.
+	errProseUnsupported = errors.New("-prose is not supported with -cache-dir, -checkpoint, -report duplicates, and the bench and lsp commands")
+
+	// errStemWithoutProse is returned when -stem is used without -prose.
+	errStemWithoutProse = errors.New("-stem requires -prose")
+)
+
+// writeProseMatches writes the percentage of sentences of each document that match other documents to w.
+func writeProseMatches(w io.Writer, matches []*prose.Match) error {
+	tabW := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintln(tabW, "matched sentences per document:")
+
+	for _, match := range matches {
+		fmt.Fprintf(tabW, "%.1f%%\t %d/%d\t %s\n", match.Percent, match.MatchedSentences, match.Sentences, match.File.Name)
+	}
+
+	if err := tabW.Flush(); err != nil {
+		return fmt.Errorf("write prose matches: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/blizzy78/textsimilarity"
+)
+
+// errInvalidRanking is returned when a -ranking flag is not a comma-separated list of "key=value" pairs.
+var errInvalidRanking = errors.New("ranking must be a comma-separated list of \"key=value\" pairs")
+
+// parseRanking returns a ranking for spec, which is a comma-separated list of key=value pairs. The keys are
+// "lines" and "occurrences" for the exponents, and "equal", "similar", and "reordered" for the level factors.
+// Keys not in spec use the values of textsimilarity.DefaultRanking.
+func parseRanking(spec string) (*textsimilarity.Ranking, error) {
+	ranking := textsimilarity.DefaultRanking
+
+	if spec == "" {
+		return &ranking, nil
+	}
+
+	fields := map[string]*float64{
+		"lines":       &ranking.LinesExponent,
+		"occurrences": &ranking.OccurrencesExponent,
+		"equal":       &ranking.EqualFactor,
+		"similar":     &ranking.SimilarFactor,
+		"reordered":   &ranking.ReorderedFactor,
+	}
+
+	for _, pair := range strings.Split(spec, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+
+		field, known := fields[strings.TrimSpace(key)]
+		if !ok || !known {
+			return nil, fmt.Errorf("%w: %s", errInvalidRanking, pair)
+		}
+
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", errInvalidRanking, pair)
+		}
+
+		*field = f
+	}
+
+	return &ranking, nil
+}
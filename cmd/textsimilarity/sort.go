@@ -0,0 +1,160 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/blizzy78/textsimilarity"
+)
+
+const (
+	// scoreSortOrder sorts similarities by their score according to a ranking, in reverse order.
+	scoreSortOrder = sortOrder("score")
+
+	// linesSortOrder sorts similarities by total number of lines, in reverse order.
+	linesSortOrder = sortOrder("lines")
+
+	// filesSortOrder sorts similarities by file name and line number of their first occurrence.
+	filesSortOrder = sortOrder("files")
+
+	// levelSortOrder sorts similarities by level, exactly equal ones first.
+	levelSortOrder = sortOrder("level")
+
+	// occurrencesSortOrder sorts similarities by number of occurrences, in reverse order.
+	occurrencesSortOrder = sortOrder("occurrences")
+)
+
+// A sortOrder is the order in which similarities are reported.
+type sortOrder string
+
+// errUnknownSortOrder is returned when an unknown sort order is requested.
+var errUnknownSortOrder = errors.New("unknown sort order")
+
+// sortOrders maps sort orders to functions that compare two similarities, using ranking if needed. A function
+// returns a negative number if sim1 should be sorted before sim2, a positive number if it should be sorted after
+// sim2, and 0 if they are equal.
+var sortOrders = map[sortOrder]func(sim1 *textsimilarity.Similarity, sim2 *textsimilarity.Similarity, ranking *textsimilarity.Ranking) int{
+	scoreSortOrder: func(sim1 *textsimilarity.Similarity, sim2 *textsimilarity.Similarity, ranking *textsimilarity.Ranking) int {
+		// reverse
+		return cmp.Compare(sim2.Score(ranking), sim1.Score(ranking))
+	},
+
+	linesSortOrder: func(sim1 *textsimilarity.Similarity, sim2 *textsimilarity.Similarity, _ *textsimilarity.Ranking) int {
+		// reverse
+		return similarityLines(sim2) - similarityLines(sim1)
+	},
+
+	filesSortOrder: func(sim1 *textsimilarity.Similarity, sim2 *textsimilarity.Similarity, _ *textsimilarity.Ranking) int {
+		return compareOccurrences(sim1, sim2)
+	},
+
+	levelSortOrder: func(sim1 *textsimilarity.Similarity, sim2 *textsimilarity.Similarity, _ *textsimilarity.Ranking) int {
+		// reverse
+		return levelRank(sim2.Level) - levelRank(sim1.Level)
+	},
+
+	occurrencesSortOrder: func(sim1 *textsimilarity.Similarity, sim2 *textsimilarity.Similarity, _ *textsimilarity.Ranking) int {
+		// reverse
+		return len(sim2.Occurrences) + sim2.OmittedOccurrences - len(sim1.Occurrences) - sim1.OmittedOccurrences
+	},
+}
+
+// levelRank returns the rank of level when sorting similarities by level. Higher ranks are more similar.
+func levelRank(level textsimilarity.SimilarityLevel) int {
+	if level == textsimilarity.ReorderedSimilarityLevel {
+		// less similar than similar lines in the same order
+		return 0
+	}
+
+	return int(level)
+}
+
+// sortOrderNames returns the names of all sort orders, sorted.
+func sortOrderNames() []string {
+	names := make([]string, 0, len(sortOrders))
+	for order := range sortOrders {
+		names = append(names, string(order))
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// sortSimilarities sorts sims according to order, using ranking if needed. Similarities that are equal according
+// to order are sorted by total number of lines, in reverse order, and then by their occurrences, so that the result
+// is reproducible. The occurrences of each similarity are sorted by file name and line number.
+func sortSimilarities(sims []*textsimilarity.Similarity, order sortOrder, ranking *textsimilarity.Ranking) {
+	for _, sim := range sims {
+		sortOccurrences(sim.Occurrences)
+	}
+
+	compare := sortOrders[order]
+	compareLines := sortOrders[linesSortOrder]
+
+	sort.SliceStable(sims, func(a int, b int) bool {
+		if c := compare(sims[a], sims[b], ranking); c != 0 {
+			return c < 0
+		}
+
+		if c := compareLines(sims[a], sims[b], ranking); c != 0 {
+			return c < 0
+		}
+
+		return compareOccurrences(sims[a], sims[b]) < 0
+	})
+}
+
+// sortOccurrences sorts occs by file name and line number.
+func sortOccurrences(occs []*textsimilarity.FileOccurrence) {
+	sort.SliceStable(occs, func(a int, b int) bool {
+		return compareOccurrence(occs[a], occs[b]) < 0
+	})
+}
+
+// compareOccurrences compares the occurrences of sim1 and sim2 pairwise, by file name and line number.
+// A similarity that has fewer occurrences, but otherwise the same, is sorted first.
+func compareOccurrences(sim1 *textsimilarity.Similarity, sim2 *textsimilarity.Similarity) int {
+	for idx := 0; idx < len(sim1.Occurrences) && idx < len(sim2.Occurrences); idx++ {
+		if c := compareOccurrence(sim1.Occurrences[idx], sim2.Occurrences[idx]); c != 0 {
+			return c
+		}
+	}
+
+	return len(sim1.Occurrences) - len(sim2.Occurrences)
+}
+
+// compareOccurrence compares occ1 and occ2 by file name and line number.
+func compareOccurrence(occ1 *textsimilarity.FileOccurrence, occ2 *textsimilarity.FileOccurrence) int {
+	if c := strings.Compare(occ1.File.Name, occ2.File.Name); c != 0 {
+		return c
+	}
+
+	if occ1.Start != occ2.Start {
+		return occ1.Start - occ2.Start
+	}
+
+	return occ1.End - occ2.End
+}
+
+// topSimilarities returns the first n similarities of sims, or all of them if n <= 0.
+// sims must already be sorted using scoreSortOrder.
+func topSimilarities(sims []*textsimilarity.Similarity, n int) []*textsimilarity.Similarity {
+	if n <= 0 || n >= len(sims) {
+		return sims
+	}
+
+	return sims[:n]
+}
+
+// similarityLines returns the number of lines of all occurrences in sim.
+func similarityLines(sim *textsimilarity.Similarity) int {
+	lines := 0
+	for _, occ := range sim.Occurrences {
+		lines += occ.End - occ.Start
+	}
+
+	return lines
+}
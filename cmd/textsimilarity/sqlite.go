@@ -0,0 +1,5 @@
+//go:build sqlite
+
+package main
+
+import _ "github.com/blizzy78/textsimilarity/report/sqlite" // register sqlite format
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/blizzy78/textsimilarity"
+)
+
+// gitBlobHashPrefix is prepended to the IDs of git blobs when used as content hashes, so that they can never
+// equal the hashes of files in the working tree.
+const gitBlobHashPrefix = "git:"
+
+// A gitIndex holds the state of the files in the git index, so that the staged contents of files can be scanned
+// instead of the contents in the working tree.
+type gitIndex struct {
+	// blobs maps the absolute paths of all files in the index to the IDs of their blobs.
+	blobs map[string]string
+
+	// staged are the absolute paths of files whose staged contents differ from HEAD.
+	staged map[string]struct{}
+
+	// modified are the absolute paths of files whose contents in the working tree differ from the index.
+	modified map[string]struct{}
+}
+
+// readGitIndex reads the state of the files in the git index of the repository in the current directory.
+func readGitIndex(ctx context.Context) (*gitIndex, error) {
+	cdup, err := gitOutput(ctx, "rev-parse", "--show-cdup")
+	if err != nil {
+		return nil, err
+	}
+
+	root := filepath.FromSlash(strings.TrimSpace(string(cdup)))
+
+	entries, err := gitFileList(ctx, "ls-files", "--stage", "--full-name", "-z")
+	if err != nil {
+		return nil, err
+	}
+
+	index := gitIndex{
+		blobs: make(map[string]string, len(entries)),
+	}
+
+	for _, entry := range entries {
+		// <mode> <object> <stage>\t<path>
+		info, path, ok := strings.Cut(entry, "\t")
+		if !ok {
+			continue
+		}
+
+		fields := strings.Fields(info)
+
+		// skip conflicts, symlinks, and submodules
+		if len(fields) != 3 || fields[2] != "0" || (fields[0] != "100644" && fields[0] != "100755") {
+			continue
+		}
+
+		absPath, err := filepath.Abs(filepath.Join(root, path))
+		if err != nil {
+			return nil, fmt.Errorf("absolute path of %s: %w", path, err)
+		}
+
+		index.blobs[absPath] = fields[1]
+	}
+
+	index.staged, err = gitRepoFiles(ctx, root, "diff", "--cached", "--name-only", "--diff-filter=ACMR", "-z", "--")
+	if err != nil {
+		return nil, err
+	}
+
+	index.modified, err = gitRepoFiles(ctx, root, "diff", "--name-only", "-z", "--")
+	if err != nil {
+		return nil, err
+	}
+
+	return &index, nil
+}
+
+// gitRepoFiles runs git with args and returns the absolute paths of its output, which is a list of NUL-separated
+// paths relative to the repository root, which is at root relative to the current directory.
+func gitRepoFiles(ctx context.Context, root string, args ...string) (map[string]struct{}, error) {
+	paths, err := gitFileList(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]struct{}, len(paths))
+
+	for _, path := range paths {
+		absPath, err := filepath.Abs(filepath.Join(root, path))
+		if err != nil {
+			return nil, fmt.Errorf("absolute path of %s: %w", path, err)
+		}
+
+		files[absPath] = struct{}{}
+	}
+
+	return files, nil
+}
+
+// tracked returns all paths that are files in i. If no files are staged, no paths are returned, since there
+// is nothing to compare.
+func (i *gitIndex) tracked(paths []string) ([]string, error) {
+	if len(i.staged) == 0 {
+		return []string{}, nil
+	}
+
+	result := make([]string, 0, len(paths))
+
+	for _, path := range paths {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("absolute path of %s: %w", path, err)
+		}
+
+		if _, ok := i.blobs[absPath]; ok {
+			result = append(result, path)
+		}
+	}
+
+	return result, nil
+}
+
+// hashes returns the content hashes of all files in paths, keyed by absolute path. The hashes are derived from
+// the IDs of the files' blobs, so no files need to be read.
+func (i *gitIndex) hashes(paths []string) (map[string]string, error) {
+	hashes := make(map[string]string, len(paths))
+
+	for _, path := range paths {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("absolute path of %s: %w", path, err)
+		}
+
+		hashes[absPath] = gitBlobHashPrefix + i.blobs[absPath]
+	}
+
+	return hashes, nil
+}
+
+// open returns a reader of the staged contents of the file at path, and true, if they differ from the contents
+// in the working tree. Otherwise, it returns false, and the file in the working tree should be read instead.
+// If i is nil, it always returns false.
+func (i *gitIndex) open(ctx context.Context, path string) (io.Reader, bool, error) {
+	if i == nil {
+		return nil, false, nil
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, false, fmt.Errorf("absolute path of %s: %w", path, err)
+	}
+
+	if _, ok := i.modified[absPath]; !ok {
+		return nil, false, nil
+	}
+
+	content, err := gitOutput(ctx, "cat-file", "blob", i.blobs[absPath])
+	if err != nil {
+		return nil, false, err
+	}
+
+	return bytes.NewReader(content), true, nil
+}
+
+// stagedSimilarities returns all similarities in sims that have any occurrence in a staged file.
+func (i *gitIndex) stagedSimilarities(sims []*textsimilarity.Similarity) ([]*textsimilarity.Similarity, error) {
+	result := make([]*textsimilarity.Similarity, 0, len(sims))
+
+	for _, sim := range sims {
+		for _, occ := range sim.Occurrences {
+			absPath, err := filepath.Abs(occ.File.Name)
+			if err != nil {
+				return nil, fmt.Errorf("absolute path of %s: %w", occ.File.Name, err)
+			}
+
+			if _, ok := i.staged[absPath]; ok {
+				result = append(result, sim)
+				break
+			}
+		}
+	}
+
+	return result, nil
+}
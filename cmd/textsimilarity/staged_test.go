@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/blizzy78/textsimilarity"
+	"github.com/matryer/is"
+)
+
+func TestGitIndex_Tracked(t *testing.T) {
+	index := gitIndex{
+		blobs: map[string]string{
+			"/repo/a.txt": "1111",
+			"/repo/b.txt": "2222",
+		},
+	}
+
+	tests := []struct {
+		name   string
+		staged map[string]struct{}
+		paths  []string
+		want   []string
+	}{
+		{"nothing staged", map[string]struct{}{}, []string{"/repo/a.txt", "/repo/b.txt"}, []string{}},
+		{"untracked", map[string]struct{}{"/repo/a.txt": {}}, []string{"/repo/a.txt", "/repo/c.txt"}, []string{"/repo/a.txt"}},
+		{"all tracked", map[string]struct{}{"/repo/b.txt": {}}, []string{"/repo/a.txt", "/repo/b.txt"}, []string{"/repo/a.txt", "/repo/b.txt"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			is := is.New(t)
+
+			index.staged = test.staged
+
+			paths, err := index.tracked(test.paths)
+			is.NoErr(err)
+			is.Equal(paths, test.want)
+		})
+	}
+}
+
+func TestGitIndex_Hashes(t *testing.T) {
+	is := is.New(t)
+
+	index := gitIndex{
+		blobs: map[string]string{
+			"/repo/a.txt": "1111",
+			"/repo/b.txt": "2222",
+		},
+	}
+
+	hashes, err := index.hashes([]string{"/repo/a.txt", "/repo/b.txt"})
+	is.NoErr(err)
+	is.Equal(hashes, map[string]string{
+		"/repo/a.txt": gitBlobHashPrefix + "1111",
+		"/repo/b.txt": gitBlobHashPrefix + "2222",
+	})
+}
+
+func TestGitIndex_StagedSimilarities(t *testing.T) {
+	index := gitIndex{
+		staged: map[string]struct{}{"/repo/b.txt": {}},
+	}
+
+	fileA := &textsimilarity.File{Name: "/repo/a.txt"}
+	fileB := &textsimilarity.File{Name: "/repo/b.txt"}
+	fileC := &textsimilarity.File{Name: "/repo/c.txt"}
+
+	tests := []struct {
+		name  string
+		files []*textsimilarity.File
+		want  bool
+	}{
+		{"unstaged", []*textsimilarity.File{fileA, fileC}, false},
+		{"staged", []*textsimilarity.File{fileA, fileB}, true},
+		{"same file", []*textsimilarity.File{fileB, fileB}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			is := is.New(t)
+
+			sim := textsimilarity.Similarity{}
+			for _, file := range test.files {
+				sim.Occurrences = append(sim.Occurrences, &textsimilarity.FileOccurrence{File: file, End: 1})
+			}
+
+			sims, err := index.stagedSimilarities([]*textsimilarity.Similarity{&sim})
+			is.NoErr(err)
+			is.Equal(len(sims) == 1, test.want)
+		})
+	}
+}
+
+func TestReadGitIndex(t *testing.T) {
+	is := is.New(t)
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	is.NoErr(err)
+
+	chdir(t, dir)
+
+	git := func(args ...string) {
+		t.Helper()
+
+		args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+
+	write := func(name string, text string) {
+		t.Helper()
+
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q")
+	write("a.txt", "a\n")
+	write("b.txt", "b\n")
+	git("add", "a.txt", "b.txt")
+	git("commit", "-q", "-m", "initial")
+
+	// b.txt is staged and modified again in the working tree, c.txt is new and staged, d.txt is untracked
+	write("b.txt", "b staged\n")
+	write("c.txt", "c\n")
+	git("add", "b.txt", "c.txt")
+	write("b.txt", "b modified\n")
+	write("d.txt", "d\n")
+
+	index, err := readGitIndex(context.Background())
+	is.NoErr(err)
+
+	abs := func(name string) string {
+		return filepath.Join(dir, name)
+	}
+
+	is.Equal(index.staged, map[string]struct{}{abs("b.txt"): {}, abs("c.txt"): {}})
+	is.Equal(index.modified, map[string]struct{}{abs("b.txt"): {}})
+
+	paths, err := index.tracked([]string{"a.txt", "b.txt", "c.txt", "d.txt"})
+	is.NoErr(err)
+	is.Equal(paths, []string{"a.txt", "b.txt", "c.txt"})
+
+	reader, ok, err := index.open(context.Background(), "b.txt")
+	is.NoErr(err)
+	is.True(ok)
+
+	text, err := io.ReadAll(reader)
+	is.NoErr(err)
+	is.Equal(string(text), "b staged\n")
+
+	// unmodified files are read from the working tree
+	_, ok, err = index.open(context.Background(), "a.txt")
+	is.NoErr(err)
+	is.True(!ok)
+}
+
+// chdir changes the current directory to dir until the end of the test.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = os.Chdir(wd)
+	})
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/blizzy78/textsimilarity"
+)
+
+// statsFiles is the maximum number of files to write the times of.
+const statsFiles = 10
+
+// writeStats writes the time spent in each phase of a scan, including writing reports in outputTime, and the
+// files that took the longest to w.
+func writeStats(w io.Writer, timings *textsimilarity.Timings, outputTime time.Duration) error {
+	tabW := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintln(tabW, "time per phase:")
+
+	for _, phase := range timings.Phases() {
+		fmt.Fprintf(tabW, "%s\t %s\n", phase.Duration.Round(time.Millisecond), phase.Phase)
+	}
+
+	fmt.Fprintf(tabW, "%s\t output\n", outputTime.Round(time.Millisecond))
+
+	files := timings.Files()
+	if len(files) > statsFiles {
+		files = files[:statsFiles]
+	}
+
+	fmt.Fprintln(tabW, "slowest files:")
+
+	for _, file := range files {
+		fmt.Fprintf(tabW, "%s\t %s\n", file.Duration.Round(time.Millisecond), file.File.Name)
+	}
+
+	if err := tabW.Flush(); err != nil {
+		return fmt.Errorf("write stats: %w", err)
+	}
+
+	return nil
+}
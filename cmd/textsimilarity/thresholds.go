@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/blizzy78/textsimilarity"
+)
+
+// thresholds holds limits that cause a non-zero exit code when exceeded. Negative values disable a limit.
+type thresholds struct {
+	// maxSimilarities is the maximum number of similarities.
+	maxSimilarities int
+
+	// maxDuplicatedLines is the maximum number of lines covered by any similarity, across all files.
+	maxDuplicatedLines int
+
+	// maxDuplicationPct is the maximum percentage of duplicated lines in relation to all lines, across all files.
+	maxDuplicationPct float64
+}
+
+// enabled returns whether any limit is set in t.
+func (t thresholds) enabled() bool {
+	return t.maxSimilarities >= 0 || t.maxDuplicatedLines >= 0 || t.maxDuplicationPct >= 0
+}
+
+// exceeded returns a description of each limit in t that is exceeded by sims, found in files.
+func (t thresholds) exceeded(sims []*textsimilarity.Similarity, files []*textsimilarity.File) []string {
+	msgs := []string{}
+
+	if t.maxSimilarities >= 0 && len(sims) > t.maxSimilarities {
+		msgs = append(msgs, fmt.Sprintf("%d similarities exceed maximum of %d", len(sims), t.maxSimilarities))
+	}
+
+	if t.maxDuplicatedLines < 0 && t.maxDuplicationPct < 0 {
+		return msgs
+	}
+
+	dupLines := 0
+	totalLines := 0
+
+	for _, stat := range textsimilarity.FilesStats(files, sims) {
+		dupLines += stat.DuplicatedLines
+		totalLines += stat.Lines
+	}
+
+	if t.maxDuplicatedLines >= 0 && dupLines > t.maxDuplicatedLines {
+		msgs = append(msgs, fmt.Sprintf("%d duplicated lines exceed maximum of %d", dupLines, t.maxDuplicatedLines))
+	}
+
+	if t.maxDuplicationPct < 0 {
+		return msgs
+	}
+
+	pct := 0.0
+	if totalLines != 0 {
+		pct = float64(dupLines) * 100.0 / float64(totalLines)
+	}
+
+	if pct > t.maxDuplicationPct {
+		msgs = append(msgs, fmt.Sprintf("%.1f%% duplication exceeds maximum of %.1f%%", pct, t.maxDuplicationPct))
+	}
+
+	return msgs
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/blizzy78/textsimilarity"
+)
+
+// errInvalidTier is returned when a -tier flag is not of the form "name=maxDist".
+var errInvalidTier = errors.New("tier must be of the form \"name=maxDist\"")
+
+// newTiers returns tiers for specs, each of the form "name=maxDist", sorted by maximum edit distance.
+func newTiers(specs []string) ([]textsimilarity.Tier, error) {
+	tiers := make([]textsimilarity.Tier, len(specs))
+
+	for idx, spec := range specs {
+		name, distStr, ok := strings.Cut(spec, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%w: %s", errInvalidTier, spec)
+		}
+
+		dist, err := strconv.Atoi(distStr)
+		if err != nil || dist < 0 {
+			return nil, fmt.Errorf("%w: %s", errInvalidTier, spec)
+		}
+
+		tiers[idx] = textsimilarity.Tier{
+			Name:            name,
+			MaxEditDistance: dist,
+		}
+	}
+
+	sort.SliceStable(tiers, func(a int, b int) bool {
+		return tiers[a].MaxEditDistance < tiers[b].MaxEditDistance
+	})
+
+	return tiers, nil
+}
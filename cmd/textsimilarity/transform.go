@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/blizzy78/textsimilarity"
+)
+
+// defaultCommentMarkers are the markers of trailing comments removed by -strip-comments if no other markers are given.
+const defaultCommentMarkers = "//,#"
+
+// transformSeparator separates the regular expression from the replacement in a -transform flag.
+const transformSeparator = "=>"
+
+// errInvalidTransform is returned when a -transform flag does not contain transformSeparator.
+var errInvalidTransform = errors.New("transform must be of the form \"regex" + transformSeparator + "replacement\"")
+
+// parseLineTransforms returns line transforms for exprs, each of the form "regex=>replacement".
+func parseLineTransforms(exprs []string) ([]textsimilarity.LineTransform, error) {
+	transforms := make([]textsimilarity.LineTransform, len(exprs))
+
+	for idx, expr := range exprs {
+		regexStr, replacement, ok := strings.Cut(expr, transformSeparator)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", errInvalidTransform, expr)
+		}
+
+		regex, err := regexp.Compile(regexStr)
+		if err != nil {
+			return nil, fmt.Errorf("transform %s: %w", expr, err)
+		}
+
+		transforms[idx] = textsimilarity.LineTransform{
+			Regex:       regex,
+			Replacement: replacement,
+		}
+	}
+
+	return transforms, nil
+}
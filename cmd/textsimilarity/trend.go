@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/blizzy78/textsimilarity/report"
+)
+
+// trend compares the saved reports at oldPath and newPath and writes the changes to stdout. It returns the
+// exit code, which is non-zero if any similarities have been added or have grown.
+func trend(oldPath string, newPath string) (int, error) {
+	oldRep, err := readSavedReport(oldPath)
+	if err != nil {
+		return -1, err
+	}
+
+	newRep, err := readSavedReport(newPath)
+	if err != nil {
+		return -1, err
+	}
+
+	changes := report.NewTrend(oldRep, newRep)
+
+	if changes.OldSummary != nil && changes.NewSummary != nil {
+		fmt.Printf("duplication: %.1f%% -> %.1f%% (%+.1f%%)\n", changes.OldSummary.DuplicationPct, changes.NewSummary.DuplicationPct,
+			changes.DuplicationPctChange())
+	}
+
+	fmt.Printf("%d added, %d removed, %d grown similarities\n", len(changes.Added), len(changes.Removed), len(changes.Grown))
+
+	for _, sim := range changes.Added {
+		fmt.Printf("\nadded - %d lines, %s\n", sim.Lines, sim.Level)
+		printSavedOccurrences(sim)
+	}
+
+	for _, sim := range changes.Removed {
+		fmt.Printf("\nremoved - %d lines, %s\n", sim.Lines, sim.Level)
+		printSavedOccurrences(sim)
+	}
+
+	for _, grown := range changes.Grown {
+		fmt.Printf("\ngrown - %d -> %d lines, %d -> %d occurrences, %s\n", grown.Old.Lines, grown.New.Lines,
+			len(grown.Old.Occurrences)+grown.Old.OmittedOccurrences, len(grown.New.Occurrences)+grown.New.OmittedOccurrences, grown.New.Level)
+		printSavedOccurrences(grown.New)
+	}
+
+	if len(changes.Added) != 0 || len(changes.Grown) != 0 {
+		return 1, nil
+	}
+
+	return 0, nil
+}
+
+// readSavedReport reads the saved report at path.
+func readSavedReport(path string) (*report.SavedReport, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+
+	defer file.Close() //nolint:errcheck // file is being read
+
+	rep, err := report.ReadSavedReport(file)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+
+	return rep, nil
+}
+
+// printSavedOccurrences writes the occurrences of sim to stdout, one per line.
+func printSavedOccurrences(sim *report.SavedSimilarity) {
+	for _, occ := range sim.Occurrences {
+		fmt.Printf("- %s: %d-%d\n", occ.File, occ.Start, occ.End)
+	}
+
+	if sim.OmittedOccurrences > 0 {
+		fmt.Printf("- ... and %d more occurrences\n", sim.OmittedOccurrences)
+	}
+}
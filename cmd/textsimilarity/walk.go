@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/blizzy78/textsimilarity/internal/generated"
+	"github.com/blizzy78/textsimilarity/internal/ignore"
+	tsio "github.com/blizzy78/textsimilarity/internal/io"
+)
+
+// ignoreFileNames are the names of ignore files that are honored when walking directories.
+var ignoreFileNames = []string{".gitignore", ".ignore"}
+
+// expandPaths returns paths with all directories replaced by the files they contain, recursively, and all URLs
+// of object stores replaced by the URLs of the objects they contain, listed using objects. If useIgnoreFiles
+// is true, ignore files found in directories are honored.
+func expandPaths(ctx context.Context, paths []string, useIgnoreFiles bool, objects *objectStore) ([]string, error) {
+	files := []string{}
+
+	for _, path := range paths {
+		if contextDone(ctx) {
+			return nil, errCanceled
+		}
+
+		if isObjectURL(path) {
+			urls, err := objects.list(ctx, path)
+			if err != nil {
+				return nil, err
+			}
+
+			files = append(files, urls...)
+
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("stat %s: %w", path, err)
+		}
+
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		dirFiles, err := walkDir(ctx, path, useIgnoreFiles)
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, dirFiles...)
+	}
+
+	return files, nil
+}
+
+// walkDir returns all regular files in root, recursively. If useIgnoreFiles is true, ignore files
+// found in directories are honored.
+func walkDir(ctx context.Context, root string, useIgnoreFiles bool) ([]string, error) {
+	files := []string{}
+	stack := ignore.Stack{}
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if contextDone(ctx) {
+			return errCanceled
+		}
+
+		slashPath := filepath.ToSlash(path)
+
+		if entry.IsDir() {
+			if path != root && entry.Name() == ".git" {
+				return filepath.SkipDir
+			}
+
+			if !useIgnoreFiles {
+				return nil
+			}
+
+			stack.PopDir(slashPath)
+
+			if path != root && stack.Ignored(slashPath, true) {
+				return filepath.SkipDir
+			}
+
+			return pushIgnoreFiles(&stack, path)
+		}
+
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		if useIgnoreFiles && stack.Ignored(slashPath, false) {
+			return nil
+		}
+
+		files = append(files, path)
+
+		return nil
+	})
+
+	if err != nil {
+		if errors.Is(err, errCanceled) {
+			return nil, errCanceled
+		}
+
+		return nil, fmt.Errorf("walk %s: %w", root, err)
+	}
+
+	return files, nil
+}
+
+// pushIgnoreFiles parses all ignore files in dir and pushes their matchers onto stack.
+func pushIgnoreFiles(stack *ignore.Stack, dir string) error {
+	for _, name := range ignoreFileNames {
+		path := filepath.Join(dir, name)
+
+		file, err := os.Open(path)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+
+			return fmt.Errorf("open %s: %w", path, err)
+		}
+
+		matcher, err := ignore.Parse(filepath.ToSlash(dir), file)
+
+		_ = file.Close()
+
+		if err != nil {
+			return fmt.Errorf("parse %s: %w", path, err)
+		}
+
+		stack.Push(matcher)
+	}
+
+	return nil
+}
+
+// readPathList reads newline-separated paths from the file at path, or from stdin if path is "-".
+// Blank lines are skipped.
+func readPathList(path string) ([]string, error) {
+	if path == "-" {
+		return readPaths(os.Stdin)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	defer file.Close() //nolint:errcheck // file is being read
+
+	paths, err := readPaths(file)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+
+	return paths, nil
+}
+
+// readPaths reads newline-separated paths from r. Blank lines are skipped.
+func readPaths(r io.Reader) ([]string, error) {
+	paths := []string{}
+
+	reader := bufio.NewReader(r)
+	buf := bytes.Buffer{}
+
+	for {
+		line, err := tsio.ReadLine(reader, &buf)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return paths, nil
+			}
+
+			return nil, fmt.Errorf("read line: %w", err)
+		}
+
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		paths = append(paths, line)
+	}
+}
+
+// skipGenerated returns those paths that are not generated, minified, or vendored files.
+func skipGenerated(ctx context.Context, paths []string) ([]string, error) {
+	filtered := make([]string, 0, len(paths))
+
+	for _, path := range paths {
+		if contextDone(ctx) {
+			return nil, errCanceled
+		}
+
+		// objects are only judged by their URLs, to avoid requesting them twice
+		gen := generated.Path(path)
+
+		if !isObjectURL(path) {
+			var err error
+			if gen, err = generated.File(path); err != nil {
+				return nil, err //nolint:wrapcheck // already wrapped
+			}
+		}
+
+		if !gen {
+			filtered = append(filtered, path)
+		}
+	}
+
+	return filtered, nil
+}
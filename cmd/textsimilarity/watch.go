@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/blizzy78/textsimilarity"
+	"github.com/blizzy78/textsimilarity/internal/metrics"
+)
+
+const (
+	// defaultWatchInterval is the default interval between scans of the watch subcommand.
+	defaultWatchInterval = time.Minute
+
+	// metricsReadHeaderTimeout is the timeout for reading request headers of the metrics server.
+	metricsReadHeaderTimeout = 10 * time.Second
+)
+
+// scanMetricsDurationBuckets are the upper bounds of the scan duration histogram's buckets, in seconds.
+var scanMetricsDurationBuckets = []float64{1, 5, 10, 30, 60, 120, 300, 600, 1800}
+
+// scanMetrics are the metrics collected by the watch subcommand.
+type scanMetrics struct {
+	// registry is the registry all metrics are registered in.
+	registry *metrics.Registry
+
+	// files counts the files scanned.
+	files *metrics.Counter
+
+	// lines counts the lines of all files scanned.
+	lines *metrics.Counter
+
+	// similaritiesTotal counts the similarities found across all scans.
+	similaritiesTotal *metrics.Counter
+
+	// similarities is the number of similarities found in the last scan.
+	similarities *metrics.Gauge
+
+	// duration observes the durations of scans.
+	duration *metrics.Histogram
+}
+
+// newScanMetrics returns new scan metrics.
+func newScanMetrics() *scanMetrics {
+	reg := metrics.NewRegistry()
+
+	return &scanMetrics{
+		registry:          reg,
+		files:             reg.NewCounter("textsimilarity_files_scanned_total", "Number of files scanned."),
+		lines:             reg.NewCounter("textsimilarity_lines_scanned_total", "Number of lines of all files scanned."),
+		similaritiesTotal: reg.NewCounter("textsimilarity_similarities_found_total", "Number of similarities found across all scans."),
+		similarities:      reg.NewGauge("textsimilarity_similarities", "Number of similarities found in the last scan."),
+		duration:          reg.NewHistogram("textsimilarity_scan_duration_seconds", "Duration of scans.", scanMetricsDurationBuckets),
+	}
+}
+
+// fileScanned records that file has been scanned.
+func (m *scanMetrics) fileScanned(file *textsimilarity.File) {
+	m.files.Inc()
+	m.lines.Add(float64(file.LineCount()))
+}
+
+// similaritiesFound records that a scan has found n similarities.
+func (m *scanMetrics) similaritiesFound(n int) {
+	m.similaritiesTotal.Add(float64(n))
+	m.similarities.Set(float64(n))
+}
+
+// watch repeatedly scans files in paths for similarities and reports them, according to opts, until ctx is canceled.
+// If opts.metricsAddr is set, metrics are served on that address.
+func watch(ctx context.Context, paths []string, opts cmdOptions) (int, error) {
+	watchMetrics := newScanMetrics()
+
+	if opts.metricsAddr != "" {
+		shutdown, err := serveMetrics(opts.metricsAddr, watchMetrics)
+		if err != nil {
+			return -1, err
+		}
+
+		defer shutdown()
+	}
+
+	for {
+		start := time.Now()
+
+		if _, err := scan(ctx, paths, opts, watchMetrics); err != nil {
+			if contextDone(ctx) {
+				return 0, nil
+			}
+
+			return -1, err
+		}
+
+		watchMetrics.duration.Observe(time.Since(start).Seconds())
+
+		select {
+		case <-ctx.Done():
+			return 0, nil
+		case <-time.After(opts.watchInterval):
+		}
+	}
+}
+
+// serveMetrics serves m at /metrics on addr. It returns a function that shuts down the server.
+func serveMetrics(addr string, m *scanMetrics) (func(), error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listen on %s: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.registry)
+
+	server := http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: metricsReadHeaderTimeout,
+	}
+
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintln(os.Stderr, fmt.Errorf("serve metrics: %w", err).Error())
+		}
+	}()
+
+	return func() {
+		_ = server.Close()
+	}, nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/blizzy78/textsimilarity"
+)
+
+// errInvalidLineWeight is returned when a -line-weight flag is not of the form "regex=weight".
+var errInvalidLineWeight = errors.New("line weight must be of the form \"regex=weight\"")
+
+// newLineWeights returns line weights for rule specs, each of the form "regex=weight", and fullLength.
+// If there are no rules and fullLength <= 0, nil is returned.
+func newLineWeights(specs []string, fullLength int) (*textsimilarity.LineWeights, error) {
+	if len(specs) == 0 && fullLength <= 0 {
+		return nil, nil
+	}
+
+	weights := textsimilarity.LineWeights{
+		Rules:      make([]textsimilarity.LineWeightRule, len(specs)),
+		FullLength: fullLength,
+	}
+
+	for idx, spec := range specs {
+		// the regex may contain "=", but the weight may not
+		sepIdx := strings.LastIndex(spec, "=")
+		if sepIdx < 0 {
+			return nil, fmt.Errorf("%w: %s", errInvalidLineWeight, spec)
+		}
+
+		regex, err := regexp.Compile(spec[:sepIdx])
+		if err != nil {
+			return nil, fmt.Errorf("line weight %s: %w", spec, err)
+		}
+
+		weight, err := strconv.ParseFloat(spec[sepIdx+1:], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", errInvalidLineWeight, spec)
+		}
+
+		weights.Rules[idx] = textsimilarity.LineWeightRule{
+			Regex:  regex,
+			Weight: weight,
+		}
+	}
+
+	return &weights, nil
+}
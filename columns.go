@@ -0,0 +1,86 @@
+package textsimilarity
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A ColumnRange is a range of columns within a single line of text. Columns are counted in runes.
+type ColumnRange struct {
+	// Start is the starting column (zero-based.)
+	Start int
+
+	// End is the ending column (zero-based, exclusive.)
+	End int
+}
+
+// setColumnRanges sets the column ranges of all occurrences of sim, according to opts. Each occurrence's
+// first and last lines are compared to those of the first occurrence. The first occurrence's ranges cover
+// the differences to all other occurrences.
+func setColumnRanges(sim *Similarity, opts *Options) {
+	first := sim.Occurrences[0]
+
+	for _, occ := range sim.Occurrences[1:] {
+		first.StartColumns, occ.StartColumns = lineColumnRanges(
+			first.File.lines[first.Start], occ.File.lines[occ.Start], first.StartColumns, opts)
+
+		first.EndColumns, occ.EndColumns = lineColumnRanges(
+			first.File.lines[first.End-1], occ.File.lines[occ.End-1], first.EndColumns, opts)
+	}
+}
+
+// lineColumnRanges returns the ranges of columns that differ between line1 and line2, according to opts.
+// The range for line1 is merged with range1. A range is nil if there are no differences.
+func lineColumnRanges(line1 *fileLine, line2 *fileLine, range1 *ColumnRange, opts *Options) (*ColumnRange, *ColumnRange) {
+	text1, offset1 := columnText(line1, opts)
+	text2, offset2 := columnText(line2, opts)
+
+	if text1 == text2 {
+		return range1, nil
+	}
+
+	runes1 := []rune(text1)
+	runes2 := []rune(text2)
+
+	prefix := 0
+	for prefix < len(runes1) && prefix < len(runes2) && runes1[prefix] == runes2[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(runes1)-prefix && suffix < len(runes2)-prefix &&
+		runes1[len(runes1)-1-suffix] == runes2[len(runes2)-1-suffix] {
+		suffix++
+	}
+
+	range2 := &ColumnRange{
+		Start: offset2 + prefix,
+		End:   offset2 + len(runes2) - suffix,
+	}
+
+	newRange1 := &ColumnRange{
+		Start: offset1 + prefix,
+		End:   offset1 + len(runes1) - suffix,
+	}
+
+	if range1 != nil {
+		newRange1.Start = min(newRange1.Start, range1.Start)
+		newRange1.End = max(newRange1.End, range1.End)
+	}
+
+	return newRange1, range2
+}
+
+// columnText returns the text of line to compare, according to opts, along with the column the text starts at.
+// Columns refer to the text as it was read, before applying any line transforms.
+func columnText(line *fileLine, opts *Options) (string, int) {
+	if !opts.flagSet(IgnoreWhitespaceFlag) {
+		return line.originalText, 0
+	}
+
+	trimmed := strings.TrimLeftFunc(line.originalText, unicode.IsSpace)
+	leading := line.originalText[:len(line.originalText)-len(trimmed)]
+
+	return strings.TrimRightFunc(trimmed, unicode.IsSpace), utf8.RuneCountInString(leading)
+}
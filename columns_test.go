@@ -0,0 +1,39 @@
+package textsimilarity
+
+import (
+	"context"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestSimilarities_ColumnRanges(t *testing.T) {
+	is := is.New(t)
+
+	file1 := newFile("1.txt", "  foo := bar(x)\naaaaaaaaaa\nreturn foo\n")
+	file2 := newFile("2.txt", "foo := baz(x)\naaaaaaaaaa\nreturn foo\n")
+
+	simsCh, progressCh, err := Similarities(context.Background(), []*File{file1, file2}, &Options{
+		Flags:           IgnoreWhitespaceFlag,
+		MaxEditDistance: 2,
+	})
+	is.NoErr(err)
+
+	var sims []*Similarity
+
+	waitForAll(func() {
+		sims = readSimilaritiesChan(simsCh)
+	}, drainProgressChan(progressCh))
+
+	is.Equal(len(sims), 1)
+	is.Equal(sims[0].Level, SimilarSimilarityLevel)
+
+	occ1 := sims[0].Occurrences[0]
+	occ2 := sims[0].Occurrences[1]
+
+	is.Equal(*occ1.StartColumns, ColumnRange{Start: 11, End: 12})
+	is.Equal(*occ2.StartColumns, ColumnRange{Start: 9, End: 10})
+
+	is.Equal(occ1.EndColumns, nil)
+	is.Equal(occ2.EndColumns, nil)
+}
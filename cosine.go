@@ -0,0 +1,342 @@
+package textsimilarity
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// cosineIndexTerms is the number of terms with the highest weights of each block that are used to find
+	// candidate blocks to compare it with.
+	cosineIndexTerms = 3
+
+	// maxCosineTermBlocks is the maximum number of blocks a term may appear in to be used to find candidate
+	// blocks. More frequent terms are not distinctive enough.
+	maxCosineTermBlocks = 1000
+)
+
+// A cosineBlock is a range of lines in a file that is a candidate for a paraphrased similarity.
+type cosineBlock struct {
+	// fileIdx is the index of the file.
+	fileIdx int
+
+	// start is the starting line number (zero-based.)
+	start int
+
+	// terms are the TF-IDF weights of the words of the block, normalized to unit length and sorted by term.
+	terms []termWeight
+
+	// simHash is the SimHash of the words of the block. It is only set if Options.MaxSimHashDistance > 0.
+	simHash uint64
+}
+
+// A termWeight is the weight of a single word in a block.
+type termWeight struct {
+	term   uint64
+	weight float64
+}
+
+// buildBlockWords sets up f.blockWords from f's lines, according to opts and f's scopes.
+func (f *File) buildBlockWords(opts *Options) {
+	f.blockWords = make([][]uint64, f.lineCount)
+
+	for idx := 0; idx < f.lineCount; idx++ {
+		line := f.lines[idx]
+		if !acceptLine(line, opts) || !f.inScope(idx) {
+			continue
+		}
+
+		// non-nil marks lines that are considered, even if they do not contain any words
+		f.blockWords[idx] = lineWords(line.text)
+	}
+}
+
+// lineWords returns the hashes of the words of text, ignoring case and punctuation.
+func lineWords(text string) []uint64 {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	hashes := make([]uint64, len(words))
+	for idx, word := range words {
+		hashes[idx] = hashString(word)
+	}
+
+	return hashes
+}
+
+// cosineSimilarities returns all similarities between blocks of Options.MinSimilarLines consecutive lines of
+// files whose words, weighted by TF-IDF, have a cosine similarity of at least Options.MinCosineSimilarity. The
+// inverse document frequencies of words are computed over all lines of all files. Similarities are found using
+// the files' blockWords only, so their lines need not be loaded. Only blocks sharing one of their most distinctive
+// words, or whose SimHashes differ in at most Options.MaxSimHashDistance bits if it is > 0, are compared, and lines
+// are only included in a single similarity.
+func cosineSimilarities(ctx context.Context, files []*File, pairMatches filePairMatches, opts *Options) []*Similarity {
+	size := max(opts.MinSimilarLines, 1)
+	idfs := inverseDocumentFrequencies(files)
+
+	blocks := []*cosineBlock{}
+	for fileIdx, file := range files {
+		forEachCosineBlock(file.blockWords, size, idfs, func(start int, terms []termWeight, counts map[uint64]int) {
+			block := cosineBlock{fileIdx: fileIdx, start: start, terms: terms}
+			if opts.MaxSimHashDistance > 0 {
+				block.simHash = simHash(counts)
+			}
+
+			blocks = append(blocks, &block)
+		})
+	}
+
+	candidates := termCandidates(blocks)
+	if opts.MaxSimHashDistance > 0 {
+		candidates = simHashCandidates(blocks, opts.MaxSimHashDistance)
+	}
+
+	used := make([]*bitVector, len(files))
+	for idx, file := range files {
+		used[idx] = newBitVector(file.lineCount)
+	}
+
+	sims := []*Similarity{}
+
+	for blockIdx, block1 := range blocks {
+		if contextDone(ctx) {
+			return sims
+		}
+
+		if blockUsed(used[block1.fileIdx], block1.start, size) {
+			continue
+		}
+
+		file1 := files[block1.fileIdx]
+		sim := Similarity{
+			Occurrences: []*FileOccurrence{{File: file1, Start: block1.start, End: block1.start + size}},
+			Level:       SimilarSimilarityLevel,
+		}
+
+		simBlocks := []*cosineBlock{block1}
+
+		for _, otherIdx := range candidates(blockIdx) {
+			block2 := blocks[otherIdx]
+			file2 := files[block2.fileIdx]
+
+			switch {
+			case file1.ReferenceOnly && file2.ReferenceOnly:
+			case pairMatches.excluded(block1.fileIdx, block2.fileIdx):
+			case blockUsed(used[block2.fileIdx], block2.start, size):
+			case cosine(block1.terms, block2.terms) < opts.MinCosineSimilarity:
+
+			default:
+				occ := FileOccurrence{File: file2, Start: block2.start, End: block2.start + size}
+				if !overlapsAny(&occ, sim.Occurrences) {
+					sim.Occurrences = append(sim.Occurrences, &occ)
+					simBlocks = append(simBlocks, block2)
+				}
+			}
+		}
+
+		if len(sim.Occurrences) < 2 {
+			continue
+		}
+
+		for _, block := range simBlocks {
+			for l := block.start; l < block.start+size; l++ {
+				used[block.fileIdx].set(l, true)
+			}
+		}
+
+		sims = append(sims, &sim)
+	}
+
+	return sims
+}
+
+// inverseDocumentFrequencies returns the smoothed inverse document frequencies of all words in the blockWords
+// of files, using each line as a document.
+func inverseDocumentFrequencies(files []*File) map[uint64]float64 {
+	frequencies := map[uint64]int{}
+	docs := 0
+
+	for _, file := range files {
+		for _, words := range file.blockWords {
+			if words == nil {
+				continue
+			}
+
+			docs++
+
+			seen := map[uint64]struct{}{}
+
+			for _, word := range words {
+				if _, ok := seen[word]; ok {
+					continue
+				}
+
+				seen[word] = struct{}{}
+				frequencies[word]++
+			}
+		}
+	}
+
+	idfs := make(map[uint64]float64, len(frequencies))
+	for word, freq := range frequencies {
+		idfs[word] = math.Log(float64(1+docs)/float64(1+freq)) + 1
+	}
+
+	return idfs
+}
+
+// forEachCosineBlock calls fn with the start, the normalized TF-IDF weights of the words, and the numbers of times
+// each word appears, of all blocks of size consecutive lines in words that are all considered for similarities and
+// contain at least one word. fn must not modify or retain counts.
+func forEachCosineBlock(words [][]uint64, size int, idfs map[uint64]float64,
+	fn func(start int, terms []termWeight, counts map[uint64]int),
+) {
+	counts := map[uint64]int{}
+	run := 0
+
+	for idx, lineWords := range words {
+		if lineWords == nil {
+			clear(counts)
+			run = 0
+
+			continue
+		}
+
+		for _, word := range lineWords {
+			counts[word]++
+		}
+
+		run++
+
+		if run > size {
+			for _, word := range words[idx-size] {
+				counts[word]--
+				if counts[word] == 0 {
+					delete(counts, word)
+				}
+			}
+		}
+
+		if run < size || len(counts) == 0 {
+			continue
+		}
+
+		terms := make([]termWeight, 0, len(counts))
+		norm := 0.0
+
+		for word, count := range counts {
+			weight := float64(count) * idfs[word]
+			terms = append(terms, termWeight{term: word, weight: weight})
+			norm += weight * weight
+		}
+
+		norm = math.Sqrt(norm)
+
+		for termIdx := range terms {
+			terms[termIdx].weight /= norm
+		}
+
+		sort.Slice(terms, func(a int, b int) bool {
+			return terms[a].term < terms[b].term
+		})
+
+		fn(idx-size+1, terms, counts)
+	}
+}
+
+// topTerms returns the n terms of terms with the highest weights.
+func topTerms(terms []termWeight, n int) []uint64 {
+	sorted := make([]termWeight, len(terms))
+	copy(sorted, terms)
+
+	sort.Slice(sorted, func(a int, b int) bool {
+		if sorted[a].weight != sorted[b].weight {
+			return sorted[a].weight > sorted[b].weight
+		}
+
+		return sorted[a].term < sorted[b].term
+	})
+
+	top := make([]uint64, 0, n)
+	for idx := 0; idx < min(n, len(sorted)); idx++ {
+		top = append(top, sorted[idx].term)
+	}
+
+	return top
+}
+
+// termCandidates returns a function that returns the indexes of the blocks after the block with a given index
+// that share one of its most distinctive terms, in ascending order.
+func termCandidates(blocks []*cosineBlock) func(blockIdx int) []int {
+	// index maps terms to the indexes of the blocks having them among their most distinctive terms
+	index := map[uint64][]int{}
+
+	for blockIdx, block := range blocks {
+		for _, term := range topTerms(block.terms, cosineIndexTerms) {
+			index[term] = append(index[term], blockIdx)
+		}
+	}
+
+	return func(blockIdx int) []int {
+		candidates := map[int]struct{}{}
+
+		for _, term := range topTerms(blocks[blockIdx].terms, cosineIndexTerms) {
+			blockIdxs := index[term]
+			if len(blockIdxs) > maxCosineTermBlocks {
+				continue
+			}
+
+			for _, otherIdx := range blockIdxs[sort.SearchInts(blockIdxs, blockIdx+1):] {
+				candidates[otherIdx] = struct{}{}
+			}
+		}
+
+		return sortedInts(candidates)
+	}
+}
+
+// sortedInts returns the keys of m, sorted.
+func sortedInts(m map[int]struct{}) []int {
+	sorted := make([]int, 0, len(m))
+	for i := range m {
+		sorted = append(sorted, i)
+	}
+
+	sort.Ints(sorted)
+
+	return sorted
+}
+
+// cosine returns the cosine similarity of terms1 and terms2, which must be normalized and sorted by term.
+func cosine(terms1 []termWeight, terms2 []termWeight) float64 {
+	dot := 0.0
+
+	for idx1, idx2 := 0, 0; idx1 < len(terms1) && idx2 < len(terms2); {
+		switch {
+		case terms1[idx1].term == terms2[idx2].term:
+			dot += terms1[idx1].weight * terms2[idx2].weight
+			idx1++
+			idx2++
+
+		case terms1[idx1].term < terms2[idx2].term:
+			idx1++
+
+		default:
+			idx2++
+		}
+	}
+
+	return dot
+}
+
+// cosineResult returns a result of all paraphrased similarities between files, with the index taskIdx. The
+// similarities are prepared according to opts, loading lines from store. Errors are reported to progressCh.
+func cosineResult(ctx context.Context, files []*File, pairMatches filePairMatches, taskIdx int, store *lineStore,
+	progressCh chan<- Progress, opts *Options,
+) taskResult {
+	return storedResult(ctx, cosineSimilarities(ctx, files, pairMatches, opts), taskIdx, store, progressCh, opts)
+}
@@ -0,0 +1,63 @@
+package textsimilarity
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestSimilarities_Cosine(t *testing.T) {
+	is := is.New(t)
+
+	newFiles := func() []*File {
+		return []*File{
+			newFile("1.txt", "Unrelated introduction of the first document.\n"+
+				"The committee approved the annual budget on Monday,\n"+
+				"after a long debate about funding for public libraries.\n"+
+				"Critics argued that museums deserved more money.\n"+
+				"Something else entirely at the end.\n"),
+			newFile("2.txt", "A different opening line here.\n"+
+				"On Monday, after debating library funding at length,\n"+
+				"the annual budget was approved by the committee.\n"+
+				"More money for museums, critics argued, was deserved.\n"+
+				"Nothing more to say.\n"),
+		}
+	}
+
+	sims := similaritiesWithOptions(t, newFiles(), &Options{MinSimilarLines: 3})
+	is.Equal(len(sims), 0)
+
+	sims = similaritiesWithOptions(t, newFiles(), &Options{MinSimilarLines: 3, MinCosineSimilarity: 0.6})
+	is.Equal(len(sims), 1)
+	is.Equal(sims[0].Level, SimilarSimilarityLevel)
+	is.Equal(sims[0].Occurrences[0].File.Name, "1.txt")
+	is.Equal(sims[0].Occurrences[0].Start, 1)
+	is.Equal(sims[0].Occurrences[0].End, 4)
+	is.Equal(sims[0].Occurrences[1].File.Name, "2.txt")
+	is.Equal(sims[0].Occurrences[1].Start, 1)
+	is.Equal(sims[0].Occurrences[1].End, 4)
+
+	sims = similaritiesWithOptions(t, newFiles(), &Options{MinSimilarLines: 3, MinCosineSimilarity: 0.99})
+	is.Equal(len(sims), 0)
+}
+
+func TestCosine(t *testing.T) {
+	is := is.New(t)
+
+	idfs := map[uint64]float64{1: 1, 2: 1, 3: 1}
+
+	terms := func(words ...uint64) []termWeight {
+		var result []termWeight
+
+		forEachCosineBlock([][]uint64{words}, 1, idfs, func(_ int, terms []termWeight, _ map[uint64]int) {
+			result = terms
+		})
+
+		return result
+	}
+
+	is.True(cosine(terms(1, 2), terms(2, 1)) > 0.999)
+	is.Equal(cosine(terms(1), terms(2)), 0.0)
+	is.True(cosine(terms(1, 2), terms(1, 3)) > 0.499)
+	is.True(cosine(terms(1, 2), terms(1, 3)) < 0.501)
+}
@@ -0,0 +1,282 @@
+// Package ctph computes context-triggered piecewise hashes (CTPH) in the style of ssdeep. Unlike cryptographic
+// hashes, the digests of similar inputs are similar, so that they can be compared to triage related documents.
+package ctph
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// rollingWindow is the size of the window of the rolling hash, and the minimum length of common substrings
+	// of signatures of related digests.
+	rollingWindow = 7
+
+	// minBlockSize is the smallest block size.
+	minBlockSize = 3
+
+	// signatureLength is the maximum length of the first signature of a digest. The second signature is at most
+	// half as long.
+	signatureLength = 64
+
+	// hashPrime is the multiplier of the piecewise FNV hash.
+	hashPrime = 0x01000193
+
+	// hashInit is the initial value of the piecewise FNV hash.
+	hashInit = 0x28021967
+
+	// maxRepeats is the maximum number of repeated characters in signatures that are considered when comparing.
+	maxRepeats = 3
+
+	// base64Chars are the characters used in signatures.
+	base64Chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+)
+
+// errInvalidDigest is returned when parsing a string that is not a valid digest.
+var errInvalidDigest = errors.New("invalid digest")
+
+// A Digest is a context-triggered piecewise hash of some data.
+type Digest struct {
+	// BlockSize is the block size used for Signature1. The block size used for Signature2 is twice as large.
+	BlockSize int
+
+	// Signature1 is the signature of the data using BlockSize.
+	Signature1 string
+
+	// Signature2 is the signature of the data using twice the block size.
+	Signature2 string
+}
+
+// A rollingHash is a hash of the last rollingWindow bytes written to it.
+type rollingHash struct {
+	window [rollingWindow]uint32
+	h1     uint32
+	h2     uint32
+	h3     uint32
+	n      int
+}
+
+// Sum returns the digest of data.
+func Sum(data []byte) Digest {
+	blockSize := minBlockSize
+	for blockSize*signatureLength < len(data) {
+		blockSize *= 2
+	}
+
+	for {
+		digest := sum(data, blockSize)
+
+		// too few pieces to compare, try again with smaller blocks
+		if blockSize > minBlockSize && len(digest.Signature1) < signatureLength/2 {
+			blockSize /= 2
+			continue
+		}
+
+		return digest
+	}
+}
+
+// sum returns the digest of data using blockSize.
+func sum(data []byte, blockSize int) Digest {
+	roll := rollingHash{}
+	sig1 := strings.Builder{}
+	sig2 := strings.Builder{}
+	hash1 := uint32(hashInit)
+	hash2 := uint32(hashInit)
+	pending := false
+
+	trigger1 := uint32(blockSize)     //nolint:gosec // block sizes are small
+	trigger2 := uint32(2 * blockSize) //nolint:gosec // block sizes are small
+
+	for _, b := range data {
+		hash1 = hash1*hashPrime ^ uint32(b)
+		hash2 = hash2*hashPrime ^ uint32(b)
+		pending = true
+
+		rolled := roll.add(b)
+
+		if rolled%trigger1 == trigger1-1 && sig1.Len() < signatureLength-1 {
+			sig1.WriteByte(base64Chars[hash1%64])
+			hash1 = hashInit
+		}
+
+		if rolled%trigger2 == trigger2-1 && sig2.Len() < signatureLength/2-1 {
+			sig2.WriteByte(base64Chars[hash2%64])
+			hash2 = hashInit
+		}
+	}
+
+	// the last piece is always included
+	if pending {
+		sig1.WriteByte(base64Chars[hash1%64])
+		sig2.WriteByte(base64Chars[hash2%64])
+	}
+
+	return Digest{
+		BlockSize:  blockSize,
+		Signature1: sig1.String(),
+		Signature2: sig2.String(),
+	}
+}
+
+// add adds b to h, and returns the hash of the last rollingWindow bytes.
+func (h *rollingHash) add(b byte) uint32 {
+	c := uint32(b)
+
+	h.h2 -= h.h1
+	h.h2 += rollingWindow * c
+
+	h.h1 += c
+	h.h1 -= h.window[h.n%rollingWindow]
+
+	h.window[h.n%rollingWindow] = c
+	h.n++
+
+	h.h3 <<= 5
+	h.h3 ^= c
+
+	return h.h1 + h.h2 + h.h3
+}
+
+// String returns d in the format used by ssdeep, which is "blocksize:signature1:signature2".
+func (d Digest) String() string {
+	return strconv.Itoa(d.BlockSize) + ":" + d.Signature1 + ":" + d.Signature2
+}
+
+// Parse parses a digest in the format returned by Digest.String.
+func Parse(s string) (Digest, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return Digest{}, fmt.Errorf("%w: %s", errInvalidDigest, s)
+	}
+
+	blockSize, err := strconv.Atoi(parts[0])
+	if err != nil || blockSize < minBlockSize {
+		return Digest{}, fmt.Errorf("%w: %s", errInvalidDigest, s)
+	}
+
+	return Digest{
+		BlockSize:  blockSize,
+		Signature1: parts[1],
+		Signature2: parts[2],
+	}, nil
+}
+
+// Compare returns a match score of d1 and d2, from 0 (unrelated) to 100 (very similar). Digests can only be
+// compared if their block sizes are equal, or differ by a factor of 2. Otherwise, the score is 0.
+func Compare(d1 Digest, d2 Digest) int {
+	if d1 == d2 {
+		return 100
+	}
+
+	switch {
+	case d1.BlockSize == d2.BlockSize:
+		return max(
+			signatureScore(d1.Signature1, d2.Signature1, d1.BlockSize),
+			signatureScore(d1.Signature2, d2.Signature2, 2*d1.BlockSize))
+
+	case d1.BlockSize == 2*d2.BlockSize:
+		return signatureScore(d1.Signature1, d2.Signature2, d1.BlockSize)
+
+	case 2*d1.BlockSize == d2.BlockSize:
+		return signatureScore(d1.Signature2, d2.Signature1, d2.BlockSize)
+
+	default:
+		return 0
+	}
+}
+
+// signatureScore returns a match score of the signatures sig1 and sig2, which were computed using blockSize.
+func signatureScore(sig1 string, sig2 string, blockSize int) int {
+	sig1 = eliminateRepeats(sig1)
+	sig2 = eliminateRepeats(sig2)
+
+	if !hasCommonSubstring(sig1, sig2) {
+		return 0
+	}
+
+	dist := editDistance(sig1, sig2)
+
+	// scale the distance by the lengths of the signatures, and convert it to a score
+	score := dist * signatureLength / (len(sig1) + len(sig2))
+	score = 100 * score / signatureLength
+
+	if score >= 100 {
+		return 0
+	}
+
+	score = 100 - score
+
+	// signatures of small inputs may match by chance, so they cannot reach high scores
+	if blockSize < (99+rollingWindow)/rollingWindow*minBlockSize {
+		score = min(score, blockSize/minBlockSize*min(len(sig1), len(sig2)))
+	}
+
+	return score
+}
+
+// eliminateRepeats returns sig with runs of more than maxRepeats equal characters shortened to maxRepeats
+// characters, since they carry little information.
+func eliminateRepeats(sig string) string {
+	result := make([]byte, 0, len(sig))
+
+	for idx := 0; idx < len(sig); idx++ {
+		if idx >= maxRepeats && sig[idx] == sig[idx-1] && sig[idx] == sig[idx-2] && sig[idx] == sig[idx-3] {
+			continue
+		}
+
+		result = append(result, sig[idx])
+	}
+
+	return string(result)
+}
+
+// hasCommonSubstring returns whether sig1 and sig2 have a common substring of length rollingWindow.
+func hasCommonSubstring(sig1 string, sig2 string) bool {
+	if len(sig1) < rollingWindow || len(sig2) < rollingWindow {
+		return false
+	}
+
+	substrings := map[string]struct{}{}
+	for idx := 0; idx+rollingWindow <= len(sig1); idx++ {
+		substrings[sig1[idx:idx+rollingWindow]] = struct{}{}
+	}
+
+	for idx := 0; idx+rollingWindow <= len(sig2); idx++ {
+		if _, ok := substrings[sig2[idx:idx+rollingWindow]]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// editDistance returns the edit distance between sig1 and sig2, where insertions and deletions cost 1, and
+// substitutions cost 2.
+func editDistance(sig1 string, sig2 string) int {
+	prev := make([]int, len(sig2)+1)
+	curr := make([]int, len(sig2)+1)
+
+	for idx2 := range prev {
+		prev[idx2] = idx2
+	}
+
+	for idx1 := 1; idx1 <= len(sig1); idx1++ {
+		curr[0] = idx1
+
+		for idx2 := 1; idx2 <= len(sig2); idx2++ {
+			cost := 0
+			if sig1[idx1-1] != sig2[idx2-1] {
+				cost = 2
+			}
+
+			curr[idx2] = min(prev[idx2]+1, curr[idx2-1]+1, prev[idx2-1]+cost)
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(sig2)]
+}
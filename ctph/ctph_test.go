@@ -0,0 +1,78 @@
+package ctph
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestCompare(t *testing.T) {
+	is := is.New(t)
+
+	text := randomText(1, 400)
+	digest := Sum([]byte(text))
+
+	is.Equal(Compare(digest, digest), 100)
+
+	edited := strings.Replace(text, "\n", "\nan inserted line of text\n", 1)
+	score := Compare(digest, Sum([]byte(edited)))
+	is.True(score >= 80)
+	is.True(score < 100)
+
+	is.Equal(Compare(digest, Sum([]byte(randomText(2, 400)))), 0)
+
+	is.Equal(Compare(Digest{BlockSize: 3, Signature1: "abc"}, Digest{BlockSize: 12, Signature1: "abc"}), 0)
+}
+
+func TestSum(t *testing.T) {
+	is := is.New(t)
+
+	digest := Sum([]byte(randomText(1, 400)))
+	is.True(digest.BlockSize > minBlockSize)
+	is.True(len(digest.Signature1) >= signatureLength/2)
+	is.True(len(digest.Signature1) <= signatureLength)
+	is.True(len(digest.Signature2) <= signatureLength/2)
+
+	is.Equal(Sum(nil), Digest{BlockSize: minBlockSize})
+}
+
+func TestParse(t *testing.T) {
+	is := is.New(t)
+
+	digest := Sum([]byte(randomText(1, 100)))
+
+	parsed, err := Parse(digest.String())
+	is.NoErr(err)
+	is.Equal(parsed, digest)
+
+	_, err = Parse("foo")
+	is.True(err != nil)
+
+	_, err = Parse("x:abc:def")
+	is.True(err != nil)
+}
+
+func TestEliminateRepeats(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(eliminateRepeats("abbbbbcdddd"), "abbbcddd")
+}
+
+// randomText returns lines of random words, using seed.
+func randomText(seed int64, lines int) string {
+	rnd := rand.New(rand.NewSource(seed)) //nolint:gosec // no need for secure random numbers
+	text := strings.Builder{}
+
+	for line := 0; line < lines; line++ {
+		for word := 0; word < 8; word++ {
+			fmt.Fprintf(&text, "w%d ", rnd.Intn(10000))
+		}
+
+		text.WriteString("\n")
+	}
+
+	return text.String()
+}
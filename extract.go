@@ -0,0 +1,213 @@
+package textsimilarity
+
+import (
+	"strings"
+	"unicode"
+)
+
+// An ExtractionKind is the kind of refactoring suggested to deduplicate a clone class.
+type ExtractionKind int
+
+const (
+	// IdenticalExtraction indicates that all occurrences are identical, apart from whitespace, so the
+	// representative can be extracted as it is.
+	IdenticalExtraction = ExtractionKind(iota)
+
+	// ParameterizableExtraction indicates that the occurrences only differ in single tokens, such as identifiers
+	// or literals, so the representative can be extracted with those tokens turned into parameters.
+	ParameterizableExtraction
+
+	// DivergentExtraction indicates that the occurrences differ in structure, so they must be merged manually.
+	DivergentExtraction
+)
+
+// An ExtractionHint is a suggestion of how to deduplicate a clone class.
+type ExtractionHint struct {
+	// Representative is the canonical representative block of the class, which is the occurrence of Occurrences
+	// that is equal to the other occurrences in the most lines.
+	Representative *FileOccurrence
+
+	// Occurrences are the occurrences of the similarity the hint is based on, which is the similarity of the
+	// class with the most occurrences and lines.
+	Occurrences []*FileOccurrence
+
+	// Kind is the kind of refactoring suggested.
+	Kind ExtractionKind
+
+	// Parameters are the parameters of the representative if Kind is ParameterizableExtraction, in order of their
+	// first uses.
+	Parameters []*ExtractionParameter
+}
+
+// An ExtractionParameter is a parameter of the representative of a clone class, that is, a token whose value
+// differs between occurrences.
+type ExtractionParameter struct {
+	// Values are the values of the parameter in each of ExtractionHint.Occurrences, in the same order.
+	Values []string
+
+	// Uses are the positions of the tokens in the representative that are replaced by the parameter. Tokens with
+	// the same values in all occurrences, such as a renamed identifier, are replaced by the same parameter.
+	Uses []TokenPosition
+}
+
+// A TokenPosition is the position of a token in a block of text.
+type TokenPosition struct {
+	// Line is the line of the token, relative to the start of the block (zero-based.)
+	Line int
+
+	// Token is the index of the token in the line (zero-based.)
+	Token int
+}
+
+// ExtractionHint returns a suggestion of how to deduplicate c, using text to read the texts of occurrences.
+// Lines are split into tokens of letters, digits, and underscores, and single other characters, ignoring
+// whitespace.
+func (c *CloneClass) ExtractionHint(text func(occ *FileOccurrence) (string, error)) (*ExtractionHint, error) {
+	sim := c.Similarities[0]
+	for _, s := range c.Similarities[1:] {
+		if len(s.Occurrences) > len(sim.Occurrences) ||
+			len(s.Occurrences) == len(sim.Occurrences) && s.Occurrences[0].End-s.Occurrences[0].Start > sim.Occurrences[0].End-sim.Occurrences[0].Start {
+			sim = s
+		}
+	}
+
+	// tokens are the tokens of each line of each occurrence
+	tokens := make([][][]string, len(sim.Occurrences))
+
+	for idx, occ := range sim.Occurrences {
+		occText, err := text(occ)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, line := range strings.Split(strings.TrimSuffix(occText, "\n"), "\n") {
+			tokens[idx] = append(tokens[idx], codeTokens(line))
+		}
+	}
+
+	hint := ExtractionHint{
+		Representative: sim.Occurrences[representativeIndex(tokens)],
+		Occurrences:    sim.Occurrences,
+		Kind:           IdenticalExtraction,
+	}
+
+	for _, occTokens := range tokens[1:] {
+		if len(occTokens) != len(tokens[0]) {
+			hint.Kind = DivergentExtraction
+			return &hint, nil
+		}
+	}
+
+	// parameters maps the values of parameters, joined by NUL, to parameters
+	parameters := map[string]*ExtractionParameter{}
+
+	for l := range tokens[0] {
+		for idx := range tokens[1:] {
+			if len(tokens[idx+1][l]) != len(tokens[0][l]) {
+				hint.Kind = DivergentExtraction
+				hint.Parameters = nil
+
+				return &hint, nil
+			}
+		}
+
+		for t := range tokens[0][l] {
+			values := make([]string, len(tokens))
+			for idx := range tokens {
+				values[idx] = tokens[idx][l][t]
+			}
+
+			if allEqual(values) {
+				continue
+			}
+
+			hint.Kind = ParameterizableExtraction
+
+			key := strings.Join(values, "\x00")
+
+			param, ok := parameters[key]
+			if !ok {
+				param = &ExtractionParameter{Values: values}
+				parameters[key] = param
+				hint.Parameters = append(hint.Parameters, param)
+			}
+
+			param.Uses = append(param.Uses, TokenPosition{Line: l, Token: t})
+		}
+	}
+
+	return &hint, nil
+}
+
+// representativeIndex returns the index of the occurrence in tokens whose lines are equal to the corresponding
+// lines of the other occurrences the most times. Ties are broken by the lowest index.
+func representativeIndex(tokens [][][]string) int {
+	best := 0
+	bestEqual := -1
+
+	for idx, occTokens := range tokens {
+		equal := 0
+
+		for otherIdx, otherTokens := range tokens {
+			if otherIdx == idx {
+				continue
+			}
+
+			for l := 0; l < min(len(occTokens), len(otherTokens)); l++ {
+				if strings.Join(occTokens[l], " ") == strings.Join(otherTokens[l], " ") {
+					equal++
+				}
+			}
+		}
+
+		if equal > bestEqual {
+			best = idx
+			bestEqual = equal
+		}
+	}
+
+	return best
+}
+
+// codeTokens splits line into tokens of letters, digits, and underscores, and single other characters,
+// ignoring whitespace.
+func codeTokens(line string) []string {
+	tokens := []string{}
+	word := -1
+
+	for idx, r := range line {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			if word < 0 {
+				word = idx
+			}
+
+			continue
+		}
+
+		if word >= 0 {
+			tokens = append(tokens, line[word:idx])
+			word = -1
+		}
+
+		if !unicode.IsSpace(r) {
+			tokens = append(tokens, string(r))
+		}
+	}
+
+	if word >= 0 {
+		tokens = append(tokens, line[word:])
+	}
+
+	return tokens
+}
+
+// allEqual returns whether all of values are equal.
+func allEqual(values []string) bool {
+	for _, v := range values[1:] {
+		if v != values[0] {
+			return false
+		}
+	}
+
+	return true
+}
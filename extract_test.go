@@ -0,0 +1,65 @@
+package textsimilarity
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestCloneClass_ExtractionHint(t *testing.T) {
+	is := is.New(t)
+
+	fileA := &File{Name: "a.go"}
+	fileB := &File{Name: "b.go"}
+	fileC := &File{Name: "c.go"}
+
+	texts := map[*File]string{
+		fileA: "x := foo(a, 1)\nreturn x + a\n",
+		fileB: "x := foo(b, 1)\nreturn x + b\n",
+		fileC: "  x := foo(c,2)\nreturn x + c\n",
+	}
+
+	text := func(occ *FileOccurrence) (string, error) {
+		return texts[occ.File], nil
+	}
+
+	class := &CloneClass{
+		Similarities: []*Similarity{
+			{Occurrences: []*FileOccurrence{{File: fileA, Start: 0, End: 2}, {File: fileB, Start: 0, End: 2}}},
+			{Occurrences: []*FileOccurrence{{File: fileA, Start: 0, End: 2}, {File: fileB, Start: 0, End: 2}, {File: fileC, Start: 0, End: 2}}},
+		},
+	}
+
+	hint, err := class.ExtractionHint(text)
+	is.NoErr(err)
+	is.Equal(hint.Kind, ParameterizableExtraction)
+	is.Equal(hint.Occurrences, class.Similarities[1].Occurrences)
+	is.Equal(hint.Representative, class.Similarities[1].Occurrences[0])
+	is.Equal(len(hint.Parameters), 2)
+	is.Equal(hint.Parameters[0].Values, []string{"a", "b", "c"})
+	is.Equal(hint.Parameters[0].Uses, []TokenPosition{{Line: 0, Token: 5}, {Line: 1, Token: 3}})
+	is.Equal(hint.Parameters[1].Values, []string{"1", "1", "2"})
+	is.Equal(hint.Parameters[1].Uses, []TokenPosition{{Line: 0, Token: 7}})
+
+	texts[fileC] = "x := foo(a, 1)\nreturn x + a\n"
+	texts[fileB] = "x := foo(a, 1)\nreturn x + a\n"
+
+	hint, err = class.ExtractionHint(text)
+	is.NoErr(err)
+	is.Equal(hint.Kind, IdenticalExtraction)
+	is.Equal(len(hint.Parameters), 0)
+
+	texts[fileB] = "x := foo(a, 1, 2)\nreturn x + a\n"
+
+	hint, err = class.ExtractionHint(text)
+	is.NoErr(err)
+	is.Equal(hint.Kind, DivergentExtraction)
+	is.Equal(hint.Representative, class.Similarities[1].Occurrences[0])
+}
+
+func TestCodeTokens(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(codeTokens("\tfoo_1 := bar(\"x y\")"), []string{"foo_1", ":", "=", "bar", "(", `"`, "x", "y", `"`, ")"})
+	is.Equal(codeTokens("  "), []string{})
+}
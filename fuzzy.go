@@ -0,0 +1,100 @@
+package textsimilarity
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/blizzy78/textsimilarity/ctph"
+)
+
+// FuzzyDuplicates returns all pairs of files whose context-triggered piecewise hashes (CTPH, in the style of
+// ssdeep) have a match score of at least minSimilarity (0-1), sorted like the result of NearDuplicates. The
+// similarity of two files is their match score (0-100) divided by 100. Only the lines considered for similarities
+// are hashed, according to opts, as in Similarities. Unlike NearDuplicates, which compares sets of identical
+// lines, FuzzyDuplicates also finds files with many slightly modified lines, but its scores are coarser. It is
+// meant to triage files that look related before analyzing them in detail. Pairs of reference-only files and
+// excluded pairs of files are not compared. minSimilarity must be greater than 0.
+//
+// File.LineCount is valid for all files after FuzzyDuplicates returns.
+func FuzzyDuplicates(ctx context.Context, files []*File, minSimilarity float64, opts *Options) ([]*NearDuplicate, error) {
+	digests := make([]*ctph.Digest, len(files))
+
+	for fileIdx, file := range files {
+		if err := file.load(nil, opts); err != nil {
+			return nil, err
+		}
+
+		if text := linesText(file, 0, file.lineCount, opts); len(text) != 0 {
+			digest := ctph.Sum(text)
+			digests[fileIdx] = &digest
+		}
+
+		file.lines = nil
+		file.linesByHash = nil
+		file.lineClasses = nil
+	}
+
+	pairMatches := newFilePairMatches(files, opts)
+	dupes := []*NearDuplicate{}
+
+	for fileIdx, file := range files {
+		if contextDone(ctx) {
+			return nil, ctx.Err()
+		}
+
+		if digests[fileIdx] == nil {
+			continue
+		}
+
+		for otherIdx, other := range files[:fileIdx] {
+			if digests[otherIdx] == nil || (file.ReferenceOnly && other.ReferenceOnly) || pairMatches.excluded(otherIdx, fileIdx) {
+				continue
+			}
+
+			similarity := float64(ctph.Compare(*digests[otherIdx], *digests[fileIdx])) / 100
+			if similarity < minSimilarity {
+				continue
+			}
+
+			dupes = append(dupes, &NearDuplicate{
+				File1:      other,
+				File2:      file,
+				Similarity: similarity,
+			})
+		}
+	}
+
+	sortNearDuplicates(dupes)
+
+	return dupes, nil
+}
+
+// setFuzzyHash sets o.FuzzyHash from its lines that are considered for similarities, according to opts. The lines
+// of o's file must be loaded.
+func (o *FileOccurrence) setFuzzyHash(opts *Options) {
+	o.FuzzyHash = ctph.Sum(linesText(o.File, o.Start, o.End, opts)).String()
+}
+
+// linesText returns the text of the lines of f from start to end (exclusive) that are considered for similarities,
+// according to opts and f's scopes, each terminated by "\n". Leading and trailing whitespace is removed if
+// IgnoreWhitespaceFlag is set. The lines of f must be loaded.
+func linesText(f *File, start int, end int, opts *Options) []byte {
+	text := bytes.Buffer{}
+
+	for lineIdx := start; lineIdx < end; lineIdx++ {
+		line := f.lines[lineIdx]
+		if !acceptLine(line, opts) || !f.inScope(lineIdx) {
+			continue
+		}
+
+		if opts.flagSet(IgnoreWhitespaceFlag) {
+			text.WriteString(line.textTrimmed)
+		} else {
+			text.WriteString(line.text)
+		}
+
+		text.WriteByte('\n')
+	}
+
+	return text.Bytes()
+}
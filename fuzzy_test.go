@@ -0,0 +1,52 @@
+package textsimilarity
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/blizzy78/textsimilarity/ctph"
+	"github.com/matryer/is"
+)
+
+func TestFuzzyDuplicates(t *testing.T) {
+	is := is.New(t)
+
+	lines := make([]string, 200)
+	for idx := range lines {
+		lines[idx] = fmt.Sprintf("line %d with some text that differs: %d", idx, idx*7919%1000)
+	}
+
+	text := strings.Join(lines, "\n") + "\n"
+
+	file1 := newFile("1.txt", text)
+	file2 := newFile("2.txt", strings.Replace(text, "line 100 ", "line one hundred ", 1))
+	file3 := newFile("3.txt", strings.ToUpper(text))
+	file4 := newFile("4.txt", "")
+
+	dupes, err := FuzzyDuplicates(context.Background(), []*File{file1, file2, file3, file4}, 0.5, &Options{})
+	is.NoErr(err)
+
+	is.Equal(len(dupes), 1)
+	is.Equal(dupes[0].File1, file1)
+	is.Equal(dupes[0].File2, file2)
+	is.True(dupes[0].Similarity >= 0.8)
+	is.True(dupes[0].Similarity < 1)
+
+	is.Equal(file3.LineCount(), 200)
+}
+
+func TestSimilarities_FuzzyHash(t *testing.T) {
+	is := is.New(t)
+
+	files := []*File{
+		newFile("1.txt", "foo bar baz\nqux quux corge\ngrault garply waldo\n"),
+		newFile("2.txt", "foo bar baz\nqux quux corge\ngrault garply waldo\n"),
+	}
+
+	sims := similaritiesWithOptions(t, files, &Options{MinSimilarLines: 3, ComputeFuzzyHashes: true})
+	is.Equal(len(sims), 1)
+	is.Equal(sims[0].Occurrences[0].FuzzyHash, ctph.Sum([]byte("foo bar baz\nqux quux corge\ngrault garply waldo\n")).String())
+	is.Equal(sims[0].Occurrences[1].FuzzyHash, sims[0].Occurrences[0].FuzzyHash)
+}
@@ -8,4 +8,5 @@ require (
 	github.com/agext/levenshtein v1.2.3
 	github.com/dropbox/godropbox v0.0.0-20230623171840-436d2007a9fd
 	github.com/matryer/is v1.4.1
+	github.com/mattn/go-sqlite3 v1.14.33
 )
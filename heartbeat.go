@@ -0,0 +1,47 @@
+package textsimilarity
+
+import (
+	"context"
+	"time"
+)
+
+// startHeartbeat sends the progress returned by progress to progressCh every interval, marked as a heartbeat,
+// until ctx is done or the returned function is called. The returned function waits until no more heartbeats
+// are sent.
+func startHeartbeat(ctx context.Context, interval time.Duration, progressCh chan<- Progress, progress func() Progress) func() {
+	stopCh := make(chan struct{})
+	doneCh := make(chan struct{})
+
+	go func() {
+		defer close(doneCh)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-stopCh:
+				return
+			case <-ctx.Done():
+				return
+			}
+
+			prog := progress()
+			prog.Heartbeat = true
+
+			select {
+			case progressCh <- prog:
+			case <-stopCh:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(stopCh)
+		<-doneCh
+	}
+}
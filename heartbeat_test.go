@@ -0,0 +1,43 @@
+package textsimilarity
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestStartHeartbeat(t *testing.T) {
+	is := is.New(t)
+
+	progressCh := make(chan Progress)
+
+	stop := startHeartbeat(context.Background(), time.Millisecond, progressCh, func() Progress {
+		return Progress{Done: 50}
+	})
+
+	for i := 0; i < 3; i++ {
+		prog := <-progressCh
+		is.True(prog.Heartbeat)
+		is.Equal(prog.Done, 50.0)
+		is.Equal(prog.File, nil)
+	}
+
+	// must not block even though nobody receives heartbeats anymore
+	time.Sleep(5 * time.Millisecond)
+	stop()
+}
+
+func TestStartHeartbeat_ContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	progressCh := make(chan Progress)
+
+	stop := startHeartbeat(ctx, time.Millisecond, progressCh, func() Progress {
+		return Progress{}
+	})
+
+	cancel()
+	stop()
+}
@@ -0,0 +1,56 @@
+package textsimilarity
+
+// A lineTable interns lines, so that identical lines of text across all files share a single fileLine.
+// Lines that are shared this way are stored only once, and can be compared for equality by pointer.
+// It also groups lines whose compared texts are equal, such as lines that only differ in indentation if
+// whitespace is ignored, into equality classes, so that they only need to be compared with other lines once.
+type lineTable struct {
+	// lines maps the original texts of lines to lines.
+	lines map[string]*fileLine
+
+	// classes maps the compared texts of lines to the first line of their equality classes.
+	classes map[string]*fileLine
+}
+
+// newLineTable returns a new, empty lineTable.
+func newLineTable() *lineTable {
+	return &lineTable{
+		lines:   map[string]*fileLine{},
+		classes: map[string]*fileLine{},
+	}
+}
+
+// line returns the fileLine for text, according to opts. If t already contains a line with the same text,
+// that line is returned. If t is nil, a new line is returned every time, which is the only line of its
+// equality class.
+func (t *lineTable) line(text string, opts *Options) *fileLine {
+	if t == nil {
+		return textToFileLine(text, opts)
+	}
+
+	if line, ok := t.lines[text]; ok {
+		return line
+	}
+
+	line := textToFileLine(text, opts)
+	t.lines[line.originalText] = line
+
+	comparedText := line.comparedText(opts)
+
+	if class, ok := t.classes[comparedText]; ok {
+		line.class = class
+	} else {
+		t.classes[comparedText] = line
+	}
+
+	return line
+}
+
+// comparedText returns the text of l that is compared with other lines, according to opts.
+func (l *fileLine) comparedText(opts *Options) string {
+	if opts.flagSet(IgnoreWhitespaceFlag) {
+		return l.textTrimmed
+	}
+
+	return l.text
+}
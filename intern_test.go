@@ -0,0 +1,40 @@
+package textsimilarity
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestLineTable(t *testing.T) {
+	is := is.New(t)
+
+	opts := Options{}
+	lines := newLineTable()
+
+	line1 := lines.line("aaaaaaaaaa", &opts)
+	line2 := lines.line("bbbbbbbbbb", &opts)
+
+	is.True(line1 != line2)
+	is.Equal(lines.line("aaaaaaaaaa", &opts), line1)
+	is.Equal(len(lines.lines), 2)
+
+	is.True((*lineTable)(nil).line("aaaaaaaaaa", &opts) != line1)
+}
+
+func TestFile_Load_Interned(t *testing.T) {
+	is := is.New(t)
+
+	file1 := newFile("1.txt", "aaaaaaaaaa\nbbbbbbbbbb\n")
+	file2 := newFile("2.txt", "bbbbbbbbbb\ncccccccccc\naaaaaaaaaa\n")
+
+	opts := Options{}
+	lines := newLineTable()
+
+	is.NoErr(file1.load(lines, &opts))
+	is.NoErr(file2.load(lines, &opts))
+
+	is.Equal(file1.lines[0], file2.lines[2])
+	is.Equal(file1.lines[1], file2.lines[0])
+	is.Equal(len(lines.lines), 3)
+}
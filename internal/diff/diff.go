@@ -0,0 +1,93 @@
+package diff
+
+const (
+	// EqualOp is used for lines that are present in both sequences.
+	EqualOp = Op(iota)
+
+	// DeleteOp is used for lines that are only present in the first sequence.
+	DeleteOp
+
+	// InsertOp is used for lines that are only present in the second sequence.
+	InsertOp
+)
+
+// An Op is the kind of an Edit.
+type Op int
+
+// An Edit is a single line of a difference between two sequences of lines.
+type Edit struct {
+	// Op is the kind of edit.
+	Op Op
+
+	// Text is the line of text.
+	Text string
+}
+
+// Lines returns the edits needed to transform lines a into lines b, based on their longest common subsequence.
+// Deletions are always returned before insertions at the same position.
+func Lines(a []string, b []string) []Edit {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+				continue
+			}
+
+			lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+		}
+	}
+
+	edits := make([]Edit, 0, max(len(a), len(b)))
+
+	idxA := 0
+	idxB := 0
+
+	for idxA < len(a) && idxB < len(b) {
+		switch {
+		case a[idxA] == b[idxB]:
+			edits = append(edits, Edit{Op: EqualOp, Text: a[idxA]})
+			idxA++
+			idxB++
+
+		case lcs[idxA+1][idxB] >= lcs[idxA][idxB+1]:
+			edits = append(edits, Edit{Op: DeleteOp, Text: a[idxA]})
+			idxA++
+
+		default:
+			edits = append(edits, Edit{Op: InsertOp, Text: b[idxB]})
+			idxB++
+		}
+	}
+
+	for ; idxA < len(a); idxA++ {
+		edits = append(edits, Edit{Op: DeleteOp, Text: a[idxA]})
+	}
+
+	for ; idxB < len(b); idxB++ {
+		edits = append(edits, Edit{Op: InsertOp, Text: b[idxB]})
+	}
+
+	return edits
+}
+
+// CommonAffixes returns the lengths (in runes) of the common prefix and the common suffix of a and b.
+// The prefix and suffix do not overlap.
+func CommonAffixes(a []rune, b []rune) (int, int) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	return prefix, suffix
+}
@@ -0,0 +1,56 @@
+package diff
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		givenA    []string
+		givenB    []string
+		wantEdits []Edit
+	}{
+		{
+			givenA:    []string{"a", "b", "c"},
+			givenB:    []string{"a", "b", "c"},
+			wantEdits: []Edit{{EqualOp, "a"}, {EqualOp, "b"}, {EqualOp, "c"}},
+		},
+		{
+			givenA:    []string{"a", "b", "c"},
+			givenB:    []string{"a", "x", "c"},
+			wantEdits: []Edit{{EqualOp, "a"}, {DeleteOp, "b"}, {InsertOp, "x"}, {EqualOp, "c"}},
+		},
+		{
+			givenA:    []string{"a", "c"},
+			givenB:    []string{"a", "b", "c", "d"},
+			wantEdits: []Edit{{EqualOp, "a"}, {InsertOp, "b"}, {EqualOp, "c"}, {InsertOp, "d"}},
+		},
+		{
+			givenA:    []string{"a", "b"},
+			givenB:    []string{},
+			wantEdits: []Edit{{DeleteOp, "a"}, {DeleteOp, "b"}},
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("[%d] a=%v, b=%v", i, test.givenA, test.givenB), func(t *testing.T) {
+			is := is.New(t)
+			is.Equal(Lines(test.givenA, test.givenB), test.wantEdits)
+		})
+	}
+}
+
+func TestCommonAffixes(t *testing.T) {
+	is := is.New(t)
+
+	prefix, suffix := CommonAffixes([]rune("foo(3)"), []rune("foo(5)"))
+	is.Equal(prefix, 4)
+	is.Equal(suffix, 1)
+
+	prefix, suffix = CommonAffixes([]rune("aaa"), []rune("aa"))
+	is.Equal(prefix, 2)
+	is.Equal(suffix, 0)
+}
@@ -0,0 +1,2 @@
+// Package diff computes differences between sequences of text lines.
+package diff
@@ -0,0 +1,2 @@
+// Package generated implements heuristics to detect generated, minified, and vendored files.
+package generated
@@ -0,0 +1,97 @@
+package generated
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	// headSize is the number of bytes at the start of a file that are inspected.
+	headSize = 8 * 1024
+
+	// headerLines is the number of lines at the start of a file that are searched for generated code markers.
+	headerLines = 20
+
+	// minifiedLineLength is the minimum length of a line (in bytes) for its file to be considered minified.
+	minifiedLineLength = 1000
+)
+
+var (
+	// vendoredDirs are the names of directories that contain vendored code.
+	vendoredDirs = map[string]struct{}{
+		"vendor":           {},
+		"node_modules":     {},
+		"bower_components": {},
+	}
+
+	// minifiedSuffixes are file name suffixes of minified files.
+	minifiedSuffixes = []string{".min.js", ".min.css", ".min.map"}
+
+	// generatedRegex matches lines that mark a file as generated.
+	generatedRegex = regexp.MustCompile(`(?i)(code generated .*do not edit|@generated\b|auto-?generated .*do not (edit|modify))`)
+)
+
+// Path returns whether p is a vendored or minified file, judging by its path alone.
+func Path(p string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(filepath.Clean(p)), "/") {
+		if _, ok := vendoredDirs[part]; ok {
+			return true
+		}
+	}
+
+	name := strings.ToLower(filepath.Base(p))
+
+	for _, suffix := range minifiedSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Content returns whether head, the start of a file's contents, marks the file as generated or minified.
+func Content(head []byte) bool {
+	lines := bytes.SplitN(head, []byte{'\n'}, headerLines+1)
+
+	for idx, line := range lines {
+		if len(line) >= minifiedLineLength {
+			return true
+		}
+
+		if idx < headerLines && generatedRegex.Match(line) {
+			return true
+		}
+	}
+
+	// a single line filling the whole head is likely minified
+	return len(lines) == 1 && len(head) >= headSize
+}
+
+// File returns whether the file at p is generated, minified, or vendored.
+func File(p string) (bool, error) {
+	if Path(p) {
+		return true, nil
+	}
+
+	file, err := os.Open(p)
+	if err != nil {
+		return false, fmt.Errorf("open %s: %w", p, err)
+	}
+	defer file.Close() //nolint:errcheck // file is being read
+
+	head := make([]byte, headSize)
+
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return false, fmt.Errorf("read %s: %w", p, err)
+	}
+
+	return Content(head[:n]), nil
+}
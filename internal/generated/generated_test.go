@@ -0,0 +1,75 @@
+package generated
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestPath(t *testing.T) {
+	tests := []struct {
+		givenPath string
+		want      bool
+	}{
+		{givenPath: "vendor/github.com/foo/bar.go", want: true},
+		{givenPath: "a/node_modules/foo/index.js", want: true},
+		{givenPath: "web/app.min.js", want: true},
+		{givenPath: "web/app.js", want: false},
+		{givenPath: "vendors/foo.go", want: false},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.givenPath, func(t *testing.T) {
+			is := is.New(t)
+			is.Equal(Path(test.givenPath), test.want)
+		})
+	}
+}
+
+func TestContent(t *testing.T) {
+	tests := []struct {
+		name      string
+		givenHead string
+		want      bool
+	}{
+		{name: "go", givenHead: "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage foo\n", want: true},
+		{name: "generated tag", givenHead: "/**\n * @generated\n */\n", want: true},
+		{name: "minified", givenHead: strings.Repeat("var a=1;", 200), want: true},
+		{name: "regular", givenHead: "package foo\n\nfunc foo() {}\n", want: false},
+		{name: "late marker", givenHead: strings.Repeat("x\n", headerLines) + "// Code generated by foo. DO NOT EDIT.\n", want: false},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			is := is.New(t)
+			is.Equal(Content([]byte(test.givenHead)), test.want)
+		})
+	}
+}
+
+func TestFile(t *testing.T) {
+	is := is.New(t)
+
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "foo.go")
+	is.NoErr(os.WriteFile(path, []byte("// Code generated by foo. DO NOT EDIT.\n"), 0o600))
+
+	generated, err := File(path)
+	is.NoErr(err)
+	is.True(generated)
+
+	path = filepath.Join(dir, "bar.go")
+	is.NoErr(os.WriteFile(path, []byte("package bar\n"), 0o600))
+
+	generated, err = File(path)
+	is.NoErr(err)
+	is.True(!generated)
+}
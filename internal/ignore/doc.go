@@ -0,0 +1,2 @@
+// Package ignore implements matching of paths against .gitignore-style pattern files.
+package ignore
@@ -0,0 +1,271 @@
+package ignore
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+
+	tsio "github.com/blizzy78/textsimilarity/internal/io"
+)
+
+// A Matcher matches paths against the patterns of a single ignore file.
+type Matcher struct {
+	// dir is the directory the ignore file is located in, using forward slashes. Patterns are relative to dir.
+	dir string
+
+	// patterns are the patterns of the ignore file, in order.
+	patterns []*pattern
+}
+
+// A pattern is a single pattern line of an ignore file.
+type pattern struct {
+	// re is the regular expression that matches paths relative to the ignore file's directory.
+	re *regexp.Regexp
+
+	// negate indicates whether the pattern re-includes paths that have been excluded before.
+	negate bool
+
+	// dirOnly indicates whether the pattern only matches directories.
+	dirOnly bool
+}
+
+// A Stack is a set of Matchers of nested directories, used while walking a directory tree.
+type Stack struct {
+	matchers []*Matcher
+}
+
+// Parse parses the ignore file patterns read from r. dir is the directory the ignore file is located in.
+func Parse(dir string, r io.Reader) (*Matcher, error) {
+	matcher := Matcher{
+		dir: cleanDir(dir),
+	}
+
+	reader := bufio.NewReader(r)
+	buf := bytes.Buffer{}
+
+	for {
+		line, err := tsio.ReadLine(reader, &buf)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return nil, fmt.Errorf("read line: %w", err)
+		}
+
+		pat, err := parsePattern(line)
+		if err != nil {
+			return nil, err
+		}
+
+		if pat == nil {
+			continue
+		}
+
+		matcher.patterns = append(matcher.patterns, pat)
+	}
+
+	return &matcher, nil
+}
+
+// parsePattern parses a single line of an ignore file. It returns nil if the line does not contain a pattern.
+func parsePattern(line string) (*pattern, error) {
+	line = trimTrailingSpace(line)
+
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil, nil //nolint:nilnil // no pattern in line
+	}
+
+	pat := pattern{}
+
+	switch {
+	case strings.HasPrefix(line, "!"):
+		pat.negate = true
+		line = line[1:]
+
+	case strings.HasPrefix(line, `\!`), strings.HasPrefix(line, `\#`):
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		pat.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+
+	if line == "" {
+		return nil, nil //nolint:nilnil // no pattern in line
+	}
+
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expr := globToRegexp(line)
+	if !anchored && !strings.HasPrefix(expr, "(?:.*/)?") {
+		expr = "(?:.*/)?" + expr
+	}
+
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return nil, fmt.Errorf("compile pattern %q: %w", line, err)
+	}
+
+	pat.re = re
+
+	return &pat, nil
+}
+
+// globToRegexp converts the gitignore glob pattern glob to a regular expression.
+func globToRegexp(glob string) string { //nolint:gocognit,cyclop // it's a parser
+	expr := strings.Builder{}
+
+	for idx := 0; idx < len(glob); idx++ {
+		char := glob[idx]
+
+		switch {
+		case strings.HasPrefix(glob[idx:], "**/") && (idx == 0 || glob[idx-1] == '/'):
+			expr.WriteString("(?:.*/)?")
+			idx += 2
+
+		case strings.HasPrefix(glob[idx:], "**") && idx+2 == len(glob) && (idx == 0 || glob[idx-1] == '/'):
+			expr.WriteString(".*")
+			idx++
+
+		case char == '*':
+			expr.WriteString("[^/]*")
+
+		case char == '?':
+			expr.WriteString("[^/]")
+
+		case char == '\\' && idx+1 < len(glob):
+			idx++
+			expr.WriteString(regexp.QuoteMeta(glob[idx : idx+1]))
+
+		case char == '[':
+			end := strings.IndexByte(glob[idx+1:], ']')
+			if end < 0 {
+				expr.WriteString(`\[`)
+				continue
+			}
+
+			class := glob[idx+1 : idx+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+
+			expr.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			idx += end + 1
+
+		default:
+			expr.WriteString(regexp.QuoteMeta(glob[idx : idx+1]))
+		}
+	}
+
+	return expr.String()
+}
+
+// trimTrailingSpace removes trailing spaces from line, unless they are escaped with a backslash.
+func trimTrailingSpace(line string) string {
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+
+	return line
+}
+
+// Match returns whether p should be ignored according to m. p must use forward slashes and be located
+// below m's directory. The second return value indicates whether any pattern matched p at all.
+func (m *Matcher) Match(p string, isDir bool) (bool, bool) {
+	rel, ok := m.rel(p)
+	if !ok {
+		return false, false
+	}
+
+	ignored := false
+	matched := false
+
+	for _, pat := range m.patterns {
+		if pat.dirOnly && !isDir {
+			continue
+		}
+
+		if !pat.re.MatchString(rel) {
+			continue
+		}
+
+		ignored = !pat.negate
+		matched = true
+	}
+
+	return ignored, matched
+}
+
+// rel returns p relative to m's directory, and whether p is located below m's directory at all.
+func (m *Matcher) rel(p string) (string, bool) {
+	p = path.Clean(p)
+
+	if m.dir == "." {
+		return p, !strings.HasPrefix(p, "../")
+	}
+
+	if !strings.HasPrefix(p, m.dir+"/") {
+		return "", false
+	}
+
+	return p[len(m.dir)+1:], true
+}
+
+// Push adds m to s. m must be the matcher of a directory nested in the directories of all matchers in s.
+func (s *Stack) Push(m *Matcher) {
+	s.matchers = append(s.matchers, m)
+}
+
+// PopDir removes all matchers from s that are not responsible for directory dir.
+func (s *Stack) PopDir(dir string) {
+	dir = cleanDir(dir)
+
+	for len(s.matchers) != 0 {
+		top := s.matchers[len(s.matchers)-1]
+		if top.dir == "." || top.dir == dir || strings.HasPrefix(dir, top.dir+"/") {
+			break
+		}
+
+		s.matchers = s.matchers[:len(s.matchers)-1]
+	}
+}
+
+// Ignored returns whether p should be ignored according to the matchers in s.
+// Matchers of more deeply nested directories take precedence.
+func (s *Stack) Ignored(p string, isDir bool) bool {
+	p = path.Clean(p)
+
+	for idx := len(s.matchers) - 1; idx >= 0; idx-- {
+		if ignored, matched := s.matchers[idx].Match(p, isDir); matched {
+			return ignored
+		}
+	}
+
+	return false
+}
+
+// cleanDir returns dir cleaned and using forward slashes.
+func cleanDir(dir string) string {
+	return path.Clean(strings.ReplaceAll(dir, `\`, "/"))
+}
+
+// Glob returns a regular expression that matches slash-separated paths against glob, using the same syntax as
+// ignore files. The expression matches a path if glob matches the whole path or any of its parent directories.
+func Glob(glob string) (*regexp.Regexp, error) {
+	glob = strings.TrimPrefix(strings.TrimSuffix(glob, "/"), "./")
+
+	expr, err := regexp.Compile("^" + globToRegexp(glob) + "(?:/.*)?$")
+	if err != nil {
+		return nil, fmt.Errorf("compile glob %s: %w", glob, err)
+	}
+
+	return expr, nil
+}
@@ -0,0 +1,99 @@
+package ignore
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestMatcher_Match(t *testing.T) {
+	tests := []struct {
+		givenPatterns string
+		givenPath     string
+		givenDir      bool
+		wantIgnored   bool
+	}{
+		{givenPatterns: "node_modules/", givenPath: "node_modules", givenDir: true, wantIgnored: true},
+		{givenPatterns: "node_modules/", givenPath: "a/b/node_modules", givenDir: true, wantIgnored: true},
+		{givenPatterns: "node_modules/", givenPath: "node_modules", givenDir: false, wantIgnored: false},
+		{givenPatterns: "*.o", givenPath: "a/b/c.o", wantIgnored: true},
+		{givenPatterns: "*.o", givenPath: "a/b/c.go", wantIgnored: false},
+		{givenPatterns: "/build", givenPath: "build", givenDir: true, wantIgnored: true},
+		{givenPatterns: "/build", givenPath: "a/build", givenDir: true, wantIgnored: false},
+		{givenPatterns: "doc/*.txt", givenPath: "doc/a.txt", wantIgnored: true},
+		{givenPatterns: "doc/*.txt", givenPath: "doc/a/b.txt", wantIgnored: false},
+		{givenPatterns: "**/foo", givenPath: "a/b/foo", wantIgnored: true},
+		{givenPatterns: "a/**/b", givenPath: "a/x/y/b", wantIgnored: true},
+		{givenPatterns: "a/**/b", givenPath: "a/b", wantIgnored: true},
+		{givenPatterns: "a/**", givenPath: "a/x/y", wantIgnored: true},
+		{givenPatterns: "*.log\n!keep.log", givenPath: "keep.log", wantIgnored: false},
+		{givenPatterns: "*.log\n!keep.log", givenPath: "other.log", wantIgnored: true},
+		{givenPatterns: "# comment\n\n", givenPath: "# comment", wantIgnored: false},
+		{givenPatterns: `\#file`, givenPath: "#file", wantIgnored: true},
+		{givenPatterns: "file[0-9].txt", givenPath: "file5.txt", wantIgnored: true},
+		{givenPatterns: "file[!0-9].txt", givenPath: "file5.txt", wantIgnored: false},
+		{givenPatterns: "file?.txt  ", givenPath: "fileX.txt", wantIgnored: true},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("[%d] patterns=%q, path=%s", i, test.givenPatterns, test.givenPath), func(t *testing.T) {
+			is := is.New(t)
+
+			matcher, err := Parse(".", strings.NewReader(test.givenPatterns))
+			is.NoErr(err)
+
+			ignored, _ := matcher.Match(test.givenPath, test.givenDir)
+			is.Equal(ignored, test.wantIgnored)
+		})
+	}
+}
+
+func TestStack_Ignored(t *testing.T) {
+	is := is.New(t)
+
+	rootMatcher, _ := Parse("root", strings.NewReader("*.log\nvendor/\n"))
+	subMatcher, _ := Parse("root/sub", strings.NewReader("!keep.log\n"))
+
+	stack := Stack{}
+	stack.Push(rootMatcher)
+	stack.Push(subMatcher)
+
+	is.True(stack.Ignored("root/a.log", false))
+	is.True(!stack.Ignored("root/sub/keep.log", false))
+	is.True(stack.Ignored("root/sub/other.log", false))
+	is.True(stack.Ignored("root/sub/vendor", true))
+
+	stack.PopDir("root/other")
+
+	is.True(stack.Ignored("root/other/keep.log", false))
+}
+
+func TestGlob(t *testing.T) {
+	tests := []struct {
+		givenGlob string
+		givenPath string
+		wantMatch bool
+	}{
+		{givenGlob: "pkg/foo", givenPath: "pkg/foo/a.go", wantMatch: true},
+		{givenGlob: "pkg/foo/", givenPath: "pkg/foo/a.go", wantMatch: true},
+		{givenGlob: "pkg/foo", givenPath: "pkg/foobar/a.go", wantMatch: false},
+		{givenGlob: "pkg/*/a.go", givenPath: "pkg/foo/a.go", wantMatch: true},
+		{givenGlob: "**/*_test.go", givenPath: "pkg/foo/a_test.go", wantMatch: true},
+		{givenGlob: "*.go", givenPath: "pkg/a.go", wantMatch: false},
+		{givenGlob: "./pkg", givenPath: "pkg/a.go", wantMatch: true},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(fmt.Sprintf("%s_%s", test.givenGlob, test.givenPath), func(t *testing.T) {
+			is := is.New(t)
+
+			expr, err := Glob(test.givenGlob)
+			is.NoErr(err)
+			is.Equal(expr.MatchString(test.givenPath), test.wantMatch)
+		})
+	}
+}
@@ -3,16 +3,23 @@ package io
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
 )
 
 // ReadLine reads a single line of text from r and returns it, using buf to do so.
-// buf will be Reset before use, and may be reused across multiple calls to ReadLine.
+// buf will be Reset before use, and may be reused across multiple calls to ReadLine. A last line that is not
+// terminated by a newline is returned as well, io.EOF is only returned when there is no more text.
 func ReadLine(r *bufio.Reader, buf *bytes.Buffer) (string, error) {
 	buf.Reset()
 
 	for {
 		data, prefix, err := r.ReadLine()
+		if errors.Is(err, io.EOF) && buf.Len() > 0 {
+			break
+		}
+
 		if err != nil {
 			return "", fmt.Errorf("read line: %w", err)
 		}
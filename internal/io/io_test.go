@@ -3,6 +3,8 @@ package io
 import (
 	"bufio"
 	"bytes"
+	"errors"
+	"io"
 	"strings"
 	"testing"
 
@@ -23,3 +25,23 @@ func TestReadLine(t *testing.T) {
 	line, _ = ReadLine(r, &buf)
 	is.Equal(line, givenLine2)
 }
+
+func TestReadLine_Unterminated(t *testing.T) {
+	is := is.New(t)
+
+	for _, givenLine := range []string{"test", strings.Repeat("x", 4096), strings.Repeat("verylongline", 1024)} {
+		r := bufio.NewReader(strings.NewReader("first\n" + givenLine))
+		buf := bytes.Buffer{}
+
+		line, err := ReadLine(r, &buf)
+		is.NoErr(err)
+		is.Equal(line, "first")
+
+		line, err = ReadLine(r, &buf)
+		is.NoErr(err)
+		is.Equal(line, givenLine)
+
+		_, err = ReadLine(r, &buf)
+		is.True(errors.Is(err, io.EOF))
+	}
+}
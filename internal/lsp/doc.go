@@ -0,0 +1,3 @@
+// Package lsp implements a minimal language server, using the Language Server Protocol over JSON-RPC, that keeps
+// track of open documents and publishes diagnostics for them.
+package lsp
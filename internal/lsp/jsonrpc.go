@@ -0,0 +1,138 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"sync"
+)
+
+const (
+	// methodNotFoundCode is the JSON-RPC error code used for requests of unknown methods.
+	methodNotFoundCode = -32601
+
+	// invalidParamsCode is the JSON-RPC error code used for requests with invalid parameters.
+	invalidParamsCode = -32602
+)
+
+// errMissingContentLength is returned when a message does not have a Content-Length header.
+var errMissingContentLength = errors.New("missing Content-Length header")
+
+// A message is a JSON-RPC request, notification, or response. Notifications do not have an ID.
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  any              `json:"result,omitempty"`
+	Error   *responseError   `json:"error,omitempty"`
+}
+
+// A responseError is the error of a JSON-RPC response.
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// A conn reads and writes messages framed by Content-Length headers. Messages may be written concurrently.
+type conn struct {
+	reader *textproto.Reader
+
+	// writeLock guards writer.
+	writeLock sync.Mutex
+	writer    *bufio.Writer
+}
+
+// newConn returns a new conn reading from r and writing to w.
+func newConn(r io.Reader, w io.Writer) *conn {
+	return &conn{
+		reader: textproto.NewReader(bufio.NewReader(r)),
+		writer: bufio.NewWriter(w),
+	}
+}
+
+// read reads the next message.
+func (c *conn) read() (*message, error) {
+	header, err := c.reader.ReadMIMEHeader()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, errMissingContentLength
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(c.reader.R, body); err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+
+	msg := message{}
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, fmt.Errorf("decode message: %w", err)
+	}
+
+	return &msg, nil
+}
+
+// write writes msg.
+func (c *conn) write(msg *message) error {
+	msg.JSONRPC = "2.0"
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("encode message: %w", err)
+	}
+
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	fmt.Fprintf(c.writer, "Content-Length: %d\r\n\r\n", len(body))
+	_, _ = c.writer.Write(body)
+
+	if err := c.writer.Flush(); err != nil {
+		return fmt.Errorf("write message: %w", err)
+	}
+
+	return nil
+}
+
+// notify writes a notification of method with params.
+func (c *conn) notify(method string, params any) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("encode params: %w", err)
+	}
+
+	return c.write(&message{
+		Method: method,
+		Params: data,
+	})
+}
+
+// reply writes a response to the request with id. If respErr is not nil, an error response is written instead.
+func (c *conn) reply(id *json.RawMessage, result any, respErr *responseError) error {
+	msg := message{
+		ID:    id,
+		Error: respErr,
+	}
+
+	if respErr == nil {
+		// a successful response must have a result, even if it is null
+		msg.Result = json.RawMessage("null")
+		if result != nil {
+			msg.Result = result
+		}
+	}
+
+	return c.write(&msg)
+}
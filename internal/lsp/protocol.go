@@ -0,0 +1,172 @@
+package lsp
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// SeverityError is the severity of diagnostics that are errors.
+	SeverityError = Severity(iota + 1)
+
+	// SeverityWarning is the severity of diagnostics that are warnings.
+	SeverityWarning
+
+	// SeverityInformation is the severity of diagnostics that are informational.
+	SeverityInformation
+
+	// SeverityHint is the severity of diagnostics that are hints.
+	SeverityHint
+)
+
+// incrementalSync is the text document sync kind of servers that receive changes of documents as edits.
+const incrementalSync = 2
+
+// A DocumentURI is the URI of a document, such as file:///path/to/file.go.
+type DocumentURI string
+
+// A Severity is the severity of a diagnostic.
+type Severity int
+
+// A Position is a position in a document. Line and Character are zero-based, Character is measured in UTF-16
+// code units.
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// A Range is a range in a document. End is exclusive.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// A Location is a range in a document.
+type Location struct {
+	URI   DocumentURI `json:"uri"`
+	Range Range       `json:"range"`
+}
+
+// A Diagnostic is a problem in a range of a document.
+type Diagnostic struct {
+	Range              Range                `json:"range"`
+	Severity           Severity             `json:"severity,omitempty"`
+	Source             string               `json:"source,omitempty"`
+	Message            string               `json:"message"`
+	RelatedInformation []RelatedInformation `json:"relatedInformation,omitempty"`
+}
+
+// RelatedInformation is a location related to a diagnostic, such as another occurrence of a problem.
+type RelatedInformation struct {
+	Location Location `json:"location"`
+	Message  string   `json:"message"`
+}
+
+// FileURI returns the URI of the file at path.
+func FileURI(path string) DocumentURI {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		absPath = path
+	}
+
+	absPath = filepath.ToSlash(absPath)
+	if !strings.HasPrefix(absPath, "/") {
+		// Windows drive letter
+		absPath = "/" + absPath
+	}
+
+	return DocumentURI((&url.URL{Scheme: "file", Path: absPath}).String())
+}
+
+// Path returns the absolute path of the file at u, and true. If u is not a file URI, it returns false.
+func (u DocumentURI) Path() (string, bool) {
+	parsed, err := url.Parse(string(u))
+	if err != nil || parsed.Scheme != "file" {
+		return "", false
+	}
+
+	path := parsed.Path
+
+	// Windows drive letter
+	if len(path) >= 3 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+
+	return filepath.FromSlash(path), true
+}
+
+// An initializeResult is the result of the initialize request.
+type initializeResult struct {
+	Capabilities serverCapabilities `json:"capabilities"`
+	ServerInfo   serverInfo         `json:"serverInfo"`
+}
+
+// serverCapabilities are the capabilities of the server.
+type serverCapabilities struct {
+	TextDocumentSync textDocumentSyncOptions `json:"textDocumentSync"`
+}
+
+// textDocumentSyncOptions specify how documents are synchronized with the server.
+type textDocumentSyncOptions struct {
+	OpenClose bool `json:"openClose"`
+	Change    int  `json:"change"`
+}
+
+// serverInfo is information about the server.
+type serverInfo struct {
+	Name string `json:"name"`
+}
+
+// A textDocumentItem is a document opened by the client.
+type textDocumentItem struct {
+	URI     DocumentURI `json:"uri"`
+	Version int         `json:"version"`
+	Text    string      `json:"text"`
+}
+
+// A textDocumentIdentifier identifies a document.
+type textDocumentIdentifier struct {
+	URI DocumentURI `json:"uri"`
+}
+
+// A versionedTextDocumentIdentifier identifies a version of a document.
+type versionedTextDocumentIdentifier struct {
+	URI     DocumentURI `json:"uri"`
+	Version int         `json:"version"`
+}
+
+// didOpenParams are the parameters of the textDocument/didOpen notification.
+type didOpenParams struct {
+	TextDocument textDocumentItem `json:"textDocument"`
+}
+
+// didChangeParams are the parameters of the textDocument/didChange notification.
+type didChangeParams struct {
+	TextDocument   versionedTextDocumentIdentifier `json:"textDocument"`
+	ContentChanges []contentChange                 `json:"contentChanges"`
+}
+
+// A contentChange is a change of a document. If Range is nil, Text is the new text of the whole document.
+type contentChange struct {
+	Range *Range `json:"range,omitempty"`
+	Text  string `json:"text"`
+}
+
+// didCloseParams are the parameters of the textDocument/didClose notification.
+type didCloseParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+}
+
+// publishDiagnosticsParams are the parameters of the textDocument/publishDiagnostics notification.
+type publishDiagnosticsParams struct {
+	URI         DocumentURI  `json:"uri"`
+	Version     *int         `json:"version,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// logMessageParams are the parameters of the window/logMessage notification.
+type logMessageParams struct {
+	Type    int    `json:"type"`
+	Message string `json:"message"`
+}
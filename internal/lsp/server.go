@@ -0,0 +1,386 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// logMessageError is the type of window/logMessage notifications that report errors.
+	logMessageError = 1
+
+	// surrogateSelf is the smallest rune that is encoded as a surrogate pair in UTF-16.
+	surrogateSelf = 0x10000
+)
+
+// A Server is a language server that keeps track of the documents opened by the client, and publishes diagnostics
+// for them when they have changed.
+type Server struct {
+	// Name is the name of the server reported to the client.
+	Name string
+
+	// Diagnose returns diagnostics for documents, given the texts of all open documents, after the documents with
+	// URIs in changed have been opened, changed, or closed. Diagnostics are published for all URIs in the result,
+	// replacing their previous diagnostics. Diagnostics of closed documents are cleared automatically.
+	// Diagnose is never called concurrently.
+	Diagnose func(ctx context.Context, docs map[DocumentURI]string, changed []DocumentURI) (map[DocumentURI][]Diagnostic, error)
+
+	// Debounce is the time to wait after a change before computing diagnostics, so that diagnostics are not
+	// computed again for every keystroke.
+	Debounce time.Duration
+}
+
+// A document is a document opened by the client.
+type document struct {
+	version int
+	text    string
+}
+
+// A session is the state of a server while serving a single client.
+type session struct {
+	server *Server
+	conn   *conn
+
+	// lock guards docs and pending.
+	lock sync.Mutex
+
+	// docs are the documents currently open, keyed by URI.
+	docs map[DocumentURI]*document
+
+	// pending are the URIs of documents that have been opened, changed, or closed since diagnostics were
+	// last computed.
+	pending map[DocumentURI]struct{}
+
+	// trigger is signaled when documents have been opened, changed, or closed.
+	trigger chan struct{}
+}
+
+// Serve serves a single client, reading messages from r and writing messages to w, until the client requests
+// the server to exit, r is closed, or ctx is canceled.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+
+	sess := session{
+		server:  s,
+		conn:    newConn(r, w),
+		docs:    map[DocumentURI]*document{},
+		pending: map[DocumentURI]struct{}{},
+		trigger: make(chan struct{}, 1),
+	}
+
+	grp := sync.WaitGroup{}
+	grp.Add(1)
+
+	go func() {
+		defer grp.Done()
+		sess.diagnoseLoop(ctx)
+	}()
+
+	defer func() {
+		cancel()
+		grp.Wait()
+	}()
+
+	msgs := make(chan *message)
+	readErr := make(chan error, 1)
+
+	go func() {
+		for {
+			msg, err := sess.conn.read()
+			if err != nil {
+				readErr <- err
+				return
+			}
+
+			select {
+			case msgs <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err() //nolint:wrapcheck // context error
+
+		case err := <-readErr:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err
+
+		case msg := <-msgs:
+			exit, err := sess.handle(msg)
+			if err != nil {
+				return err
+			}
+
+			if exit {
+				return nil
+			}
+		}
+	}
+}
+
+// handle handles msg. It returns true if the server should exit.
+func (s *session) handle(msg *message) (bool, error) { //nolint:cyclop // it's a switch
+	var err error
+
+	switch msg.Method {
+	case "initialize":
+		err = s.conn.reply(msg.ID, &initializeResult{
+			Capabilities: serverCapabilities{
+				TextDocumentSync: textDocumentSyncOptions{
+					OpenClose: true,
+					Change:    incrementalSync,
+				},
+			},
+			ServerInfo: serverInfo{Name: s.server.Name},
+		}, nil)
+
+	case "shutdown":
+		err = s.conn.reply(msg.ID, nil, nil)
+
+	case "exit":
+		return true, nil
+
+	case "textDocument/didOpen":
+		params := didOpenParams{}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return false, s.invalidParams(msg, err)
+		}
+
+		s.update(params.TextDocument.URI, func(*document) *document {
+			return &document{version: params.TextDocument.Version, text: params.TextDocument.Text}
+		})
+
+	case "textDocument/didChange":
+		params := didChangeParams{}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return false, s.invalidParams(msg, err)
+		}
+
+		s.update(params.TextDocument.URI, func(doc *document) *document {
+			if doc == nil {
+				return nil
+			}
+
+			text := doc.text
+			for _, change := range params.ContentChanges {
+				text = applyChange(text, change)
+			}
+
+			return &document{version: params.TextDocument.Version, text: text}
+		})
+
+	case "textDocument/didClose":
+		params := didCloseParams{}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return false, s.invalidParams(msg, err)
+		}
+
+		s.update(params.TextDocument.URI, func(*document) *document {
+			return nil
+		})
+
+	default:
+		// requests must be answered, other notifications are ignored
+		if msg.ID != nil {
+			err = s.conn.reply(msg.ID, nil, &responseError{
+				Code:    methodNotFoundCode,
+				Message: "method not found: " + msg.Method,
+			})
+		}
+	}
+
+	return false, err
+}
+
+// invalidParams replies to msg with an error if it is a request. Invalid notifications are ignored.
+func (s *session) invalidParams(msg *message, err error) error {
+	if msg.ID == nil {
+		return nil
+	}
+
+	return s.conn.reply(msg.ID, nil, &responseError{
+		Code:    invalidParamsCode,
+		Message: err.Error(),
+	})
+}
+
+// update replaces the document with uri by the result of calling fun with the current document, which is nil if
+// the document is not open. If fun returns nil, the document is closed. Diagnostics are computed afterwards.
+func (s *session) update(uri DocumentURI, fun func(*document) *document) {
+	s.lock.Lock()
+
+	doc := fun(s.docs[uri])
+	if doc != nil {
+		s.docs[uri] = doc
+	} else {
+		delete(s.docs, uri)
+	}
+
+	s.pending[uri] = struct{}{}
+
+	s.lock.Unlock()
+
+	select {
+	case s.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// diagnoseLoop computes and publishes diagnostics whenever documents have been opened, changed, or closed,
+// until ctx is canceled.
+func (s *session) diagnoseLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.trigger:
+		}
+
+		if s.server.Debounce > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(s.server.Debounce):
+			}
+		}
+
+		if err := s.diagnose(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			_ = s.conn.notify("window/logMessage", &logMessageParams{
+				Type:    logMessageError,
+				Message: err.Error(),
+			})
+		}
+	}
+}
+
+// diagnose computes and publishes diagnostics for all pending documents.
+func (s *session) diagnose(ctx context.Context) error {
+	s.lock.Lock()
+
+	changed := make([]DocumentURI, 0, len(s.pending))
+	for uri := range s.pending {
+		changed = append(changed, uri)
+	}
+
+	sort.Slice(changed, func(a int, b int) bool {
+		return changed[a] < changed[b]
+	})
+
+	s.pending = map[DocumentURI]struct{}{}
+
+	texts := make(map[DocumentURI]string, len(s.docs))
+	versions := make(map[DocumentURI]int, len(s.docs))
+
+	for uri, doc := range s.docs {
+		texts[uri] = doc.text
+		versions[uri] = doc.version
+	}
+
+	s.lock.Unlock()
+
+	diags, err := s.server.Diagnose(ctx, texts, changed)
+	if err != nil {
+		return err
+	}
+
+	if diags == nil {
+		diags = map[DocumentURI][]Diagnostic{}
+	}
+
+	for _, uri := range changed {
+		if _, ok := texts[uri]; !ok {
+			diags[uri] = nil
+		}
+	}
+
+	uris := make([]DocumentURI, 0, len(diags))
+	for uri := range diags {
+		uris = append(uris, uri)
+	}
+
+	sort.Slice(uris, func(a int, b int) bool {
+		return uris[a] < uris[b]
+	})
+
+	for _, uri := range uris {
+		params := publishDiagnosticsParams{
+			URI:         uri,
+			Diagnostics: diags[uri],
+		}
+
+		if params.Diagnostics == nil {
+			params.Diagnostics = []Diagnostic{}
+		}
+
+		if version, ok := versions[uri]; ok {
+			params.Version = &version
+		}
+
+		if err := s.conn.notify("textDocument/publishDiagnostics", &params); err != nil {
+			return fmt.Errorf("publish diagnostics: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// applyChange returns text with change applied.
+func applyChange(text string, change contentChange) string {
+	if change.Range == nil {
+		return change.Text
+	}
+
+	start := offset(text, change.Range.Start)
+	end := max(offset(text, change.Range.End), start)
+
+	return text[:start] + change.Text + text[end:]
+}
+
+// offset returns the byte offset of pos in text. Positions beyond the end of a line or of text are clamped.
+func offset(text string, pos Position) int {
+	off := 0
+
+	for line := 0; line < pos.Line; line++ {
+		idx := strings.IndexByte(text[off:], '\n')
+		if idx < 0 {
+			return len(text)
+		}
+
+		off += idx + 1
+	}
+
+	for units := 0; units < pos.Character && off < len(text); {
+		r, size := utf8.DecodeRuneInString(text[off:])
+		if r == '\n' {
+			break
+		}
+
+		units++
+		if r >= surrogateSelf {
+			// encoded as a surrogate pair
+			units++
+		}
+		off += size
+	}
+
+	return off
+}
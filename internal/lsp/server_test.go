@@ -0,0 +1,144 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestApplyChange(t *testing.T) {
+	is := is.New(t)
+
+	text := "foo\nb😀r\nbaz\n"
+
+	is.Equal(applyChange(text, contentChange{Text: "new"}), "new")
+
+	is.Equal(applyChange(text, contentChange{
+		Range: &Range{Start: Position{Line: 1, Character: 1}, End: Position{Line: 1, Character: 3}},
+		Text:  "a",
+	}), "foo\nbar\nbaz\n")
+
+	is.Equal(applyChange(text, contentChange{
+		Range: &Range{Start: Position{Line: 0, Character: 3}, End: Position{Line: 2, Character: 0}},
+		Text:  " ",
+	}), "foo baz\n")
+
+	is.Equal(applyChange(text, contentChange{
+		Range: &Range{Start: Position{Line: 0, Character: 99}, End: Position{Line: 99, Character: 0}},
+		Text:  "!",
+	}), "foo!")
+}
+
+func TestDocumentURI_Path(t *testing.T) {
+	is := is.New(t)
+
+	path, ok := DocumentURI("file:///some/dir/file%20name.go").Path()
+	is.True(ok)
+	is.Equal(path, "/some/dir/file name.go")
+
+	is.Equal(FileURI("/some/dir/file name.go"), DocumentURI("file:///some/dir/file%20name.go"))
+
+	_, ok = DocumentURI("untitled:Untitled-1").Path()
+	is.True(!ok)
+}
+
+func TestServer_Serve(t *testing.T) {
+	is := is.New(t)
+
+	server := Server{
+		Name: "test",
+		Diagnose: func(_ context.Context, docs map[DocumentURI]string, changed []DocumentURI) (map[DocumentURI][]Diagnostic, error) {
+			diags := map[DocumentURI][]Diagnostic{}
+
+			for _, uri := range changed {
+				if text, ok := docs[uri]; ok {
+					diags[uri] = []Diagnostic{{Message: text}}
+				}
+			}
+
+			return diags, nil
+		},
+	}
+
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- server.Serve(context.Background(), serverR, serverW)
+	}()
+
+	client := newConn(clientR, clientW)
+
+	id := json.RawMessage("1")
+	is.NoErr(client.write(&message{ID: &id, Method: "initialize", Params: json.RawMessage("{}")}))
+
+	msg, err := client.read()
+	is.NoErr(err)
+	is.Equal(string(*msg.ID), "1")
+	is.Equal(msg.Result.(map[string]any)["serverInfo"], map[string]any{"name": "test"})
+
+	is.NoErr(client.notify("textDocument/didOpen", &didOpenParams{
+		TextDocument: textDocumentItem{URI: "file:///a.txt", Version: 1, Text: "foo\n"},
+	}))
+
+	params := readDiagnostics(t, client)
+	is.Equal(params.URI, DocumentURI("file:///a.txt"))
+	is.Equal(*params.Version, 1)
+	is.Equal(params.Diagnostics[0].Message, "foo\n")
+
+	is.NoErr(client.notify("textDocument/didChange", &didChangeParams{
+		TextDocument: versionedTextDocumentIdentifier{URI: "file:///a.txt", Version: 2},
+		ContentChanges: []contentChange{{
+			Range: &Range{Start: Position{Line: 0, Character: 3}, End: Position{Line: 0, Character: 3}},
+			Text:  "bar",
+		}},
+	}))
+
+	params = readDiagnostics(t, client)
+	is.Equal(*params.Version, 2)
+	is.Equal(params.Diagnostics[0].Message, "foobar\n")
+
+	is.NoErr(client.notify("textDocument/didClose", &didCloseParams{
+		TextDocument: textDocumentIdentifier{URI: "file:///a.txt"},
+	}))
+
+	params = readDiagnostics(t, client)
+	is.Equal(params.Version, nil)
+	is.Equal(len(params.Diagnostics), 0)
+
+	id = json.RawMessage("2")
+	is.NoErr(client.write(&message{ID: &id, Method: "unknown"}))
+
+	msg, err = client.read()
+	is.NoErr(err)
+	is.Equal(msg.Error.Code, methodNotFoundCode)
+
+	is.NoErr(client.notify("exit", nil))
+	is.NoErr(<-done)
+}
+
+// readDiagnostics reads a textDocument/publishDiagnostics notification from client.
+func readDiagnostics(t *testing.T, client *conn) *publishDiagnosticsParams {
+	t.Helper()
+
+	msg, err := client.read()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if msg.Method != "textDocument/publishDiagnostics" {
+		t.Fatalf("unexpected message: %s", msg.Method)
+	}
+
+	params := publishDiagnosticsParams{}
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		t.Fatal(err)
+	}
+
+	return &params
+}
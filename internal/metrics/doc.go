@@ -0,0 +1,2 @@
+// Package metrics implements simple metrics that can be exposed in the Prometheus text format.
+package metrics
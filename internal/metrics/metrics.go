@@ -0,0 +1,204 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// A Registry is a set of metrics that can be written in the Prometheus text format.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+// metric is a single metric in a Registry.
+type metric interface {
+	// write writes the metric's samples to w in the Prometheus text format.
+	write(w io.Writer)
+}
+
+// A Counter is a metric whose value only ever increases.
+type Counter struct {
+	name string
+	help string
+
+	mu    sync.Mutex
+	value float64
+}
+
+// A Gauge is a metric whose value may go up and down.
+type Gauge struct {
+	name string
+	help string
+
+	mu    sync.Mutex
+	value float64
+}
+
+// A Histogram is a metric that counts observed values in buckets.
+type Histogram struct {
+	name string
+	help string
+
+	// buckets are the upper bounds of the buckets, in ascending order.
+	buckets []float64
+
+	mu     sync.Mutex
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewRegistry returns a new, empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// NewCounter returns a new Counter that is registered in r.
+func (r *Registry) NewCounter(name string, help string) *Counter {
+	counter := Counter{
+		name: name,
+		help: help,
+	}
+
+	r.register(&counter)
+
+	return &counter
+}
+
+// NewGauge returns a new Gauge that is registered in r.
+func (r *Registry) NewGauge(name string, help string) *Gauge {
+	gauge := Gauge{
+		name: name,
+		help: help,
+	}
+
+	r.register(&gauge)
+
+	return &gauge
+}
+
+// NewHistogram returns a new Histogram that is registered in r. buckets are the upper bounds of the buckets,
+// in ascending order. A bucket for +Inf is added implicitly.
+func (r *Registry) NewHistogram(name string, help string, buckets []float64) *Histogram {
+	histogram := Histogram{
+		name:    name,
+		help:    help,
+		buckets: buckets,
+		counts:  make([]uint64, len(buckets)),
+	}
+
+	r.register(&histogram)
+
+	return &histogram
+}
+
+// register adds m to r.
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.metrics = append(r.metrics, m)
+}
+
+// Write writes all metrics in r to w in the Prometheus text format.
+func (r *Registry) Write(w io.Writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, m := range r.metrics {
+		m.write(w)
+	}
+}
+
+// ServeHTTP implements http.Handler. It writes all metrics in r in the Prometheus text format.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.Write(w)
+}
+
+// Add adds delta to c. delta must not be negative.
+func (c *Counter) Add(delta float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.value += delta
+}
+
+// Inc increments c by 1.
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+func (c *Counter) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	writeHeader(w, c.name, c.help, "counter")
+	fmt.Fprintf(w, "%s %s\n", c.name, formatFloat(c.value))
+}
+
+// Set sets g to value.
+func (g *Gauge) Set(value float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.value = value
+}
+
+func (g *Gauge) write(w io.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	writeHeader(w, g.name, g.help, "gauge")
+	fmt.Fprintf(w, "%s %s\n", g.name, formatFloat(g.value))
+}
+
+// Observe adds value to h.
+func (h *Histogram) Observe(value float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for idx, bound := range h.buckets {
+		if value <= bound {
+			h.counts[idx]++
+		}
+	}
+
+	h.sum += value
+	h.count++
+}
+
+func (h *Histogram) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	writeHeader(w, h.name, h.help, "histogram")
+
+	for idx, bound := range h.buckets {
+		fmt.Fprintf(w, "%s_bucket{le=\"%s\"} %d\n", h.name, formatFloat(bound), h.counts[idx])
+	}
+
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", h.name, h.count)
+	fmt.Fprintf(w, "%s_sum %s\n", h.name, formatFloat(h.sum))
+	fmt.Fprintf(w, "%s_count %d\n", h.name, h.count)
+}
+
+// writeHeader writes the HELP and TYPE lines of a metric to w.
+func writeHeader(w io.Writer, name string, help string, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, typ)
+}
+
+// formatFloat formats f as a sample value.
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestRegistry_Write(t *testing.T) {
+	is := is.New(t)
+
+	reg := NewRegistry()
+
+	counter := reg.NewCounter("files_total", "Files scanned.")
+	gauge := reg.NewGauge("similarities", "Similarities found.")
+	histogram := reg.NewHistogram("duration_seconds", "Scan duration.", []float64{1, 10})
+
+	counter.Add(3)
+	counter.Inc()
+	gauge.Set(2)
+	histogram.Observe(0.5)
+	histogram.Observe(5)
+	histogram.Observe(50)
+
+	buf := bytes.Buffer{}
+	reg.Write(&buf)
+
+	is.Equal(buf.String(), `# HELP files_total Files scanned.
+# TYPE files_total counter
+files_total 4
+# HELP similarities Similarities found.
+# TYPE similarities gauge
+similarities 2
+# HELP duration_seconds Scan duration.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{le="1"} 1
+duration_seconds_bucket{le="10"} 2
+duration_seconds_bucket{le="+Inf"} 3
+duration_seconds_sum 55.5
+duration_seconds_count 3
+`)
+}
@@ -0,0 +1,3 @@
+// Package s3 implements a minimal client for S3-compatible object stores, supporting listing and reading
+// objects, using requests signed with AWS Signature Version 4.
+package s3
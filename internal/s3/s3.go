@@ -0,0 +1,223 @@
+package s3
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// urlScheme is the scheme of URLs of objects, as in s3://bucket/key.
+	urlScheme = "s3://"
+
+	// defaultRegion is the region used if none is configured.
+	defaultRegion = "us-east-1"
+)
+
+// errRequestFailed is returned when the object store responds with an error.
+var errRequestFailed = errors.New("request failed")
+
+// A Client lists and reads objects in an S3-compatible object store. Buckets are addressed using path-style
+// URLs, that is, Endpoint/bucket/key, which are supported by most S3-compatible object stores.
+type Client struct {
+	// Endpoint is the base URL of the object store.
+	Endpoint *url.URL
+
+	// Region is the region used to sign requests.
+	Region string
+
+	// AccessKeyID is the access key used to sign requests. If empty, requests are not signed, which only
+	// allows access to public buckets.
+	AccessKeyID string
+
+	// SecretAccessKey is the secret key used to sign requests.
+	SecretAccessKey string
+
+	// SessionToken is the token of temporary credentials, if any.
+	SessionToken string
+
+	// HTTPClient is used to send requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+
+	// now returns the current time. If nil, time.Now is used.
+	now func() time.Time
+}
+
+// An Object is a single object in a bucket.
+type Object struct {
+	// Key is the object's key.
+	Key string
+
+	// Size is the size of the object in bytes.
+	Size int64
+
+	// ETag is the entity tag of the object, which changes when the object's contents change.
+	ETag string
+}
+
+// listBucketResult is the response to a ListObjectsV2 request.
+type listBucketResult struct {
+	Contents []struct {
+		Key  string `xml:"Key"`
+		Size int64  `xml:"Size"`
+		ETag string `xml:"ETag"`
+	} `xml:"Contents"`
+
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// NewClientFromEnv returns a new Client configured using the standard AWS environment variables:
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN, and AWS_REGION (or AWS_DEFAULT_REGION.)
+// The endpoint can be set using AWS_ENDPOINT_URL_S3 (or AWS_ENDPOINT_URL), for example to use MinIO,
+// and defaults to AWS S3 in the configured region.
+func NewClientFromEnv() (*Client, error) {
+	region := firstEnv("AWS_REGION", "AWS_DEFAULT_REGION")
+	if region == "" {
+		region = defaultRegion
+	}
+
+	endpoint := firstEnv("AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL")
+	if endpoint == "" {
+		endpoint = "https://s3." + region + ".amazonaws.com"
+	}
+
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("parse endpoint %s: %w", endpoint, err)
+	}
+
+	return &Client{
+		Endpoint:        endpointURL,
+		Region:          region,
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}, nil
+}
+
+// ParseURL returns the bucket and key (or key prefix) of u, which must be of the form s3://bucket/key.
+// If u is not such a URL, ok is false.
+func ParseURL(u string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(u, urlScheme)
+	if !ok {
+		return "", "", false
+	}
+
+	bucket, key, _ := strings.Cut(rest, "/")
+	if bucket == "" {
+		return "", "", false
+	}
+
+	return bucket, key, true
+}
+
+// URL returns the URL of the object with key in bucket, in the form s3://bucket/key.
+func URL(bucket string, key string) string {
+	return urlScheme + bucket + "/" + key
+}
+
+// List returns all objects in bucket whose keys start with prefix, sorted by key.
+func (c *Client) List(ctx context.Context, bucket string, prefix string) ([]*Object, error) {
+	objects := []*Object{}
+	token := ""
+
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := c.do(ctx, bucket, "", query)
+		if err != nil {
+			return nil, err
+		}
+
+		result := listBucketResult{}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+
+		_ = resp.Body.Close()
+
+		if err != nil {
+			return nil, fmt.Errorf("decode listing of %s: %w", URL(bucket, prefix), err)
+		}
+
+		for _, content := range result.Contents {
+			objects = append(objects, &Object{
+				Key:  content.Key,
+				Size: content.Size,
+				ETag: content.ETag,
+			})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+
+		token = result.NextContinuationToken
+	}
+}
+
+// Open returns a reader of the contents of the object with key in bucket. The contents are streamed while
+// being read. The reader must be closed by the caller.
+func (c *Client) Open(ctx context.Context, bucket string, key string) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, bucket, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+// do sends a GET request for key in bucket, with query, and returns the response if it was successful.
+func (c *Client) do(ctx context.Context, bucket string, key string, query url.Values) (*http.Response, error) {
+	reqURL := *c.Endpoint
+	reqURL.Path = strings.TrimSuffix(reqURL.Path, "/") + "/" + bucket + "/" + key
+	reqURL.RawPath = escapePath(reqURL.Path)
+	reqURL.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request for %s: %w", URL(bucket, key), err)
+	}
+
+	c.sign(req)
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request %s: %w", URL(bucket, key), err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s: %s", errRequestFailed, URL(bucket, key), resp.Status)
+	}
+
+	return resp, nil
+}
+
+// firstEnv returns the value of the first of the environment variables with names that is not empty.
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,142 @@
+package s3
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// signAlgorithm is the algorithm of AWS Signature Version 4.
+	signAlgorithm = "AWS4-HMAC-SHA256"
+
+	// emptyPayloadHash is the SHA-256 hash of an empty request body.
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	// amzDateFormat is the format of the X-Amz-Date header.
+	amzDateFormat = "20060102T150405Z"
+)
+
+// sign signs req, which must not have a body, using AWS Signature Version 4. All headers already set on req
+// are signed. If c.AccessKeyID is empty, req is left unsigned.
+func (c *Client) sign(req *http.Request) {
+	if c.AccessKeyID == "" {
+		return
+	}
+
+	now := time.Now
+	if c.now != nil {
+		now = c.now
+	}
+
+	date := now().UTC().Format(amzDateFormat)
+	day := date[:8]
+
+	req.Header.Set("X-Amz-Date", date)
+	req.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+
+	if c.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.SessionToken)
+	}
+
+	headers, signedHeaders := canonicalHeaders(req)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		headers,
+		signedHeaders,
+		emptyPayloadHash,
+	}, "\n")
+
+	scope := day + "/" + c.Region + "/s3/aws4_request"
+
+	stringToSign := strings.Join([]string{
+		signAlgorithm,
+		date,
+		scope,
+		hexSHA256(canonicalRequest),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+c.SecretAccessKey), day)
+	key = hmacSHA256(key, c.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", signAlgorithm+" Credential="+c.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+// canonicalHeaders returns the canonical headers of req, including the host, and the list of their names.
+func canonicalHeaders(req *http.Request) (string, string) {
+	values := map[string]string{
+		"host": req.URL.Host,
+	}
+
+	for name, vals := range req.Header {
+		values[strings.ToLower(name)] = strings.TrimSpace(strings.Join(vals, ","))
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	headers := strings.Builder{}
+	for _, name := range names {
+		headers.WriteString(name + ":" + values[name] + "\n")
+	}
+
+	return headers.String(), strings.Join(names, ";")
+}
+
+// canonicalQuery returns query encoded as a canonical query string, sorted by name and with spaces encoded
+// as %20.
+func canonicalQuery(query url.Values) string {
+	return strings.ReplaceAll(query.Encode(), "+", "%20")
+}
+
+// escapePath returns p with all bytes except unreserved characters and slashes percent-encoded, as required
+// for canonical URIs.
+func escapePath(p string) string {
+	escaped := strings.Builder{}
+
+	for idx := 0; idx < len(p); idx++ {
+		char := p[idx]
+
+		if char == '/' || char == '-' || char == '_' || char == '.' || char == '~' ||
+			(char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (char >= '0' && char <= '9') {
+			escaped.WriteByte(char)
+			continue
+		}
+
+		fmt.Fprintf(&escaped, "%%%02X", char)
+	}
+
+	return escaped.String()
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data using key.
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
+
+// hexSHA256 returns the hex-encoded SHA-256 hash of s.
+func hexSHA256(s string) string {
+	hash := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(hash[:])
+}
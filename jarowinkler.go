@@ -0,0 +1,127 @@
+package textsimilarity
+
+const (
+	// jaroWinklerPrefixScale is the factor by which the Jaro similarity is boosted for each common prefix rune.
+	jaroWinklerPrefixScale = 0.1
+
+	// jaroWinklerMaxPrefix is the maximum number of common prefix runes considered.
+	jaroWinklerMaxPrefix = 4
+
+	// jaroWinklerBoostThreshold is the Jaro similarity above which it is boosted for common prefixes.
+	jaroWinklerBoostThreshold = 0.7
+)
+
+// jaroWinklerLinesSimilarity returns the similarity level between fileLine1 and fileLine2, whose texts are known
+// to differ, using the Jaro-Winkler similarity between them.
+func jaroWinklerLinesSimilarity(fileLine1 *fileLine, fileLine2 *fileLine, opts *Options) SimilarityLevel {
+	minSim := opts.MinJaroWinklerSimilarity
+	if minSim <= 0 {
+		minSim = DefaultMinJaroWinklerSimilarity
+	}
+
+	length1 := fileLine1.length
+	length2 := fileLine2.length
+
+	if opts.flagSet(IgnoreWhitespaceFlag) {
+		length1 = fileLine1.lengthTrimmed
+		length2 = fileLine2.lengthTrimmed
+	}
+
+	if length1 == 0 || length2 == 0 {
+		return differentSimilarityLevel
+	}
+
+	// the similarity is at most that of lines where all runes of the shorter line match, with a full prefix boost
+	maxJaro := (2 + float64(min(length1, length2))/float64(max(length1, length2))) / 3
+	if maxJaro+jaroWinklerMaxPrefix*jaroWinklerPrefixScale*(1-maxJaro) < minSim {
+		return differentSimilarityLevel
+	}
+
+	runes1 := runesPool.Get().(*[]rune) //nolint:forcetypeassert // we know what's in the pool
+	defer runesPool.Put(runes1)
+
+	runes2 := runesPool.Get().(*[]rune) //nolint:forcetypeassert // we know what's in the pool
+	defer runesPool.Put(runes2)
+
+	if jaroWinkler(fileLine1.runes(runes1, opts), fileLine2.runes(runes2, opts)) < minSim {
+		return differentSimilarityLevel
+	}
+
+	return SimilarSimilarityLevel
+}
+
+// jaroWinkler returns the Jaro-Winkler similarity between s1 and s2, between 0 (completely different) and
+// 1 (equal).
+func jaroWinkler(s1 []rune, s2 []rune) float64 {
+	sim := jaro(s1, s2)
+	if sim <= jaroWinklerBoostThreshold {
+		return sim
+	}
+
+	prefix := 0
+	for prefix < min(len(s1), len(s2), jaroWinklerMaxPrefix) && s1[prefix] == s2[prefix] {
+		prefix++
+	}
+
+	return sim + float64(prefix)*jaroWinklerPrefixScale*(1-sim)
+}
+
+// jaro returns the Jaro similarity between s1 and s2, between 0 (completely different) and 1 (equal).
+func jaro(s1 []rune, s2 []rune) float64 {
+	if len(s1) == 0 && len(s2) == 0 {
+		return 1
+	}
+
+	if len(s1) == 0 || len(s2) == 0 {
+		return 0
+	}
+
+	// runes only match if they are not farther apart than this
+	window := max(max(len(s1), len(s2))/2-1, 0)
+
+	matched1 := make([]bool, len(s1))
+	matched2 := make([]bool, len(s2))
+	matches := 0
+
+	for idx1, r := range s1 {
+		for idx2 := max(idx1-window, 0); idx2 < min(idx1+window+1, len(s2)); idx2++ {
+			if matched2[idx2] || s2[idx2] != r {
+				continue
+			}
+
+			matched1[idx1] = true
+			matched2[idx2] = true
+			matches++
+
+			break
+		}
+	}
+
+	if matches == 0 {
+		return 0
+	}
+
+	// transpositions are matched runes that are not in the same order in both strings
+	transpositions := 0
+	idx2 := 0
+
+	for idx1, r := range s1 {
+		if !matched1[idx1] {
+			continue
+		}
+
+		for !matched2[idx2] {
+			idx2++
+		}
+
+		if s2[idx2] != r {
+			transpositions++
+		}
+
+		idx2++
+	}
+
+	m := float64(matches)
+
+	return (m/float64(len(s1)) + m/float64(len(s2)) + (m-float64(transpositions)/2)/m) / 3
+}
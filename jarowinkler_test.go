@@ -0,0 +1,49 @@
+package textsimilarity
+
+import (
+	"math"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestJaroWinkler(t *testing.T) {
+	is := is.New(t)
+
+	approx := func(a float64, b float64) bool {
+		return math.Abs(a-b) < 0.001
+	}
+
+	is.True(approx(jaroWinkler([]rune("MARTHA"), []rune("MARHTA")), 0.961))
+	is.True(approx(jaroWinkler([]rune("DWAYNE"), []rune("DUANE")), 0.84))
+	is.True(approx(jaroWinkler([]rune("DIXON"), []rune("DICKSONX")), 0.813))
+	is.Equal(jaroWinkler([]rune("abc"), []rune("abc")), 1.0)
+	is.Equal(jaroWinkler([]rune("abc"), []rune("xyz")), 0.0)
+	is.Equal(jaroWinkler([]rune(""), []rune("xyz")), 0.0)
+}
+
+func TestSimilarities_JaroWinkler(t *testing.T) {
+	is := is.New(t)
+
+	newFiles := func() []*File {
+		return []*File{
+			newFile("1.txt", "customerName\norderNumber\nshippingAddress\n"),
+			newFile("2.txt", "cusotmerName\norderNubmer\nshippingAdrdess\n"),
+		}
+	}
+
+	sims := similaritiesWithOptions(t, newFiles(), &Options{MinSimilarLines: 3, MaxEditDistance: 1})
+	is.Equal(len(sims), 0)
+
+	sims = similaritiesWithOptions(t, newFiles(), &Options{LineMetric: JaroWinklerLineMetric, MinSimilarLines: 3})
+	is.Equal(len(sims), 1)
+	is.Equal(sims[0].Level, SimilarSimilarityLevel)
+	is.Equal(sims[0].Occurrences[0].End, 3)
+
+	sims = similaritiesWithOptions(t, newFiles(), &Options{
+		LineMetric:               JaroWinklerLineMetric,
+		MinJaroWinklerSimilarity: 0.99,
+		MinSimilarLines:          3,
+	})
+	is.Equal(len(sims), 0)
+}
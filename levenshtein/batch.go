@@ -0,0 +1,96 @@
+package levenshtein
+
+// BatchDistance returns the Levenshtein distances between needle and each of candidates, in the same order. If
+// maxDist >= 0, distances greater than maxDist are not necessarily calculated exactly, but are reported as some
+// value greater than maxDist, like DistanceAtMost. If needle has at most 64 runes, its table of character positions
+// is built only once and reused for all candidates, which is faster than calling Distance or DistanceAtMost for
+// each candidate. Like Distance, it does not support runes greater than 65535.
+func BatchDistance(needle []rune, candidates [][]rune, maxDist int) []int {
+	dists := make([]int, len(candidates))
+
+	if len(needle) == 0 || len(needle) > 64 {
+		for idx, candidate := range candidates {
+			if maxDist >= 0 {
+				dists[idx], _ = DistanceAtMost(needle, candidate, maxDist)
+				continue
+			}
+
+			dists[idx] = Distance(needle, candidate)
+		}
+
+		return dists
+	}
+
+	uint64s := uint64sPool.Get().(*[uintsSize]uint64) //nolint:forcetypeassert // we know what's in the pool
+	defer uint64sPool.Put(uint64s)
+
+	peq := uint64s[:peqSize]
+
+	for idx, r := range needle {
+		peq[r] |= uint64(1) << idx
+	}
+
+	for idx, candidate := range candidates {
+		if maxDist >= 0 && abs(len(needle)-len(candidate)) > maxDist {
+			dists[idx] = abs(len(needle) - len(candidate))
+			continue
+		}
+
+		dists[idx] = m64Text(len(needle), candidate, peq, maxDist)
+	}
+
+	for _, r := range needle {
+		peq[r] = 0
+	}
+
+	return dists
+}
+
+// m64Text returns the Levenshtein distance between a pattern of patternLen runes, with at most 64 runes, whose
+// table of character positions is peq, and text b. It works like m64, but peq must already be set up, and is left
+// unchanged. If maxDist >= 0, the calculation is aborted as soon as the distance is known to be greater than
+// maxDist, and some value greater than maxDist is returned.
+//
+//nolint:varnamelen // copied code
+func m64Text(patternLen int, b []rune, peq []uint64, maxDist int) int {
+	pv := ^uint64(0)
+	mv := uint64(0)
+	sc := patternLen
+	ls := uint64(1) << (patternLen - 1)
+
+	for idx, c := range b {
+		eq := peq[c]
+		xv := eq | mv
+		eq |= ((eq & pv) + pv) ^ pv
+		mv |= ^(eq | pv)
+		pv &= eq
+
+		if (mv & ls) != 0 {
+			sc++
+		}
+
+		if (pv & ls) != 0 {
+			sc--
+		}
+
+		// each remaining rune of b decreases the distance by at most 1
+		if maxDist >= 0 && sc-(len(b)-idx-1) > maxDist {
+			return sc - (len(b) - idx - 1)
+		}
+
+		mv = (mv << 1) | 1
+		pv = (pv << 1) | ^(xv | mv)
+		mv &= xv
+	}
+
+	return sc
+}
+
+// abs returns the absolute value of x.
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+
+	return x
+}
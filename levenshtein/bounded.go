@@ -0,0 +1,119 @@
+package levenshtein
+
+import "sync"
+
+// intsPool is used to allocate rows for DistanceAtMost.
+var intsPool = sync.Pool{
+	New: func() any {
+		return &[]int{}
+	},
+}
+
+// DistanceAtMost returns the Levenshtein distance between a and b, and whether it is at most k.
+// The calculation is aborted as soon as the distance is known to be greater than k. In that case,
+// the returned distance is greater than k, but not necessarily the actual distance.
+func DistanceAtMost(a []rune, b []rune, k int) (int, bool) { //nolint:varnamelen // a and b are fine here
+	if k < 0 {
+		return Distance(a, b), false
+	}
+
+	return distanceAtMost(a, b, k)
+}
+
+// DistanceBytesAtMost returns the Levenshtein distance between a and b, which must only contain ASCII characters,
+// and whether it is at most k. It works like DistanceAtMost, but does not require conversion to runes.
+func DistanceBytesAtMost(a []byte, b []byte, k int) (int, bool) { //nolint:varnamelen // a and b are fine here
+	if k < 0 {
+		return DistanceBytes(a, b), false
+	}
+
+	return distanceAtMost(a, b, k)
+}
+
+// distanceAtMost returns the Levenshtein distance between a and b, and whether it is at most k, with k >= 0.
+func distanceAtMost[T char](a []T, b []T, k int) (int, bool) { //nolint:varnamelen // a and b are fine here
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+
+	if len(a)-len(b) > k {
+		return len(a) - len(b), false
+	}
+
+	for len(b) != 0 && a[0] == b[0] {
+		a = a[1:]
+		b = b[1:]
+	}
+
+	for len(b) != 0 && a[len(a)-1] == b[len(b)-1] {
+		a = a[:len(a)-1]
+		b = b[:len(b)-1]
+	}
+
+	if len(b) == 0 {
+		return len(a), len(a) <= k
+	}
+
+	rows := intsPool.Get().(*[]int) //nolint:forcetypeassert // we know what's in the pool
+	defer intsPool.Put(rows)
+
+	if cap(*rows) < 2*(len(b)+1) {
+		*rows = make([]int, 2*(len(b)+1))
+	}
+
+	*rows = (*rows)[:2*(len(b)+1)]
+
+	dist := bandedDistance(a, b, k, (*rows)[:len(b)+1], (*rows)[len(b)+1:])
+
+	return dist, dist <= k
+}
+
+// bandedDistance returns the Levenshtein distance between a and b, with len(a) >= len(b), or k+1 if the distance
+// is greater than k. Only cells of the distance matrix that are at most k cells away from the diagonal are
+// calculated, and the calculation is aborted as soon as all cells of a row are greater than k.
+// prev and cur must both have a length of len(b)+1.
+//
+//nolint:varnamelen // a and b are fine here
+func bandedDistance[T char](a []T, b []T, k int, prev []int, cur []int) int {
+	infinity := k + 1
+
+	for j := range prev {
+		prev[j] = min(j, infinity)
+	}
+
+	for i := 1; i <= len(a); i++ {
+		lo := max(1, i-k)
+		hi := min(len(b), i+k)
+
+		cur[lo-1] = infinity
+		if lo == 1 {
+			cur[0] = min(i, infinity)
+		}
+
+		rowMin := cur[lo-1]
+
+		for j := lo; j <= hi; j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			dist := min(min(prev[j-1]+cost, prev[j]+1), min(cur[j-1]+1, infinity))
+			cur[j] = dist
+
+			rowMin = min(rowMin, dist)
+		}
+
+		if hi < len(b) {
+			cur[hi+1] = infinity
+		}
+
+		if rowMin > k {
+			return infinity
+		}
+
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
@@ -2,7 +2,10 @@ package levenshtein
 
 import "testing"
 
-var Dist int
+var (
+	Dist  int
+	Dists []int
+)
 
 func BenchmarkDistance64(b *testing.B) {
 	s1 := []rune("Cras enim velit")
@@ -21,3 +24,51 @@ func BenchmarkDistance(b *testing.B) {
 		Dist = Distance(s1, s2)
 	}
 }
+
+func BenchmarkDistanceAtMost(b *testing.B) {
+	s1 := []rune("Cras enim velit, vehicula nec viverra at, elementum non augue. Praesent pulvinar mi volutpat enim blandit, vitae porta urna aliquam.")
+	s2 := []rune("Vivamus id urna nec quam lacinia dapibus. Integer sit amet tortor at nisl placerat vulputate. Nulla facilisi, sed fermentum erat.")
+
+	for i := 0; i < b.N; i++ {
+		Dist, _ = DistanceAtMost(s1, s2, 5)
+	}
+}
+
+func BenchmarkDistanceBytes(b *testing.B) {
+	s1 := []byte("Cras enim velit, vehicula nec viverra at, elementum non augue. Praesent pulvinar mi volutpat enim blandit, vitae porta urna aliquam.")
+	s2 := []byte("Cras enim velit, vehicula nec viverra at, elementum non augue. Praesent pulvinar mi volutpat enim blandit, vitae porta urna aliquam.")
+
+	for i := 0; i < b.N; i++ {
+		Dist = DistanceBytes(s1, s2)
+	}
+}
+
+func BenchmarkBatchDistance(b *testing.B) {
+	needle := []rune("Cras enim velit, vehicula nec viverra at")
+	candidates := [][]rune{
+		[]rune("Cras enim vel1t, vehicula nec viverra at"),
+		[]rune("Vivamus id urna nec quam lacinia dapibus"),
+		[]rune("Integer sit amet tortor at nisl placerat"),
+		[]rune("Nulla facilisi, sed fermentum erat, enim"),
+	}
+
+	for i := 0; i < b.N; i++ {
+		Dists = BatchDistance(needle, candidates, 5)
+	}
+}
+
+func BenchmarkDistanceAtMost_Batch(b *testing.B) {
+	needle := []rune("Cras enim velit, vehicula nec viverra at")
+	candidates := [][]rune{
+		[]rune("Cras enim vel1t, vehicula nec viverra at"),
+		[]rune("Vivamus id urna nec quam lacinia dapibus"),
+		[]rune("Integer sit amet tortor at nisl placerat"),
+		[]rune("Nulla facilisi, sed fermentum erat, enim"),
+	}
+
+	for i := 0; i < b.N; i++ {
+		for _, candidate := range candidates {
+			Dist, _ = DistanceAtMost(needle, candidate, 5)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package levenshtein
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestDistanceAtMost(t *testing.T) {
+	strs := []string{
+		"",
+		"a",
+		"abc",
+		"kitten",
+		"sitting",
+		"Cras enim velit",
+		"Cras enim velit, vehicula nec viverra at",
+		"Cras enim vel1t, vehicula nec viverra at",
+		"Cras enim velit, vehicula nec viverra at, elementum non augue. Praesent pulvinar mi volutpat enim blandit.",
+		"Cras enim velit, vehicula nec viverra at, elementum non augue! Praesent pulvinar mi volutpat enim blandit?",
+		"ÄÖÜ äöü",
+	}
+
+	for _, s1 := range strs {
+		for _, s2 := range strs {
+			for k := 0; k <= 8; k++ {
+				t.Run(s1+"|"+s2, func(t *testing.T) {
+					is := is.New(t)
+
+					want := Distance([]rune(s1), []rune(s2))
+
+					dist, ok := DistanceAtMost([]rune(s1), []rune(s2), k)
+					is.Equal(ok, want <= k)
+
+					if ok {
+						is.Equal(dist, want)
+					} else {
+						is.True(dist > k)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestDistanceBytes(t *testing.T) {
+	strs := []string{
+		"",
+		"a",
+		"kitten",
+		"sitting",
+		"Cras enim velit, vehicula nec viverra at",
+		"Cras enim vel1t, vehicula nec viverra at",
+		"Cras enim velit, vehicula nec viverra at, elementum non augue. Praesent pulvinar mi volutpat enim blandit.",
+		"Cras enim velit, vehicula nec viverra at, elementum non augue! Praesent pulvinar mi volutpat enim blandit?",
+	}
+
+	for _, s1 := range strs {
+		for _, s2 := range strs {
+			t.Run(s1+"|"+s2, func(t *testing.T) {
+				is := is.New(t)
+
+				want := Distance([]rune(s1), []rune(s2))
+				is.Equal(DistanceBytes([]byte(s1), []byte(s2)), want)
+
+				dist, ok := DistanceBytesAtMost([]byte(s1), []byte(s2), 3)
+				is.Equal(ok, want <= 3)
+
+				if ok {
+					is.Equal(dist, want)
+				}
+			})
+		}
+	}
+}
+
+func TestBatchDistance(t *testing.T) {
+	is := is.New(t)
+
+	strs := []string{
+		"",
+		"a",
+		"abc",
+		"kitten",
+		"sitting",
+		"Cras enim velit",
+		"Cras enim velit, vehicula nec viverra at",
+		"Cras enim vel1t, vehicula nec viverra at",
+		"Cras enim velit, vehicula nec viverra at, elementum non augue. Praesent pulvinar mi volutpat enim blandit.",
+		"Cras enim velit, vehicula nec viverra at, elementum non augue! Praesent pulvinar mi volutpat enim blandit?",
+		"ÄÖÜ äöü",
+	}
+
+	candidates := make([][]rune, len(strs))
+	for idx, s := range strs {
+		candidates[idx] = []rune(s)
+	}
+
+	for _, needle := range strs {
+		for _, k := range []int{-1, 0, 3, 8} {
+			dists := BatchDistance([]rune(needle), candidates, k)
+			is.Equal(len(dists), len(strs))
+
+			for idx, s := range strs {
+				want := Distance([]rune(needle), []rune(s))
+
+				if k < 0 || want <= k {
+					is.Equal(dists[idx], want)
+				} else {
+					is.True(dists[idx] > k)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package textsimilarity
+
+import (
+	"container/list"
+	"sync"
+)
+
+// lineCacheShards is the number of shards of a lineCache. Each shard has its own lock, so that concurrent
+// comparisons rarely wait for each other.
+const lineCacheShards = 64
+
+// A lineCache is a bounded cache of the similarity levels of pairs of lines that are known to differ. Pairs are
+// keyed by the hashes of their lines, so pairs whose hashes collide share a level, which is very unlikely. The
+// least recently used pairs are evicted when the cache is full. It is safe for concurrent use.
+type lineCache struct {
+	shards [lineCacheShards]lineCacheShard
+}
+
+// A lineCacheShard is a single shard of a lineCache.
+type lineCacheShard struct {
+	lock sync.Mutex
+
+	// capacity is the maximum number of entries of the shard.
+	capacity int
+
+	// entries maps keys to their elements in order.
+	entries map[linePairKey]*list.Element
+
+	// order is the list of lineCacheEntries, most recently used first.
+	order *list.List
+}
+
+// A linePairKey is the key of a pair of lines in a lineCache. The hashes are ordered, since the similarity
+// level does not depend on the order of the lines.
+type linePairKey struct {
+	// hash1 is the smaller hash.
+	hash1 uint64
+
+	// hash2 is the larger hash.
+	hash2 uint64
+}
+
+// A lineCacheEntry is a single entry of a lineCacheShard.
+type lineCacheEntry struct {
+	key   linePairKey
+	level SimilarityLevel
+}
+
+// newLineCache returns a new lineCache that holds at most size pairs of lines, rounded up to a multiple of
+// the number of shards.
+func newLineCache(size int) *lineCache {
+	capacity := max((size+lineCacheShards-1)/lineCacheShards, 1)
+
+	cache := lineCache{}
+
+	for idx := range cache.shards {
+		cache.shards[idx].capacity = capacity
+		cache.shards[idx].entries = map[linePairKey]*list.Element{}
+		cache.shards[idx].order = list.New()
+	}
+
+	return &cache
+}
+
+// newLinePairKey returns the key of the pair of lines with hashes hash1 and hash2.
+func newLinePairKey(hash1 uint64, hash2 uint64) linePairKey {
+	return linePairKey{
+		hash1: min(hash1, hash2),
+		hash2: max(hash1, hash2),
+	}
+}
+
+// get returns the cached similarity level of the pair of lines with key, and whether it was found.
+func (c *lineCache) get(key linePairKey) (SimilarityLevel, bool) {
+	shard := c.shard(key)
+
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	elem, ok := shard.entries[key]
+	if !ok {
+		return differentSimilarityLevel, false
+	}
+
+	shard.order.MoveToFront(elem)
+
+	return elem.Value.(*lineCacheEntry).level, true //nolint:forcetypeassert // only entries are stored
+}
+
+// put caches level as the similarity level of the pair of lines with key, evicting the least recently used
+// pair of its shard if the shard is full.
+func (c *lineCache) put(key linePairKey, level SimilarityLevel) {
+	shard := c.shard(key)
+
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	if elem, ok := shard.entries[key]; ok {
+		elem.Value.(*lineCacheEntry).level = level //nolint:forcetypeassert // only entries are stored
+		shard.order.MoveToFront(elem)
+
+		return
+	}
+
+	if shard.order.Len() < shard.capacity {
+		shard.entries[key] = shard.order.PushFront(&lineCacheEntry{key: key, level: level})
+		return
+	}
+
+	// reuse the least recently used entry
+	oldest := shard.order.Back()
+	entry := oldest.Value.(*lineCacheEntry) //nolint:forcetypeassert // only entries are stored
+
+	delete(shard.entries, entry.key)
+
+	entry.key = key
+	entry.level = level
+
+	shard.entries[key] = oldest
+	shard.order.MoveToFront(oldest)
+}
+
+// shard returns the shard of c that holds key.
+func (c *lineCache) shard(key linePairKey) *lineCacheShard {
+	return &c.shards[(key.hash1^key.hash2>>7)%lineCacheShards]
+}
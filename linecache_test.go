@@ -0,0 +1,75 @@
+package textsimilarity
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestLineCache(t *testing.T) {
+	is := is.New(t)
+
+	cache := newLineCache(1)
+
+	key1 := newLinePairKey(2, 1)
+	is.Equal(key1, newLinePairKey(1, 2))
+
+	_, ok := cache.get(key1)
+	is.True(!ok)
+
+	cache.put(key1, SimilarSimilarityLevel)
+
+	level, ok := cache.get(key1)
+	is.True(ok)
+	is.Equal(level, SimilarSimilarityLevel)
+
+	// find another key in the same shard, which evicts key1 from the shard of capacity 1
+	key2 := newLinePairKey(3, 1)
+	for h := uint64(3); cache.shard(key2) != cache.shard(key1); h++ {
+		key2 = newLinePairKey(h, 1)
+	}
+
+	cache.put(key2, differentSimilarityLevel)
+
+	_, ok = cache.get(key1)
+	is.True(!ok)
+
+	level, ok = cache.get(key2)
+	is.True(ok)
+	is.Equal(level, differentSimilarityLevel)
+}
+
+func TestSimilarities_LineCache(t *testing.T) {
+	is := is.New(t)
+
+	files := []*File{
+		newFile("a.txt", "foo 1\nbar 1\nbaz 1\n"),
+		newFile("b.txt", "foo 2\nbar 2\nbaz 2\n"),
+		newFile("c.txt", "foo 3\nbar 3\nbaz 3\n"),
+	}
+
+	opts := Options{
+		MinSimilarLines: 3,
+		MaxEditDistance: 1,
+	}
+
+	sims := similaritiesWithOptions(t, files, &opts)
+	is.Equal(len(sims), 1)
+
+	files = []*File{
+		newFile("a.txt", "foo 1\nbar 1\nbaz 1\n"),
+		newFile("b.txt", "foo 2\nbar 2\nbaz 2\n"),
+		newFile("c.txt", "foo 3\nbar 3\nbaz 3\n"),
+	}
+
+	opts.LineCacheSize = 2
+
+	cachedSims := similaritiesWithOptions(t, files, &opts)
+	is.True(opts.lineCache == nil)
+	is.Equal(len(cachedSims), len(sims))
+
+	for idx, sim := range sims {
+		is.Equal(len(cachedSims[idx].Occurrences), len(sim.Occurrences))
+		is.Equal(cachedSims[idx].Level, sim.Level)
+	}
+}
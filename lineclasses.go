@@ -0,0 +1,251 @@
+package textsimilarity
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/blizzy78/textsimilarity/levenshtein"
+)
+
+// A similarClass is an equality class of lines of a file that are similar to a line.
+type similarClass struct {
+	// lines are the line numbers of the lines of the class (zero-based, ascending.)
+	lines []int
+
+	// level is the similarity level of the lines of the class.
+	level SimilarityLevel
+
+	// next is the index into lines of the next line that may be an occurrence.
+	next int
+}
+
+// classOccurrences adds all occurrences of line in file, beginning with startLine, to buf, according to opts,
+// like lineOccurrences. Instead of comparing line with each line of file, it is compared with each equality class
+// of lines of file only once, so that lines that occur many times, such as braces, are not compared repeatedly.
+// file must have an index of equality classes.
+func classOccurrences(ctx context.Context, file *fileToCheck, line *fileLine, startLine int, buf *occurrenceBuffer, opts *Options) SimilarityLevel {
+	start := opts.Timings.start()
+	classes := similarClasses(file, line, startLine, opts)
+	opts.Timings.record(FuzzyMatchingPhase, start)
+
+	level := EqualSimilarityLevel
+
+	for {
+		if contextDone(ctx) {
+			return level
+		}
+
+		lineIdx := -1
+		lineLevel := differentSimilarityLevel
+
+		for idx := range classes {
+			class := &classes[idx]
+
+			for class.next < len(class.lines) && (class.lines[class.next] < startLine || file.linesDone.isSet(class.lines[class.next])) {
+				class.next++
+			}
+
+			if class.next < len(class.lines) && (lineIdx < 0 || class.lines[class.next] < lineIdx) {
+				lineIdx = class.lines[class.next]
+				lineLevel = class.level
+			}
+		}
+
+		if lineIdx < 0 {
+			return level
+		}
+
+		buf.add(file, lineIdx)
+
+		if lineLevel < level {
+			level = lineLevel
+		}
+
+		startLine = lineIdx + 1
+	}
+}
+
+// similarClasses returns the equality classes of lines of file that are similar to line, and that have lines
+// at or after startLine, according to opts. The Levenshtein distances between line and the classes are
+// calculated in a single batch, if possible.
+func similarClasses(file *fileToCheck, line *fileLine, startLine int, opts *Options) []similarClass {
+	classes := []similarClass{}
+	batch := newDistanceBatch(line, opts)
+	if batch != nil {
+		defer batch.release()
+	}
+
+	for class, lines := range file.f.lineClasses {
+		if lines[len(lines)-1] < startLine {
+			continue
+		}
+
+		level, ok := quickLinesSimilarity(class, line, opts)
+		if !ok {
+			if batch.add(class, lines) {
+				continue
+			}
+
+			level = distanceLinesSimilarity(class, line, opts)
+		}
+
+		if level == differentSimilarityLevel {
+			continue
+		}
+
+		classes = append(classes, newSimilarClass(lines, level, startLine))
+	}
+
+	if batch == nil {
+		return classes
+	}
+
+	for idx, dist := range levenshtein.BatchDistance(batch.needle, batch.candidates(), batch.maxDist) {
+		level := differentSimilarityLevel
+		if dist <= batch.maxDist {
+			level = SimilarSimilarityLevel
+		}
+
+		if opts.lineCache != nil {
+			opts.lineCache.put(newLinePairKey(batch.lines[idx].hash, line.hash), level)
+		}
+
+		if level == differentSimilarityLevel {
+			continue
+		}
+
+		classes = append(classes, newSimilarClass(batch.classLines[idx], level, startLine))
+	}
+
+	return classes
+}
+
+// newSimilarClass returns a similarClass for the lines of an equality class with similarity level level,
+// whose next line is the first line at or after startLine.
+func newSimilarClass(lines []int, level SimilarityLevel, startLine int) similarClass {
+	return similarClass{
+		lines: lines,
+		level: level,
+		next:  sort.SearchInts(lines, startLine),
+	}
+}
+
+// A distanceBatch collects the lines whose Levenshtein distances to a needle are calculated at once, so that
+// the needle's table of character positions only needs to be built once.
+type distanceBatch struct {
+	// needleLine is the needle.
+	needleLine *fileLine
+
+	// needle is the text of the needle.
+	needle []rune
+
+	// maxDist is the maximum Levenshtein distance between similar lines.
+	maxDist int
+
+	// lines are the lines added to the batch.
+	lines []*fileLine
+
+	// classLines are the line numbers of the equality classes of lines, in the same order.
+	classLines [][]int
+
+	// runes are the texts of lines, concatenated.
+	runes []rune
+
+	// ends are the end offsets into runes of the texts of lines, in the same order.
+	ends []int
+
+	// buf is a buffer for converting texts of lines into runes.
+	buf []rune
+
+	// needleBuf is a buffer for converting the text of the needle into runes.
+	needleBuf []rune
+
+	// candidatesBuf is a buffer for the texts of lines.
+	candidatesBuf [][]rune
+
+	opts *Options
+}
+
+// distanceBatchPool is used to allocate distanceBatches, so that their buffers are reused.
+var distanceBatchPool = sync.Pool{
+	New: func() any {
+		return &distanceBatch{}
+	},
+}
+
+// newDistanceBatch returns a new distanceBatch for needle, according to opts. It returns nil if the
+// distances to needle cannot be calculated in a batch.
+func newDistanceBatch(needle *fileLine, opts *Options) *distanceBatch {
+	if needle.flagSet(slowLevenshteinLineFlag) || opts.LineMetric == JaroWinklerLineMetric || opts.flagSet(WordDistanceFlag) {
+		return nil
+	}
+
+	batch := distanceBatchPool.Get().(*distanceBatch) //nolint:forcetypeassert // we know what's in the pool
+
+	batch.needleLine = needle
+	batch.needle = needle.runes(&batch.needleBuf, opts)
+	batch.maxDist = maxEditDistance(opts)
+	batch.lines = batch.lines[:0]
+	batch.classLines = batch.classLines[:0]
+	batch.runes = batch.runes[:0]
+	batch.ends = batch.ends[:0]
+	batch.opts = opts
+
+	if len(batch.needle) == 0 || len(batch.needle) > 64 {
+		batch.release()
+		return nil
+	}
+
+	return batch
+}
+
+// release returns b to the pool. b must not be used afterwards.
+func (b *distanceBatch) release() {
+	// don't keep lines alive
+	clear(b.lines)
+	clear(b.classLines)
+	clear(b.candidatesBuf)
+
+	b.needleLine = nil
+	b.needle = nil
+	b.opts = nil
+
+	distanceBatchPool.Put(b)
+}
+
+// add adds line, the first line of the equality class of lines with line numbers classLines, to b, and returns
+// whether it was added. A nil b does not add any lines. Lines whose similarity levels to the needle are already
+// cached are not added, so that the caller looks them up instead.
+func (b *distanceBatch) add(line *fileLine, classLines []int) bool {
+	if b == nil || line.flagSet(slowLevenshteinLineFlag) {
+		return false
+	}
+
+	if b.opts.lineCache != nil {
+		if _, ok := b.opts.lineCache.get(newLinePairKey(line.hash, b.needleLine.hash)); ok {
+			return false
+		}
+	}
+
+	b.lines = append(b.lines, line)
+	b.classLines = append(b.classLines, classLines)
+	b.runes = append(b.runes, line.runes(&b.buf, b.opts)...)
+	b.ends = append(b.ends, len(b.runes))
+
+	return true
+}
+
+// candidates returns the texts of the lines of b, in the same order.
+func (b *distanceBatch) candidates() [][]rune {
+	b.candidatesBuf = b.candidatesBuf[:0]
+
+	start := 0
+
+	for _, end := range b.ends {
+		b.candidatesBuf = append(b.candidatesBuf, b.runes[start:end:end])
+		start = end
+	}
+
+	return b.candidatesBuf
+}
@@ -0,0 +1,96 @@
+package textsimilarity
+
+import (
+	"context"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestLineTable_Classes(t *testing.T) {
+	is := is.New(t)
+
+	opts := Options{Flags: IgnoreWhitespaceFlag}
+	lines := newLineTable()
+
+	line1 := lines.line("aaaaaaaaaa", &opts)
+	line2 := lines.line("  aaaaaaaaaa", &opts)
+	line3 := lines.line("bbbbbbbbbb", &opts)
+
+	is.True(line1 != line2)
+	is.Equal(line1.class, line1)
+	is.Equal(line2.class, line1)
+	is.Equal(line3.class, line3)
+	is.Equal(linesSimilarity(line1, line2, &opts), EqualSimilarityLevel)
+
+	opts = Options{}
+	lines = newLineTable()
+
+	line1 = lines.line("aaaaaaaaaa", &opts)
+	line2 = lines.line("  aaaaaaaaaa", &opts)
+
+	is.Equal(line2.class, line2)
+}
+
+func TestClassOccurrences(t *testing.T) {
+	is := is.New(t)
+
+	opts := Options{MaxEditDistance: 2}
+
+	file := newFile("a.txt", "}\naaaaaaaaaa\n}\naaaaaaaaab\n}\nbbbbbbbbbb\naaaaaaaaaa\n}\n")
+	is.NoErr(file.load(newLineTable(), &opts))
+	is.Equal(len(file.lineClasses), 4)
+
+	ftc := fileToCheck{
+		f:         file,
+		linesDone: newBitVector(file.lineCount),
+	}
+
+	ftc.linesDone.set(6, true)
+
+	buf := occurrenceBuffer{}
+
+	level := classOccurrences(context.Background(), &ftc, file.lines[1], 1, &buf, &opts)
+	is.Equal(level, SimilarSimilarityLevel)
+	is.Equal(len(buf.occs), 2)
+	is.Equal(buf.occs[0].Start, 1)
+	is.Equal(buf.occs[1].Start, 3)
+
+	buf.reset()
+
+	level = classOccurrences(context.Background(), &ftc, file.lines[0], 1, &buf, &opts)
+	is.Equal(level, EqualSimilarityLevel)
+	is.Equal(len(buf.occs), 3)
+	is.Equal(buf.occs[0].Start, 2)
+	is.Equal(buf.occs[1].Start, 4)
+	is.Equal(buf.occs[2].Start, 7)
+}
+
+func TestSimilarClasses_Batch(t *testing.T) {
+	is := is.New(t)
+
+	for _, lineCacheSize := range []int{0, 16} {
+		opts := Options{MaxEditDistance: 2}
+		if lineCacheSize > 0 {
+			opts.lineCache = newLineCache(lineCacheSize)
+		}
+
+		file := newFile("a.txt", "äääääääääa\naaaaaaaaaa\näääääääääb\nbbbbbbbbbb\näääääääääa\n")
+		is.NoErr(file.load(newLineTable(), &opts))
+
+		ftc := fileToCheck{
+			f:         file,
+			linesDone: newBitVector(file.lineCount),
+		}
+
+		// twice, so that the second time uses the cache, if any
+		for i := 0; i < 2; i++ {
+			classes := similarClasses(&ftc, file.lines[0], 0, &opts)
+			is.Equal(len(classes), 2)
+
+			for _, class := range classes {
+				is.Equal(class.level, linesSimilarity(file.lines[class.lines[0]], file.lines[0], &opts))
+			}
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package textsimilarity
+
+// setLineLevels sets sim.LineLevels by comparing the corresponding lines of sim's occurrences, according to opts.
+// It is left nil if the lines of the occurrences do not correspond to each other. The lines of all files of sim's
+// occurrences must be loaded.
+func setLineLevels(sim *Similarity, opts *Options) {
+	sim.LineLevels = nil
+
+	if sim.Level == ReorderedSimilarityLevel {
+		return
+	}
+
+	first := occurrenceLines(sim.Occurrences[0], opts)
+
+	levels := make([]SimilarityLevel, len(first))
+	for idx := range levels {
+		levels[idx] = EqualSimilarityLevel
+	}
+
+	if sim.Level == EqualSimilarityLevel {
+		sim.LineLevels = levels
+		return
+	}
+
+	for _, occ := range sim.Occurrences[1:] {
+		lines := occurrenceLines(occ, opts)
+		if len(lines) != len(first) {
+			return
+		}
+
+		for idx, line := range lines {
+			level := linesSimilarity(first[idx], line, opts)
+
+			// paraphrased similarities may contain lines that are completely different
+			if level == differentSimilarityLevel {
+				return
+			}
+
+			levels[idx] = min(levels[idx], level)
+		}
+	}
+
+	sim.LineLevels = levels
+}
@@ -0,0 +1,40 @@
+package textsimilarity
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestSimilarities_LineLevels(t *testing.T) {
+	is := is.New(t)
+
+	files := []*File{
+		newFile("1.txt", "alpha bravo charlie\n\ndelta echo foxtrot\ngolf hotel india\n"),
+		newFile("2.txt", "alpha bravo charlie\n\ndelta echo foxtrt\ngolf hotel india\n"),
+	}
+
+	sims := similaritiesWithOptions(t, files, &Options{
+		Flags:           IgnoreBlankLinesFlag,
+		MinSimilarLines: 3,
+		MaxEditDistance: 2,
+	})
+
+	is.Equal(len(sims), 1)
+	is.Equal(sims[0].Level, SimilarSimilarityLevel)
+	is.Equal(sims[0].LineLevels, []SimilarityLevel{EqualSimilarityLevel, SimilarSimilarityLevel, EqualSimilarityLevel})
+}
+
+func TestSimilarities_LineLevelsEqual(t *testing.T) {
+	is := is.New(t)
+
+	files := []*File{
+		newFile("1.txt", "alpha bravo charlie\ndelta echo foxtrot\ngolf hotel india\n"),
+		newFile("2.txt", "alpha bravo charlie\ndelta echo foxtrot\ngolf hotel india\n"),
+	}
+
+	sims := similaritiesWithOptions(t, files, &Options{MinSimilarLines: 3})
+	is.Equal(len(sims), 1)
+	is.Equal(sims[0].Level, EqualSimilarityLevel)
+	is.Equal(sims[0].LineLevels, []SimilarityLevel{EqualSimilarityLevel, EqualSimilarityLevel, EqualSimilarityLevel})
+}
@@ -0,0 +1,161 @@
+package textsimilarity
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	tsio "github.com/blizzy78/textsimilarity/internal/io"
+)
+
+// ErrInvalidUTF8 is wrapped by a LoadError when a line of a file is not valid UTF-8 text.
+var ErrInvalidUTF8 = errors.New("invalid UTF-8")
+
+// A LoadError is an error that occurred while loading a line of a file.
+type LoadError struct {
+	// File is the file that failed to load.
+	File *File
+
+	// Line is the number of the line that failed to load (one-based.)
+	Line int
+
+	// Err is the error that occurred.
+	Err error
+}
+
+// A LoadedFile is a file whose contents have been read, so that it can be scanned by multiple calls to
+// Similarities, possibly with different Options, without reading it again. Its lines, processed according to
+// the options affecting them, such as IgnoreWhitespaceFlag and LineTransforms, are kept in memory and reused as
+// long as those options are unchanged. Since they stay in memory, they are never spilled according to
+// Options.MaxLinesMemory. A LoadedFile is safe for concurrent use.
+type LoadedFile struct {
+	// file is the file that has been loaded, with Lines set to its lines.
+	file File
+
+	// lock guards linesKey and lines.
+	lock sync.Mutex
+
+	// linesKey is the key of the options lines have been processed with.
+	linesKey string
+
+	// lines are the processed lines, or nil if no lines have been processed yet.
+	lines []*fileLine
+}
+
+// Load reads the contents of f and returns a LoadedFile for it. f is not modified, but its R is read until EOF.
+// Unlike Similarities, Load also checks that all lines are valid UTF-8 text. If reading or checking a line fails,
+// a *LoadError is returned.
+func Load(f *File) (*LoadedFile, error) {
+	loaded := LoadedFile{file: *f}
+
+	if f.Lines != nil {
+		for lineIdx, text := range f.Lines {
+			if !utf8.ValidString(text) {
+				return nil, &LoadError{File: f, Line: lineIdx + 1, Err: ErrInvalidUTF8}
+			}
+		}
+
+		return &loaded, nil
+	}
+
+	lines := []string{}
+
+	reader := bufio.NewReader(f.R)
+	buf := bytes.Buffer{}
+
+	for {
+		text, err := tsio.ReadLine(reader, &buf)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return nil, &LoadError{File: f, Line: len(lines) + 1, Err: err}
+		}
+
+		if !utf8.ValidString(text) {
+			return nil, &LoadError{File: f, Line: len(lines) + 1, Err: ErrInvalidUTF8}
+		}
+
+		lines = append(lines, text)
+	}
+
+	loaded.file.R = nil
+	loaded.file.Open = nil
+	loaded.file.Lines = lines
+
+	return &loaded, nil
+}
+
+// File returns a new File with the contents of l, to be passed to Similarities. A File must not be passed to
+// multiple calls to Similarities, but any number of Files may be returned for the same LoadedFile.
+func (l *LoadedFile) File() *File {
+	f := l.file
+	f.loaded = l
+
+	return &f
+}
+
+// Preload processes the lines of l according to opts ahead of time, so that Similarities does not need to
+// process them if it is called with options that affect lines in the same way.
+func (l *LoadedFile) Preload(opts *Options) {
+	l.processedLines(opts)
+}
+
+// processedLines returns the lines of l, processed according to opts. Lines are only processed again if the
+// options affecting them have changed since the last call.
+func (l *LoadedFile) processedLines(opts *Options) []*fileLine {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	key := lineOptionsKey(opts)
+	if l.lines != nil && key == l.linesKey {
+		return l.lines
+	}
+
+	lines := make([]*fileLine, len(l.file.Lines))
+	table := newLineTable()
+
+	for lineIdx, text := range l.file.Lines {
+		lines[lineIdx] = table.line(text, opts)
+	}
+
+	l.linesKey = key
+	l.lines = lines
+
+	return lines
+}
+
+// lineOptionsKey returns a key of all options in opts that affect how individual lines are processed. LineWeights
+// are compared by identity.
+func lineOptionsKey(opts *Options) string {
+	key := strings.Builder{}
+
+	_, _ = fmt.Fprintf(&key, "%d\x00%q\x00%p", opts.Flags&(IgnoreWhitespaceFlag|MaskLiteralsFlag|WordDistanceFlag),
+		opts.TrailingCommentMarkers, opts.LineWeights)
+
+	if opts.IgnoreLineRegex != nil {
+		_, _ = fmt.Fprintf(&key, "\x00ignore=%s", opts.IgnoreLineRegex)
+	}
+
+	for _, transform := range opts.LineTransforms {
+		_, _ = fmt.Fprintf(&key, "\x00transform=%s\x00%s", transform.Regex, transform.Replacement)
+	}
+
+	return key.String()
+}
+
+// Error implements error.
+func (e *LoadError) Error() string {
+	return fmt.Sprintf("load %s, line %d: %v", e.File.Name, e.Line, e.Err)
+}
+
+// Unwrap returns e.Err.
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
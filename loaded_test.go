@@ -0,0 +1,98 @@
+package textsimilarity
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/matryer/is"
+)
+
+var errTest = errors.New("test")
+
+func TestLoad(t *testing.T) {
+	is := is.New(t)
+
+	text := "aaaaaaaaaa\nbbbbbbbbbb\ncccccccccc\n"
+
+	loaded1, err := Load(newFile("1.txt", text))
+	is.NoErr(err)
+
+	loaded2, err := Load(&File{Name: "2.txt", Lines: strings.Split(strings.TrimSuffix(text, "\n"), "\n")})
+	is.NoErr(err)
+
+	sims := similaritiesWithOptions(t, []*File{loaded1.File(), loaded2.File()}, &Options{MinSimilarLines: 3})
+	is.Equal(len(sims), 1)
+	is.Equal(sims[0].Occurrences[0].File.Name, "1.txt")
+	is.Equal(sims[0].Occurrences[0].End, 3)
+
+	lines := loaded1.lines
+
+	// lines are reused if options affecting them are unchanged
+	sims = similaritiesWithOptions(t, []*File{loaded1.File(), loaded2.File()}, &Options{MinSimilarLines: 4})
+	is.Equal(len(sims), 0)
+	is.Equal(loaded1.lines[0], lines[0])
+
+	// lines are processed again otherwise
+	sims = similaritiesWithOptions(t, []*File{loaded1.File(), loaded2.File()}, &Options{Flags: IgnoreWhitespaceFlag, MinSimilarLines: 3})
+	is.Equal(len(sims), 1)
+	is.True(loaded1.lines[0] != lines[0])
+}
+
+func TestLoad_InvalidUTF8(t *testing.T) {
+	is := is.New(t)
+
+	file := newFile("1.txt", "aaaaaaaaaa\nbbbb\xffbbbb\n")
+
+	_, err := Load(file)
+
+	loadErr := &LoadError{}
+	is.True(errors.As(err, &loadErr))
+	is.Equal(loadErr.File, file)
+	is.Equal(loadErr.Line, 2)
+	is.True(errors.Is(err, ErrInvalidUTF8))
+	is.Equal(err.Error(), "load 1.txt, line 2: invalid UTF-8")
+
+	_, err = Load(&File{Name: "2.txt", Lines: []string{"\xff"}})
+	is.True(errors.Is(err, ErrInvalidUTF8))
+}
+
+func TestLoad_ReadError(t *testing.T) {
+	is := is.New(t)
+
+	file := &File{Name: "1.txt", R: io.MultiReader(strings.NewReader("aaaaaaaaaa\n"), iotest.ErrReader(errTest))}
+
+	_, err := Load(file)
+
+	loadErr := &LoadError{}
+	is.True(errors.As(err, &loadErr))
+	is.Equal(loadErr.Line, 2)
+	is.True(errors.Is(err, errTest))
+
+	_, _, err = Similarities(context.Background(), []*File{
+		{Name: "2.txt", R: io.MultiReader(strings.NewReader("aaaaaaaaaa\n"), iotest.ErrReader(errTest))},
+	}, &Options{})
+	is.True(errors.As(err, &loadErr))
+	is.Equal(loadErr.File.Name, "2.txt")
+	is.Equal(loadErr.Line, 2)
+}
+
+func TestLoadedFile_Preload(t *testing.T) {
+	is := is.New(t)
+
+	loaded, err := Load(newFile("1.txt", "aaaaaaaaaa\n"))
+	is.NoErr(err)
+
+	opts := Options{}
+	loaded.Preload(&opts)
+
+	lines := loaded.lines
+	is.Equal(len(lines), 1)
+
+	file := loaded.File()
+	is.NoErr(file.load(nil, &opts))
+	is.Equal(file.lines[0], lines[0])
+}
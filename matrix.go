@@ -0,0 +1,227 @@
+package textsimilarity
+
+import "sort"
+
+// A SimilarityMatrix holds the fraction of lines shared by each pair of files, for example for scoring
+// plagiarism across submissions.
+type SimilarityMatrix struct {
+	// Files are the files of the matrix, in the same order as passed to NewSimilarityMatrix.
+	Files []*File
+
+	// Fractions are the fractions of lines shared by each pair of files (0-1.) Fractions[i][j] is the number of
+	// lines of Files[i] and Files[j] covered by similarities that occur in both files, in relation to all lines of
+	// both files. The matrix is symmetric, and Fractions[i][i] is 1.
+	Fractions [][]float64
+}
+
+// A matrixPair is a pair of files in a SimilarityMatrix, by index. The lines of file are covered by similarities
+// that also occur in other.
+type matrixPair struct {
+	file  int
+	other int
+}
+
+// NewSimilarityMatrix returns the similarity matrix of files, in which sims have been found. files and sims
+// must have been passed to and returned by Similarities, respectively.
+func NewSimilarityMatrix(files []*File, sims []*Similarity) *SimilarityMatrix {
+	fileIndexes := make(map[*File]int, len(files))
+	for idx, file := range files {
+		fileIndexes[file] = idx
+	}
+
+	// covered are the distinct lines of each file that are covered by similarities that also occur in another file
+	covered := map[matrixPair]map[int]struct{}{}
+
+	for _, sim := range sims {
+		for _, occ := range sim.Occurrences {
+			fileIdx, ok := fileIndexes[occ.File]
+			if !ok {
+				continue
+			}
+
+			for _, occ2 := range sim.Occurrences {
+				otherIdx, ok := fileIndexes[occ2.File]
+				if !ok || otherIdx == fileIdx {
+					continue
+				}
+
+				pair := matrixPair{
+					file:  fileIdx,
+					other: otherIdx,
+				}
+
+				lines, ok := covered[pair]
+				if !ok {
+					lines = map[int]struct{}{}
+					covered[pair] = lines
+				}
+
+				for l := occ.Start; l < occ.End; l++ {
+					lines[l] = struct{}{}
+				}
+			}
+		}
+	}
+
+	matrix := SimilarityMatrix{
+		Files:     files,
+		Fractions: make([][]float64, len(files)),
+	}
+
+	for idx := range files {
+		matrix.Fractions[idx] = make([]float64, len(files))
+		matrix.Fractions[idx][idx] = 1
+	}
+
+	for idx1, file1 := range files {
+		for idx2 := idx1 + 1; idx2 < len(files); idx2++ {
+			totalLines := file1.LineCount() + files[idx2].LineCount()
+			if totalLines == 0 {
+				continue
+			}
+
+			sharedLines := len(covered[matrixPair{file: idx1, other: idx2}]) + len(covered[matrixPair{file: idx2, other: idx1}])
+			fraction := float64(sharedLines) / float64(totalLines)
+
+			matrix.Fractions[idx1][idx2] = fraction
+			matrix.Fractions[idx2][idx1] = fraction
+		}
+	}
+
+	return &matrix
+}
+
+const (
+	// SingleLinkage uses the highest similarity between any files of two clusters as their similarity.
+	SingleLinkage = Linkage(iota)
+
+	// CompleteLinkage uses the lowest similarity between any files of two clusters as their similarity.
+	CompleteLinkage
+
+	// AverageLinkage uses the average similarity between all pairs of files of two clusters as their similarity.
+	AverageLinkage
+)
+
+// A Linkage determines the similarity of two clusters of files, based on the similarities of their files.
+type Linkage int
+
+// A FileCluster is a group of files that are similar to each other.
+type FileCluster struct {
+	// Files are the files in the cluster, in the order of the similarity matrix.
+	Files []*File
+
+	// Similarity is the similarity of the two clusters that have been merged into this cluster, according
+	// to the linkage used.
+	Similarity float64
+}
+
+// Clusters groups the files of m into clusters using agglomerative hierarchical clustering: starting with each
+// file in its own cluster, the two most similar clusters according to linkage are merged repeatedly, as long as
+// their similarity is at least minSimilarity (0-1), and greater than 0. Only clusters of two or more files are returned, sorted by
+// number of files (descending), and then by the order of their first files in m. Run time is cubic in the number
+// of files.
+func (m *SimilarityMatrix) Clusters(linkage Linkage, minSimilarity float64) []*FileCluster {
+	// members are the indexes of the files in each cluster, nil for clusters that have been merged into others
+	members := make([][]int, len(m.Files))
+
+	// similarities are the similarities between clusters
+	similarities := make([][]float64, len(m.Files))
+
+	mergedSimilarities := make([]float64, len(m.Files))
+
+	for idx := range m.Files {
+		members[idx] = []int{idx}
+		similarities[idx] = make([]float64, len(m.Files))
+		copy(similarities[idx], m.Fractions[idx])
+	}
+
+	for {
+		best1, best2 := -1, -1
+		bestSimilarity := 0.0
+
+		for idx1 := range members {
+			if members[idx1] == nil {
+				continue
+			}
+
+			for idx2 := idx1 + 1; idx2 < len(members); idx2++ {
+				if members[idx2] == nil {
+					continue
+				}
+
+				if sim := similarities[idx1][idx2]; sim > 0 && sim >= minSimilarity && (best1 < 0 || sim > bestSimilarity) {
+					best1, best2 = idx1, idx2
+					bestSimilarity = sim
+				}
+			}
+		}
+
+		if best1 < 0 {
+			break
+		}
+
+		// update similarities of the merged cluster, which takes the place of best1
+		for idx := range members {
+			if members[idx] == nil || idx == best1 || idx == best2 {
+				continue
+			}
+
+			sim := linkage.merge(similarities[best1][idx], len(members[best1]), similarities[best2][idx], len(members[best2]))
+			similarities[best1][idx] = sim
+			similarities[idx][best1] = sim
+		}
+
+		members[best1] = append(members[best1], members[best2]...)
+		members[best2] = nil
+		mergedSimilarities[best1] = bestSimilarity
+	}
+
+	clusters := []*FileCluster{}
+
+	for idx, fileIdxs := range members {
+		if len(fileIdxs) < 2 {
+			continue
+		}
+
+		sort.Ints(fileIdxs)
+
+		cluster := FileCluster{
+			Files:      make([]*File, len(fileIdxs)),
+			Similarity: mergedSimilarities[idx],
+		}
+
+		for memberIdx, fileIdx := range fileIdxs {
+			cluster.Files[memberIdx] = m.Files[fileIdx]
+		}
+
+		clusters = append(clusters, &cluster)
+	}
+
+	fileIndexes := make(map[*File]int, len(m.Files))
+	for idx, file := range m.Files {
+		fileIndexes[file] = idx
+	}
+
+	sort.SliceStable(clusters, func(a int, b int) bool {
+		if len(clusters[a].Files) != len(clusters[b].Files) {
+			return len(clusters[a].Files) > len(clusters[b].Files)
+		}
+
+		return fileIndexes[clusters[a].Files[0]] < fileIndexes[clusters[b].Files[0]]
+	})
+
+	return clusters
+}
+
+// merge returns the similarity of a cluster, merged from two clusters of sizes size1 and size2, to another cluster,
+// given the similarities sim1 and sim2 of the two clusters to the other cluster.
+func (l Linkage) merge(sim1 float64, size1 int, sim2 float64, size2 int) float64 {
+	switch l {
+	case CompleteLinkage:
+		return min(sim1, sim2)
+	case AverageLinkage:
+		return (sim1*float64(size1) + sim2*float64(size2)) / float64(size1+size2)
+	default:
+		return max(sim1, sim2)
+	}
+}
@@ -0,0 +1,59 @@
+package textsimilarity
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestNewSimilarityMatrix(t *testing.T) {
+	is := is.New(t)
+
+	file1 := newFile("1.txt", "aaaaaaaaaa\nbbbbbbbbbb\nxxxxxxxxxx\nyyyyyyyyyy\n")
+	file2 := newFile("2.txt", "aaaaaaaaaa\nbbbbbbbbbb\n")
+	file3 := newFile("3.txt", "wwwwwwwwww\n")
+
+	files := []*File{file1, file2, file3}
+
+	matrix := NewSimilarityMatrix(files, similaritiesWithOptions(t, files, &Options{MinSimilarLines: 2}))
+
+	is.Equal(matrix.Files, files)
+	is.Equal(matrix.Fractions, [][]float64{
+		{1, 4.0 / 6.0, 0},
+		{4.0 / 6.0, 1, 0},
+		{0, 0, 1},
+	})
+}
+
+func TestSimilarityMatrix_Clusters(t *testing.T) {
+	is := is.New(t)
+
+	files := []*File{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}, {Name: "e"}}
+
+	matrix := SimilarityMatrix{
+		Files: files,
+		Fractions: [][]float64{
+			{1, 0.9, 0.5, 0, 0},
+			{0.9, 1, 0.2, 0, 0},
+			{0.5, 0.2, 1, 0, 0},
+			{0, 0, 0, 1, 0.6},
+			{0, 0, 0, 0.6, 1},
+		},
+	}
+
+	clusters := matrix.Clusters(SingleLinkage, 0.4)
+	is.Equal(len(clusters), 2)
+	is.Equal(clusters[0].Files, []*File{files[0], files[1], files[2]})
+	is.Equal(clusters[0].Similarity, 0.5)
+	is.Equal(clusters[1].Files, []*File{files[3], files[4]})
+
+	clusters = matrix.Clusters(CompleteLinkage, 0.4)
+	is.Equal(len(clusters), 2)
+	is.Equal(clusters[0].Files, []*File{files[0], files[1]})
+	is.Equal(clusters[0].Similarity, 0.9)
+
+	clusters = matrix.Clusters(AverageLinkage, 0.3)
+	is.Equal(len(clusters), 2)
+	is.Equal(clusters[0].Files, []*File{files[0], files[1], files[2]})
+	is.Equal(clusters[0].Similarity, 0.35)
+}
@@ -0,0 +1,197 @@
+package textsimilarity
+
+import (
+	"context"
+	"math"
+	"sort"
+)
+
+// A NearDuplicate is a pair of files that are near-duplicates of each other as a whole.
+type NearDuplicate struct {
+	File1 *File
+	File2 *File
+
+	// Similarity is the number of identical lines of both files, in relation to the number of lines of the
+	// larger file (0-1.) For pairs returned by FuzzyDuplicates, it is the match score of the files' fuzzy hashes
+	// instead.
+	Similarity float64
+}
+
+// NearDuplicates returns all pairs of files whose similarity as a whole is at least minSimilarity (0-1), sorted
+// by similarity (descending), and then by file names. The similarity of two files is the number of their identical
+// lines, regardless of order, in relation to the number of lines of the larger file. Lines are compared and
+// considered according to opts, as in Similarities, but no blocks of similar lines are detected, which is much
+// faster when only whole files are of interest. Pairs of reference-only files and excluded pairs of files are
+// not compared. minSimilarity must be greater than 0.
+//
+// File.LineCount is valid for all files after NearDuplicates returns.
+//
+// To avoid comparing all pairs of files, only files sharing at least one line in a prefix of their lines, in
+// a global order of lines from rare to frequent, are compared. This finds all pairs whose similarity can reach
+// minSimilarity.
+func NearDuplicates(ctx context.Context, files []*File, minSimilarity float64, opts *Options) ([]*NearDuplicate, error) {
+	// tokens are the hashes of the lines of each file considered for similarities, with repeated lines made
+	// distinct, so that files can be compared as sets
+	tokens := make([][]uint64, len(files))
+	frequencies := map[uint64]int{}
+
+	for fileIdx, file := range files {
+		if err := file.load(nil, opts); err != nil {
+			return nil, err
+		}
+
+		tokens[fileIdx] = lineTokens(file, opts)
+
+		for _, token := range tokens[fileIdx] {
+			frequencies[token]++
+		}
+
+		file.lines = nil
+		file.linesByHash = nil
+		file.lineClasses = nil
+	}
+
+	// order tokens from rare to frequent
+	for _, fileTokens := range tokens {
+		sort.Slice(fileTokens, func(a int, b int) bool {
+			return tokenLess(fileTokens[a], fileTokens[b], frequencies)
+		})
+	}
+
+	pairMatches := newFilePairMatches(files, opts)
+
+	// index maps tokens to the files having them in their prefixes
+	index := map[uint64][]int{}
+
+	dupes := []*NearDuplicate{}
+
+	for fileIdx, file := range files {
+		if contextDone(ctx) {
+			return nil, ctx.Err()
+		}
+
+		fileTokens := tokens[fileIdx]
+		if len(fileTokens) == 0 {
+			continue
+		}
+
+		// files can only reach minSimilarity if they share at least one token in their prefixes
+		prefixLen := len(fileTokens) - int(math.Ceil(minSimilarity*float64(len(fileTokens)))) + 1
+		prefixLen = min(max(prefixLen, 0), len(fileTokens))
+
+		candidates := map[int]struct{}{}
+
+		for _, token := range fileTokens[:prefixLen] {
+			for _, otherIdx := range index[token] {
+				candidates[otherIdx] = struct{}{}
+			}
+
+			index[token] = append(index[token], fileIdx)
+		}
+
+		for otherIdx := range candidates {
+			other := files[otherIdx]
+
+			if (file.ReferenceOnly && other.ReferenceOnly) || pairMatches.excluded(otherIdx, fileIdx) {
+				continue
+			}
+
+			// files of very different sizes can never reach minSimilarity
+			otherLen := len(tokens[otherIdx])
+			if float64(min(otherLen, len(fileTokens))) < minSimilarity*float64(max(otherLen, len(fileTokens))) {
+				continue
+			}
+
+			similarity := tokensSimilarity(fileTokens, tokens[otherIdx], frequencies)
+			if similarity < minSimilarity {
+				continue
+			}
+
+			dupes = append(dupes, &NearDuplicate{
+				File1:      other,
+				File2:      file,
+				Similarity: similarity,
+			})
+		}
+	}
+
+	sortNearDuplicates(dupes)
+
+	return dupes, nil
+}
+
+// sortNearDuplicates sorts dupes by similarity (descending), and then by file names.
+func sortNearDuplicates(dupes []*NearDuplicate) {
+	sort.SliceStable(dupes, func(a int, b int) bool {
+		dupe1 := dupes[a]
+		dupe2 := dupes[b]
+
+		if dupe1.Similarity != dupe2.Similarity {
+			return dupe1.Similarity > dupe2.Similarity
+		}
+
+		if dupe1.File1.Name != dupe2.File1.Name {
+			return dupe1.File1.Name < dupe2.File1.Name
+		}
+
+		return dupe1.File2.Name < dupe2.File2.Name
+	})
+}
+
+// lineTokens returns the hashes of the lines of f that are considered for similarities, according to opts and
+// f's scopes.
+// Repeated lines are made distinct by mixing in the number of times they have been seen before. The lines of
+// f must be loaded.
+func lineTokens(f *File, opts *Options) []uint64 {
+	tokens := make([]uint64, 0, f.lineCount)
+	seen := map[uint64]uint64{}
+
+	for lineIdx := 0; lineIdx < f.lineCount; lineIdx++ {
+		line := f.lines[lineIdx]
+		if !acceptLine(line, opts) || !f.inScope(lineIdx) {
+			continue
+		}
+
+		count := seen[line.hash]
+		seen[line.hash] = count + 1
+
+		tokens = append(tokens, mixHash(line.hash+count*fnvPrime64))
+	}
+
+	return tokens
+}
+
+// tokensSimilarity returns the number of tokens shared by tokens1 and tokens2, in relation to the number of tokens
+// of the larger one. Both must be sorted using tokenLess.
+func tokensSimilarity(tokens1 []uint64, tokens2 []uint64, frequencies map[uint64]int) float64 {
+	shared := 0
+
+	for idx1, idx2 := 0, 0; idx1 < len(tokens1) && idx2 < len(tokens2); {
+		switch {
+		case tokens1[idx1] == tokens2[idx2]:
+			shared++
+			idx1++
+			idx2++
+
+		case tokenLess(tokens1[idx1], tokens2[idx2], frequencies):
+			idx1++
+
+		default:
+			idx2++
+		}
+	}
+
+	return float64(shared) / float64(max(len(tokens1), len(tokens2)))
+}
+
+// tokenLess returns whether token1 comes before token2 in the global order of tokens, from rare to frequent.
+func tokenLess(token1 uint64, token2 uint64, frequencies map[uint64]int) bool {
+	freq1 := frequencies[token1]
+	freq2 := frequencies[token2]
+
+	if freq1 != freq2 {
+		return freq1 < freq2
+	}
+
+	return token1 < token2
+}
@@ -0,0 +1,91 @@
+package textsimilarity
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestNearDuplicates(t *testing.T) {
+	is := is.New(t)
+
+	file1 := newFile("1.txt", "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n")
+	file2 := newFile("2.txt", "a\nb\nc\nd\ne\nf\ng\nh\ni\nx\n")
+	file3 := newFile("3.txt", "j\ni\nh\ng\nf\ne\nd\nc\nb\na\n")
+	file4 := newFile("4.txt", "a\nb\nc\nx\ny\nz\n")
+
+	dupes, err := NearDuplicates(context.Background(), []*File{file1, file2, file3, file4}, 0.9, &Options{})
+	is.NoErr(err)
+
+	is.Equal(len(dupes), 3)
+
+	is.Equal(dupes[0].File1, file1)
+	is.Equal(dupes[0].File2, file3)
+	is.Equal(dupes[0].Similarity, 1.0)
+
+	is.Equal(dupes[1].File1, file1)
+	is.Equal(dupes[1].File2, file2)
+	is.Equal(dupes[1].Similarity, 0.9)
+
+	is.Equal(dupes[2].File1, file2)
+	is.Equal(dupes[2].File2, file3)
+	is.Equal(dupes[2].Similarity, 0.9)
+
+	is.Equal(file4.LineCount(), 6)
+}
+
+func TestNearDuplicates_RepeatedLines(t *testing.T) {
+	is := is.New(t)
+
+	file1 := newFile("1.txt", "a\na\na\na\nb\n")
+	file2 := newFile("2.txt", "a\nb\nb\nb\nb\n")
+
+	dupes, err := NearDuplicates(context.Background(), []*File{file1, file2}, 0.4, &Options{})
+	is.NoErr(err)
+
+	is.Equal(len(dupes), 1)
+	is.Equal(dupes[0].Similarity, 0.4)
+}
+
+func TestNearDuplicates_Options(t *testing.T) {
+	is := is.New(t)
+
+	file1 := newFile("1.txt", "a\n\nb\n\nc\n")
+	file2 := newFile("2.txt", "  a\nb\n  c\n")
+
+	dupes, err := NearDuplicates(context.Background(), []*File{file1, file2}, 1.0, &Options{})
+	is.NoErr(err)
+	is.Equal(len(dupes), 0)
+
+	file1 = newFile("1.txt", "a\n\nb\n\nc\n")
+	file2 = newFile("2.txt", "  a\nb\n  c\n")
+
+	dupes, err = NearDuplicates(context.Background(), []*File{file1, file2}, 1.0, &Options{
+		Flags: IgnoreWhitespaceFlag | IgnoreBlankLinesFlag,
+	})
+	is.NoErr(err)
+	is.Equal(len(dupes), 1)
+}
+
+func TestNearDuplicates_Excluded(t *testing.T) {
+	is := is.New(t)
+
+	file1 := newFile("1.txt", "a\nb\nc\n")
+	file2 := newFile("2.txt", "a\nb\nc\n")
+	file2.ReferenceOnly = true
+	file3 := newFile("3.txt", "a\nb\nc\n")
+	file3.ReferenceOnly = true
+
+	dupes, err := NearDuplicates(context.Background(), []*File{file1, file2, file3}, 1.0, &Options{
+		ExcludedFilePairs: []FilePair{
+			{Name1: regexp.MustCompile(`^1`), Name2: regexp.MustCompile(`^2`)},
+		},
+	})
+	is.NoErr(err)
+
+	is.Equal(len(dupes), 1)
+	is.Equal(dupes[0].File1, file1)
+	is.Equal(dupes[0].File2, file3)
+}
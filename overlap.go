@@ -0,0 +1,147 @@
+package textsimilarity
+
+import "sort"
+
+// finish emits the similarities selected from all similarities found so far to emit, if Options.OverlapPolicy is
+// KeepLargestOverlapPolicy. Similarities are selected by the number of lines they cover (descending), dropping those
+// that overlap similarities selected earlier, and are then emitted in the order they have been found.
+func (e *similarityEmitter) finish(emit func(*Similarity)) {
+	if e.opts.OverlapPolicy != KeepLargestOverlapPolicy {
+		return
+	}
+
+	order := make([]int, len(e.found))
+	for idx := range order {
+		order[idx] = idx
+	}
+
+	sort.SliceStable(order, func(a int, b int) bool {
+		return coveredLines(e.found[order[a]]) > coveredLines(e.found[order[b]])
+	})
+
+	selected := make([]bool, len(e.found))
+
+	for _, idx := range order {
+		sim := e.found[idx]
+		if e.covered(sim) {
+			continue
+		}
+
+		e.cover(sim)
+		selected[idx] = true
+	}
+
+	for idx, sim := range e.found {
+		if selected[idx] {
+			emit(sim)
+		}
+	}
+}
+
+// coveredLines returns the total number of lines covered by the occurrences of sim.
+func coveredLines(sim *Similarity) int {
+	lines := 0
+	for _, occ := range sim.Occurrences {
+		lines += occ.End - occ.Start
+	}
+
+	return lines
+}
+
+// split returns the parts of sim that do not cover any lines covered by similarities emitted earlier, and that
+// still have enough lines, according to Options. Parts are prepared like sim, and keep its level. Only similarities
+// whose occurrences consist of corresponding lines can be split, so nil is returned for other similarities.
+// The lines of all files of sim's occurrences must be loaded.
+func (e *similarityEmitter) split(sim *Similarity) []*Similarity {
+	if !correspondingRows(sim, e.opts) {
+		return nil
+	}
+
+	rows := sim.Occurrences[0].End - sim.Occurrences[0].Start
+	parts := []*Similarity{}
+	partStart := -1
+
+	for row := 0; row <= rows; row++ {
+		if row < rows && !e.rowCovered(sim, row) {
+			if partStart < 0 {
+				partStart = row
+			}
+
+			continue
+		}
+
+		if partStart < 0 {
+			continue
+		}
+
+		if part := similarityPart(sim, partStart, row, e.opts); part != nil {
+			parts = append(parts, part)
+		}
+
+		partStart = -1
+	}
+
+	return parts
+}
+
+// correspondingRows returns whether the lines of all occurrences of sim at the same offsets correspond to each
+// other, that is, whether all occurrences have the same number of lines, and the same lines are considered for
+// similarities, according to opts.
+func correspondingRows(sim *Similarity, opts *Options) bool {
+	if sim.Level == ReorderedSimilarityLevel {
+		return false
+	}
+
+	first := sim.Occurrences[0]
+
+	for _, occ := range sim.Occurrences[1:] {
+		if occ.End-occ.Start != first.End-first.Start {
+			return false
+		}
+
+		for row := 0; row < occ.End-occ.Start; row++ {
+			if acceptLine(occ.File.lines[occ.Start+row], opts) != acceptLine(first.File.lines[first.Start+row], opts) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// rowCovered returns whether the line at offset row of any occurrence of sim is covered by similarities emitted
+// earlier.
+func (e *similarityEmitter) rowCovered(sim *Similarity, row int) bool {
+	for _, occ := range sim.Occurrences {
+		if e.fileLinesCovered(occ.File).isSet(occ.Start + row) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// similarityPart returns a prepared similarity of the lines of sim's occurrences from offset start to end
+// (exclusive), with sim's level, or nil if it does not have enough lines, according to opts.
+func similarityPart(sim *Similarity, start int, end int, opts *Options) *Similarity {
+	part := Similarity{
+		Occurrences: make([]*FileOccurrence, len(sim.Occurrences)),
+		Level:       sim.Level,
+	}
+
+	for idx, occ := range sim.Occurrences {
+		part.Occurrences[idx] = &FileOccurrence{
+			File:  occ.File,
+			Start: occ.Start + start,
+			End:   occ.Start + end,
+		}
+	}
+
+	if !part.Occurrences[0].enoughLines(opts) || !part.Occurrences[0].enoughChars(opts) {
+		return nil
+	}
+
+	prepareSimilarity(&part, opts)
+
+	return &part
+}
@@ -0,0 +1,102 @@
+package textsimilarity
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestSimilarities_OverlapPolicy(t *testing.T) {
+	lines := make([]string, 30)
+	for idx := range lines {
+		lines[idx] = fmt.Sprintf("%x", sha256.Sum256([]byte(fmt.Sprintf("line %d", idx))))
+	}
+
+	text := func(lines []string) string {
+		return strings.Join(lines, "\n") + "\n"
+	}
+
+	// a.txt and b.txt share lines 0-9, b.txt and c.txt share lines 5-16 of b.txt
+	texts := []string{
+		text(append(append([]string{}, lines[:10]...), lines[20:23]...)),
+		text(lines[:17]),
+		text(append(append([]string{}, lines[5:17]...), lines[25:28]...)),
+	}
+
+	tests := []struct {
+		policy OverlapPolicy
+		want   []string
+	}{
+		{DropOverlapPolicy, []string{"a.txt:0-10 b.txt:0-10", "b.txt:5-17 c.txt:0-12"}},
+		{KeepAllOverlapPolicy, []string{"a.txt:0-10 b.txt:0-10", "b.txt:5-17 c.txt:0-12"}},
+		{KeepLargestOverlapPolicy, []string{"b.txt:5-17 c.txt:0-12"}},
+		{SplitOverlapPolicy, []string{"a.txt:0-10 b.txt:0-10", "b.txt:10-17 c.txt:5-12"}},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%d", test.policy), func(t *testing.T) {
+			is := is.New(t)
+
+			files := []*File{
+				newFile("a.txt", texts[0]),
+				newFile("b.txt", texts[1]),
+				newFile("c.txt", texts[2]),
+			}
+
+			sims := similaritiesWithOptions(t, files, &Options{MinSimilarLines: 3, OverlapPolicy: test.policy})
+
+			got := make([]string, len(sims))
+			for idx, sim := range sims {
+				occs := make([]string, len(sim.Occurrences))
+				for occIdx, occ := range sim.Occurrences {
+					occs[occIdx] = fmt.Sprintf("%s:%d-%d", occ.File.Name, occ.Start, occ.End)
+				}
+
+				got[idx] = strings.Join(occs, " ")
+
+				is.True(sim.ID() != "")
+			}
+
+			is.Equal(got, test.want)
+		})
+	}
+}
+
+func TestSimilarities_OverlapPolicy_Default(t *testing.T) {
+	is := is.New(t)
+
+	lines := make([]string, 45)
+	for idx := range lines {
+		lines[idx] = fmt.Sprintf("%x", sha256.Sum256([]byte(fmt.Sprintf("line %d", idx))))
+	}
+
+	text := func(parts ...[]string) string {
+		all := []string{}
+		for _, part := range parts {
+			all = append(all, part...)
+		}
+
+		return strings.Join(all, "\n") + "\n"
+	}
+
+	// lines 0-19 are shared by a.txt and b.txt, lines 10-39 by b.txt and c.txt
+	files := []*File{
+		newFile("a.txt", text(lines[40:42], lines[:20])),
+		newFile("b.txt", text(lines[:15], lines[20:40])),
+		newFile("c.txt", text(lines[42:45], lines[10:15], lines[20:40])),
+	}
+
+	sims := similaritiesWithOptions(t, files, &Options{MinSimilarLines: 5})
+	is.Equal(len(sims), 2)
+
+	// the duplicate between b.txt and c.txt is reported even though it overlaps the one between a.txt and b.txt
+	is.Equal(sims[1].Occurrences[0].File.Name, "b.txt")
+	is.Equal(sims[1].Occurrences[0].Start, 10)
+	is.Equal(sims[1].Occurrences[0].End, 35)
+	is.Equal(sims[1].Occurrences[1].File.Name, "c.txt")
+	is.Equal(sims[1].Occurrences[1].Start, 3)
+	is.Equal(sims[1].Occurrences[1].End, 28)
+}
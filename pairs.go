@@ -0,0 +1,75 @@
+package textsimilarity
+
+import (
+	"path/filepath"
+	"regexp"
+)
+
+const (
+	// name1PairMatch is set when a file's name matches FilePair.Name1.
+	name1PairMatch = uint8(1 << iota)
+
+	// name2PairMatch is set when a file's name matches FilePair.Name2.
+	name2PairMatch
+)
+
+// A FilePair declares pairs of files that are never compared with each other, such as a file and its generated
+// mock, or tests and their fixtures. File names are matched using forward slashes as separators.
+type FilePair struct {
+	// Name1 matches the names of the files on one side of the pairs.
+	Name1 *regexp.Regexp
+
+	// Name2 matches the names of the files on the other side of the pairs.
+	Name2 *regexp.Regexp
+}
+
+// filePairMatches records which sides of Options.ExcludedFilePairs each file's name matches.
+type filePairMatches [][]uint8
+
+// newFilePairMatches returns the matches of the names of files against opts.ExcludedFilePairs. If there are
+// no excluded pairs, nil is returned.
+func newFilePairMatches(files []*File, opts *Options) filePairMatches {
+	if len(opts.ExcludedFilePairs) == 0 {
+		return nil
+	}
+
+	matches := make(filePairMatches, len(files))
+
+	for fileIdx, file := range files {
+		name := filepath.ToSlash(file.Name)
+		fileMatches := make([]uint8, len(opts.ExcludedFilePairs))
+
+		for pairIdx, pair := range opts.ExcludedFilePairs {
+			if pair.Name1.MatchString(name) {
+				fileMatches[pairIdx] |= name1PairMatch
+			}
+
+			if pair.Name2.MatchString(name) {
+				fileMatches[pairIdx] |= name2PairMatch
+			}
+		}
+
+		matches[fileIdx] = fileMatches
+	}
+
+	return matches
+}
+
+// excluded returns whether the files at fileIdx1 and fileIdx2 must not be compared with each other.
+// A file is never excluded from being compared with itself.
+func (m filePairMatches) excluded(fileIdx1 int, fileIdx2 int) bool {
+	if m == nil || fileIdx1 == fileIdx2 {
+		return false
+	}
+
+	for pairIdx, match1 := range m[fileIdx1] {
+		match2 := m[fileIdx2][pairIdx]
+
+		if (match1&name1PairMatch != 0 && match2&name2PairMatch != 0) ||
+			(match1&name2PairMatch != 0 && match2&name1PairMatch != 0) {
+			return true
+		}
+	}
+
+	return false
+}
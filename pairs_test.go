@@ -0,0 +1,57 @@
+package textsimilarity
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestFilePairMatches_Excluded(t *testing.T) {
+	is := is.New(t)
+
+	files := []*File{
+		{Name: "a.go"},
+		{Name: "a_mock.go"},
+		{Name: "b.go"},
+		{Name: "b_mock.go"},
+	}
+
+	matches := newFilePairMatches(files, &Options{
+		ExcludedFilePairs: []FilePair{
+			{Name1: regexp.MustCompile(`^[a-z]+\.go$`), Name2: regexp.MustCompile(`_mock\.go$`)},
+		},
+	})
+
+	is.True(matches.excluded(0, 1))
+	is.True(matches.excluded(1, 0))
+	is.True(matches.excluded(2, 1))
+	is.True(!matches.excluded(0, 2))
+	is.True(!matches.excluded(1, 3))
+	is.True(!matches.excluded(1, 1))
+
+	is.True(!newFilePairMatches(files, &Options{}).excluded(0, 1))
+}
+
+func TestSimilarities_ExcludedFilePairs(t *testing.T) {
+	is := is.New(t)
+
+	newFiles := func() []*File {
+		return []*File{
+			newFile("a.go", "aaaaaaaaaa\nbbbbbbbbbb\n"),
+			newFile("testdata/a.go", "aaaaaaaaaa\nbbbbbbbbbb\n"),
+		}
+	}
+
+	sims := similaritiesWithOptions(t, newFiles(), &Options{MinSimilarLines: 2})
+	is.Equal(len(sims), 1)
+
+	sims = similaritiesWithOptions(t, newFiles(), &Options{
+		MinSimilarLines: 2,
+		ExcludedFilePairs: []FilePair{
+			{Name1: regexp.MustCompile(`^testdata/`), Name2: regexp.MustCompile(`.`)},
+		},
+	})
+
+	is.Equal(len(sims), 0)
+}
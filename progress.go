@@ -0,0 +1,83 @@
+package textsimilarity
+
+// newProgressChannels returns a channel to send progress to, and the channel that the caller receives progress
+// from, according to opts. Sending progress to the former is subject to opts.ProgressPolicy. Closing the former
+// closes the latter once all pending progress has been received.
+func newProgressChannels(opts *Options) (chan Progress, <-chan Progress) {
+	outCh := make(chan Progress, max(opts.ProgressBufferSize, 0))
+
+	var forward func(inCh <-chan Progress, outCh chan<- Progress)
+
+	switch opts.ProgressPolicy {
+	case DropProgressPolicy:
+		forward = dropProgress
+	case CoalesceProgressPolicy:
+		forward = coalesceProgress
+	default:
+		return outCh, outCh
+	}
+
+	inCh := make(chan Progress)
+	go forward(inCh, outCh)
+
+	return inCh, outCh
+}
+
+// dropProgress forwards progress from inCh to outCh until inCh is closed, then closes outCh. Progress is
+// dropped if outCh is not ready, unless it is an error.
+func dropProgress(inCh <-chan Progress, outCh chan<- Progress) {
+	defer close(outCh)
+
+	for prog := range inCh {
+		if prog.Err != nil {
+			outCh <- prog
+			continue
+		}
+
+		select {
+		case outCh <- prog:
+		default:
+		}
+	}
+}
+
+// coalesceProgress forwards progress from inCh to outCh until inCh is closed, then forwards all pending progress
+// and closes outCh. While outCh is not ready, pending progress is replaced by newer progress, unless it is an error.
+func coalesceProgress(inCh <-chan Progress, outCh chan<- Progress) {
+	defer close(outCh)
+
+	pending := []Progress{}
+
+	for {
+		var (
+			sendCh chan<- Progress
+			next   Progress
+		)
+
+		if len(pending) > 0 {
+			sendCh = outCh
+			next = pending[0]
+		}
+
+		select {
+		case prog, ok := <-inCh:
+			if !ok {
+				for _, prog := range pending {
+					outCh <- prog
+				}
+
+				return
+			}
+
+			if prog.Err == nil && len(pending) > 0 && pending[len(pending)-1].Err == nil {
+				pending[len(pending)-1] = prog
+				continue
+			}
+
+			pending = append(pending, prog)
+
+		case sendCh <- next:
+			pending = pending[1:]
+		}
+	}
+}
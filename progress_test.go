@@ -0,0 +1,99 @@
+package textsimilarity
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestDropProgress(t *testing.T) {
+	is := is.New(t)
+
+	inCh, outCh := newProgressChannels(&Options{ProgressPolicy: DropProgressPolicy, ProgressBufferSize: 1})
+
+	errTest := errors.New("test")
+
+	// nobody receives, so the second update is dropped
+	inCh <- Progress{Done: 1}
+	inCh <- Progress{Done: 2}
+
+	// the second update has been handled once the third one is received, which may or may not be dropped
+	inCh <- Progress{Done: 3}
+
+	go func() {
+		inCh <- Progress{Err: errTest}
+		close(inCh)
+	}()
+
+	progs := []Progress{}
+	for prog := range outCh {
+		is.True(prog.Done != 2)
+		progs = append(progs, prog)
+	}
+
+	is.True(len(progs) >= 2)
+	is.Equal(progs[0].Done, 1.0)
+	is.Equal(progs[len(progs)-1].Err, errTest)
+}
+
+func TestCoalesceProgress(t *testing.T) {
+	is := is.New(t)
+
+	inCh, outCh := newProgressChannels(&Options{ProgressPolicy: CoalesceProgressPolicy})
+
+	errTest := errors.New("test")
+
+	// nobody receives, so updates before and after the error are coalesced
+	inCh <- Progress{Done: 1}
+	inCh <- Progress{Done: 2}
+	inCh <- Progress{Err: errTest}
+	inCh <- Progress{Done: 3}
+	inCh <- Progress{Done: 4}
+	close(inCh)
+
+	progs := []Progress{}
+	for prog := range outCh {
+		progs = append(progs, prog)
+	}
+
+	is.Equal(len(progs), 3)
+	is.Equal(progs[0].Done, 2.0)
+	is.Equal(progs[1].Err, errTest)
+	is.Equal(progs[2].Done, 4.0)
+}
+
+func TestSimilarities_Buffers(t *testing.T) {
+	is := is.New(t)
+
+	files := []*File{
+		newFile("a.txt", "foo 1\nbar 1\nbaz 1\n"),
+		newFile("b.txt", "foo 2\nbar 2\nbaz 2\n"),
+		newFile("c.txt", "qux\n"),
+	}
+
+	opts := Options{
+		MinSimilarLines:      3,
+		MaxEditDistance:      1,
+		SimilarityBufferSize: 10,
+		ProgressBufferSize:   10,
+	}
+
+	simsCh, progressCh, err := Similarities(context.Background(), files, &opts)
+	is.NoErr(err)
+
+	// with large enough buffers, the channels can be drained one after the other
+	sims := []*Similarity{}
+	for sim := range simsCh {
+		sims = append(sims, sim)
+	}
+
+	progs := 0
+	for range progressCh {
+		progs++
+	}
+
+	is.Equal(len(sims), 1)
+	is.Equal(progs, 3)
+}
@@ -0,0 +1,234 @@
+// Package prose prepares prose documents, such as essays, for finding similarities between them. Documents are
+// split into sentences by SentenceSegmenter, which are normalized by lowercasing them, removing punctuation and
+// stopwords, and optionally stemming their words, so that copied sentences are found even if they have been slightly
+// edited or reflowed.
+package prose
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/blizzy78/textsimilarity"
+)
+
+// abbreviations are words that end with a period, but do not end a sentence.
+var abbreviations = map[string]struct{}{
+	"dr.": {}, "e.g.": {}, "etc.": {}, "i.e.": {}, "jr.": {}, "mr.": {}, "mrs.": {}, "ms.": {}, "no.": {},
+	"prof.": {}, "sr.": {}, "st.": {}, "vs.": {},
+}
+
+// Options specifies how sentences are normalized.
+type Options struct {
+	// Stopwords are the words that are removed from sentences, in lower case. If nil, DefaultStopwords is used.
+	Stopwords map[string]struct{}
+
+	// Stem indicates whether words should be stemmed, so that different inflections of words are equal.
+	Stem bool
+}
+
+// A Sentence is a single sentence of a document.
+type Sentence struct {
+	// Text is the text of the sentence, with runs of whitespace replaced by single spaces.
+	Text string
+
+	// Start is the line the sentence starts on (zero-based.)
+	Start int
+
+	// End is the line after the line the sentence ends on (zero-based, exclusive.)
+	End int
+}
+
+// SentenceSegmenter is a textsimilarity.Segmenter that makes each sentence of a document a unit, normalized
+// according to Options. Sentences that do not contain any words after removing stopwords are skipped.
+type SentenceSegmenter struct {
+	// Options specifies how sentences are normalized.
+	Options Options
+}
+
+// A Match is the extent to which a document matches other documents.
+type Match struct {
+	// File is the file of the document.
+	File *textsimilarity.File
+
+	// Sentences is the number of sentences of File that contain any words, after removing stopwords.
+	Sentences int
+
+	// MatchedSentences is the number of those sentences that are part of any similarity.
+	MatchedSentences int
+
+	// Percent is the percentage of matched sentences (0-100.)
+	Percent float64
+}
+
+var _ textsimilarity.Segmenter = SentenceSegmenter{}
+
+// Segment implements textsimilarity.Segmenter.
+func (s SentenceSegmenter) Segment(r io.Reader, fn func(unit textsimilarity.Unit) error) error {
+	text, err := io.ReadAll(r)
+	if err != nil {
+		return err //nolint:wrapcheck // wrapped by callers
+	}
+
+	for _, sentence := range Sentences(string(text)) {
+		normalized := Normalize(sentence.Text, &s.Options)
+		if normalized == "" {
+			continue
+		}
+
+		if err := fn(textsimilarity.Unit{Text: normalized, StartLine: sentence.Start, EndLine: sentence.End}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Sentences splits text into sentences. Sentences end with a period, exclamation mark, or question mark that is
+// followed by whitespace, or at empty lines. Common abbreviations do not end sentences.
+func Sentences(text string) []*Sentence {
+	sentences := []*Sentence{}
+	words := []string{}
+	start := 0
+
+	flush := func(end int) {
+		if len(words) == 0 {
+			return
+		}
+
+		sentences = append(sentences, &Sentence{
+			Text:  strings.Join(words, " "),
+			Start: start,
+			End:   end,
+		})
+
+		words = words[:0]
+	}
+
+	for lineIdx, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		fields := strings.Fields(line)
+
+		// empty lines separate paragraphs
+		if len(fields) == 0 {
+			flush(lineIdx)
+			continue
+		}
+
+		for _, word := range fields {
+			if len(words) == 0 {
+				start = lineIdx
+			}
+
+			words = append(words, word)
+
+			if endsSentence(word) {
+				flush(lineIdx + 1)
+			}
+		}
+	}
+
+	flush(strings.Count(strings.TrimSuffix(text, "\n"), "\n") + 1)
+
+	return sentences
+}
+
+// endsSentence returns whether word ends a sentence.
+func endsSentence(word string) bool {
+	// closing quotes and parentheses may follow the end of a sentence
+	trimmed := strings.TrimRight(word, `"')]`+"”’")
+	if trimmed == "" {
+		return false
+	}
+
+	if _, ok := abbreviations[strings.ToLower(trimmed)]; ok {
+		return false
+	}
+
+	return strings.ContainsAny(trimmed[len(trimmed)-1:], ".!?")
+}
+
+// Normalize returns sentence in lower case, without punctuation and stopwords, and with words stemmed, according
+// to opts. Possessive "'s" is removed before stemming. Words are separated by single spaces.
+func Normalize(sentence string, opts *Options) string {
+	stopwords := opts.Stopwords
+	if stopwords == nil {
+		stopwords = DefaultStopwords
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(sentence), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+
+	normalized := make([]string, 0, len(words))
+
+	for _, word := range words {
+		word = strings.Trim(word, "'")
+		if word == "" {
+			continue
+		}
+
+		if _, ok := stopwords[word]; ok {
+			continue
+		}
+
+		if opts.Stem {
+			word = Stem(strings.TrimSuffix(word, "'s"))
+		}
+
+		normalized = append(normalized, word)
+	}
+
+	return strings.Join(normalized, " ")
+}
+
+// Matches returns the extent to which each of files matches other files, according to sims, sorted by percentage
+// (descending), and then by file name. sims must have been found in files using a SentenceSegmenter.
+func Matches(sims []*textsimilarity.Similarity, files []*textsimilarity.File) []*Match {
+	matched := map[*textsimilarity.File][]bool{}
+
+	for _, sim := range sims {
+		for _, occ := range sim.Occurrences {
+			sentences, ok := matched[occ.File]
+			if !ok {
+				sentences = make([]bool, occ.File.UnitCount())
+				matched[occ.File] = sentences
+			}
+
+			for idx := occ.StartUnit; idx < occ.EndUnit; idx++ {
+				sentences[idx] = true
+			}
+		}
+	}
+
+	matches := make([]*Match, len(files))
+
+	for idx, file := range files {
+		match := Match{
+			File:      file,
+			Sentences: file.UnitCount(),
+		}
+
+		for _, m := range matched[file] {
+			if m {
+				match.MatchedSentences++
+			}
+		}
+
+		if match.Sentences > 0 {
+			match.Percent = float64(match.MatchedSentences) * 100 / float64(match.Sentences)
+		}
+
+		matches[idx] = &match
+	}
+
+	sort.SliceStable(matches, func(a int, b int) bool {
+		if matches[a].Percent != matches[b].Percent {
+			return matches[a].Percent > matches[b].Percent
+		}
+
+		return matches[a].File.Name < matches[b].File.Name
+	})
+
+	return matches
+}
@@ -0,0 +1,128 @@
+package prose
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/blizzy78/textsimilarity"
+	"github.com/matryer/is"
+)
+
+func TestSentences(t *testing.T) {
+	is := is.New(t)
+
+	text := "The quick brown fox. It jumps\nover the lazy dog, e.g. Rex!\n\nA new paragraph\nwithout a period\n\nWhat? \"Really.\" Pi is 3.14 or so"
+
+	sentences := Sentences(text)
+	is.Equal(len(sentences), 6)
+
+	is.Equal(*sentences[0], Sentence{Text: "The quick brown fox.", Start: 0, End: 1})
+	is.Equal(*sentences[1], Sentence{Text: "It jumps over the lazy dog, e.g. Rex!", Start: 0, End: 2})
+	is.Equal(*sentences[2], Sentence{Text: "A new paragraph without a period", Start: 3, End: 5})
+	is.Equal(*sentences[3], Sentence{Text: "What?", Start: 6, End: 7})
+	is.Equal(*sentences[4], Sentence{Text: "\"Really.\"", Start: 6, End: 7})
+	is.Equal(*sentences[5], Sentence{Text: "Pi is 3.14 or so", Start: 6, End: 7})
+}
+
+func TestNormalize(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(Normalize("The Dogs were running, and the cat's tail TWITCHED!", &Options{}), "dogs running cat's tail twitched")
+	is.Equal(Normalize("The Dogs were running, and the cat's tail TWITCHED!", &Options{Stem: true}), "dog run cat tail twitch")
+	is.Equal(Normalize("The dogs", &Options{Stopwords: map[string]struct{}{"dogs": {}}}), "the")
+	is.Equal(Normalize("It is what it is.", &Options{}), "")
+}
+
+func TestStem(t *testing.T) {
+	is := is.New(t)
+
+	for word, stem := range map[string]string{
+		"running":    "run",
+		"runs":       "run",
+		"falling":    "fall",
+		"classes":    "class",
+		"class":      "class",
+		"studies":    "studi",
+		"studied":    "studi",
+		"relational": "relate",
+		"quickly":    "quick",
+		"is":         "is",
+	} {
+		is.Equal(Stem(word), stem) // word
+	}
+}
+
+func TestSentenceSegmenter(t *testing.T) {
+	is := is.New(t)
+
+	units := []textsimilarity.Unit{}
+
+	err := SentenceSegmenter{Options: Options{Stem: true}}.Segment(strings.NewReader("The dogs were running. It is what\nit is. Cats sleep.\n"),
+		func(unit textsimilarity.Unit) error {
+			units = append(units, unit)
+			return nil
+		})
+	is.NoErr(err)
+
+	is.Equal(units, []textsimilarity.Unit{
+		{Text: "dog run", StartLine: 0, EndLine: 1},
+		{Text: "cat sleep", StartLine: 1, EndLine: 2},
+	})
+}
+
+func TestMatches(t *testing.T) {
+	is := is.New(t)
+
+	original := "Photosynthesis converts light energy into chemical energy.\nPlants store this energy as glucose.\n" +
+		"Chlorophyll absorbs mostly red and blue light.\nOxygen is released as a byproduct.\n"
+
+	// reflowed and slightly edited copy of the first three sentences, plus an original sentence
+	copied := "Photosynthesis converts light energy into\nchemical energy. Plants stored this energy as glucose.\n" +
+		"The chlorophyll absorbs mostly red and blue light.\n\nMitochondria are the powerhouse of the cell.\n"
+
+	files := []*textsimilarity.File{
+		{Name: "a.txt", R: strings.NewReader(original)},
+		{Name: "b.txt", R: strings.NewReader(copied)},
+	}
+
+	simsCh, progressCh, err := textsimilarity.Similarities(context.Background(), files, &textsimilarity.Options{
+		MinSimilarLines: 2,
+		CaptureText:     true,
+		Segmenter:       SentenceSegmenter{Options: Options{Stem: true}},
+	})
+	is.NoErr(err)
+
+	go func() {
+		for range progressCh { //nolint:revive // drain channel
+		}
+	}()
+
+	sims := []*textsimilarity.Similarity{}
+	for sim := range simsCh {
+		sims = append(sims, sim)
+	}
+
+	is.Equal(len(sims), 1)
+
+	for _, occ := range sims[0].Occurrences {
+		is.Equal(occ.StartUnit, 0)
+		is.Equal(occ.EndUnit, 3)
+		is.Equal(occ.Start, 0)
+		is.Equal(occ.End, 3)
+		is.Equal(occ.StartColumns, nil)
+	}
+
+	is.Equal(sims[0].Occurrences[0].Text,
+		"photosynthesi convert light energy chemical energy\nplant store energy glucose\nchlorophyll absorb most red blue light\n")
+
+	matches := Matches(sims, files)
+	is.Equal(len(matches), 2)
+
+	for idx, file := range files {
+		is.Equal(matches[idx].File, file)
+		is.Equal(matches[idx].Sentences, 4)
+		is.Equal(matches[idx].MatchedSentences, 3)
+		is.Equal(matches[idx].Percent, 75.0)
+	}
+}
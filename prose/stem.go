@@ -0,0 +1,88 @@
+package prose
+
+import "strings"
+
+// DefaultStopwords are common English words that carry little meaning on their own.
+var DefaultStopwords = newStopwords(`a about above after again against all am an and any are as at be because been
+before being below between both but by can could did do does doing down during each few for from further had has
+have having he her here hers herself him himself his how i if in into is it it's its itself just me more most my
+myself no nor not now of off on once only or other our ours ourselves out over own same she should so some such
+than that the their theirs them themselves then there these they this those through to too under until up very was
+we were what when where which while who whom why will with would you your yours yourself yourselves`)
+
+// stemSuffixes are the suffixes removed by Stem, longest first, with their replacements.
+var stemSuffixes = []struct {
+	suffix      string
+	replacement string
+}{
+	{"ational", "ate"},
+	{"fulness", "ful"},
+	{"iveness", "ive"},
+	{"ization", "ize"},
+	{"ousness", "ous"},
+	{"ations", "ate"},
+	{"ation", "ate"},
+	{"ments", ""},
+	{"ingly", ""},
+	{"ness", ""},
+	{"ment", ""},
+	{"edly", ""},
+	{"sses", "ss"},
+	{"ies", "i"},
+	{"ing", ""},
+	{"ied", "i"},
+	{"ly", ""},
+	{"ed", ""},
+	{"es", ""},
+	{"s", ""},
+}
+
+// minStemLength is the minimum length of stems. Suffixes are not removed if the stem would be shorter.
+const minStemLength = 3
+
+// newStopwords returns a set of the whitespace-separated words of s.
+func newStopwords(s string) map[string]struct{} {
+	words := strings.Fields(s)
+
+	stopwords := make(map[string]struct{}, len(words))
+	for _, word := range words {
+		stopwords[word] = struct{}{}
+	}
+
+	return stopwords
+}
+
+// Stem returns the stem of word, which must be in lower case, by removing common English suffixes. It is a light
+// stemmer: Different inflections of most words have the same stem, but stems are not always proper words.
+func Stem(word string) string {
+	for _, s := range stemSuffixes {
+		if !strings.HasSuffix(word, s.suffix) || strings.HasSuffix(word, "ss") && s.suffix == "s" {
+			continue
+		}
+
+		stem := word[:len(word)-len(s.suffix)]
+		if len(stem) < minStemLength {
+			continue
+		}
+
+		// "running" -> "run", but "falling" -> "fall"
+		if (s.suffix == "ing" || s.suffix == "ed") && doubledConsonant(stem) {
+			stem = stem[:len(stem)-1]
+		}
+
+		return stem + s.replacement
+	}
+
+	return word
+}
+
+// doubledConsonant returns whether word ends with a doubled consonant other than l, s, or z.
+func doubledConsonant(word string) bool {
+	if len(word) < 2 {
+		return false
+	}
+
+	last := word[len(word)-1]
+
+	return last == word[len(word)-2] && !strings.ContainsRune("aeiouylsz", rune(last))
+}
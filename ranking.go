@@ -0,0 +1,64 @@
+package textsimilarity
+
+import "math"
+
+// DefaultRanking is the Ranking used by Similarity.Score if no Ranking is specified. It ranks similarities by
+// their total number of lines, with similar and reordered similarities being ranked lower than equal ones.
+var DefaultRanking = Ranking{
+	LinesExponent:       1,
+	OccurrencesExponent: 1,
+	EqualFactor:         1,
+	SimilarFactor:       0.8,
+	ReorderedFactor:     0.6,
+}
+
+// A Ranking combines the number of lines, the number of occurrences, and the level of a similarity into a single
+// score. The score is computed as lines^LinesExponent * occurrences^OccurrencesExponent * factor, where lines is the
+// average number of lines of the occurrences, occurrences includes omitted occurrences, and factor depends on the
+// level.
+type Ranking struct {
+	// LinesExponent is the exponent applied to the number of lines.
+	LinesExponent float64
+
+	// OccurrencesExponent is the exponent applied to the number of occurrences.
+	OccurrencesExponent float64
+
+	// EqualFactor is the factor used for EqualSimilarityLevel.
+	EqualFactor float64
+
+	// SimilarFactor is the factor used for SimilarSimilarityLevel.
+	SimilarFactor float64
+
+	// ReorderedFactor is the factor used for ReorderedSimilarityLevel.
+	ReorderedFactor float64
+}
+
+// Score returns the score of s according to ranking, for sorting similarities. Higher scores are more relevant.
+// If ranking is nil, DefaultRanking is used.
+func (s *Similarity) Score(ranking *Ranking) float64 {
+	if ranking == nil {
+		ranking = &DefaultRanking
+	}
+
+	totalLines := 0
+	for _, occ := range s.Occurrences {
+		totalLines += occ.End - occ.Start
+	}
+
+	lines := float64(totalLines) / float64(len(s.Occurrences))
+	occs := float64(len(s.Occurrences) + s.OmittedOccurrences)
+
+	return math.Pow(lines, ranking.LinesExponent) * math.Pow(occs, ranking.OccurrencesExponent) * ranking.levelFactor(s.Level)
+}
+
+// levelFactor returns the factor used for level.
+func (r *Ranking) levelFactor(level SimilarityLevel) float64 {
+	switch level {
+	case SimilarSimilarityLevel:
+		return r.SimilarFactor
+	case ReorderedSimilarityLevel:
+		return r.ReorderedFactor
+	default:
+		return r.EqualFactor
+	}
+}
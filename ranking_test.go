@@ -0,0 +1,30 @@
+package textsimilarity
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestSimilarity_Score(t *testing.T) {
+	is := is.New(t)
+
+	file := &File{Name: "a.go"}
+
+	sim := Similarity{
+		Level: SimilarSimilarityLevel,
+		Occurrences: []*FileOccurrence{
+			{File: file, Start: 0, End: 10},
+			{File: file, Start: 20, End: 30},
+		},
+		OmittedOccurrences: 2,
+	}
+
+	is.Equal(sim.Score(nil), 10*4*0.8)
+
+	is.Equal(sim.Score(&Ranking{
+		LinesExponent:       2,
+		OccurrencesExponent: 0.5,
+		SimilarFactor:       1,
+	}), 100*2.0)
+}
@@ -0,0 +1,267 @@
+package textsimilarity
+
+import (
+	"context"
+	"math/bits"
+)
+
+const (
+	// maxReorderedBlockLines is the maximum number of lines of reordered blocks, unless Options.MinSimilarLines
+	// is larger.
+	maxReorderedBlockLines = 40
+
+	// maxReorderedGroupSize is the maximum number of blocks with the same set of lines that are compared with
+	// each other when looking for reordered blocks. Larger groups usually consist of boilerplate and are skipped.
+	maxReorderedGroupSize = 100
+)
+
+// A reorderedBlock is a range of lines in a file that is a candidate for a reordered similarity.
+type reorderedBlock struct {
+	// fileIdx is the index of the file.
+	fileIdx int
+
+	// start is the starting line number (zero-based.)
+	start int
+}
+
+// buildReorderHashes sets up f.reorderHashes from f's lines, according to opts and f's scopes.
+func (f *File) buildReorderHashes(opts *Options) {
+	f.reorderHashes = make([]uint64, f.lineCount)
+
+	for idx := 0; idx < f.lineCount; idx++ {
+		line := f.lines[idx]
+		if !acceptLine(line, opts) || !f.inScope(idx) {
+			continue
+		}
+
+		// 0 marks lines that are not considered
+		f.reorderHashes[idx] = line.hash | 1
+	}
+}
+
+// reorderedSimilarities returns all similarities of ReorderedSimilarityLevel between files, that is, blocks of
+// lines that contain the same lines in a different order, according to opts. Only exactly equal lines are
+// considered, and blocks must differ in their first and last lines. Similarities are found using the files'
+// reorderHashes only, so their lines need not be loaded. Larger blocks are found first, and lines are only
+// included in a single similarity.
+func reorderedSimilarities(ctx context.Context, files []*File, pairMatches filePairMatches, opts *Options) []*Similarity {
+	minSize := max(opts.MinSimilarLines, 2)
+	maxSize := max(minSize, maxReorderedBlockLines)
+
+	used := make([]*bitVector, len(files))
+	for idx, file := range files {
+		used[idx] = newBitVector(file.lineCount)
+	}
+
+	sims := []*Similarity{}
+
+	for size := maxSize; size >= minSize; size-- {
+		if contextDone(ctx) {
+			return sims
+		}
+
+		blocks := map[uint64][]reorderedBlock{}
+		keys := []uint64{}
+
+		for fileIdx, file := range files {
+			forEachReorderedBlock(file.reorderHashes, size, func(start int, key uint64) {
+				if _, ok := blocks[key]; !ok {
+					keys = append(keys, key)
+				}
+
+				blocks[key] = append(blocks[key], reorderedBlock{fileIdx: fileIdx, start: start})
+			})
+		}
+
+		for _, key := range keys {
+			group := blocks[key]
+			if len(group) < 2 || len(group) > maxReorderedGroupSize {
+				continue
+			}
+
+			if sim := reorderedGroupSimilarity(group, size, files, used, pairMatches); sim != nil {
+				sims = append(sims, sim)
+			}
+		}
+	}
+
+	return sims
+}
+
+// reorderedGroupSimilarity returns a similarity of the blocks of group, which all have the same key, or nil if
+// there is none. The first block that is not yet used is used as the first occurrence, and all other blocks that
+// contain the same lines in a different order as other occurrences. The lines of all occurrences are marked
+// as used.
+func reorderedGroupSimilarity(group []reorderedBlock, size int, files []*File, used []*bitVector,
+	pairMatches filePairMatches,
+) *Similarity {
+	for idx1, block1 := range group {
+		if blockUsed(used[block1.fileIdx], block1.start, size) {
+			continue
+		}
+
+		file1 := files[block1.fileIdx]
+		sim := Similarity{
+			Occurrences: []*FileOccurrence{{File: file1, Start: block1.start, End: block1.start + size}},
+			Level:       ReorderedSimilarityLevel,
+		}
+
+		simBlocks := []reorderedBlock{block1}
+
+		for _, block2 := range group[idx1+1:] {
+			file2 := files[block2.fileIdx]
+
+			switch {
+			case file1.ReferenceOnly && file2.ReferenceOnly:
+			case pairMatches.excluded(block1.fileIdx, block2.fileIdx):
+			case blockUsed(used[block2.fileIdx], block2.start, size):
+			case !reorderedBlocks(file1.reorderHashes[block1.start:block1.start+size], file2.reorderHashes[block2.start:block2.start+size]):
+
+			default:
+				occ := FileOccurrence{File: file2, Start: block2.start, End: block2.start + size}
+				if !overlapsAny(&occ, sim.Occurrences) {
+					sim.Occurrences = append(sim.Occurrences, &occ)
+					simBlocks = append(simBlocks, block2)
+				}
+			}
+		}
+
+		if len(sim.Occurrences) < 2 {
+			continue
+		}
+
+		for _, block := range simBlocks {
+			for l := block.start; l < block.start+size; l++ {
+				used[block.fileIdx].set(l, true)
+			}
+		}
+
+		return &sim
+	}
+
+	return nil
+}
+
+// blockUsed returns whether any of size lines starting at start are set in used.
+func blockUsed(used *bitVector, start int, size int) bool {
+	for l := start; l < start+size; l++ {
+		if used.isSet(l) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// reorderedBlocks returns whether hashes1 and hashes2 contain the same lines, but differ in their first and
+// last lines, and therefore in their order.
+func reorderedBlocks(hashes1 []uint64, hashes2 []uint64) bool {
+	if hashes1[0] == hashes2[0] || hashes1[len(hashes1)-1] == hashes2[len(hashes2)-1] {
+		return false
+	}
+
+	counts := map[uint64]int{}
+
+	for idx, hash := range hashes1 {
+		counts[hash]++
+		counts[hashes2[idx]]--
+	}
+
+	for _, count := range counts {
+		if count != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// overlapsAny returns whether occ overlaps any of occs.
+func overlapsAny(occ *FileOccurrence, occs []*FileOccurrence) bool {
+	for _, other := range occs {
+		if occ.File == other.File && occ.Start < other.End && other.Start < occ.End {
+			return true
+		}
+	}
+
+	return false
+}
+
+// reorderedResult returns a result of all reordered similarities between files, with the index taskIdx. The
+// similarities are prepared according to opts, loading lines from store. Errors are reported to progressCh.
+func reorderedResult(ctx context.Context, files []*File, pairMatches filePairMatches, taskIdx int, store *lineStore,
+	progressCh chan<- Progress, opts *Options,
+) taskResult {
+	return storedResult(ctx, reorderedSimilarities(ctx, files, pairMatches, opts), taskIdx, store, progressCh, opts)
+}
+
+// storedResult returns a result of sims with the index taskIdx of a task after all other tasks, so that the
+// similarities are emitted last. The similarities are prepared according to opts, loading lines from store.
+// Errors are reported to progressCh.
+func storedResult(ctx context.Context, sims []*Similarity, taskIdx int, store *lineStore, progressCh chan<- Progress,
+	opts *Options,
+) taskResult {
+	// the result is never complete, so that it is not included in checkpoints, and found again when resuming
+	res := taskResult{
+		taskIdx: taskIdx,
+	}
+
+	for _, sim := range sims {
+		if contextDone(ctx) {
+			break
+		}
+
+		if err := prepareStoredSimilarity(sim, store, opts); err != nil {
+			progressCh <- Progress{
+				File: sim.Occurrences[0].File,
+				Err:  err,
+			}
+
+			break
+		}
+
+		res.sims = append(res.sims, sim)
+	}
+
+	return res
+}
+
+// forEachReorderedBlock calls fn with the start and key of all blocks of size consecutive lines in hashes that
+// are all considered for similarities. Blocks containing the same lines in any order have the same key.
+func forEachReorderedBlock(hashes []uint64, size int, fn func(start int, key uint64)) {
+	sum1 := uint64(0)
+	sum2 := uint64(0)
+	run := 0
+
+	for idx, hash := range hashes {
+		if hash == 0 {
+			sum1, sum2, run = 0, 0, 0
+			continue
+		}
+
+		sum1 += mixHash(hash)
+		sum2 += bits.RotateLeft64(hash, 29) * fnvPrime64
+		run++
+
+		if run > size {
+			removed := hashes[idx-size]
+			sum1 -= mixHash(removed)
+			sum2 -= bits.RotateLeft64(removed, 29) * fnvPrime64
+		}
+
+		if run >= size {
+			fn(idx-size+1, sum1^bits.RotateLeft64(sum2, 32))
+		}
+	}
+}
+
+// mixHash returns hash with its bits mixed, so that sums of mixed hashes are unlikely to collide.
+func mixHash(hash uint64) uint64 {
+	hash ^= hash >> 33
+	hash *= 0xff51afd7ed558ccd
+	hash ^= hash >> 33
+	hash *= 0xc4ceb9fe1a85ec53
+	hash ^= hash >> 33
+
+	return hash
+}
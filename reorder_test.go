@@ -0,0 +1,72 @@
+package textsimilarity
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestSimilarities_ReorderedBlocks(t *testing.T) {
+	is := is.New(t)
+
+	newFiles := func() []*File {
+		return []*File{
+			newFile("1.txt", "xxxxxxxxxx\naaaaaaaaaa\nbbbbbbbbbb\ncccccccccc\ndddddddddd\nyyyyyyyyyy\n"),
+			newFile("2.txt", "zzzzzzzzzz\ncccccccccc\naaaaaaaaaa\ndddddddddd\nbbbbbbbbbb\nwwwwwwwwww\n"),
+		}
+	}
+
+	sims := similaritiesWithOptions(t, newFiles(), &Options{MinSimilarLines: 3, MaxEditDistance: 1})
+	is.Equal(len(sims), 0)
+
+	sims = similaritiesWithOptions(t, newFiles(), &Options{
+		Flags:           ReorderedBlocksFlag,
+		MinSimilarLines: 3,
+		MaxEditDistance: 1,
+		CaptureText:     true,
+	})
+
+	is.Equal(len(sims), 1)
+	is.Equal(sims[0].Level, ReorderedSimilarityLevel)
+	is.Equal(sims[0].Occurrences[0].Start, 1)
+	is.Equal(sims[0].Occurrences[0].End, 5)
+	is.Equal(sims[0].Occurrences[1].Start, 1)
+	is.Equal(sims[0].Occurrences[1].End, 5)
+	is.Equal(sims[0].Occurrences[1].Text, "cccccccccc\naaaaaaaaaa\ndddddddddd\nbbbbbbbbbb\n")
+}
+
+func TestSimilarities_ReorderedBlocks_SameOrder(t *testing.T) {
+	is := is.New(t)
+
+	files := []*File{
+		newFile("1.txt", "aaaaaaaaaa\nbbbbbbbbbb\ncccccccccc\n"),
+		newFile("2.txt", "aaaaaaaaaa\nbbbbbbbbbb\ncccccccccc\n"),
+	}
+
+	sims := similaritiesWithOptions(t, files, &Options{Flags: ReorderedBlocksFlag, MinSimilarLines: 3})
+	is.Equal(len(sims), 1)
+	is.Equal(sims[0].Level, EqualSimilarityLevel)
+}
+
+func TestReorderedBlocks(t *testing.T) {
+	is := is.New(t)
+
+	is.True(reorderedBlocks([]uint64{1, 3, 5, 7}, []uint64{5, 1, 7, 3}))
+	is.True(!reorderedBlocks([]uint64{1, 3, 5, 7}, []uint64{1, 3, 5, 7}))
+	is.True(!reorderedBlocks([]uint64{1, 3, 5, 7}, []uint64{1, 5, 3, 7}))
+	is.True(!reorderedBlocks([]uint64{1, 3, 5, 7}, []uint64{5, 1, 9, 3}))
+}
+
+func TestForEachReorderedBlock(t *testing.T) {
+	is := is.New(t)
+
+	keys := map[int]uint64{}
+
+	forEachReorderedBlock([]uint64{1, 3, 5, 0, 5, 3, 1, 7}, 3, func(start int, key uint64) {
+		keys[start] = key
+	})
+
+	is.Equal(len(keys), 3)
+	is.Equal(keys[0], keys[4])
+	is.True(keys[0] != keys[5])
+}
@@ -0,0 +1,173 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/blizzy78/textsimilarity"
+)
+
+// annotateReporter writes each file containing similarities with duplicated regions marked in the margin,
+// similar to coverage-annotated listings.
+type annotateReporter struct {
+	opts *Options
+}
+
+// An annotatedOccurrence is an occurrence of a similarity in a file written by annotateReporter.
+type annotatedOccurrence struct {
+	// number is the number of the similarity in the report (one-based.)
+	number int
+
+	// sim is the similarity.
+	sim *textsimilarity.Similarity
+
+	// occ is the occurrence of sim.
+	occ *textsimilarity.FileOccurrence
+}
+
+func init() { //nolint:gochecknoinits // register built-in format
+	Register("annotate", newAnnotateReporter)
+}
+
+// newAnnotateReporter returns a new Reporter that writes each file containing similarities with duplicated
+// regions marked in the margin.
+func newAnnotateReporter(opts *Options) (Reporter, error) {
+	return &annotateReporter{
+		opts: opts,
+	}, nil
+}
+
+// Report implements Reporter. Files are written in order of their names, and files without any similarities
+// are not written. Each line is prefixed by its line number and the numbers of the similarities covering it.
+// The first line of each occurrence is preceded by a note listing the partner occurrences of its similarity.
+func (r *annotateReporter) Report(ctx context.Context, w io.Writer, sims []*textsimilarity.Similarity) error {
+	files := map[*textsimilarity.File][]*annotatedOccurrence{}
+
+	for idx, sim := range sims {
+		for _, occ := range sim.Occurrences {
+			files[occ.File] = append(files[occ.File], &annotatedOccurrence{
+				number: idx + 1,
+				sim:    sim,
+				occ:    occ,
+			})
+		}
+	}
+
+	sortedFiles := make([]*textsimilarity.File, 0, len(files))
+	for file := range files {
+		sortedFiles = append(sortedFiles, file)
+	}
+
+	sort.Slice(sortedFiles, func(a int, b int) bool {
+		return sortedFiles[a].Name < sortedFiles[b].Name
+	})
+
+	for idx, file := range sortedFiles {
+		if contextDone(ctx) {
+			return ctx.Err()
+		}
+
+		if idx > 0 {
+			fmt.Fprintln(w)
+		}
+
+		if err := r.reportFile(w, file, files[file]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reportFile writes file with occs marked in the margin to w.
+func (r *annotateReporter) reportFile(w io.Writer, file *textsimilarity.File, occs []*annotatedOccurrence) error {
+	lines := file.Lines
+	if lines == nil {
+		text, err := fileText(file.Name, 0, math.MaxInt)
+		if err != nil {
+			return err
+		}
+
+		lines = splitLines(text)
+	}
+
+	sort.SliceStable(occs, func(a int, b int) bool {
+		return occs[a].occ.Start < occs[b].occ.Start
+	})
+
+	// margins are the numbers of the similarities covering each line
+	margins := make([][]*annotatedOccurrence, len(lines))
+	marginWidth := 0
+
+	for _, occ := range occs {
+		for l := occ.occ.Start; l < min(occ.occ.End, len(lines)); l++ {
+			margins[l] = append(margins[l], occ)
+			marginWidth = max(marginWidth, len(annotateMargin(margins[l], false)))
+		}
+	}
+
+	numWidth := len(strconv.Itoa(len(lines)))
+
+	fmt.Fprintln(w, "==> "+file.Name+" <==")
+
+	next := 0
+
+	for l, line := range lines {
+		for ; next < len(occs) && occs[next].occ.Start == l; next++ {
+			note := fmt.Sprintf("%*s %-*s   %s", numWidth, "", marginWidth, "", annotateNote(occs[next]))
+			fmt.Fprintln(w, colorize(note, levelColor(occs[next].sim.Level), r.opts.Color))
+		}
+
+		margin := annotateMargin(margins[l], r.opts.Color)
+		padding := strings.Repeat(" ", marginWidth-len(annotateMargin(margins[l], false)))
+
+		fmt.Fprintf(w, "%s %s%s %s %s\n", colorize(fmt.Sprintf("%*d", numWidth, l+1), colorFaint, r.opts.Color), margin, padding,
+			colorize("|", colorFaint, r.opts.Color), line)
+	}
+
+	return nil
+}
+
+// annotateMargin returns the numbers of the similarities of occs, separated by commas, using color if enabled.
+func annotateMargin(occs []*annotatedOccurrence, color bool) string {
+	numbers := make([]string, len(occs))
+	for idx, occ := range occs {
+		numbers[idx] = colorize("#"+strconv.Itoa(occ.number), levelColor(occ.sim.Level), color)
+	}
+
+	return strings.Join(numbers, ",")
+}
+
+// annotateNote returns a note for the first line of occ, listing the other occurrences of its similarity.
+func annotateNote(occ *annotatedOccurrence) string {
+	note := fmt.Sprintf("v #%d - %d lines, %s", occ.number, similarityLines(occ.sim), occ.sim.Level.String())
+
+	if id := occ.sim.ID(); id != "" {
+		note += " [" + id + "]"
+	}
+
+	partners := []string{}
+
+	for _, partner := range occ.sim.Occurrences {
+		if partner == occ.occ {
+			continue
+		}
+
+		partners = append(partners, partner.File.Name+":"+lineRange(partner))
+	}
+
+	if occ.sim.OmittedOccurrences > 0 {
+		partners = append(partners, fmt.Sprintf("%d more", occ.sim.OmittedOccurrences))
+	}
+
+	if len(partners) > 0 {
+		note += ", also in " + strings.Join(partners, ", ")
+	}
+
+	return note
+}
@@ -0,0 +1,133 @@
+package report
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec // used for fingerprints only
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+
+	"github.com/blizzy78/textsimilarity"
+)
+
+// codeClimateCheckName is the name of the check reported for all similarities.
+const codeClimateCheckName = "duplicate-text"
+
+// codeClimateReporter writes similarities as a stream of Code Climate issues, as expected from a Code Climate engine.
+type codeClimateReporter struct{}
+
+type codeClimateIssue struct {
+	Type           string                 `json:"type"`
+	CheckName      string                 `json:"check_name"`
+	Description    string                 `json:"description"`
+	Categories     []string               `json:"categories"`
+	Location       *codeClimateLocation   `json:"location"`
+	OtherLocations []*codeClimateLocation `json:"other_locations,omitempty"`
+	Severity       string                 `json:"severity"`
+	Fingerprint    string                 `json:"fingerprint"`
+}
+
+type codeClimateLocation struct {
+	Path  string           `json:"path"`
+	Lines codeClimateLines `json:"lines"`
+}
+
+type codeClimateLines struct {
+	Begin int `json:"begin"`
+	End   int `json:"end"`
+}
+
+func init() { //nolint:gochecknoinits // register built-in format
+	Register("codeclimate", newCodeClimateReporter)
+}
+
+// newCodeClimateReporter returns a new Reporter that writes similarities as a stream of Code Climate issues.
+func newCodeClimateReporter(_ *Options) (Reporter, error) {
+	return &codeClimateReporter{}, nil
+}
+
+// Report implements Reporter. Each occurrence of a similarity is reported as a separate issue, with all other
+// occurrences as other locations. Issues are JSON documents, each terminated by a NUL character.
+func (r *codeClimateReporter) Report(ctx context.Context, w io.Writer, sims []*textsimilarity.Similarity) error {
+	for _, sim := range sims {
+		if contextDone(ctx) {
+			return ctx.Err()
+		}
+
+		for idx := range sim.Occurrences {
+			data, err := json.Marshal(codeClimateOccurrenceIssue(sim, idx))
+			if err != nil {
+				return fmt.Errorf("encode Code Climate issue: %w", err)
+			}
+
+			data = append(data, 0)
+
+			if _, err := w.Write(data); err != nil {
+				return fmt.Errorf("write Code Climate issue: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// codeClimateOccurrenceIssue returns a Code Climate issue for the occurrence of sim at index occIdx.
+func codeClimateOccurrenceIssue(sim *textsimilarity.Similarity, occIdx int) *codeClimateIssue {
+	severity := "major"
+	if sim.Level != textsimilarity.EqualSimilarityLevel {
+		severity = "minor"
+	}
+
+	occ := sim.Occurrences[occIdx]
+
+	issue := codeClimateIssue{
+		Type:      "issue",
+		CheckName: codeClimateCheckName,
+		Description: fmt.Sprintf("%d lines %s to code in %d other places",
+			occ.End-occ.Start, sim.Level.String(), totalOccurrences(sim)-1),
+		Categories:  []string{"Duplication"},
+		Location:    codeClimateOccurrenceLocation(occ),
+		Severity:    severity,
+		Fingerprint: codeClimateFingerprint(sim, occIdx),
+	}
+
+	for idx, otherOcc := range sim.Occurrences {
+		if idx == occIdx {
+			continue
+		}
+
+		issue.OtherLocations = append(issue.OtherLocations, codeClimateOccurrenceLocation(otherOcc))
+	}
+
+	return &issue
+}
+
+// codeClimateOccurrenceLocation returns a Code Climate location for occ.
+func codeClimateOccurrenceLocation(occ *textsimilarity.FileOccurrence) *codeClimateLocation {
+	return &codeClimateLocation{
+		Path: filepath.ToSlash(occ.File.Name),
+		Lines: codeClimateLines{
+			Begin: occ.Start + 1,
+			End:   occ.End,
+		},
+	}
+}
+
+// codeClimateFingerprint returns a fingerprint for the occurrence of sim at index occIdx. The fingerprint is
+// derived from the similarity's ID, so it does not change when text is moved within a file. If the similarity
+// has no ID, the occurrence's line numbers are used instead.
+func codeClimateFingerprint(sim *textsimilarity.Similarity, occIdx int) string {
+	occ := sim.Occurrences[occIdx]
+
+	key := sim.ID()
+	if key == "" {
+		key = lineRange(occ)
+	}
+
+	hash := md5.Sum([]byte(key + "\x00" + filepath.ToSlash(occ.File.Name) + "\x00" + strconv.Itoa(occIdx))) //nolint:gosec // used for fingerprints only
+
+	return hex.EncodeToString(hash[:])
+}
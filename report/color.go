@@ -0,0 +1,39 @@
+package report
+
+import "github.com/blizzy78/textsimilarity"
+
+const (
+	// colorReset is the ANSI escape sequence to reset all colors.
+	colorReset = "\033[0m"
+
+	// colorRed is the ANSI escape sequence to set the foreground color to red.
+	colorRed = "\033[31m"
+
+	// colorGreen is the ANSI escape sequence to set the foreground color to green.
+	colorGreen = "\033[32m"
+
+	// colorYellow is the ANSI escape sequence to set the foreground color to yellow.
+	colorYellow = "\033[33m"
+
+	// colorFaint is the ANSI escape sequence to set faint intensity.
+	colorFaint = "\033[2m"
+)
+
+// colorize returns s wrapped in ANSI escape sequences to set its foreground color to color.
+// If enabled is false, s is returned unchanged.
+func colorize(s string, color string, enabled bool) string {
+	if !enabled || s == "" {
+		return s
+	}
+
+	return color + s + colorReset
+}
+
+// levelColor returns the color to use for similarities of level.
+func levelColor(level textsimilarity.SimilarityLevel) string {
+	if level == textsimilarity.EqualSimilarityLevel {
+		return colorGreen
+	}
+
+	return colorYellow
+}
@@ -0,0 +1,180 @@
+package report
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/blizzy78/textsimilarity"
+)
+
+// csvReporter writes similarities as CSV, one row per occurrence.
+type csvReporter struct{}
+
+var (
+	// csvHeader is the header row written by csvReporter.
+	csvHeader = []string{"similarity", "level", "lines", "file", "start", "end"}
+
+	// csvFilesHeader is the header row written by csvReporter for per-file statistics.
+	csvFilesHeader = []string{"file", "lines", "duplicatedLines", "duplicationPct", "partners"}
+
+	// csvDuplicatesHeader is the header row written by csvReporter for near-duplicate files.
+	csvDuplicatesHeader = []string{"similarity", "file1", "file2"}
+)
+
+func init() { //nolint:gochecknoinits // register built-in format
+	Register("csv", newCSVReporter)
+}
+
+// newCSVReporter returns a new Reporter that writes similarities as CSV, one row per occurrence.
+func newCSVReporter(_ *Options) (Reporter, error) {
+	return &csvReporter{}, nil
+}
+
+// Report implements Reporter.
+func (r *csvReporter) Report(ctx context.Context, w io.Writer, sims []*textsimilarity.Similarity) error {
+	csvWriter := csv.NewWriter(w)
+
+	if err := csvWriter.Write(csvHeader); err != nil {
+		return fmt.Errorf("write CSV: %w", err)
+	}
+
+	for idx, sim := range sims {
+		if contextDone(ctx) {
+			return ctx.Err()
+		}
+
+		for _, occ := range sim.Occurrences {
+			err := csvWriter.Write([]string{
+				strconv.Itoa(idx + 1),
+				levelID(sim.Level),
+				strconv.Itoa(similarityLines(sim)),
+				occ.File.Name,
+				strconv.Itoa(occ.Start + 1),
+				strconv.Itoa(occ.End),
+			})
+
+			if err != nil {
+				return fmt.Errorf("write CSV: %w", err)
+			}
+		}
+	}
+
+	csvWriter.Flush()
+
+	if err := csvWriter.Error(); err != nil {
+		return fmt.Errorf("write CSV: %w", err)
+	}
+
+	return nil
+}
+
+// ReportMatrix implements MatrixReporter. The first row and column contain the file names.
+func (r *csvReporter) ReportMatrix(ctx context.Context, w io.Writer, matrix *textsimilarity.SimilarityMatrix) error {
+	csvWriter := csv.NewWriter(w)
+
+	row := make([]string, len(matrix.Files)+1)
+	row[0] = "file"
+
+	for idx, file := range matrix.Files {
+		row[idx+1] = file.Name
+	}
+
+	if err := csvWriter.Write(row); err != nil {
+		return fmt.Errorf("write CSV: %w", err)
+	}
+
+	for idx, file := range matrix.Files {
+		if contextDone(ctx) {
+			return ctx.Err()
+		}
+
+		row[0] = file.Name
+
+		for idx2, fraction := range matrix.Fractions[idx] {
+			row[idx2+1] = strconv.FormatFloat(fraction, 'f', 3, 64)
+		}
+
+		if err := csvWriter.Write(row); err != nil {
+			return fmt.Errorf("write CSV: %w", err)
+		}
+	}
+
+	csvWriter.Flush()
+
+	if err := csvWriter.Error(); err != nil {
+		return fmt.Errorf("write CSV: %w", err)
+	}
+
+	return nil
+}
+
+// ReportFiles implements FilesReporter.
+func (r *csvReporter) ReportFiles(ctx context.Context, w io.Writer, stats []*textsimilarity.FileStats) error {
+	csvWriter := csv.NewWriter(w)
+
+	if err := csvWriter.Write(csvFilesHeader); err != nil {
+		return fmt.Errorf("write CSV: %w", err)
+	}
+
+	for _, stat := range stats {
+		if contextDone(ctx) {
+			return ctx.Err()
+		}
+
+		err := csvWriter.Write([]string{
+			stat.File.Name,
+			strconv.Itoa(stat.Lines),
+			strconv.Itoa(stat.DuplicatedLines),
+			strconv.FormatFloat(stat.DuplicationPct(), 'f', 1, 64),
+			strconv.Itoa(stat.Partners),
+		})
+
+		if err != nil {
+			return fmt.Errorf("write CSV: %w", err)
+		}
+	}
+
+	csvWriter.Flush()
+
+	if err := csvWriter.Error(); err != nil {
+		return fmt.Errorf("write CSV: %w", err)
+	}
+
+	return nil
+}
+
+// ReportDuplicates implements DuplicatesReporter.
+func (r *csvReporter) ReportDuplicates(ctx context.Context, w io.Writer, dupes []*textsimilarity.NearDuplicate) error {
+	csvWriter := csv.NewWriter(w)
+
+	if err := csvWriter.Write(csvDuplicatesHeader); err != nil {
+		return fmt.Errorf("write CSV: %w", err)
+	}
+
+	for _, dupe := range dupes {
+		if contextDone(ctx) {
+			return ctx.Err()
+		}
+
+		err := csvWriter.Write([]string{
+			strconv.FormatFloat(dupe.Similarity, 'f', 3, 64),
+			dupe.File1.Name,
+			dupe.File2.Name,
+		})
+
+		if err != nil {
+			return fmt.Errorf("write CSV: %w", err)
+		}
+	}
+
+	csvWriter.Flush()
+
+	if err := csvWriter.Error(); err != nil {
+		return fmt.Errorf("write CSV: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/blizzy78/textsimilarity/internal/diff"
+)
+
+// writeDiff writes the differences between text1 and text2 to w, one line per line of text.
+// Lines are prefixed with markers: "-" for removed lines, "+" for added lines, and "~" for changed lines.
+// For changed lines, the differing part is marked inline. If color is true, ANSI colors are used.
+func writeDiff(w io.Writer, text1 string, text2 string, color bool) {
+	edits := diff.Lines(splitLines(text1), splitLines(text2))
+
+	for idx := 0; idx < len(edits); {
+		switch edits[idx].Op {
+		case diff.EqualOp:
+			fmt.Fprintln(w, "  "+edits[idx].Text)
+			idx++
+
+		default:
+			idx = writeChangedLines(w, edits, idx, color)
+		}
+	}
+}
+
+// writeChangedLines writes the run of deletions and insertions in edits starting at idx to w, and returns
+// the index of the first edit after that run. Deletions and insertions are paired up as changed lines.
+func writeChangedLines(w io.Writer, edits []diff.Edit, idx int, color bool) int {
+	deletes := []string{}
+	for ; idx < len(edits) && edits[idx].Op == diff.DeleteOp; idx++ {
+		deletes = append(deletes, edits[idx].Text)
+	}
+
+	inserts := []string{}
+	for ; idx < len(edits) && edits[idx].Op == diff.InsertOp; idx++ {
+		inserts = append(inserts, edits[idx].Text)
+	}
+
+	changed := min(len(deletes), len(inserts))
+
+	for lineIdx := 0; lineIdx < changed; lineIdx++ {
+		fmt.Fprintln(w, colorize("~", colorYellow, color)+" "+changedLine(deletes[lineIdx], inserts[lineIdx], color))
+	}
+
+	for _, line := range deletes[changed:] {
+		fmt.Fprintln(w, colorize("- "+line, colorRed, color))
+	}
+
+	for _, line := range inserts[changed:] {
+		fmt.Fprintln(w, colorize("+ "+line, colorGreen, color))
+	}
+
+	return idx
+}
+
+// changedLine returns a single line that shows the differences between line1 and line2 inline.
+// If color is true, removed text is colored red and added text is colored green. Otherwise, removed
+// text is shown as "[-text-]" and added text as "{+text+}".
+func changedLine(line1 string, line2 string, color bool) string {
+	runes1 := []rune(line1)
+	runes2 := []rune(line2)
+
+	prefix, suffix := diff.CommonAffixes(runes1, runes2)
+
+	removed := string(runes1[prefix : len(runes1)-suffix])
+	added := string(runes2[prefix : len(runes2)-suffix])
+
+	if !color {
+		if removed != "" {
+			removed = "[-" + removed + "-]"
+		}
+
+		if added != "" {
+			added = "{+" + added + "+}"
+		}
+	}
+
+	return string(runes1[:prefix]) + colorize(removed, colorRed, color) + colorize(added, colorGreen, color) + string(runes1[len(runes1)-suffix:])
+}
+
+// splitLines splits text into lines. A trailing newline does not produce an additional empty line.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
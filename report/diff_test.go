@@ -0,0 +1,30 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestWriteDiff(t *testing.T) {
+	is := is.New(t)
+
+	buf := bytes.Buffer{}
+	writeDiff(&buf, "foo\nbar(3)\nbaz\n", "foo\nbar(5)\nbaz\nqux\n", false)
+
+	is.Equal(buf.String(), `  foo
+~ bar([-3-]{+5+})
+  baz
++ qux
+`)
+}
+
+func TestWriteDiff_Color(t *testing.T) {
+	is := is.New(t)
+
+	buf := bytes.Buffer{}
+	writeDiff(&buf, "foo\nbar\n", "foo\n", true)
+
+	is.Equal(buf.String(), "  foo\n"+colorRed+"- bar"+colorReset+"\n")
+}
@@ -0,0 +1,4 @@
+// Package report provides Reporters that write similarities in different formats, such as plain text or JSON.
+// Additional formats can be made available by registering a Factory using Register, usually from the init
+// function of a package in another Go module. The command line utility offers all registered formats.
+package report
@@ -0,0 +1,102 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/blizzy78/textsimilarity"
+)
+
+// dotReporter writes the graph of files and the similarities between them in the Graphviz DOT language.
+type dotReporter struct{}
+
+// A dotEdge is an edge between two files in the graph written by dotReporter.
+type dotEdge struct {
+	file1 string
+	file2 string
+}
+
+// dotQuoter escapes strings for use in quoted DOT IDs.
+var dotQuoter = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+func init() { //nolint:gochecknoinits // register built-in format
+	Register("dot", newDOTReporter)
+}
+
+// newDOTReporter returns a new Reporter that writes the graph of files and the similarities between them
+// in the Graphviz DOT language.
+func newDOTReporter(_ *Options) (Reporter, error) {
+	return &dotReporter{}, nil
+}
+
+// Report implements Reporter. Nodes are files, and edges are weighted by the number of lines shared between
+// two files, that is, the sum of the lengths of the similarities occurring in both files. Similarities within
+// a single file are not included.
+func (r *dotReporter) Report(ctx context.Context, w io.Writer, sims []*textsimilarity.Similarity) error {
+	files := map[string]struct{}{}
+	edges := map[dotEdge]int{}
+
+	for _, sim := range sims {
+		if contextDone(ctx) {
+			return ctx.Err()
+		}
+
+		for idx, occ := range sim.Occurrences {
+			files[occ.File.Name] = struct{}{}
+
+			for _, occ2 := range sim.Occurrences[idx+1:] {
+				if occ2.File == occ.File {
+					continue
+				}
+
+				edge := dotEdge{
+					file1: min(occ.File.Name, occ2.File.Name),
+					file2: max(occ.File.Name, occ2.File.Name),
+				}
+
+				edges[edge] += min(occ.End-occ.Start, occ2.End-occ2.Start)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	sortedEdges := make([]dotEdge, 0, len(edges))
+	for edge := range edges {
+		sortedEdges = append(sortedEdges, edge)
+	}
+
+	sort.Slice(sortedEdges, func(a int, b int) bool {
+		if sortedEdges[a].file1 != sortedEdges[b].file1 {
+			return sortedEdges[a].file1 < sortedEdges[b].file1
+		}
+
+		return sortedEdges[a].file2 < sortedEdges[b].file2
+	})
+
+	fmt.Fprintln(w, "graph similarities {")
+
+	for _, name := range names {
+		fmt.Fprintf(w, "  \"%s\";\n", dotQuoter.Replace(name))
+	}
+
+	for _, edge := range sortedEdges {
+		lines := edges[edge]
+		fmt.Fprintf(w, "  \"%s\" -- \"%s\" [weight=%d, label=\"%d\"];\n", dotQuoter.Replace(edge.file1), dotQuoter.Replace(edge.file2), lines, lines)
+	}
+
+	_, err := fmt.Fprintln(w, "}")
+	if err != nil {
+		return fmt.Errorf("write DOT: %w", err)
+	}
+
+	return nil
+}
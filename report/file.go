@@ -0,0 +1,77 @@
+package report
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	tsio "github.com/blizzy78/textsimilarity/internal/io"
+)
+
+// writeTempFile writes text to a temporary file and returns its path.
+func writeTempFile(text string) (string, error) {
+	file, err := os.CreateTemp("", "similarity")
+	if err != nil {
+		return "", fmt.Errorf("create: %w", err)
+	}
+
+	closed := false
+
+	defer func() {
+		if closed {
+			return
+		}
+
+		_ = file.Close()
+	}()
+
+	if _, err = file.WriteString(text); err != nil {
+		return "", fmt.Errorf("write: %w", err)
+	}
+
+	closed = true
+
+	if err = file.Close(); err != nil {
+		return "", fmt.Errorf("close: %w", err)
+	}
+
+	return file.Name(), nil
+}
+
+// fileText returns the text of file path, starting from startLine (zero-based), up to endLine (zero-based, exclusive.)
+func fileText(path string, startLine int, endLine int) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("open: %w", err)
+	}
+	defer file.Close() //nolint:errcheck // file is being read
+
+	textBuf := strings.Builder{}
+
+	reader := bufio.NewReader(file)
+	buf := bytes.Buffer{}
+
+	for lineIdx := 0; lineIdx < endLine; lineIdx++ {
+		line, err := tsio.ReadLine(reader, &buf)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return "", fmt.Errorf("read line: %w", err)
+		}
+
+		if lineIdx < startLine {
+			continue
+		}
+
+		textBuf.WriteString(line)
+		textBuf.WriteString("\n")
+	}
+
+	return textBuf.String(), nil
+}
@@ -0,0 +1,236 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+
+	"github.com/blizzy78/textsimilarity"
+)
+
+// htmlReporter writes similarities as a standalone HTML page.
+type htmlReporter struct {
+	opts *Options
+}
+
+// maxHeat is the maximum heat of a line in the coverage section of the HTML page. Lines covered by more
+// similarities are shown with the same heat.
+const maxHeat = 4
+
+// htmlReport is the data passed to htmlTemplate.
+type htmlReport struct {
+	Similarities []*htmlSimilarity
+	Coverage     []*htmlFileCoverage
+}
+
+// htmlSimilarity is a single similarity passed to htmlTemplate.
+type htmlSimilarity struct {
+	Number      int
+	Level       string
+	Lines       int
+	Occurrences []htmlOccurrence
+	Omitted     int
+	Text        string
+}
+
+// htmlOccurrence is a single occurrence of a htmlSimilarity.
+type htmlOccurrence struct {
+	File  string
+	Range string
+	Link  string
+}
+
+// htmlFileCoverage is the coverage of a single file passed to htmlTemplate.
+type htmlFileCoverage struct {
+	File  string
+	Lines []htmlLineCoverage
+}
+
+// htmlLineCoverage is the coverage of a single line of a htmlFileCoverage.
+type htmlLineCoverage struct {
+	Line  int
+	Count int
+	Heat  int
+}
+
+// htmlTemplate is the template used by htmlReporter.
+var htmlTemplate = template.Must(template.New("html").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Similarities</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+section { margin-bottom: 2em; }
+pre { background: #f4f4f4; padding: 1em; overflow-x: auto; }
+.similar { color: #b36b00; }
+.equal { color: #b30000; }
+.heat { display: flex; flex-wrap: wrap; margin: 0.25em 0 1em; }
+.heat span { width: 4px; height: 12px; }
+.h0 { background: #eee; }
+.h1 { background: #fdd49e; }
+.h2 { background: #fc8d59; }
+.h3 { background: #e34a33; }
+.h4 { background: #b30000; }
+</style>
+</head>
+<body>
+<h1>Similarities</h1>
+<p>{{len .Similarities}} similarities found.</p>
+{{range .Similarities}}<section>
+<h2>Similarity #{{.Number}} &ndash; {{.Lines}} lines, <span class="{{if eq .Level "similar"}}similar{{else}}equal{{end}}">{{.Level}}</span></h2>
+<ul>
+{{range .Occurrences}}<li>{{if .Link}}<a href="{{.Link}}">{{end}}<code>{{.File}}</code>: {{.Range}}{{if .Link}}</a>{{end}}</li>
+{{end}}{{if .Omitted}}<li>&hellip; and {{.Omitted}} more occurrences</li>
+{{end}}</ul>
+<pre>{{.Text}}</pre>
+</section>
+{{end}}{{if .Coverage}}<section>
+<h2>Coverage</h2>
+{{range .Coverage}}<div><code>{{.File}}</code></div>
+<div class="heat">{{range .Lines}}<span class="h{{.Heat}}" title="line {{.Line}}: {{.Count}}"></span>{{end}}</div>
+{{end}}</section>
+{{end}}</body>
+</html>
+`))
+
+// htmlFileCluster is a single cluster of files passed to htmlClustersTemplate.
+type htmlFileCluster struct {
+	Number     int
+	Similarity string
+	Files      []string
+}
+
+// htmlClustersTemplate is the template used by htmlReporter for clusters of files.
+var htmlClustersTemplate = template.Must(template.New("htmlClusters").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>File Clusters</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+section { margin-bottom: 2em; }
+</style>
+</head>
+<body>
+<h1>File Clusters</h1>
+<p>{{len .}} clusters found.</p>
+{{range .}}<section>
+<h2>Cluster #{{.Number}} &ndash; {{len .Files}} files, {{.Similarity}} similar</h2>
+<ul>
+{{range .Files}}<li><code>{{.}}</code></li>
+{{end}}</ul>
+</section>
+{{end}}</body>
+</html>
+`))
+
+func init() { //nolint:gochecknoinits // register built-in format
+	Register("html", newHTMLReporter)
+}
+
+// newHTMLReporter returns a new Reporter that writes similarities as a standalone HTML page.
+func newHTMLReporter(opts *Options) (Reporter, error) {
+	return &htmlReporter{
+		opts: opts,
+	}, nil
+}
+
+// Report implements Reporter. The text of each similarity's first occurrence is included in the page.
+func (r *htmlReporter) Report(ctx context.Context, w io.Writer, sims []*textsimilarity.Similarity) error {
+	htmlSims := make([]*htmlSimilarity, len(sims))
+
+	for idx, sim := range sims {
+		if contextDone(ctx) {
+			return ctx.Err()
+		}
+
+		text, err := r.opts.text(sim.Occurrences[0])
+		if err != nil {
+			return err
+		}
+
+		htmlSim := htmlSimilarity{
+			Number:      idx + 1,
+			Level:       sim.Level.String(),
+			Lines:       similarityLines(sim),
+			Occurrences: make([]htmlOccurrence, len(sim.Occurrences)),
+			Omitted:     sim.OmittedOccurrences,
+			Text:        text,
+		}
+
+		for occIdx, occ := range sim.Occurrences {
+			link, err := r.opts.link(occ)
+			if err != nil {
+				return err
+			}
+
+			htmlSim.Occurrences[occIdx] = htmlOccurrence{
+				File:  occ.File.Name,
+				Range: lineRange(occ),
+				Link:  link,
+			}
+		}
+
+		htmlSims[idx] = &htmlSim
+	}
+
+	rep := htmlReport{
+		Similarities: htmlSims,
+	}
+
+	if r.opts.Coverage {
+		rep.Coverage = htmlCoverage(sims)
+	}
+
+	if err := htmlTemplate.Execute(w, &rep); err != nil {
+		return fmt.Errorf("execute HTML template: %w", err)
+	}
+
+	return nil
+}
+
+// ReportClusters implements ClustersReporter.
+func (r *htmlReporter) ReportClusters(_ context.Context, w io.Writer, clusters []*textsimilarity.FileCluster) error {
+	htmlClusters := make([]*htmlFileCluster, len(clusters))
+
+	for idx, cluster := range clusters {
+		htmlClusters[idx] = &htmlFileCluster{
+			Number:     idx + 1,
+			Similarity: fmt.Sprintf("%.1f%%", cluster.Similarity*100),
+			Files:      clusterFileNames(cluster),
+		}
+	}
+
+	if err := htmlClustersTemplate.Execute(w, htmlClusters); err != nil {
+		return fmt.Errorf("execute HTML template: %w", err)
+	}
+
+	return nil
+}
+
+// htmlCoverage returns the coverage of all files covered by sims.
+func htmlCoverage(sims []*textsimilarity.Similarity) []*htmlFileCoverage {
+	coverage := textsimilarity.FilesCoverage(sims)
+	htmlCov := make([]*htmlFileCoverage, len(coverage))
+
+	for idx, fileCov := range coverage {
+		htmlFileCov := htmlFileCoverage{
+			File:  fileCov.File.Name,
+			Lines: make([]htmlLineCoverage, len(fileCov.Lines)),
+		}
+
+		for l, count := range fileCov.Lines {
+			htmlFileCov.Lines[l] = htmlLineCoverage{
+				Line:  l + 1,
+				Count: count,
+				Heat:  min(count, maxHeat),
+			}
+		}
+
+		htmlCov[idx] = &htmlFileCov
+	}
+
+	return htmlCov
+}
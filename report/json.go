@@ -0,0 +1,461 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/blizzy78/textsimilarity"
+)
+
+// jsonReporter writes similarities as a JSON document.
+type jsonReporter struct {
+	opts *Options
+}
+
+// jsonReport is the top-level JSON document written by jsonReporter.
+type jsonReport struct {
+	Similarities []*jsonSimilarity `json:"similarities"`
+
+	// Coverage is only included if Options.Coverage is set.
+	Coverage []*jsonFileCoverage `json:"coverage,omitempty"`
+
+	// Summary is only included if the files scanned are known.
+	Summary *jsonSummary `json:"summary,omitempty"`
+}
+
+// jsonSummary is the summary of all files scanned in a jsonReport.
+type jsonSummary struct {
+	Files           int     `json:"files"`
+	Lines           int     `json:"lines"`
+	DuplicatedLines int     `json:"duplicatedLines"`
+	DuplicationPct  float64 `json:"duplicationPct"`
+}
+
+// jsonFileCoverage is the coverage of a single file in a jsonReport.
+type jsonFileCoverage struct {
+	File string `json:"file"`
+
+	// Lines are the numbers of similarities covering each line of the file, starting with the first line.
+	Lines []int `json:"lines"`
+}
+
+// jsonSimilarity is a single similarity in a jsonReport.
+type jsonSimilarity struct {
+	ID          string            `json:"id"`
+	Level       string            `json:"level"`
+	Lines       int               `json:"lines"`
+	Occurrences []*jsonOccurrence `json:"occurrences"`
+
+	// Tier is the name of the tier the similarity has been assigned to, if any.
+	Tier string `json:"tier,omitempty"`
+
+	// LineLevels are the levels of the corresponding lines of the occurrences. They are only included for
+	// similar similarities, since the lines of equal similarities are all equal.
+	LineLevels []string `json:"lineLevels,omitempty"`
+
+	// OmittedOccurrences is the number of occurrences not included in Occurrences.
+	OmittedOccurrences int `json:"omittedOccurrences,omitempty"`
+}
+
+// jsonOccurrence is a single occurrence of a jsonSimilarity. Line numbers are one-based and inclusive.
+type jsonOccurrence struct {
+	File         string       `json:"file"`
+	Start        int          `json:"start"`
+	End          int          `json:"end"`
+	StartColumns *jsonColumns `json:"startColumns,omitempty"`
+	EndColumns   *jsonColumns `json:"endColumns,omitempty"`
+
+	// Scope is the name of the scope containing the occurrence, such as a function, if any.
+	Scope string `json:"scope,omitempty"`
+
+	// SimHash is the SimHash of the occurrence as 16 hexadecimal digits, if it has been computed.
+	SimHash string `json:"simHash,omitempty"`
+
+	// FuzzyHash is the ssdeep-style fuzzy hash of the occurrence, if it has been computed.
+	FuzzyHash string `json:"fuzzyHash,omitempty"`
+
+	// Meta is the metadata of the occurrence's file, if any.
+	Meta map[string]any `json:"meta,omitempty"`
+}
+
+// jsonColumns is a range of columns within a line of a jsonOccurrence. Column numbers are one-based and inclusive.
+type jsonColumns struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// jsonFilesReport is the top-level JSON document written by jsonReporter for per-file statistics.
+type jsonFilesReport struct {
+	Files []*jsonFileStats `json:"files"`
+}
+
+// jsonFileStats are the statistics of a single file in a jsonFilesReport.
+type jsonFileStats struct {
+	File            string  `json:"file"`
+	Lines           int     `json:"lines"`
+	DuplicatedLines int     `json:"duplicatedLines"`
+	DuplicationPct  float64 `json:"duplicationPct"`
+	Partners        int     `json:"partners"`
+}
+
+// jsonClassesReport is the top-level JSON document written by jsonReporter for clone classes.
+type jsonClassesReport struct {
+	Classes []*jsonCloneClass `json:"classes"`
+}
+
+// jsonCloneClass is a single clone class in a jsonClassesReport.
+type jsonCloneClass struct {
+	Lines        int               `json:"lines"`
+	Similarities int               `json:"similarities"`
+	Regions      []*jsonOccurrence `json:"regions"`
+
+	// Hint is only included if Options.Hints is set.
+	Hint *jsonExtractionHint `json:"hint,omitempty"`
+}
+
+// jsonExtractionHint is a suggestion of how to deduplicate a jsonCloneClass.
+type jsonExtractionHint struct {
+	Representative *jsonOccurrence            `json:"representative"`
+	Kind           string                     `json:"kind"`
+	Occurrences    []*jsonOccurrence          `json:"occurrences"`
+	Parameters     []*jsonExtractionParameter `json:"parameters,omitempty"`
+}
+
+// jsonExtractionParameter is a parameter of a jsonExtractionHint.
+type jsonExtractionParameter struct {
+	// Values are the values of the parameter in each occurrence of the hint, in the same order.
+	Values []string `json:"values"`
+
+	// Uses are the positions of the tokens replaced by the parameter in the representative.
+	Uses []*jsonTokenPosition `json:"uses"`
+}
+
+// jsonTokenPosition is the position of a token. Line numbers are one-based, token indexes are zero-based.
+type jsonTokenPosition struct {
+	Line  int `json:"line"`
+	Token int `json:"token"`
+}
+
+// jsonMatrixReport is the top-level JSON document written by jsonReporter for a similarity matrix.
+type jsonMatrixReport struct {
+	Files []string `json:"files"`
+
+	// Fractions are the fractions of lines shared by each pair of files, in the order of Files.
+	Fractions [][]float64 `json:"fractions"`
+}
+
+// jsonClustersReport is the top-level JSON document written by jsonReporter for clusters of files.
+type jsonClustersReport struct {
+	Clusters []*jsonFileCluster `json:"clusters"`
+}
+
+// jsonFileCluster is a single cluster of files in a jsonClustersReport.
+type jsonFileCluster struct {
+	Similarity float64  `json:"similarity"`
+	Files      []string `json:"files"`
+}
+
+// jsonDuplicatesReport is the top-level JSON document written by jsonReporter for near-duplicate files.
+type jsonDuplicatesReport struct {
+	Duplicates []*jsonNearDuplicate `json:"duplicates"`
+}
+
+// jsonNearDuplicate is a single pair of files in a jsonDuplicatesReport.
+type jsonNearDuplicate struct {
+	Similarity float64 `json:"similarity"`
+	File1      string  `json:"file1"`
+	File2      string  `json:"file2"`
+}
+
+func init() { //nolint:gochecknoinits // register built-in format
+	Register("json", newJSONReporter)
+}
+
+// newJSONReporter returns a new Reporter that writes similarities as a JSON document.
+func newJSONReporter(opts *Options) (Reporter, error) {
+	return &jsonReporter{
+		opts: opts,
+	}, nil
+}
+
+// Report implements Reporter.
+func (r *jsonReporter) Report(ctx context.Context, w io.Writer, sims []*textsimilarity.Similarity) error {
+	return r.report(ctx, w, sims, nil)
+}
+
+// ReportWithSummary implements SummaryReporter.
+func (r *jsonReporter) ReportWithSummary(ctx context.Context, w io.Writer, sims []*textsimilarity.Similarity,
+	files []*textsimilarity.File,
+) error {
+	return r.report(ctx, w, sims, files)
+}
+
+// report writes a report about sims to w. If files is not nil, a summary of files is included.
+func (r *jsonReporter) report(ctx context.Context, w io.Writer, sims []*textsimilarity.Similarity, files []*textsimilarity.File) error {
+	rep := jsonReport{
+		Similarities: make([]*jsonSimilarity, 0, len(sims)),
+	}
+
+	for _, sim := range sims {
+		if contextDone(ctx) {
+			return ctx.Err()
+		}
+
+		jsonSim := jsonSimilarity{
+			ID:                 sim.ID(),
+			Level:              levelID(sim.Level),
+			Tier:               sim.Tier,
+			LineLevels:         jsonLineLevels(sim),
+			Lines:              similarityLines(sim),
+			Occurrences:        make([]*jsonOccurrence, len(sim.Occurrences)),
+			OmittedOccurrences: sim.OmittedOccurrences,
+		}
+
+		for idx, occ := range sim.Occurrences {
+			jsonSim.Occurrences[idx] = &jsonOccurrence{
+				File:         occ.File.Name,
+				Start:        occ.Start + 1,
+				End:          occ.End,
+				StartColumns: newJSONColumns(occ.StartColumns),
+				EndColumns:   newJSONColumns(occ.EndColumns),
+				Scope:        scopeName(occ),
+				SimHash:      simHashString(occ.SimHash),
+				FuzzyHash:    occ.FuzzyHash,
+				Meta:         occ.File.Meta,
+			}
+		}
+
+		rep.Similarities = append(rep.Similarities, &jsonSim)
+	}
+
+	if r.opts != nil && r.opts.Coverage {
+		for _, fileCov := range textsimilarity.FilesCoverage(sims) {
+			rep.Coverage = append(rep.Coverage, &jsonFileCoverage{
+				File:  fileCov.File.Name,
+				Lines: fileCov.Lines,
+			})
+		}
+	}
+
+	if files != nil {
+		rep.Summary = newJSONSummary(sims, files)
+	}
+
+	return writeJSON(w, &rep)
+}
+
+// newJSONSummary returns the summary of files, in which sims have been found.
+func newJSONSummary(sims []*textsimilarity.Similarity, files []*textsimilarity.File) *jsonSummary {
+	summary := jsonSummary{
+		Files: len(files),
+	}
+
+	for _, stat := range textsimilarity.FilesStats(files, sims) {
+		summary.Lines += stat.Lines
+		summary.DuplicatedLines += stat.DuplicatedLines
+	}
+
+	if summary.Lines != 0 {
+		summary.DuplicationPct = float64(summary.DuplicatedLines) * 100.0 / float64(summary.Lines)
+	}
+
+	return &summary
+}
+
+// newJSONColumns returns cols as jsonColumns, or nil if cols is nil.
+func newJSONColumns(cols *textsimilarity.ColumnRange) *jsonColumns {
+	if cols == nil {
+		return nil
+	}
+
+	return &jsonColumns{
+		Start: cols.Start + 1,
+		End:   cols.End,
+	}
+}
+
+// ReportFiles implements FilesReporter.
+func (r *jsonReporter) ReportFiles(ctx context.Context, w io.Writer, stats []*textsimilarity.FileStats) error {
+	rep := jsonFilesReport{
+		Files: make([]*jsonFileStats, len(stats)),
+	}
+
+	for idx, stat := range stats {
+		if contextDone(ctx) {
+			return ctx.Err()
+		}
+
+		rep.Files[idx] = &jsonFileStats{
+			File:            stat.File.Name,
+			Lines:           stat.Lines,
+			DuplicatedLines: stat.DuplicatedLines,
+			DuplicationPct:  stat.DuplicationPct(),
+			Partners:        stat.Partners,
+		}
+	}
+
+	return writeJSON(w, &rep)
+}
+
+// ReportClasses implements ClassesReporter.
+func (r *jsonReporter) ReportClasses(ctx context.Context, w io.Writer, classes []*textsimilarity.CloneClass) error {
+	rep := jsonClassesReport{
+		Classes: make([]*jsonCloneClass, len(classes)),
+	}
+
+	for idx, class := range classes {
+		if contextDone(ctx) {
+			return ctx.Err()
+		}
+
+		jsonClass := jsonCloneClass{
+			Lines:        class.Lines(),
+			Similarities: len(class.Similarities),
+			Regions:      make([]*jsonOccurrence, len(class.Regions)),
+		}
+
+		for regionIdx, region := range class.Regions {
+			jsonClass.Regions[regionIdx] = &jsonOccurrence{
+				File:  region.File.Name,
+				Start: region.Start + 1,
+				End:   region.End,
+			}
+		}
+
+		if r.opts.Hints {
+			hint, err := class.ExtractionHint(r.opts.text)
+			if err != nil {
+				return fmt.Errorf("extraction hint: %w", err)
+			}
+
+			jsonClass.Hint = newJSONExtractionHint(hint)
+		}
+
+		rep.Classes[idx] = &jsonClass
+	}
+
+	return writeJSON(w, &rep)
+}
+
+// newJSONExtractionHint returns hint as a jsonExtractionHint.
+func newJSONExtractionHint(hint *textsimilarity.ExtractionHint) *jsonExtractionHint {
+	jsonHint := jsonExtractionHint{
+		Representative: &jsonOccurrence{
+			File:  hint.Representative.File.Name,
+			Start: hint.Representative.Start + 1,
+			End:   hint.Representative.End,
+		},
+		Kind:        extractionKindName(hint.Kind),
+		Occurrences: make([]*jsonOccurrence, len(hint.Occurrences)),
+	}
+
+	for idx, occ := range hint.Occurrences {
+		jsonHint.Occurrences[idx] = &jsonOccurrence{
+			File:  occ.File.Name,
+			Start: occ.Start + 1,
+			End:   occ.End,
+		}
+	}
+
+	for _, param := range hint.Parameters {
+		jsonParam := jsonExtractionParameter{
+			Values: param.Values,
+			Uses:   make([]*jsonTokenPosition, len(param.Uses)),
+		}
+
+		for idx, use := range param.Uses {
+			jsonParam.Uses[idx] = &jsonTokenPosition{
+				Line:  hint.Representative.Start + use.Line + 1,
+				Token: use.Token,
+			}
+		}
+
+		jsonHint.Parameters = append(jsonHint.Parameters, &jsonParam)
+	}
+
+	return &jsonHint
+}
+
+// ReportMatrix implements MatrixReporter.
+func (r *jsonReporter) ReportMatrix(_ context.Context, w io.Writer, matrix *textsimilarity.SimilarityMatrix) error {
+	rep := jsonMatrixReport{
+		Files:     make([]string, len(matrix.Files)),
+		Fractions: matrix.Fractions,
+	}
+
+	for idx, file := range matrix.Files {
+		rep.Files[idx] = file.Name
+	}
+
+	return writeJSON(w, &rep)
+}
+
+// ReportClusters implements ClustersReporter.
+func (r *jsonReporter) ReportClusters(_ context.Context, w io.Writer, clusters []*textsimilarity.FileCluster) error {
+	rep := jsonClustersReport{
+		Clusters: make([]*jsonFileCluster, len(clusters)),
+	}
+
+	for idx, cluster := range clusters {
+		rep.Clusters[idx] = &jsonFileCluster{
+			Similarity: cluster.Similarity,
+			Files:      clusterFileNames(cluster),
+		}
+	}
+
+	return writeJSON(w, &rep)
+}
+
+// ReportDuplicates implements DuplicatesReporter.
+func (r *jsonReporter) ReportDuplicates(_ context.Context, w io.Writer, dupes []*textsimilarity.NearDuplicate) error {
+	rep := jsonDuplicatesReport{
+		Duplicates: make([]*jsonNearDuplicate, len(dupes)),
+	}
+
+	for idx, dupe := range dupes {
+		rep.Duplicates[idx] = &jsonNearDuplicate{
+			Similarity: dupe.Similarity,
+			File1:      dupe.File1.Name,
+			File2:      dupe.File2.Name,
+		}
+	}
+
+	return writeJSON(w, &rep)
+}
+
+// writeJSON writes v to w as indented JSON.
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("encode JSON: %w", err)
+	}
+
+	return nil
+}
+
+// simHashString returns hash as 16 hexadecimal digits, or an empty string if hash is 0.
+func simHashString(hash uint64) string {
+	if hash == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("%016x", hash)
+}
+
+// jsonLineLevels returns the IDs of the levels of sim's lines, or nil if sim is not of SimilarSimilarityLevel.
+func jsonLineLevels(sim *textsimilarity.Similarity) []string {
+	if sim.Level != textsimilarity.SimilarSimilarityLevel || sim.LineLevels == nil {
+		return nil
+	}
+
+	levels := make([]string, len(sim.LineLevels))
+	for idx, level := range sim.LineLevels {
+		levels[idx] = levelID(level)
+	}
+
+	return levels
+}
@@ -0,0 +1,72 @@
+package report
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/blizzy78/textsimilarity"
+)
+
+const (
+	// hyperlinkStart is the start of an OSC 8 escape sequence to begin a hyperlink in a terminal.
+	hyperlinkStart = "\033]8;;"
+
+	// hyperlinkEnd is the end of an OSC 8 escape sequence.
+	hyperlinkEnd = "\033\\"
+)
+
+// A Link is the data passed to Options.LinkFormat to construct a link to an occurrence.
+type Link struct {
+	// Path is the slash-separated path of the occurrence's file, as given.
+	Path string
+
+	// AbsPath is the slash-separated absolute path of the occurrence's file.
+	AbsPath string
+
+	// Line is the starting line number of the occurrence (one-based.)
+	Line int
+
+	// EndLine is the ending line number of the occurrence (one-based, inclusive.)
+	EndLine int
+}
+
+// link returns a link to occ, according to o.LinkFormat. If o.LinkFormat is nil, it returns an empty string.
+func (o *Options) link(occ *textsimilarity.FileOccurrence) (string, error) {
+	if o.LinkFormat == nil {
+		return "", nil
+	}
+
+	absPath, err := filepath.Abs(occ.File.Name)
+	if err != nil {
+		return "", fmt.Errorf("absolute path of %s: %w", occ.File.Name, err)
+	}
+
+	buf := strings.Builder{}
+
+	err = o.LinkFormat.Execute(&buf, &Link{
+		Path:    filepath.ToSlash(occ.File.Name),
+		AbsPath: filepath.ToSlash(absPath),
+		Line:    occ.Start + 1,
+		EndLine: occ.End,
+	})
+
+	if err != nil {
+		return "", fmt.Errorf("execute link template: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// hyperlink returns s as a terminal hyperlink to url. If enabled is false or url is empty, s is followed
+// by url in parentheses instead.
+func hyperlink(s string, url string, enabled bool) string {
+	switch {
+	case url == "":
+		return s
+	case !enabled:
+		return s + " (" + url + ")"
+	default:
+		return hyperlinkStart + url + hyperlinkEnd + s + hyperlinkStart + hyperlinkEnd
+	}
+}
@@ -0,0 +1,235 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/blizzy78/textsimilarity"
+)
+
+// A Reporter writes a report about similarities.
+type Reporter interface {
+	// Report writes a report about sims to w. sims are expected to be sorted already.
+	Report(ctx context.Context, w io.Writer, sims []*textsimilarity.Similarity) error
+}
+
+// A SummaryReporter writes a report about similarities, along with a summary of all files scanned. Reporters
+// may optionally implement SummaryReporter, which is then used instead of Reporter.Report.
+type SummaryReporter interface {
+	// ReportWithSummary writes a report about sims, found in files, to w. sims are expected to be sorted already.
+	ReportWithSummary(ctx context.Context, w io.Writer, sims []*textsimilarity.Similarity, files []*textsimilarity.File) error
+}
+
+// A FilesReporter writes a report about per-file statistics. Reporters may optionally implement FilesReporter.
+type FilesReporter interface {
+	// ReportFiles writes a report about stats to w. stats are expected to be sorted already.
+	ReportFiles(ctx context.Context, w io.Writer, stats []*textsimilarity.FileStats) error
+}
+
+// A ClassesReporter writes a report about clone classes. Reporters may optionally implement ClassesReporter.
+type ClassesReporter interface {
+	// ReportClasses writes a report about classes to w. classes are expected to be sorted already.
+	ReportClasses(ctx context.Context, w io.Writer, classes []*textsimilarity.CloneClass) error
+}
+
+// A MatrixReporter writes a similarity matrix of files. Reporters may optionally implement MatrixReporter.
+type MatrixReporter interface {
+	// ReportMatrix writes matrix to w.
+	ReportMatrix(ctx context.Context, w io.Writer, matrix *textsimilarity.SimilarityMatrix) error
+}
+
+// A ClustersReporter writes a report about clusters of similar files. Reporters may optionally implement
+// ClustersReporter.
+type ClustersReporter interface {
+	// ReportClusters writes a report about clusters to w. clusters are expected to be sorted already.
+	ReportClusters(ctx context.Context, w io.Writer, clusters []*textsimilarity.FileCluster) error
+}
+
+// A DuplicatesReporter writes a report about files that are near-duplicates of each other as a whole. Reporters
+// may optionally implement DuplicatesReporter.
+type DuplicatesReporter interface {
+	// ReportDuplicates writes a report about dupes to w. dupes are expected to be sorted already.
+	ReportDuplicates(ctx context.Context, w io.Writer, dupes []*textsimilarity.NearDuplicate) error
+}
+
+// A Factory creates a new Reporter, configured according to opts.
+type Factory func(opts *Options) (Reporter, error)
+
+// Options specifies options for Reporters. Reporters may ignore options that do not apply to their format.
+type Options struct {
+	// PrintEqual indicates whether the text of exactly equal similarities should be printed.
+	PrintEqual bool
+
+	// DiffTool is a command line template for a diff tool to print similar, but not exactly equal, similarities.
+	// The template is executed with a struct containing the fields File1 and File2.
+	DiffTool *template.Template
+
+	// IgnoreDiffToolRC indicates whether the return code of running DiffTool should be ignored.
+	IgnoreDiffToolRC bool
+
+	// Diff indicates whether differences of similar, but not exactly equal, similarities should be printed
+	// using the built-in diff renderer. DiffTool takes precedence if set.
+	Diff bool
+
+	// Color indicates whether output may use ANSI colors.
+	Color bool
+
+	// LinkFormat is a template for links to occurrences, such as "vscode://file{{.AbsPath}}:{{.Line}}".
+	// The template is executed with a Link. If nil, no links are written.
+	LinkFormat *template.Template
+
+	// PreviewLines is the number of lines of each occurrence's text to print as a preview. If <= 0,
+	// no previews are printed.
+	PreviewLines int
+
+	// Coverage indicates whether the number of similarities covering each line of each file should be included,
+	// for rendering heat maps or editor gutters. Only the json and html formats support this.
+	Coverage bool
+
+	// Hints indicates whether a canonical representative and refactoring hints should be included for each clone
+	// class (see textsimilarity.CloneClass.ExtractionHint.) Only the text and json formats support this.
+	Hints bool
+
+	// Text returns the text of an occurrence. If nil, the occurrence's captured text is used if it is complete
+	// (see textsimilarity.Options.CaptureText), otherwise the text will be read from the file at occ.File.Name.
+	Text func(occ *textsimilarity.FileOccurrence) (string, error)
+}
+
+// errUnknownFormat is returned when a Reporter for an unknown format is requested.
+var errUnknownFormat = errors.New("unknown report format")
+
+var (
+	// factoriesLock guards factories.
+	factoriesLock sync.RWMutex
+
+	// factories maps format names to Factories.
+	factories = map[string]Factory{}
+)
+
+// Register makes a Reporter Factory available under format name. If a Factory is already registered
+// under the same name, it will be replaced. Register is intended to be called from the init function of
+// a package providing a format, so that the format is available as soon as the package is imported.
+// It panics if name is empty or factory is nil.
+func Register(name string, factory Factory) {
+	if name == "" {
+		panic("report: Register with empty format name")
+	}
+
+	if factory == nil {
+		panic("report: Register with nil factory for format " + name)
+	}
+
+	factoriesLock.Lock()
+	defer factoriesLock.Unlock()
+
+	factories[name] = factory
+}
+
+// New returns a new Reporter for format name, configured according to opts.
+func New(name string, opts *Options) (Reporter, error) {
+	factoriesLock.RLock()
+	factory, ok := factories[name]
+	factoriesLock.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", errUnknownFormat, name)
+	}
+
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	return factory(opts)
+}
+
+// Names returns the names of all registered formats, sorted alphabetically.
+func Names() []string {
+	factoriesLock.RLock()
+	defer factoriesLock.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// text returns the text of occ, according to o.
+func (o *Options) text(occ *textsimilarity.FileOccurrence) (string, error) {
+	if o.Text != nil {
+		return o.Text(occ)
+	}
+
+	if occ.Text != "" && strings.Count(occ.Text, "\n") == occ.End-occ.Start {
+		return occ.Text, nil
+	}
+
+	return fileText(occ.File.Name, occ.Start, occ.End)
+}
+
+// extractionKindName returns a human-readable name of kind.
+func extractionKindName(kind textsimilarity.ExtractionKind) string {
+	switch kind {
+	case textsimilarity.IdenticalExtraction:
+		return "identical"
+	case textsimilarity.ParameterizableExtraction:
+		return "parameterizable"
+	default:
+		return "divergent"
+	}
+}
+
+// levelID returns a machine-readable name of level.
+func levelID(level textsimilarity.SimilarityLevel) string {
+	switch level {
+	case textsimilarity.SimilarSimilarityLevel:
+		return "similar"
+	case textsimilarity.ReorderedSimilarityLevel:
+		return "reordered"
+	default:
+		return "equal"
+	}
+}
+
+// scopeName returns the name of the scope containing occ, or an empty string if there is none.
+func scopeName(occ *textsimilarity.FileOccurrence) string {
+	if scope := occ.Scope(); scope != nil {
+		return scope.Name
+	}
+
+	return ""
+}
+
+// similarityLines returns the number of lines of sim's first occurrence.
+func similarityLines(sim *textsimilarity.Similarity) int {
+	return sim.Occurrences[0].End - sim.Occurrences[0].Start
+}
+
+// totalOccurrences returns the number of occurrences of sim, including omitted ones.
+func totalOccurrences(sim *textsimilarity.Similarity) int {
+	return len(sim.Occurrences) + sim.OmittedOccurrences
+}
+
+// clusterFileNames returns the names of the files of cluster.
+func clusterFileNames(cluster *textsimilarity.FileCluster) []string {
+	names := make([]string, len(cluster.Files))
+	for idx, file := range cluster.Files {
+		names[idx] = file.Name
+	}
+
+	return names
+}
+
+// contextDone returns whether ctx is done.
+func contextDone(ctx context.Context) bool {
+	return ctx.Err() != nil
+}
@@ -0,0 +1,591 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"slices"
+	"testing"
+	"text/template"
+
+	"github.com/blizzy78/textsimilarity"
+	"github.com/matryer/is"
+)
+
+type testReporter struct{}
+
+func TestRegister(t *testing.T) {
+	is := is.New(t)
+
+	Register("test", func(_ *Options) (Reporter, error) {
+		return &testReporter{}, nil
+	})
+
+	rep, err := New("test", nil)
+	is.NoErr(err)
+	is.Equal(rep, &testReporter{})
+
+	is.True(slices.Contains(Names(), "test"))
+	is.True(slices.Contains(Names(), "text"))
+	is.True(slices.Contains(Names(), "json"))
+}
+
+func TestRegister_Invalid(t *testing.T) {
+	is := is.New(t)
+
+	panics := func(f func()) (panicked bool) {
+		defer func() {
+			panicked = recover() != nil
+		}()
+
+		f()
+
+		return false
+	}
+
+	is.True(panics(func() {
+		Register("", func(_ *Options) (Reporter, error) {
+			return &testReporter{}, nil
+		})
+	}))
+
+	is.True(panics(func() {
+		Register("test", nil)
+	}))
+}
+
+func TestNew_Unknown(t *testing.T) {
+	is := is.New(t)
+
+	_, err := New("unknown", nil)
+	is.True(err != nil)
+}
+
+func TestTextReporter(t *testing.T) {
+	is := is.New(t)
+
+	rep, _ := New("text", &Options{
+		PrintEqual: true,
+		Text: func(_ *textsimilarity.FileOccurrence) (string, error) {
+			return "foo\nbar\n", nil
+		},
+	})
+
+	buf := bytes.Buffer{}
+	err := rep.Report(context.Background(), &buf, testSimilarities())
+	is.NoErr(err)
+
+	is.Equal(buf.String(), `similarity #1 - 2 lines, exactly equal
+- 1.txt: 1-2
+- 2.txt: 5-6
+
+------------------------------
+foo
+bar
+------------------------------
+
+similarity #2 - 1 lines, similar
+- 1.txt: 10
+- 2.txt: 20
+`)
+}
+
+func TestAnnotateReporter(t *testing.T) {
+	is := is.New(t)
+
+	rep, _ := New("annotate", &Options{})
+
+	sims := testSimilarities()
+	sims[0].Occurrences[0].File.Lines = []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"}
+	sims[0].Occurrences[1].File.Lines = []string{"a", "b", "c", "d", "e", "f"}
+	sims[0].OmittedOccurrences = 1
+	sims[1].Occurrences = sims[1].Occurrences[:1]
+
+	buf := bytes.Buffer{}
+	err := rep.Report(context.Background(), &buf, sims)
+	is.NoErr(err)
+
+	is.Equal(buf.String(), `==> 1.txt <==
+        v #1 - 2 lines, exactly equal, also in 2.txt:5-6, 1 more
+ 1 #1 | a
+ 2 #1 | b
+ 3    | c
+ 4    | d
+ 5    | e
+ 6    | f
+ 7    | g
+ 8    | h
+ 9    | i
+        v #2 - 1 lines, similar
+10 #2 | j
+11    | k
+
+==> 2.txt <==
+1    | a
+2    | b
+3    | c
+4    | d
+       v #1 - 2 lines, exactly equal, also in 1.txt:1-2, 1 more
+5 #1 | e
+6 #1 | f
+`)
+}
+
+func TestTextReporter_Preview(t *testing.T) {
+	is := is.New(t)
+
+	rep, _ := New("text", &Options{
+		PreviewLines: 2,
+		Text: func(_ *textsimilarity.FileOccurrence) (string, error) {
+			return "foo\nbar\nbaz\n", nil
+		},
+	})
+
+	sims := testSimilarities()[:1]
+	sims[0].Occurrences = sims[0].Occurrences[:1]
+
+	buf := bytes.Buffer{}
+	err := rep.Report(context.Background(), &buf, sims)
+	is.NoErr(err)
+
+	is.Equal(buf.String(), `similarity #1 - 2 lines, exactly equal
+- 1.txt: 1-2
+  1 | foo
+  2 | bar
+    | ... (1 more lines)
+`)
+
+	rep, _ = New("text", &Options{
+		PreviewLines: 1,
+		Color:        true,
+		Text: func(_ *textsimilarity.FileOccurrence) (string, error) {
+			return "foo\n", nil
+		},
+	})
+
+	buf.Reset()
+	err = rep.Report(context.Background(), &buf, sims)
+	is.NoErr(err)
+
+	is.Equal(buf.String(), colorGreen+"similarity #1 - 2 lines, exactly equal"+colorReset+"\n"+
+		"- 1.txt: 1-2\n"+
+		"  "+colorFaint+"1 |"+colorReset+" "+colorGreen+"foo"+colorReset+"\n")
+}
+
+func TestTextReporter_CapturedText(t *testing.T) {
+	is := is.New(t)
+
+	rep, _ := New("text", &Options{
+		PreviewLines: 1,
+	})
+
+	sims := testSimilarities()[:1]
+	sims[0].Occurrences = sims[0].Occurrences[:1]
+	sims[0].Occurrences[0].Text = "foo\nbar\n"
+
+	buf := bytes.Buffer{}
+	err := rep.Report(context.Background(), &buf, sims)
+	is.NoErr(err)
+
+	is.Equal(buf.String(), `similarity #1 - 2 lines, exactly equal
+- 1.txt: 1-2
+  1 | foo
+    | ... (1 more lines)
+`)
+}
+
+func TestTextReporter_LinkFormat(t *testing.T) {
+	is := is.New(t)
+
+	rep, _ := New("text", &Options{
+		LinkFormat: template.Must(template.New("link").Parse("https://example.com/{{.Path}}#L{{.Line}}-L{{.EndLine}}")),
+	})
+
+	sims := testSimilarities()[:1]
+
+	buf := bytes.Buffer{}
+	err := rep.Report(context.Background(), &buf, sims)
+	is.NoErr(err)
+
+	is.Equal(buf.String(), `similarity #1 - 2 lines, exactly equal
+- 1.txt: 1-2 (https://example.com/1.txt#L1-L2)
+- 2.txt: 5-6 (https://example.com/2.txt#L5-L6)
+`)
+}
+
+func TestTextReporter_OmittedOccurrences(t *testing.T) {
+	is := is.New(t)
+
+	rep, _ := New("text", &Options{})
+
+	sims := testSimilarities()[:1]
+	sims[0].OmittedOccurrences = 3
+
+	buf := bytes.Buffer{}
+	err := rep.Report(context.Background(), &buf, sims)
+	is.NoErr(err)
+
+	is.Equal(buf.String(), `similarity #1 - 2 lines, exactly equal
+- 1.txt: 1-2
+- 2.txt: 5-6
+- ... and 3 more occurrences
+`)
+}
+
+func TestJSONReporter(t *testing.T) {
+	is := is.New(t)
+
+	rep, _ := New("json", nil)
+
+	buf := bytes.Buffer{}
+	err := rep.Report(context.Background(), &buf, testSimilarities())
+	is.NoErr(err)
+
+	jsonRep := jsonReport{}
+	is.NoErr(json.Unmarshal(buf.Bytes(), &jsonRep))
+
+	is.Equal(len(jsonRep.Similarities), 2)
+	is.Equal(jsonRep.Similarities[0].Level, "equal")
+	is.Equal(jsonRep.Similarities[0].Lines, 2)
+	is.Equal(*jsonRep.Similarities[0].Occurrences[1], jsonOccurrence{File: "2.txt", Start: 5, End: 6})
+	is.Equal(jsonRep.Similarities[0].LineLevels, nil)
+	is.Equal(jsonRep.Similarities[1].Level, "similar")
+	is.Equal(jsonRep.Similarities[1].LineLevels, []string{"similar"})
+	is.Equal(*jsonRep.Similarities[1].Occurrences[0].StartColumns, jsonColumns{Start: 5, End: 7})
+	is.Equal(jsonRep.Similarities[1].Occurrences[0].EndColumns, nil)
+}
+
+func TestJSONReporter_Meta(t *testing.T) {
+	is := is.New(t)
+
+	sims := testSimilarities()
+	sims[0].Occurrences[0].File.Meta = map[string]any{"repo": "foo", "stars": 42}
+
+	rep, _ := New("json", nil)
+
+	buf := bytes.Buffer{}
+	err := rep.Report(context.Background(), &buf, sims)
+	is.NoErr(err)
+
+	jsonRep := jsonReport{}
+	is.NoErr(json.Unmarshal(buf.Bytes(), &jsonRep))
+
+	is.Equal(jsonRep.Similarities[0].Occurrences[0].Meta, map[string]any{"repo": "foo", "stars": 42.0})
+	is.Equal(jsonRep.Similarities[0].Occurrences[1].Meta, nil)
+}
+
+func TestCSVReporter(t *testing.T) {
+	is := is.New(t)
+
+	rep, _ := New("csv", nil)
+
+	buf := bytes.Buffer{}
+	err := rep.Report(context.Background(), &buf, testSimilarities())
+	is.NoErr(err)
+
+	is.Equal(buf.String(), `similarity,level,lines,file,start,end
+1,equal,2,1.txt,1,2
+1,equal,2,2.txt,5,6
+2,similar,1,1.txt,10,10
+2,similar,1,2.txt,20,20
+`)
+}
+
+func TestCSVReporter_ReportFiles(t *testing.T) {
+	is := is.New(t)
+
+	rep, _ := New("csv", nil)
+
+	buf := bytes.Buffer{}
+	err := rep.(FilesReporter).ReportFiles(context.Background(), &buf, testFilesStats())
+	is.NoErr(err)
+
+	is.Equal(buf.String(), `file,lines,duplicatedLines,duplicationPct,partners
+1.txt,4,3,75.0,1
+2.txt,40,3,7.5,1
+`)
+}
+
+func TestCSVReporter_ReportMatrix(t *testing.T) {
+	is := is.New(t)
+
+	rep, _ := New("csv", nil)
+
+	sims := testSimilarities()
+
+	matrix := textsimilarity.SimilarityMatrix{
+		Files:     []*textsimilarity.File{sims[0].Occurrences[0].File, sims[0].Occurrences[1].File},
+		Fractions: [][]float64{{1, 0.25}, {0.25, 1}},
+	}
+
+	buf := bytes.Buffer{}
+	err := rep.(MatrixReporter).ReportMatrix(context.Background(), &buf, &matrix)
+	is.NoErr(err)
+
+	is.Equal(buf.String(), `file,1.txt,2.txt
+1.txt,1.000,0.250
+2.txt,0.250,1.000
+`)
+}
+
+func TestJSONReporter_ReportClusters(t *testing.T) {
+	is := is.New(t)
+
+	rep, _ := New("json", nil)
+
+	sims := testSimilarities()
+
+	clusters := []*textsimilarity.FileCluster{
+		{Files: []*textsimilarity.File{sims[0].Occurrences[0].File, sims[0].Occurrences[1].File}, Similarity: 0.75},
+	}
+
+	buf := bytes.Buffer{}
+	err := rep.(ClustersReporter).ReportClusters(context.Background(), &buf, clusters)
+	is.NoErr(err)
+
+	clustersRep := jsonClustersReport{}
+	is.NoErr(json.Unmarshal(buf.Bytes(), &clustersRep))
+
+	is.Equal(len(clustersRep.Clusters), 1)
+	is.Equal(*clustersRep.Clusters[0], jsonFileCluster{Similarity: 0.75, Files: []string{"1.txt", "2.txt"}})
+}
+
+func TestCSVReporter_ReportDuplicates(t *testing.T) {
+	is := is.New(t)
+
+	rep, _ := New("csv", nil)
+
+	sims := testSimilarities()
+
+	dupes := []*textsimilarity.NearDuplicate{
+		{File1: sims[0].Occurrences[0].File, File2: sims[0].Occurrences[1].File, Similarity: 0.95},
+	}
+
+	buf := bytes.Buffer{}
+	err := rep.(DuplicatesReporter).ReportDuplicates(context.Background(), &buf, dupes)
+	is.NoErr(err)
+
+	is.Equal(buf.String(), `similarity,file1,file2
+0.950,1.txt,2.txt
+`)
+}
+
+func TestTextReporter_ReportFiles(t *testing.T) {
+	is := is.New(t)
+
+	rep, _ := New("text", &Options{})
+
+	buf := bytes.Buffer{}
+	err := rep.(FilesReporter).ReportFiles(context.Background(), &buf, testFilesStats())
+	is.NoErr(err)
+
+	is.Equal(buf.String(), "  75.0%   3/4 lines  1 partners 1.txt\n"+
+		"   7.5%  3/40 lines  1 partners 2.txt\n")
+}
+
+func TestJSONReporter_Coverage(t *testing.T) {
+	is := is.New(t)
+
+	rep, _ := New("json", &Options{Coverage: true})
+
+	buf := bytes.Buffer{}
+	err := rep.Report(context.Background(), &buf, testSimilarities())
+	is.NoErr(err)
+
+	jsonRep := jsonReport{}
+	is.NoErr(json.Unmarshal(buf.Bytes(), &jsonRep))
+
+	is.Equal(len(jsonRep.Coverage), 2)
+	is.Equal(jsonRep.Coverage[0].File, "1.txt")
+	is.Equal(jsonRep.Coverage[0].Lines, []int{1, 1, 0, 0, 0, 0, 0, 0, 0, 1})
+}
+
+func TestTextReporter_ReportClasses(t *testing.T) {
+	is := is.New(t)
+
+	rep, _ := New("text", &Options{})
+
+	buf := bytes.Buffer{}
+	err := rep.(ClassesReporter).ReportClasses(context.Background(), &buf, textsimilarity.CloneClasses(testSimilarities()))
+	is.NoErr(err)
+
+	is.Equal(buf.String(), `clone class #1 - 2 regions, 4 lines, 1 similarities
+- 1.txt: 1-2
+- 2.txt: 5-6
+
+clone class #2 - 2 regions, 2 lines, 1 similarities
+- 1.txt: 10
+- 2.txt: 20
+`)
+}
+
+func TestTextReporter_ReportClasses_Hints(t *testing.T) {
+	is := is.New(t)
+
+	rep, _ := New("text", &Options{
+		Hints: true,
+		Text: func(occ *textsimilarity.FileOccurrence) (string, error) {
+			return "foo(" + occ.File.Name + ")\n" + occ.File.Name + "\n", nil
+		},
+	})
+
+	buf := bytes.Buffer{}
+	err := rep.(ClassesReporter).ReportClasses(context.Background(), &buf, textsimilarity.CloneClasses(testSimilarities()[:1]))
+	is.NoErr(err)
+
+	is.Equal(buf.String(), `clone class #1 - 2 regions, 4 lines, 1 similarities
+- 1.txt: 1-2
+- 2.txt: 5-6
+representative: 1.txt: 1-2
+hint: parameterizable, extract representative with 1 parameters
+- $1: 1 | 2 (lines 1, 2)
+`)
+}
+
+func TestJSONReporter_ReportClasses(t *testing.T) {
+	is := is.New(t)
+
+	rep, _ := New("json", nil)
+
+	buf := bytes.Buffer{}
+	err := rep.(ClassesReporter).ReportClasses(context.Background(), &buf, textsimilarity.CloneClasses(testSimilarities()))
+	is.NoErr(err)
+
+	classesRep := jsonClassesReport{}
+	is.NoErr(json.Unmarshal(buf.Bytes(), &classesRep))
+
+	is.Equal(len(classesRep.Classes), 2)
+	is.Equal(classesRep.Classes[0].Lines, 4)
+	is.Equal(*classesRep.Classes[0].Regions[1], jsonOccurrence{File: "2.txt", Start: 5, End: 6})
+}
+
+func TestJSONReporter_ReportClasses_Hints(t *testing.T) {
+	is := is.New(t)
+
+	rep, _ := New("json", &Options{
+		Hints: true,
+		Text: func(_ *textsimilarity.FileOccurrence) (string, error) {
+			return "foo\nbar\n", nil
+		},
+	})
+
+	buf := bytes.Buffer{}
+	err := rep.(ClassesReporter).ReportClasses(context.Background(), &buf, textsimilarity.CloneClasses(testSimilarities()))
+	is.NoErr(err)
+
+	classesRep := jsonClassesReport{}
+	is.NoErr(json.Unmarshal(buf.Bytes(), &classesRep))
+
+	is.Equal(classesRep.Classes[0].Hint.Kind, "identical")
+	is.Equal(*classesRep.Classes[0].Hint.Representative, jsonOccurrence{File: "1.txt", Start: 1, End: 2})
+	is.Equal(len(classesRep.Classes[0].Hint.Occurrences), 2)
+	is.Equal(len(classesRep.Classes[0].Hint.Parameters), 0)
+}
+
+func TestDOTReporter(t *testing.T) {
+	is := is.New(t)
+
+	rep, _ := New("dot", nil)
+
+	buf := bytes.Buffer{}
+	err := rep.Report(context.Background(), &buf, testSimilarities())
+	is.NoErr(err)
+
+	is.Equal(buf.String(), `graph similarities {
+  "1.txt";
+  "2.txt";
+  "1.txt" -- "2.txt" [weight=3, label="3"];
+}
+`)
+}
+
+func TestCodeClimateReporter(t *testing.T) {
+	is := is.New(t)
+
+	rep, _ := New("codeclimate", nil)
+
+	buf := bytes.Buffer{}
+	err := rep.Report(context.Background(), &buf, testSimilarities())
+	is.NoErr(err)
+
+	docs := bytes.Split(bytes.TrimSuffix(buf.Bytes(), []byte{0}), []byte{0})
+	is.Equal(len(docs), 4)
+
+	issues := make([]*codeClimateIssue, len(docs))
+
+	for idx, doc := range docs {
+		issues[idx] = &codeClimateIssue{}
+		is.NoErr(json.Unmarshal(doc, issues[idx]))
+	}
+
+	is.Equal(issues[0].Type, "issue")
+	is.Equal(issues[0].Categories, []string{"Duplication"})
+	is.Equal(issues[0].Severity, "major")
+	is.Equal(*issues[0].Location, codeClimateLocation{Path: "1.txt", Lines: codeClimateLines{Begin: 1, End: 2}})
+	is.Equal(len(issues[0].OtherLocations), 1)
+	is.Equal(issues[0].OtherLocations[0].Path, "2.txt")
+	is.Equal(issues[2].Severity, "minor")
+
+	fingerprints := map[string]struct{}{}
+	for _, issue := range issues {
+		fingerprints[issue.Fingerprint] = struct{}{}
+	}
+
+	is.Equal(len(fingerprints), 4)
+}
+
+func TestSARIFReporter(t *testing.T) {
+	is := is.New(t)
+
+	rep, _ := New("sarif", nil)
+
+	buf := bytes.Buffer{}
+	err := rep.Report(context.Background(), &buf, testSimilarities())
+	is.NoErr(err)
+
+	log := sarifLog{}
+	is.NoErr(json.Unmarshal(buf.Bytes(), &log))
+
+	is.Equal(log.Version, sarifVersion)
+	is.Equal(len(log.Runs[0].Results), 2)
+	is.Equal(log.Runs[0].Results[0].Locations[0].PhysicalLocation.Region, sarifRegion{StartLine: 1, EndLine: 2})
+	is.Equal(len(log.Runs[0].Results[0].RelatedLocations), 1)
+	is.Equal(log.Runs[0].Results[1].Locations[0].PhysicalLocation.Region, sarifRegion{StartLine: 10, StartColumn: 5, EndLine: 10})
+}
+
+func (r *testReporter) Report(_ context.Context, _ io.Writer, _ []*textsimilarity.Similarity) error {
+	return nil
+}
+
+func testSimilarities() []*textsimilarity.Similarity {
+	file1 := &textsimilarity.File{Name: "1.txt"}
+	file2 := &textsimilarity.File{Name: "2.txt"}
+
+	return []*textsimilarity.Similarity{
+		{
+			Occurrences: []*textsimilarity.FileOccurrence{
+				{File: file1, Start: 0, End: 2},
+				{File: file2, Start: 4, End: 6},
+			},
+			Level: textsimilarity.EqualSimilarityLevel,
+		},
+		{
+			Occurrences: []*textsimilarity.FileOccurrence{
+				{File: file1, Start: 9, End: 10, StartColumns: &textsimilarity.ColumnRange{Start: 4, End: 7}},
+				{File: file2, Start: 19, End: 20},
+			},
+			Level:      textsimilarity.SimilarSimilarityLevel,
+			LineLevels: []textsimilarity.SimilarityLevel{textsimilarity.SimilarSimilarityLevel},
+		},
+	}
+}
+
+func testFilesStats() []*textsimilarity.FileStats {
+	sims := testSimilarities()
+
+	return []*textsimilarity.FileStats{
+		{File: sims[0].Occurrences[0].File, Lines: 4, DuplicatedLines: 3, Partners: 1},
+		{File: sims[0].Occurrences[1].File, Lines: 40, DuplicatedLines: 3, Partners: 1},
+	}
+}
@@ -0,0 +1,198 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/blizzy78/textsimilarity"
+)
+
+const (
+	// sarifVersion is the version of the SARIF format written by sarifReporter.
+	sarifVersion = "2.1.0"
+
+	// sarifSchema is the JSON schema URI of the SARIF format written by sarifReporter.
+	sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	// sarifColumnKind is the kind of columns used in regions, since columns are counted in runes.
+	sarifColumnKind = "unicodeCodePoints"
+
+	// sarifRuleID is the ID of the rule reported for all similarities.
+	sarifRuleID = "duplicate-text"
+
+	// toolName is the name of the tool as reported in machine-readable formats.
+	toolName = "textsimilarity"
+
+	// toolURI is the URI of the tool's website as reported in machine-readable formats.
+	toolURI = "https://github.com/blizzy78/textsimilarity"
+)
+
+// sarifReporter writes similarities in the Static Analysis Results Interchange Format (SARIF.)
+type sarifReporter struct{}
+
+type sarifLog struct {
+	Version string      `json:"version"`
+	Schema  string      `json:"$schema"`
+	Runs    []*sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool      `json:"tool"`
+	ColumnKind string         `json:"columnKind"`
+	Results    []*sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string       `json:"name"`
+	InformationURI string       `json:"informationUri"`
+	Rules          []*sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID           string           `json:"ruleId"`
+	Level            string           `json:"level"`
+	Message          sarifMessage     `json:"message"`
+	Locations        []*sarifLocation `json:"locations"`
+	RelatedLocations []*sarifLocation `json:"relatedLocations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	ID               int                   `json:"id,omitempty"`
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+func init() { //nolint:gochecknoinits // register built-in format
+	Register("sarif", newSARIFReporter)
+}
+
+// newSARIFReporter returns a new Reporter that writes similarities in the Static Analysis Results Interchange Format (SARIF.)
+func newSARIFReporter(_ *Options) (Reporter, error) {
+	return &sarifReporter{}, nil
+}
+
+// Report implements Reporter. Each similarity is reported as a single result located at its first occurrence,
+// with all other occurrences as related locations.
+func (r *sarifReporter) Report(ctx context.Context, w io.Writer, sims []*textsimilarity.Similarity) error {
+	run := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           toolName,
+				InformationURI: toolURI,
+				Rules: []*sarifRule{
+					{
+						ID:               sarifRuleID,
+						ShortDescription: sarifMessage{Text: "Duplicated text"},
+					},
+				},
+			},
+		},
+		ColumnKind: sarifColumnKind,
+		Results:    make([]*sarifResult, 0, len(sims)),
+	}
+
+	for _, sim := range sims {
+		if contextDone(ctx) {
+			return ctx.Err()
+		}
+
+		run.Results = append(run.Results, sarifSimilarityResult(sim))
+	}
+
+	log := sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs:    []*sarifRun{&run},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(&log); err != nil {
+		return fmt.Errorf("encode SARIF: %w", err)
+	}
+
+	return nil
+}
+
+// sarifSimilarityResult returns a SARIF result for sim.
+func sarifSimilarityResult(sim *textsimilarity.Similarity) *sarifResult {
+	level := "warning"
+	if sim.Level != textsimilarity.EqualSimilarityLevel {
+		level = "note"
+	}
+
+	res := sarifResult{
+		RuleID: sarifRuleID,
+		Level:  level,
+		Message: sarifMessage{
+			Text: fmt.Sprintf("%d lines, %s, found in %d places", similarityLines(sim), sim.Level.String(), totalOccurrences(sim)),
+		},
+		Locations: []*sarifLocation{sarifOccurrenceLocation(sim.Occurrences[0], 0)},
+	}
+
+	for idx, occ := range sim.Occurrences[1:] {
+		res.RelatedLocations = append(res.RelatedLocations, sarifOccurrenceLocation(occ, idx+1))
+	}
+
+	return &res
+}
+
+// sarifOccurrenceLocation returns a SARIF location for occ, using id. If occ has column ranges, the region
+// is narrowed to start at the first differing column of the first line, and to end after the last differing
+// column of the last line.
+func sarifOccurrenceLocation(occ *textsimilarity.FileOccurrence, id int) *sarifLocation {
+	loc := sarifLocation{
+		ID: id,
+		PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{
+				URI: filepath.ToSlash(occ.File.Name),
+			},
+			Region: sarifRegion{
+				StartLine: occ.Start + 1,
+				EndLine:   occ.End,
+			},
+		},
+	}
+
+	if occ.StartColumns != nil {
+		loc.PhysicalLocation.Region.StartColumn = occ.StartColumns.Start + 1
+	}
+
+	if occ.EndColumns != nil {
+		loc.PhysicalLocation.Region.EndColumn = occ.EndColumns.End + 1
+	}
+
+	return &loc
+}
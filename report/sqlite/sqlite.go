@@ -0,0 +1,227 @@
+// Package sqlite provides a report format that writes similarities into a SQLite database, so that large
+// results can be queried using SQL instead of searching text output. Importing the package registers the
+// "sqlite" format with package report. The package requires cgo.
+//
+// Databases are created using Schema. Line numbers are one-based and inclusive, as in the json format.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/blizzy78/textsimilarity"
+	"github.com/blizzy78/textsimilarity/report"
+
+	// register database driver
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Schema is the schema of databases written by Export.
+const Schema = `CREATE TABLE files (
+	id INTEGER PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE,
+	lines INTEGER NOT NULL
+);
+
+CREATE TABLE similarities (
+	id INTEGER PRIMARY KEY,
+	similarity_id TEXT NOT NULL,
+	level TEXT NOT NULL,
+	lines INTEGER NOT NULL,
+	occurrences INTEGER NOT NULL,
+	omitted_occurrences INTEGER NOT NULL
+);
+
+CREATE TABLE occurrences (
+	id INTEGER PRIMARY KEY,
+	similarity INTEGER NOT NULL REFERENCES similarities (id),
+	file INTEGER NOT NULL REFERENCES files (id),
+	start_line INTEGER NOT NULL,
+	end_line INTEGER NOT NULL
+);
+
+CREATE INDEX occurrences_similarity ON occurrences (similarity);
+CREATE INDEX occurrences_file ON occurrences (file);
+`
+
+// reporter writes similarities into a SQLite database.
+type reporter struct{}
+
+func init() { //nolint:gochecknoinits // register format
+	report.Register("sqlite", newReporter)
+}
+
+// newReporter returns a new Reporter that writes similarities into a SQLite database.
+func newReporter(_ *report.Options) (report.Reporter, error) {
+	return &reporter{}, nil
+}
+
+// Report implements report.Reporter. Only files in which sims occur are written.
+func (r *reporter) Report(ctx context.Context, w io.Writer, sims []*textsimilarity.Similarity) error {
+	return r.report(ctx, w, sims, nil)
+}
+
+// ReportWithSummary implements report.SummaryReporter. All files are written.
+func (r *reporter) ReportWithSummary(ctx context.Context, w io.Writer, sims []*textsimilarity.Similarity,
+	files []*textsimilarity.File,
+) error {
+	return r.report(ctx, w, sims, files)
+}
+
+// report writes a database of sims, found in files, to w. The database is written to a temporary file first.
+func (r *reporter) report(ctx context.Context, w io.Writer, sims []*textsimilarity.Similarity, files []*textsimilarity.File) error {
+	dir, err := os.MkdirTemp("", "textsimilarity-sqlite-*")
+	if err != nil {
+		return fmt.Errorf("create temporary directory: %w", err)
+	}
+
+	defer os.RemoveAll(dir) //nolint:errcheck // best effort
+
+	path := dir + string(os.PathSeparator) + "report.db"
+
+	if err := Export(ctx, path, sims, files); err != nil {
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", path, err)
+	}
+
+	defer file.Close() //nolint:errcheck // file is being read
+
+	if _, err := io.Copy(w, file); err != nil {
+		return fmt.Errorf("copy database: %w", err)
+	}
+
+	return nil
+}
+
+// Export writes sims, found in files, into a new SQLite database at path, which must not exist yet. If files is nil,
+// only files in which sims occur are written.
+func Export(ctx context.Context, path string, sims []*textsimilarity.Similarity, files []*textsimilarity.File) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s: %w", path, os.ErrExist)
+	}
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return fmt.Errorf("open database %s: %w", path, err)
+	}
+
+	defer db.Close() //nolint:errcheck // closed explicitly below
+
+	if _, err := db.ExecContext(ctx, Schema); err != nil {
+		return fmt.Errorf("create schema: %w", err)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	defer tx.Rollback() //nolint:errcheck // no-op after commit
+
+	if err := insert(ctx, tx, sims, files); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	if err := db.Close(); err != nil {
+		return fmt.Errorf("close database %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// insert inserts files and sims using tx. If files is nil, only files in which sims occur are inserted.
+func insert(ctx context.Context, tx *sql.Tx, sims []*textsimilarity.Similarity, files []*textsimilarity.File) error {
+	fileStmt, err := tx.PrepareContext(ctx, "INSERT INTO files (name, lines) VALUES (?, ?)")
+	if err != nil {
+		return fmt.Errorf("prepare statement: %w", err)
+	}
+
+	simStmt, err := tx.PrepareContext(ctx, `INSERT INTO similarities (similarity_id, level, lines, occurrences, omitted_occurrences)
+VALUES (?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("prepare statement: %w", err)
+	}
+
+	occStmt, err := tx.PrepareContext(ctx, "INSERT INTO occurrences (similarity, file, start_line, end_line) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		return fmt.Errorf("prepare statement: %w", err)
+	}
+
+	// fileIDs maps files to their row IDs
+	fileIDs := map[*textsimilarity.File]int64{}
+
+	insertFile := func(file *textsimilarity.File) (int64, error) {
+		if id, ok := fileIDs[file]; ok {
+			return id, nil
+		}
+
+		res, err := fileStmt.ExecContext(ctx, file.Name, file.LineCount())
+		if err != nil {
+			return 0, fmt.Errorf("insert file %s: %w", file.Name, err)
+		}
+
+		id, err := res.LastInsertId()
+		if err != nil {
+			return 0, fmt.Errorf("insert file %s: %w", file.Name, err)
+		}
+
+		fileIDs[file] = id
+
+		return id, nil
+	}
+
+	for _, file := range files {
+		if _, err := insertFile(file); err != nil {
+			return err
+		}
+	}
+
+	for _, sim := range sims {
+		res, err := simStmt.ExecContext(ctx, sim.ID(), levelID(sim.Level), sim.Occurrences[0].End-sim.Occurrences[0].Start,
+			len(sim.Occurrences)+sim.OmittedOccurrences, sim.OmittedOccurrences)
+		if err != nil {
+			return fmt.Errorf("insert similarity: %w", err)
+		}
+
+		simID, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("insert similarity: %w", err)
+		}
+
+		for _, occ := range sim.Occurrences {
+			fileID, err := insertFile(occ.File)
+			if err != nil {
+				return err
+			}
+
+			if _, err := occStmt.ExecContext(ctx, simID, fileID, occ.Start+1, occ.End); err != nil {
+				return fmt.Errorf("insert occurrence: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// levelID returns a machine-readable name of level, as used by the json format.
+func levelID(level textsimilarity.SimilarityLevel) string {
+	switch level {
+	case textsimilarity.SimilarSimilarityLevel:
+		return "similar"
+	case textsimilarity.ReorderedSimilarityLevel:
+		return "reordered"
+	default:
+		return "equal"
+	}
+}
@@ -0,0 +1,68 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/blizzy78/textsimilarity"
+	"github.com/matryer/is"
+)
+
+func TestExport(t *testing.T) {
+	is := is.New(t)
+
+	file1 := &textsimilarity.File{Name: "1.txt"}
+	file2 := &textsimilarity.File{Name: "2.txt"}
+	file3 := &textsimilarity.File{Name: "3.txt"}
+
+	sims := []*textsimilarity.Similarity{
+		{
+			Occurrences: []*textsimilarity.FileOccurrence{
+				{File: file1, Start: 0, End: 2},
+				{File: file2, Start: 4, End: 6},
+			},
+			Level:              textsimilarity.SimilarSimilarityLevel,
+			OmittedOccurrences: 1,
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "report.db")
+
+	is.NoErr(Export(context.Background(), path, sims, []*textsimilarity.File{file1, file2, file3}))
+
+	db, err := sql.Open("sqlite3", path)
+	is.NoErr(err)
+
+	defer db.Close() //nolint:errcheck // only read
+
+	files := 0
+	is.NoErr(db.QueryRow("SELECT COUNT(*) FROM files").Scan(&files))
+	is.Equal(files, 3)
+
+	var (
+		level       string
+		occurrences int
+		omitted     int
+	)
+
+	is.NoErr(db.QueryRow("SELECT level, occurrences, omitted_occurrences FROM similarities").Scan(&level, &occurrences, &omitted))
+	is.Equal(level, "similar")
+	is.Equal(occurrences, 3)
+	is.Equal(omitted, 1)
+
+	var (
+		name  string
+		start int
+		end   int
+	)
+
+	is.NoErr(db.QueryRow(`SELECT f.name, o.start_line, o.end_line FROM occurrences o JOIN files f ON f.id = o.file
+ORDER BY f.name DESC LIMIT 1`).Scan(&name, &start, &end))
+	is.Equal(name, "2.txt")
+	is.Equal(start, 5)
+	is.Equal(end, 6)
+
+	is.True(Export(context.Background(), path, sims, nil) != nil)
+}
@@ -0,0 +1,366 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/blizzy78/textsimilarity"
+)
+
+// separator is printed before and after text of similarities.
+const separator = "------------------------------"
+
+// textReporter writes similarities as human-readable plain text.
+type textReporter struct {
+	opts *Options
+}
+
+func init() { //nolint:gochecknoinits // register built-in format
+	Register("text", newTextReporter)
+}
+
+// newTextReporter returns a new Reporter that writes similarities as human-readable plain text.
+func newTextReporter(opts *Options) (Reporter, error) {
+	return &textReporter{
+		opts: opts,
+	}, nil
+}
+
+// Report implements Reporter. If r.opts.DiffTool is set, it will run it to show differences.
+func (r *textReporter) Report(ctx context.Context, w io.Writer, sims []*textsimilarity.Similarity) error {
+	for idx, sim := range sims {
+		if contextDone(ctx) {
+			return ctx.Err()
+		}
+
+		if idx > 0 {
+			fmt.Fprintln(w)
+		}
+
+		color := levelColor(sim.Level)
+
+		header := fmt.Sprintf("similarity #%d - %d lines, %s", idx+1, similarityLines(sim), sim.Level.String())
+		if sim.Tier != "" {
+			header += " (" + sim.Tier + ")"
+		}
+
+		fmt.Fprintln(w, colorize(header, color, r.opts.Color))
+
+		for _, occ := range sim.Occurrences {
+			link, err := r.opts.link(occ)
+			if err != nil {
+				return err
+			}
+
+			location := occ.File.Name + ": " + lineRange(occ)
+			if scope := scopeName(occ); scope != "" {
+				location += " (" + scope + ")"
+			}
+
+			fmt.Fprintf(w, "- %s\n", hyperlink(location, link, r.opts.Color))
+
+			if err := r.preview(w, occ, color); err != nil {
+				return err
+			}
+		}
+
+		if sim.OmittedOccurrences > 0 {
+			fmt.Fprintf(w, "- ... and %d more occurrences\n", sim.OmittedOccurrences)
+		}
+
+		if err := r.dumpOrDiff(ctx, w, sim); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReportFiles implements FilesReporter. Each file is written on a single line.
+func (r *textReporter) ReportFiles(ctx context.Context, w io.Writer, stats []*textsimilarity.FileStats) error {
+	tabW := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	for _, stat := range stats {
+		if contextDone(ctx) {
+			return ctx.Err()
+		}
+
+		fmt.Fprintf(tabW, "%.1f%%\t%d/%d lines\t%d partners\t %s\n", stat.DuplicationPct(), stat.DuplicatedLines, stat.Lines, stat.Partners, stat.File.Name)
+	}
+
+	if err := tabW.Flush(); err != nil {
+		return fmt.Errorf("flush: %w", err)
+	}
+
+	return nil
+}
+
+// ReportClasses implements ClassesReporter.
+func (r *textReporter) ReportClasses(ctx context.Context, w io.Writer, classes []*textsimilarity.CloneClass) error {
+	for idx, class := range classes {
+		if contextDone(ctx) {
+			return ctx.Err()
+		}
+
+		if idx > 0 {
+			fmt.Fprintln(w)
+		}
+
+		fmt.Fprintf(w, "clone class #%d - %d regions, %d lines, %d similarities\n", idx+1, len(class.Regions), class.Lines(), len(class.Similarities))
+
+		for _, region := range class.Regions {
+			link, err := r.opts.link(region)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(w, "- %s\n", hyperlink(region.File.Name+": "+lineRange(region), link, r.opts.Color))
+		}
+
+		if r.opts.Hints {
+			if err := r.hint(w, class); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// hint writes the canonical representative and refactoring hint of class to w.
+func (r *textReporter) hint(w io.Writer, class *textsimilarity.CloneClass) error {
+	hint, err := class.ExtractionHint(r.opts.text)
+	if err != nil {
+		return fmt.Errorf("extraction hint: %w", err)
+	}
+
+	fmt.Fprintf(w, "representative: %s: %s\n", hint.Representative.File.Name, lineRange(hint.Representative))
+
+	switch hint.Kind {
+	case textsimilarity.IdenticalExtraction:
+		fmt.Fprintln(w, "hint: identical, extract representative as is")
+	case textsimilarity.ParameterizableExtraction:
+		fmt.Fprintf(w, "hint: parameterizable, extract representative with %d parameters\n", len(hint.Parameters))
+	case textsimilarity.DivergentExtraction:
+		fmt.Fprintln(w, "hint: divergent, occurrences differ in structure and must be merged manually")
+	}
+
+	for idx, param := range hint.Parameters {
+		lines := make([]string, 0, len(param.Uses))
+		for _, use := range param.Uses {
+			line := strconv.Itoa(hint.Representative.Start + use.Line + 1)
+			if len(lines) == 0 || lines[len(lines)-1] != line {
+				lines = append(lines, line)
+			}
+		}
+
+		label := "line"
+		if len(lines) > 1 {
+			label = "lines"
+		}
+
+		fmt.Fprintf(w, "- $%d: %s (%s %s)\n", idx+1, strings.Join(param.Values, " | "), label, strings.Join(lines, ", "))
+	}
+
+	return nil
+}
+
+// ReportDuplicates implements DuplicatesReporter. Each pair of files is written on a single line.
+func (r *textReporter) ReportDuplicates(ctx context.Context, w io.Writer, dupes []*textsimilarity.NearDuplicate) error {
+	tabW := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	for _, dupe := range dupes {
+		if contextDone(ctx) {
+			return ctx.Err()
+		}
+
+		fmt.Fprintf(tabW, "%.1f%%\t %s - %s\n", dupe.Similarity*100, dupe.File1.Name, dupe.File2.Name)
+	}
+
+	if err := tabW.Flush(); err != nil {
+		return fmt.Errorf("flush: %w", err)
+	}
+
+	return nil
+}
+
+// preview writes the first r.opts.PreviewLines lines of occ's text to w, prefixed by line numbers,
+// using color if enabled.
+func (r *textReporter) preview(w io.Writer, occ *textsimilarity.FileOccurrence, color string) error {
+	if r.opts.PreviewLines <= 0 {
+		return nil
+	}
+
+	text, err := r.opts.text(occ)
+	if err != nil {
+		return err
+	}
+
+	lines := splitLines(text)
+	numWidth := len(strconv.Itoa(occ.Start + min(len(lines), r.opts.PreviewLines)))
+
+	for idx, line := range lines {
+		if idx == r.opts.PreviewLines {
+			fmt.Fprintf(w, "  %s\n", colorize(fmt.Sprintf("%*s | ... (%d more lines)", numWidth, "", len(lines)-idx), colorFaint, r.opts.Color))
+			break
+		}
+
+		fmt.Fprintf(w, "  %s %s\n", colorize(fmt.Sprintf("%*d |", numWidth, occ.Start+idx+1), colorFaint, r.opts.Color), colorize(line, color, r.opts.Color))
+	}
+
+	return nil
+}
+
+// dumpOrDiff writes sim's text to w:
+// If sim.Level==textsimilarity.EqualSimilarityLevel and r.opts.PrintEqual==true, it will dump the first occurrence's text.
+// If sim.Level==textsimilarity.SimilarSimilarityLevel and r.opts.DiffTool!=nil, it will run r.opts.DiffTool to print differences.
+// If sim.Level==textsimilarity.SimilarSimilarityLevel and r.opts.Diff==true, it will print differences using the built-in diff renderer.
+func (r *textReporter) dumpOrDiff(ctx context.Context, w io.Writer, sim *textsimilarity.Similarity) error {
+	switch {
+	case sim.Level == textsimilarity.EqualSimilarityLevel && r.opts.PrintEqual:
+		fmt.Fprintln(w, "\n"+separator)
+
+		if err := r.dump(w, sim.Occurrences[0]); err != nil {
+			return err
+		}
+
+		fmt.Fprintln(w, separator)
+
+	case sim.Level == textsimilarity.SimilarSimilarityLevel && r.opts.DiffTool != nil:
+		fmt.Fprintln(w, "\n"+separator)
+
+		if err := r.diff(ctx, w, sim); err != nil {
+			return err
+		}
+
+		fmt.Fprintln(w, separator)
+
+	case sim.Level == textsimilarity.SimilarSimilarityLevel && r.opts.Diff:
+		text1, text2, err := differentTexts(sim, r.opts)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(w, "\n"+separator)
+		writeDiff(w, text1, text2, r.opts.Color)
+		fmt.Fprintln(w, separator)
+	}
+
+	return nil
+}
+
+// dump writes the text of occ to w.
+func (r *textReporter) dump(w io.Writer, occ *textsimilarity.FileOccurrence) error {
+	text, err := r.opts.text(occ)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(w, text)
+
+	return nil
+}
+
+// diff uses r.opts.DiffTool to write differences between occurrences in sim to w.
+func (r *textReporter) diff(ctx context.Context, w io.Writer, sim *textsimilarity.Similarity) error {
+	text1, text2, err := differentTexts(sim, r.opts)
+	if err != nil {
+		return err
+	}
+
+	path1, err := writeTempFile(text1)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := os.Remove(path1); err != nil {
+			fmt.Fprintln(os.Stderr, fmt.Errorf("remove temporary file %s: %w", path1, err).Error())
+		}
+	}()
+
+	path2, err := writeTempFile(text2)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := os.Remove(path2); err != nil {
+			fmt.Fprintln(os.Stderr, fmt.Errorf("remove temporary file %s: %w", path2, err).Error())
+		}
+	}()
+
+	return r.runDiffTool(ctx, w, path1, path2)
+}
+
+// runDiffTool runs r.opts.DiffTool to write differences between files path1 and path2 to w.
+func (r *textReporter) runDiffTool(ctx context.Context, w io.Writer, path1 string, path2 string) error {
+	buf := strings.Builder{}
+
+	err := r.opts.DiffTool.Execute(&buf, struct {
+		File1 string
+		File2 string
+	}{
+		File1: path1,
+		File2: path2,
+	})
+
+	if err != nil {
+		return fmt.Errorf("construct diff tool command line: %w", err)
+	}
+
+	cmdLine := buf.String()
+	parts := strings.Split(cmdLine, " ")
+
+	cmd := exec.CommandContext(ctx, parts[0], parts[1:]...) //nolint:gosec // okay
+
+	output, err := cmd.CombinedOutput()
+	fmt.Fprint(w, string(output))
+
+	if err != nil && !r.opts.IgnoreDiffToolRC {
+		return fmt.Errorf("%s: %w", cmdLine, err)
+	}
+
+	return nil
+}
+
+// differentTexts returns the text of sim's first occurrence, as well as the text of the first occurrence
+// that is not exactly equal to it, according to opts.
+func differentTexts(sim *textsimilarity.Similarity, opts *Options) (string, string, error) {
+	text1, err := opts.text(sim.Occurrences[0])
+	if err != nil {
+		return "", "", err
+	}
+
+	var text2 string
+
+	for _, occ := range sim.Occurrences[1:] {
+		text2, err = opts.text(occ)
+		if err != nil {
+			return "", "", err
+		}
+
+		if text2 == text1 {
+			continue
+		}
+
+		break
+	}
+
+	return text1, text2, nil
+}
+
+// lineRange returns the range of lines of occ as a string, using one-based line numbers.
+func lineRange(occ *textsimilarity.FileOccurrence) string {
+	if occ.End == occ.Start+1 {
+		return fmt.Sprintf("%d", occ.Start+1)
+	}
+
+	return fmt.Sprintf("%d-%d", occ.Start+1, occ.End)
+}
@@ -0,0 +1,217 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// A SavedReport is a report that has previously been written by the json format.
+type SavedReport struct {
+	Similarities []*SavedSimilarity
+
+	// Summary is the summary of all files scanned. It is nil if the report does not include a summary.
+	Summary *SavedSummary
+}
+
+// A SavedSimilarity is a single similarity in a SavedReport.
+type SavedSimilarity struct {
+	ID          string
+	Level       string
+	Lines       int
+	Occurrences []*SavedOccurrence
+
+	// OmittedOccurrences is the number of occurrences not included in Occurrences.
+	OmittedOccurrences int
+}
+
+// A SavedOccurrence is a single occurrence of a SavedSimilarity. Line numbers are one-based and inclusive.
+type SavedOccurrence struct {
+	File  string
+	Start int
+	End   int
+}
+
+// A SavedSummary is the summary of all files scanned in a SavedReport.
+type SavedSummary struct {
+	Files           int
+	Lines           int
+	DuplicatedLines int
+	DuplicationPct  float64
+}
+
+// A Trend is the difference between two saved reports, an old and a new one.
+type Trend struct {
+	// Added are the similarities of the new report that are not contained in the old report.
+	Added []*SavedSimilarity
+
+	// Removed are the similarities of the old report that are not contained in the new report.
+	Removed []*SavedSimilarity
+
+	// Grown are the similarities that have grown in number of lines or occurrences.
+	Grown []*GrownSimilarity
+
+	// OldSummary and NewSummary are the summaries of the old and new report, respectively. Either may be nil.
+	OldSummary *SavedSummary
+	NewSummary *SavedSummary
+}
+
+// A GrownSimilarity is a similarity that has grown in number of lines or occurrences.
+type GrownSimilarity struct {
+	Old *SavedSimilarity
+	New *SavedSimilarity
+}
+
+// ReadSavedReport reads a SavedReport from r, which must have been written by the json format.
+func ReadSavedReport(r io.Reader) (*SavedReport, error) {
+	rep := jsonReport{}
+	if err := json.NewDecoder(r).Decode(&rep); err != nil {
+		return nil, fmt.Errorf("decode report: %w", err)
+	}
+
+	saved := SavedReport{
+		Similarities: make([]*SavedSimilarity, len(rep.Similarities)),
+	}
+
+	for idx, jsonSim := range rep.Similarities {
+		sim := SavedSimilarity{
+			ID:                 jsonSim.ID,
+			Level:              jsonSim.Level,
+			Lines:              jsonSim.Lines,
+			Occurrences:        make([]*SavedOccurrence, len(jsonSim.Occurrences)),
+			OmittedOccurrences: jsonSim.OmittedOccurrences,
+		}
+
+		for occIdx, occ := range jsonSim.Occurrences {
+			sim.Occurrences[occIdx] = &SavedOccurrence{
+				File:  occ.File,
+				Start: occ.Start,
+				End:   occ.End,
+			}
+		}
+
+		saved.Similarities[idx] = &sim
+	}
+
+	if rep.Summary != nil {
+		saved.Summary = &SavedSummary{
+			Files:           rep.Summary.Files,
+			Lines:           rep.Summary.Lines,
+			DuplicatedLines: rep.Summary.DuplicatedLines,
+			DuplicationPct:  rep.Summary.DuplicationPct,
+		}
+	}
+
+	return &saved, nil
+}
+
+// NewTrend returns the difference between oldRep and newRep. Similarities are matched by ID first. A remaining
+// similarity of newRep matches a remaining similarity of oldRep if each occurrence of the old similarity overlaps
+// an occurrence of the new similarity, and it is reported as grown if it has more lines or occurrences.
+func NewTrend(oldRep *SavedReport, newRep *SavedReport) *Trend {
+	trend := Trend{
+		Added:      []*SavedSimilarity{},
+		Removed:    []*SavedSimilarity{},
+		Grown:      []*GrownSimilarity{},
+		OldSummary: oldRep.Summary,
+		NewSummary: newRep.Summary,
+	}
+
+	oldByID := map[string][]*SavedSimilarity{}
+	for _, sim := range oldRep.Similarities {
+		oldByID[sim.ID] = append(oldByID[sim.ID], sim)
+	}
+
+	matched := map[*SavedSimilarity]struct{}{}
+	unmatchedNew := []*SavedSimilarity{}
+
+	for _, sim := range newRep.Similarities {
+		if olds := oldByID[sim.ID]; sim.ID != "" && len(olds) != 0 {
+			matched[olds[0]] = struct{}{}
+			oldByID[sim.ID] = olds[1:]
+
+			continue
+		}
+
+		unmatchedNew = append(unmatchedNew, sim)
+	}
+
+	for _, sim := range unmatchedNew {
+		old := containedSimilarity(oldRep.Similarities, matched, sim)
+
+		switch {
+		case old == nil:
+			trend.Added = append(trend.Added, sim)
+
+		case sim.Lines > old.Lines || sim.occurrences() > old.occurrences():
+			matched[old] = struct{}{}
+
+			trend.Grown = append(trend.Grown, &GrownSimilarity{
+				Old: old,
+				New: sim,
+			})
+
+		default:
+			matched[old] = struct{}{}
+		}
+	}
+
+	for _, sim := range oldRep.Similarities {
+		if _, ok := matched[sim]; !ok {
+			trend.Removed = append(trend.Removed, sim)
+		}
+	}
+
+	return &trend
+}
+
+// DuplicationPctChange returns the change in duplication percentage from the old to the new report, in
+// percentage points. It returns 0 if either report does not include a summary.
+func (t *Trend) DuplicationPctChange() float64 {
+	if t.OldSummary == nil || t.NewSummary == nil {
+		return 0
+	}
+
+	return t.NewSummary.DuplicationPct - t.OldSummary.DuplicationPct
+}
+
+// containedSimilarity returns the first of sims that is not in matched, and each of whose occurrences overlaps
+// an occurrence of sim. If there is no such similarity, nil is returned.
+func containedSimilarity(sims []*SavedSimilarity, matched map[*SavedSimilarity]struct{}, sim *SavedSimilarity) *SavedSimilarity {
+	for _, old := range sims {
+		if _, ok := matched[old]; ok {
+			continue
+		}
+
+		if old.overlappedBy(sim) {
+			return old
+		}
+	}
+
+	return nil
+}
+
+// overlappedBy returns whether each occurrence of s overlaps an occurrence of sim.
+func (s *SavedSimilarity) overlappedBy(sim *SavedSimilarity) bool {
+	for _, occ := range s.Occurrences {
+		found := false
+
+		for _, occ2 := range sim.Occurrences {
+			if occ.File == occ2.File && occ.Start <= occ2.End && occ2.Start <= occ.End {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// occurrences returns the total number of occurrences of s, including omitted ones.
+func (s *SavedSimilarity) occurrences() int {
+	return len(s.Occurrences) + s.OmittedOccurrences
+}
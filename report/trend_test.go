@@ -0,0 +1,69 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/blizzy78/textsimilarity"
+	"github.com/matryer/is"
+)
+
+func TestReadSavedReport(t *testing.T) {
+	is := is.New(t)
+
+	sims := testSimilarities()
+
+	files := []*textsimilarity.File{
+		sims[0].Occurrences[0].File,
+		sims[0].Occurrences[1].File,
+	}
+
+	rep, _ := New("json", nil)
+
+	buf := bytes.Buffer{}
+	err := rep.(SummaryReporter).ReportWithSummary(context.Background(), &buf, sims, files)
+	is.NoErr(err)
+
+	saved, err := ReadSavedReport(&buf)
+	is.NoErr(err)
+
+	is.Equal(len(saved.Similarities), 2)
+	is.Equal(saved.Similarities[0].Level, "equal")
+	is.Equal(saved.Similarities[0].Lines, 2)
+	is.Equal(*saved.Similarities[0].Occurrences[1], SavedOccurrence{File: "2.txt", Start: 5, End: 6})
+	is.Equal(saved.Summary.Files, 2)
+}
+
+func TestNewTrend(t *testing.T) {
+	is := is.New(t)
+
+	oldRep := SavedReport{
+		Similarities: []*SavedSimilarity{
+			{ID: "1", Lines: 10, Occurrences: []*SavedOccurrence{{File: "a", Start: 1, End: 10}, {File: "b", Start: 1, End: 10}}},
+			{ID: "2", Lines: 10, Occurrences: []*SavedOccurrence{{File: "a", Start: 21, End: 30}, {File: "c", Start: 1, End: 10}}},
+			{ID: "3", Lines: 5, Occurrences: []*SavedOccurrence{{File: "d", Start: 1, End: 5}, {File: "e", Start: 1, End: 5}}},
+		},
+		Summary: &SavedSummary{DuplicationPct: 10},
+	}
+
+	newRep := SavedReport{
+		Similarities: []*SavedSimilarity{
+			{ID: "1", Lines: 10, Occurrences: []*SavedOccurrence{{File: "a", Start: 1, End: 10}, {File: "b", Start: 1, End: 10}}},
+			{ID: "4", Lines: 15, Occurrences: []*SavedOccurrence{{File: "a", Start: 21, End: 35}, {File: "c", Start: 1, End: 15}}},
+			{ID: "5", Lines: 8, Occurrences: []*SavedOccurrence{{File: "f", Start: 1, End: 8}, {File: "g", Start: 1, End: 8}}},
+		},
+		Summary: &SavedSummary{DuplicationPct: 12.5},
+	}
+
+	trend := NewTrend(&oldRep, &newRep)
+
+	is.Equal(len(trend.Added), 1)
+	is.Equal(trend.Added[0].ID, "5")
+	is.Equal(len(trend.Removed), 1)
+	is.Equal(trend.Removed[0].ID, "3")
+	is.Equal(len(trend.Grown), 1)
+	is.Equal(trend.Grown[0].Old.ID, "2")
+	is.Equal(trend.Grown[0].New.ID, "4")
+	is.Equal(trend.DuplicationPctChange(), 2.5)
+}
@@ -0,0 +1,203 @@
+package report
+
+import (
+	"context"
+	_ "embed"
+	"io"
+	"sort"
+
+	"github.com/blizzy78/textsimilarity"
+)
+
+// VizVersion is the version of the document schema written by the viz format. It is only incremented when
+// the schema changes in a way that is incompatible with existing consumers. Fields may be added without
+// incrementing it.
+const VizVersion = 1
+
+// VizSchema is the JSON Schema of the documents written by the viz format.
+//
+//go:embed viz.schema.json
+var VizSchema string
+
+// vizReporter writes similarities as a JSON document designed for visualizations, such as interactive viewers.
+// Unlike jsonReporter, files are listed along with their line counts and coverage, occurrences refer to files
+// by index, and similarities are grouped into clone classes.
+type vizReporter struct{}
+
+// vizReport is the top-level document written by vizReporter.
+type vizReport struct {
+	Version      int              `json:"version"`
+	Files        []*vizFile       `json:"files"`
+	Classes      []*vizClass      `json:"classes"`
+	Similarities []*vizSimilarity `json:"similarities"`
+}
+
+// vizFile is a single file in a vizReport.
+type vizFile struct {
+	Name            string `json:"name"`
+	Lines           int    `json:"lines"`
+	DuplicatedLines int    `json:"duplicatedLines"`
+
+	// Coverage are the numbers of occurrences covering each line of the file, starting with the first line.
+	// It is omitted if no lines are covered.
+	Coverage []int `json:"coverage,omitempty"`
+}
+
+// vizClass is a single clone class in a vizReport.
+type vizClass struct {
+	Lines   int            `json:"lines"`
+	Members []*vizLocation `json:"members"`
+
+	// Similarities are the indexes of the similarities in the class.
+	Similarities []int `json:"similarities"`
+}
+
+// vizSimilarity is a single similarity in a vizReport.
+type vizSimilarity struct {
+	ID          string         `json:"id"`
+	Level       string         `json:"level"`
+	Lines       int            `json:"lines"`
+	Class       int            `json:"class"`
+	Occurrences []*vizLocation `json:"occurrences"`
+
+	// OmittedOccurrences is the number of occurrences not included in Occurrences.
+	OmittedOccurrences int `json:"omittedOccurrences,omitempty"`
+}
+
+// vizLocation is a range of lines in a file of a vizReport. Line numbers are one-based and inclusive.
+type vizLocation struct {
+	// File is the index of the file.
+	File  int `json:"file"`
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+func init() { //nolint:gochecknoinits // register built-in format
+	Register("viz", newVizReporter)
+}
+
+// newVizReporter returns a new Reporter that writes similarities as a JSON document designed for visualizations.
+func newVizReporter(_ *Options) (Reporter, error) {
+	return &vizReporter{}, nil
+}
+
+// Report implements Reporter. Only files that contain any of sims are included.
+func (r *vizReporter) Report(ctx context.Context, w io.Writer, sims []*textsimilarity.Similarity) error {
+	return r.ReportWithSummary(ctx, w, sims, nil)
+}
+
+// ReportWithSummary implements SummaryReporter. All files are included, sorted by name.
+func (r *vizReporter) ReportWithSummary(ctx context.Context, w io.Writer, sims []*textsimilarity.Similarity,
+	files []*textsimilarity.File,
+) error {
+	allFiles := []*textsimilarity.File{}
+	fileIdxs := map[*textsimilarity.File]int{}
+
+	rep := vizReport{
+		Version:      VizVersion,
+		Files:        []*vizFile{},
+		Classes:      []*vizClass{},
+		Similarities: make([]*vizSimilarity, len(sims)),
+	}
+
+	addFile := func(file *textsimilarity.File) {
+		if _, ok := fileIdxs[file]; ok {
+			return
+		}
+
+		fileIdxs[file] = -1
+		allFiles = append(allFiles, file)
+	}
+
+	for _, file := range files {
+		addFile(file)
+	}
+
+	for _, sim := range sims {
+		for _, occ := range sim.Occurrences {
+			addFile(occ.File)
+		}
+	}
+
+	sort.SliceStable(allFiles, func(a int, b int) bool {
+		return allFiles[a].Name < allFiles[b].Name
+	})
+
+	for idx, file := range allFiles {
+		fileIdxs[file] = idx
+
+		rep.Files = append(rep.Files, &vizFile{
+			Name:  file.Name,
+			Lines: file.LineCount(),
+		})
+	}
+
+	for _, fileCov := range textsimilarity.FilesCoverage(sims) {
+		vizFile := rep.Files[fileIdxs[fileCov.File]]
+		vizFile.Coverage = fileCov.Lines
+
+		for _, count := range fileCov.Lines {
+			if count > 0 {
+				vizFile.DuplicatedLines++
+			}
+		}
+	}
+
+	location := func(occ *textsimilarity.FileOccurrence) *vizLocation {
+		return &vizLocation{
+			File:  fileIdxs[occ.File],
+			Start: occ.Start + 1,
+			End:   occ.End,
+		}
+	}
+
+	simIdxs := make(map[*textsimilarity.Similarity]int, len(sims))
+
+	for idx, sim := range sims {
+		if contextDone(ctx) {
+			return ctx.Err()
+		}
+
+		simIdxs[sim] = idx
+
+		vizSim := vizSimilarity{
+			ID:                 sim.ID(),
+			Level:              levelID(sim.Level),
+			Lines:              similarityLines(sim),
+			Occurrences:        make([]*vizLocation, len(sim.Occurrences)),
+			OmittedOccurrences: sim.OmittedOccurrences,
+		}
+
+		for occIdx, occ := range sim.Occurrences {
+			vizSim.Occurrences[occIdx] = location(occ)
+		}
+
+		rep.Similarities[idx] = &vizSim
+	}
+
+	for classIdx, class := range textsimilarity.CloneClasses(sims) {
+		if contextDone(ctx) {
+			return ctx.Err()
+		}
+
+		vizClass := vizClass{
+			Lines:        class.Lines(),
+			Members:      make([]*vizLocation, len(class.Regions)),
+			Similarities: make([]int, len(class.Similarities)),
+		}
+
+		for idx, region := range class.Regions {
+			vizClass.Members[idx] = location(region)
+		}
+
+		for idx, sim := range class.Similarities {
+			simIdx := simIdxs[sim]
+			vizClass.Similarities[idx] = simIdx
+			rep.Similarities[simIdx].Class = classIdx
+		}
+
+		rep.Classes = append(rep.Classes, &vizClass)
+	}
+
+	return writeJSON(w, &rep)
+}
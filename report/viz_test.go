@@ -0,0 +1,67 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/blizzy78/textsimilarity"
+	"github.com/matryer/is"
+)
+
+func TestVizReporter(t *testing.T) {
+	is := is.New(t)
+
+	rep, _ := New("viz", nil)
+
+	sims := testSimilarities()
+	file3 := &textsimilarity.File{Name: "0.txt"}
+	files := []*textsimilarity.File{sims[0].Occurrences[0].File, sims[0].Occurrences[1].File, file3}
+
+	buf := bytes.Buffer{}
+	err := rep.(SummaryReporter).ReportWithSummary(context.Background(), &buf, sims, files)
+	is.NoErr(err)
+
+	vizRep := vizReport{}
+	is.NoErr(json.Unmarshal(buf.Bytes(), &vizRep))
+
+	is.Equal(vizRep.Version, VizVersion)
+
+	is.Equal(len(vizRep.Files), 3)
+	is.Equal(vizRep.Files[0].Name, "0.txt")
+	is.Equal(vizRep.Files[0].Coverage, nil)
+	is.Equal(vizRep.Files[1].Name, "1.txt")
+	is.Equal(vizRep.Files[1].DuplicatedLines, 3)
+	is.Equal(vizRep.Files[1].Coverage, []int{1, 1, 0, 0, 0, 0, 0, 0, 0, 1})
+
+	is.Equal(files[0], sims[0].Occurrences[0].File) // files must not be reordered
+
+	is.Equal(len(vizRep.Similarities), 2)
+	is.Equal(vizRep.Similarities[1].Level, "similar")
+	is.Equal(vizRep.Similarities[1].Class, 1)
+	is.Equal(*vizRep.Similarities[1].Occurrences[1], vizLocation{File: 2, Start: 20, End: 20})
+
+	is.Equal(len(vizRep.Classes), 2)
+	is.Equal(vizRep.Classes[0].Lines, 4)
+	is.Equal(vizRep.Classes[0].Similarities, []int{0})
+	is.Equal(*vizRep.Classes[0].Members[1], vizLocation{File: 2, Start: 5, End: 6})
+}
+
+func TestVizSchema(t *testing.T) {
+	is := is.New(t)
+
+	schema := struct {
+		Required   []string `json:"required"`
+		Properties struct {
+			Version struct {
+				Const int `json:"const"`
+			} `json:"version"`
+		} `json:"properties"`
+	}{}
+
+	is.NoErr(json.Unmarshal([]byte(VizSchema), &schema))
+
+	is.Equal(schema.Required, []string{"version", "files", "classes", "similarities"})
+	is.Equal(schema.Properties.Version.Const, VizVersion)
+}
@@ -0,0 +1,104 @@
+package main //nolint:revive // no need for package documentation here
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/blizzy78/textsimilarity/rpc"
+	pb "github.com/blizzy78/textsimilarity/rpc/textsimilaritypb"
+	"google.golang.org/grpc"
+)
+
+// restReadHeaderTimeout is the timeout for reading request headers of the REST server.
+const restReadHeaderTimeout = 10 * time.Second
+
+func main() {
+	addr := ":50051"
+	restAddr := ""
+	maxMessageMB := 64
+
+	flag.StringVar(&addr, "addr", addr, "address to listen on")
+	flag.StringVar(&restAddr, "rest-addr", restAddr, "address to serve the REST job API on (disabled if empty)")
+	flag.IntVar(&maxMessageMB, "max-message", maxMessageMB, "maximum size of requests in MB")
+	flag.Parse()
+
+	if err := serve(addr, restAddr, maxMessageMB*1024*1024); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
+
+// serve serves the TextSimilarity gRPC service on addr until SIGINT or SIGTERM is received. If restAddr is set,
+// the REST job API is served on that address as well.
+func serve(addr string, restAddr string, maxMessageBytes int) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", addr, err)
+	}
+
+	if restAddr != "" {
+		shutdown, err := serveREST(ctx, restAddr, maxMessageBytes)
+		if err != nil {
+			return err
+		}
+
+		defer shutdown()
+	}
+
+	server := grpc.NewServer(grpc.MaxRecvMsgSize(maxMessageBytes))
+	pb.RegisterTextSimilarityServer(server, rpc.NewServer())
+
+	go func() {
+		<-ctx.Done()
+		server.GracefulStop()
+	}()
+
+	if err := server.Serve(listener); err != nil {
+		return fmt.Errorf("serve: %w", err)
+	}
+
+	return nil
+}
+
+// serveREST serves the REST job API on addr, accepting requests of up to maxRequestBytes. Running jobs are
+// canceled when ctx is done. It returns a function that shuts down the server.
+func serveREST(ctx context.Context, addr string, maxRequestBytes int) (func(), error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listen on %s: %w", addr, err)
+	}
+
+	jobs := rpc.NewJobServer()
+	jobs.MaxRequestBytes = int64(maxRequestBytes)
+
+	server := http.Server{
+		Handler:           jobs,
+		ReadHeaderTimeout: restReadHeaderTimeout,
+	}
+
+	go func() {
+		<-ctx.Done()
+		jobs.Close()
+	}()
+
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(os.Stderr, "serve REST: %v\n", err)
+		}
+	}()
+
+	return func() {
+		_ = server.Close()
+	}, nil
+}
@@ -0,0 +1,21 @@
+module github.com/blizzy78/textsimilarity/rpc
+
+go 1.22
+
+require (
+	github.com/blizzy78/textsimilarity v0.0.0
+	github.com/matryer/is v1.4.1
+	google.golang.org/grpc v1.70.0
+	google.golang.org/protobuf v1.35.2
+)
+
+require (
+	github.com/agext/levenshtein v1.2.3 // indirect
+	github.com/dropbox/godropbox v0.0.0-20230623171840-436d2007a9fd // indirect
+	golang.org/x/net v0.32.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20241202173237-19429a94021a // indirect
+)
+
+replace github.com/blizzy78/textsimilarity => ../
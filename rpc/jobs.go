@@ -0,0 +1,398 @@
+package rpc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/blizzy78/textsimilarity"
+	pb "github.com/blizzy78/textsimilarity/rpc/textsimilaritypb"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// defaultMaxJobRequestBytes is the default maximum size of a request body to start a job.
+const defaultMaxJobRequestBytes = 64 * 1024 * 1024
+
+const (
+	// runningJobState is the state of a job whose scan is still running.
+	runningJobState = "running"
+
+	// doneJobState is the state of a job whose scan is complete.
+	doneJobState = "done"
+
+	// canceledJobState is the state of a job that has been canceled.
+	canceledJobState = "canceled"
+
+	// failedJobState is the state of a job whose scan has failed.
+	failedJobState = "failed"
+)
+
+// A JobServer serves a REST API to run scans as jobs, for clients that cannot use gRPC. Jobs are started by
+// POSTing an AnalyzeRequest, in its JSON form, to /jobs, which responds with the ID of the new job. While a job
+// is running, GET /jobs/{id}/progress returns its current progress, and GET /jobs/{id}/results streams the
+// similarities found so far and all that are found later, as one JSON object per line, until the job ends.
+// DELETE /jobs/{id} cancels a job if it is still running, and removes it. Jobs that have ended are kept until
+// they are removed, so that their results can still be fetched.
+type JobServer struct {
+	// MaxRequestBytes is the maximum size of a request body to start a job.
+	MaxRequestBytes int64
+
+	// mux routes requests to handlers.
+	mux *http.ServeMux
+
+	// jobsLock protects jobs.
+	jobsLock sync.Mutex
+
+	// jobs maps job IDs to jobs.
+	jobs map[string]*job
+}
+
+// A job is a single scan started via a JobServer.
+type job struct {
+	// cancel cancels the scan.
+	cancel context.CancelFunc
+
+	// lock protects all fields below.
+	lock sync.Mutex
+
+	// progress is the latest progress of the scan.
+	progress textsimilarity.Progress
+
+	// sims are the similarities found so far, in order.
+	sims []*pb.Similarity
+
+	// state is the state of the job.
+	state string
+
+	// err is the error that caused the scan to fail, if any.
+	err error
+
+	// changed is closed and replaced whenever any of the fields above change.
+	changed chan struct{}
+}
+
+// A jobResponse is the response to a request to start a job.
+type jobResponse struct {
+	ID string `json:"id"`
+}
+
+// A jobProgressResponse is the response to a request for the progress of a job.
+type jobProgressResponse struct {
+	// State is the state of the job: running, done, canceled, or failed.
+	State string `json:"state"`
+
+	// Percent is the progress percentage from 0 to 100.
+	Percent float64 `json:"percent"`
+
+	// ETA is the estimated time of completion, if known.
+	ETA *time.Time `json:"eta,omitempty"`
+
+	// File is the file that has been scanned last, if any.
+	File string `json:"file,omitempty"`
+
+	// Similarities is the number of similarities found so far.
+	Similarities int `json:"similarities"`
+
+	// Error is the error that caused the job to fail, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// NewJobServer returns a new JobServer.
+func NewJobServer() *JobServer {
+	server := JobServer{
+		MaxRequestBytes: defaultMaxJobRequestBytes,
+		mux:             http.NewServeMux(),
+		jobs:            map[string]*job{},
+	}
+
+	server.mux.HandleFunc("POST /jobs", server.startJob)
+	server.mux.HandleFunc("GET /jobs/{id}/progress", server.jobProgress)
+	server.mux.HandleFunc("GET /jobs/{id}/results", server.jobResults)
+	server.mux.HandleFunc("DELETE /jobs/{id}", server.deleteJob)
+
+	return &server
+}
+
+// ServeHTTP implements http.Handler.
+func (s *JobServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Close cancels all jobs that are still running, and removes all jobs.
+func (s *JobServer) Close() {
+	s.jobsLock.Lock()
+	defer s.jobsLock.Unlock()
+
+	for id, j := range s.jobs {
+		j.cancel()
+		delete(s.jobs, id)
+	}
+}
+
+// startJob handles POST /jobs.
+func (s *JobServer) startJob(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.MaxRequestBytes))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("read request: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	req := pb.AnalyzeRequest{}
+	if err := protojson.Unmarshal(body, &req); err != nil {
+		http.Error(w, fmt.Sprintf("parse request: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	files, err := newFiles(req.GetFiles())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	opts, err := newOptions(req.GetOptions())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// the job outlives the request
+	ctx, cancel := context.WithCancel(context.Background())
+
+	simsCh, progressCh, err := textsimilarity.Similarities(ctx, files, opts)
+	if err != nil {
+		cancel()
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	j := job{
+		cancel:  cancel,
+		state:   runningJobState,
+		changed: make(chan struct{}),
+	}
+
+	s.jobsLock.Lock()
+	s.jobs[id] = &j
+	s.jobsLock.Unlock()
+
+	go j.run(ctx, simsCh, progressCh)
+
+	w.Header().Set("Location", "/jobs/"+id)
+	writeJSON(w, http.StatusCreated, jobResponse{ID: id})
+}
+
+// jobProgress handles GET /jobs/{id}/progress.
+func (s *JobServer) jobProgress(w http.ResponseWriter, r *http.Request) {
+	j := s.job(r.PathValue("id"))
+	if j == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	j.lock.Lock()
+
+	resp := jobProgressResponse{
+		State:        j.state,
+		Percent:      j.progress.Done,
+		Similarities: len(j.sims),
+	}
+
+	if !j.progress.ETA.IsZero() {
+		eta := j.progress.ETA
+		resp.ETA = &eta
+	}
+
+	if j.progress.File != nil {
+		resp.File = j.progress.File.Name
+	}
+
+	if j.err != nil {
+		resp.Error = j.err.Error()
+	}
+
+	j.lock.Unlock()
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// jobResults handles GET /jobs/{id}/results. Similarities are written as they are found, until the job ends or
+// the client goes away.
+func (s *JobServer) jobResults(w http.ResponseWriter, r *http.Request) {
+	j := s.job(r.PathValue("id"))
+	if j == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+
+	next := 0
+
+	for {
+		sims, running, changed := j.snapshot(next)
+
+		for _, sim := range sims {
+			line, err := protojson.Marshal(sim)
+			if err != nil {
+				return
+			}
+
+			if _, err := w.Write(append(line, '\n')); err != nil {
+				return
+			}
+		}
+
+		next += len(sims)
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		if !running {
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// deleteJob handles DELETE /jobs/{id}.
+func (s *JobServer) deleteJob(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	s.jobsLock.Lock()
+	j, ok := s.jobs[id]
+	delete(s.jobs, id)
+	s.jobsLock.Unlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	j.cancel()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// job returns the job with id, or nil if there is none.
+func (s *JobServer) job(id string) *job {
+	s.jobsLock.Lock()
+	defer s.jobsLock.Unlock()
+
+	return s.jobs[id]
+}
+
+// run drains simsCh and progressCh of the scan of j, updating j accordingly, until both are closed. If the scan
+// fails, ctx is canceled.
+func (j *job) run(ctx context.Context, simsCh <-chan *textsimilarity.Similarity, progressCh <-chan textsimilarity.Progress) {
+	for simsCh != nil || progressCh != nil {
+		select {
+		case sim, ok := <-simsCh:
+			if !ok {
+				simsCh = nil
+				continue
+			}
+
+			pbSim := newSimilarity(sim)
+
+			j.update(func() {
+				j.sims = append(j.sims, pbSim)
+			})
+
+		case prog, ok := <-progressCh:
+			if !ok {
+				progressCh = nil
+				continue
+			}
+
+			if prog.Err != nil {
+				j.update(func() {
+					if j.err == nil {
+						j.err = fmt.Errorf("scan %s: %w", prog.File.Name, prog.Err)
+					}
+				})
+
+				j.cancel()
+
+				continue
+			}
+
+			j.update(func() {
+				j.progress = prog
+			})
+		}
+	}
+
+	j.update(func() {
+		switch {
+		case j.err != nil:
+			j.state = failedJobState
+		case errors.Is(ctx.Err(), context.Canceled):
+			j.state = canceledJobState
+		default:
+			j.state = doneJobState
+		}
+	})
+
+	j.cancel()
+}
+
+// update calls fn while holding j's lock, and notifies everyone waiting for changes of j.
+func (j *job) update(fn func()) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	fn()
+
+	close(j.changed)
+	j.changed = make(chan struct{})
+}
+
+// snapshot returns the similarities of j found so far, beginning with the one at index start, whether j is still
+// running, and a channel that is closed when j changes next.
+func (j *job) snapshot(start int) ([]*pb.Similarity, bool, <-chan struct{}) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	return j.sims[start:], j.state == runningJobState, j.changed
+}
+
+// newJobID returns a new random job ID.
+func newJobID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("generate job ID: %w", err)
+	}
+
+	return hex.EncodeToString(id), nil
+}
+
+// writeJSON writes v as JSON to w, with status code status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,167 @@
+package rpc
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	pb "github.com/blizzy78/textsimilarity/rpc/textsimilaritypb"
+	"github.com/matryer/is"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func TestJobServer(t *testing.T) {
+	is := is.New(t)
+
+	server := newTestJobServer(t)
+
+	reqBody, err := protojson.Marshal(&pb.AnalyzeRequest{
+		Files: []*pb.File{
+			{Name: "1.txt", Content: []byte("aaaaaaaaaa\nbbbbbbbbbb\ncccccccccc\n")},
+			{Name: "2.txt", Content: []byte("xxxxxxxxxx\naaaaaaaaaa\nbbbbbbbxbb\ncccccccccc\n")},
+		},
+		Options: &pb.Options{
+			MinSimilarLines: 3,
+		},
+	})
+	is.NoErr(err)
+
+	resp, err := http.Post(server.URL+"/jobs", "application/json", strings.NewReader(string(reqBody))) //nolint:noctx // test
+	is.NoErr(err)
+
+	defer resp.Body.Close()
+
+	is.Equal(resp.StatusCode, http.StatusCreated)
+
+	job := jobResponse{}
+	is.NoErr(json.NewDecoder(resp.Body).Decode(&job))
+	is.Equal(resp.Header.Get("Location"), "/jobs/"+job.ID)
+
+	// results are streamed until the job is done
+	resp, err = http.Get(server.URL + "/jobs/" + job.ID + "/results") //nolint:noctx // test
+	is.NoErr(err)
+
+	defer resp.Body.Close()
+
+	is.Equal(resp.StatusCode, http.StatusOK)
+
+	sims := []*pb.Similarity{}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		sim := pb.Similarity{}
+		is.NoErr(protojson.Unmarshal(scanner.Bytes(), &sim))
+		sims = append(sims, &sim)
+	}
+
+	is.NoErr(scanner.Err())
+	is.Equal(len(sims), 1)
+	is.Equal(len(sims[0].GetOccurrences()), 2)
+	is.Equal(sims[0].GetOccurrences()[1].GetStartLine(), int32(1))
+
+	resp, err = http.Get(server.URL + "/jobs/" + job.ID + "/progress") //nolint:noctx // test
+	is.NoErr(err)
+
+	defer resp.Body.Close()
+
+	progress := jobProgressResponse{}
+	is.NoErr(json.NewDecoder(resp.Body).Decode(&progress))
+	is.Equal(progress.State, doneJobState)
+	is.Equal(progress.Percent, 100.0)
+	is.Equal(progress.Similarities, 1)
+	is.Equal(progress.Error, "")
+
+	is.Equal(deleteJob(t, server, job.ID), http.StatusNoContent)
+
+	resp, err = http.Get(server.URL + "/jobs/" + job.ID + "/progress") //nolint:noctx // test
+	is.NoErr(err)
+
+	defer resp.Body.Close()
+
+	is.Equal(resp.StatusCode, http.StatusNotFound)
+}
+
+func TestJobServer_BadRequest(t *testing.T) {
+	tests := []struct {
+		description string
+		givenBody   string
+	}{
+		{
+			description: "invalid JSON",
+			givenBody:   "{",
+		},
+		{
+			description: "duplicate file name",
+			givenBody:   `{"files": [{"name": "1.txt"}, {"name": "1.txt"}]}`,
+		},
+		{
+			description: "invalid regex",
+			givenBody:   `{"options": {"ignoreLineRegex": "("}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			is := is.New(t)
+
+			server := newTestJobServer(t)
+
+			resp, err := http.Post(server.URL+"/jobs", "application/json", strings.NewReader(test.givenBody)) //nolint:noctx // test
+			is.NoErr(err)
+
+			defer resp.Body.Close()
+
+			is.Equal(resp.StatusCode, http.StatusBadRequest)
+		})
+	}
+}
+
+func TestJobServer_UnknownJob(t *testing.T) {
+	is := is.New(t)
+
+	server := newTestJobServer(t)
+
+	is.Equal(deleteJob(t, server, "unknown"), http.StatusNotFound)
+
+	resp, err := http.Get(server.URL + "/jobs/unknown/results") //nolint:noctx // test
+	is.NoErr(err)
+
+	defer resp.Body.Close()
+
+	is.Equal(resp.StatusCode, http.StatusNotFound)
+}
+
+func newTestJobServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	jobs := NewJobServer()
+	server := httptest.NewServer(jobs)
+
+	t.Cleanup(func() {
+		server.Close()
+		jobs.Close()
+	})
+
+	return server
+}
+
+func deleteJob(t *testing.T, server *httptest.Server, id string) int {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodDelete, server.URL+"/jobs/"+id, nil) //nolint:noctx // test
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer resp.Body.Close()
+
+	return resp.StatusCode
+}
@@ -0,0 +1,230 @@
+// Package rpc provides a gRPC server for analyzing files for similarities, so that the engine can be used
+// from other languages. The service is defined in package textsimilaritypb.
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/blizzy78/textsimilarity"
+	pb "github.com/blizzy78/textsimilarity/rpc/textsimilaritypb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// defaultMinSimilarLines is the minimum number of similar lines used if a request does not specify it,
+// matching the default of the command line tool.
+const defaultMinSimilarLines = 10
+
+// A Server implements the TextSimilarity gRPC service.
+type Server struct {
+	pb.UnimplementedTextSimilarityServer
+}
+
+// NewServer returns a new Server.
+func NewServer() *Server {
+	return &Server{}
+}
+
+// Analyze implements pb.TextSimilarityServer.
+func (s *Server) Analyze(req *pb.AnalyzeRequest, stream pb.TextSimilarity_AnalyzeServer) error {
+	files, err := newFiles(req.GetFiles())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	opts, err := newOptions(req.GetOptions())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	simsCh, progressCh, err := textsimilarity.Similarities(ctx, files, opts)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	sendErr := error(nil)
+
+	// the stream must not be used concurrently, so both channels are drained here
+	for simsCh != nil || progressCh != nil {
+		var resp *pb.AnalyzeResponse
+
+		select {
+		case sim, ok := <-simsCh:
+			if !ok {
+				simsCh = nil
+				continue
+			}
+
+			resp = &pb.AnalyzeResponse{
+				Message: &pb.AnalyzeResponse_Similarity{Similarity: newSimilarity(sim)},
+			}
+
+		case prog, ok := <-progressCh:
+			if !ok {
+				progressCh = nil
+				continue
+			}
+
+			if prog.Err != nil {
+				if sendErr == nil {
+					sendErr = status.Error(codes.Internal, fmt.Sprintf("scan %s: %s", prog.File.Name, prog.Err))
+				}
+
+				cancel()
+
+				continue
+			}
+
+			resp = &pb.AnalyzeResponse{
+				Message: &pb.AnalyzeResponse_Progress{Progress: newProgress(prog)},
+			}
+		}
+
+		if sendErr != nil {
+			continue
+		}
+
+		if err := stream.Send(resp); err != nil {
+			sendErr = err
+
+			cancel()
+		}
+	}
+
+	if sendErr != nil {
+		return sendErr
+	}
+
+	if err := stream.Context().Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+
+	return nil
+}
+
+// errDuplicateFileName is returned when a request contains multiple files with the same name.
+var errDuplicateFileName = errors.New("duplicate file name")
+
+// newFiles returns files for reqFiles.
+func newFiles(reqFiles []*pb.File) ([]*textsimilarity.File, error) {
+	files := make([]*textsimilarity.File, len(reqFiles))
+	names := make(map[string]struct{}, len(reqFiles))
+
+	for idx, reqFile := range reqFiles {
+		if _, ok := names[reqFile.GetName()]; ok {
+			return nil, fmt.Errorf("%w: %s", errDuplicateFileName, reqFile.GetName())
+		}
+
+		names[reqFile.GetName()] = struct{}{}
+
+		files[idx] = &textsimilarity.File{
+			Name:          reqFile.GetName(),
+			R:             bytes.NewReader(reqFile.GetContent()),
+			ReferenceOnly: reqFile.GetReferenceOnly(),
+		}
+	}
+
+	return files, nil
+}
+
+// newOptions returns options for reqOpts. Unset fields use the defaults of the command line tool.
+func newOptions(reqOpts *pb.Options) (*textsimilarity.Options, error) {
+	opts := textsimilarity.Options{
+		MinLineLength:   int(reqOpts.GetMinLineLength()),
+		MinSimilarLines: int(reqOpts.GetMinSimilarLines()),
+		MaxEditDistance: int(reqOpts.GetMaxEditDistance()),
+		MinOccurrences:  int(reqOpts.GetMinOccurrences()),
+		MaxOccurrences:  int(reqOpts.GetMaxOccurrences()),
+		CaptureText:     reqOpts.GetCaptureText(),
+	}
+
+	if opts.MinSimilarLines <= 0 {
+		opts.MinSimilarLines = defaultMinSimilarLines
+	}
+
+	if opts.MaxEditDistance <= 0 {
+		opts.MaxEditDistance = textsimilarity.DefaultMaxEditDistance
+	}
+
+	if reqOpts.GetIgnoreWhitespace() {
+		opts.Flags |= textsimilarity.IgnoreWhitespaceFlag
+	}
+
+	if reqOpts.GetIgnoreBlankLines() {
+		opts.Flags |= textsimilarity.IgnoreBlankLinesFlag
+	}
+
+	if reqOpts.GetIgnoreLineRegex() != "" {
+		regex, err := regexp.Compile(reqOpts.GetIgnoreLineRegex())
+		if err != nil {
+			return nil, fmt.Errorf("ignore line regex: %w", err)
+		}
+
+		opts.IgnoreLineRegex = regex
+	}
+
+	return &opts, nil
+}
+
+// newProgress returns a progress message for prog.
+func newProgress(prog textsimilarity.Progress) *pb.Progress {
+	return &pb.Progress{
+		File: prog.File.Name,
+		Done: prog.Done,
+		Eta:  timestamppb.New(prog.ETA),
+	}
+}
+
+// newSimilarity returns a similarity message for sim.
+func newSimilarity(sim *textsimilarity.Similarity) *pb.Similarity {
+	pbSim := pb.Similarity{
+		Id:          sim.ID(),
+		Level:       newSimilarityLevel(sim.Level),
+		Occurrences: make([]*pb.Occurrence, len(sim.Occurrences)),
+	}
+
+	for idx, occ := range sim.Occurrences {
+		pbSim.Occurrences[idx] = &pb.Occurrence{
+			File:         occ.File.Name,
+			StartLine:    int32(occ.Start), //nolint:gosec // line numbers are small
+			EndLine:      int32(occ.End),   //nolint:gosec // line numbers are small
+			StartColumns: newColumnRange(occ.StartColumns),
+			EndColumns:   newColumnRange(occ.EndColumns),
+			Text:         occ.Text,
+		}
+	}
+
+	return &pbSim
+}
+
+// newSimilarityLevel returns the similarity level message for level.
+func newSimilarityLevel(level textsimilarity.SimilarityLevel) pb.SimilarityLevel {
+	switch level {
+	case textsimilarity.EqualSimilarityLevel:
+		return pb.SimilarityLevel_SIMILARITY_LEVEL_EQUAL
+	case textsimilarity.SimilarSimilarityLevel:
+		return pb.SimilarityLevel_SIMILARITY_LEVEL_SIMILAR
+	default:
+		return pb.SimilarityLevel_SIMILARITY_LEVEL_UNSPECIFIED
+	}
+}
+
+// newColumnRange returns a column range message for r. If r is nil, nil is returned.
+func newColumnRange(r *textsimilarity.ColumnRange) *pb.ColumnRange {
+	if r == nil {
+		return nil
+	}
+
+	return &pb.ColumnRange{
+		Start: int32(r.Start), //nolint:gosec // column numbers are small
+		End:   int32(r.End),   //nolint:gosec // column numbers are small
+	}
+}
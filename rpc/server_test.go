@@ -0,0 +1,141 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	pb "github.com/blizzy78/textsimilarity/rpc/textsimilaritypb"
+	"github.com/matryer/is"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestServer_Analyze(t *testing.T) {
+	is := is.New(t)
+
+	client := newTestClient(t)
+
+	stream, err := client.Analyze(context.Background(), &pb.AnalyzeRequest{
+		Files: []*pb.File{
+			{Name: "1.txt", Content: []byte("aaaaaaaaaa\nbbbbbbbbbb\ncccccccccc\n")},
+			{Name: "2.txt", Content: []byte("xxxxxxxxxx\naaaaaaaaaa\nbbbbbbbxbb\ncccccccccc\n")},
+		},
+		Options: &pb.Options{
+			MinSimilarLines: 3,
+			CaptureText:     true,
+		},
+	})
+	is.NoErr(err)
+
+	sims, progress := receiveAll(t, stream)
+
+	is.Equal(len(progress), 2)
+	is.Equal(progress[len(progress)-1].GetDone(), 100.0)
+
+	is.Equal(len(sims), 1)
+	is.True(sims[0].GetId() != "")
+	is.Equal(sims[0].GetLevel(), pb.SimilarityLevel_SIMILARITY_LEVEL_SIMILAR)
+	is.Equal(len(sims[0].GetOccurrences()), 2)
+
+	occ := sims[0].GetOccurrences()[1]
+	is.Equal(occ.GetFile(), "2.txt")
+	is.Equal(occ.GetStartLine(), int32(1))
+	is.Equal(occ.GetEndLine(), int32(4))
+	is.Equal(occ.GetText(), "aaaaaaaaaa\nbbbbbbbxbb\ncccccccccc\n")
+}
+
+func TestServer_Analyze_InvalidArgument(t *testing.T) {
+	tests := []struct {
+		description string
+		givenReq    *pb.AnalyzeRequest
+	}{
+		{
+			description: "duplicate file name",
+			givenReq: &pb.AnalyzeRequest{
+				Files: []*pb.File{{Name: "1.txt"}, {Name: "1.txt"}},
+			},
+		},
+		{
+			description: "invalid regex",
+			givenReq: &pb.AnalyzeRequest{
+				Options: &pb.Options{IgnoreLineRegex: "("},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			is := is.New(t)
+
+			client := newTestClient(t)
+
+			stream, err := client.Analyze(context.Background(), test.givenReq)
+			is.NoErr(err)
+
+			_, err = stream.Recv()
+			is.Equal(status.Code(err), codes.InvalidArgument)
+		})
+	}
+}
+
+func newTestClient(t *testing.T) pb.TextSimilarityClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1024 * 1024)
+
+	server := grpc.NewServer()
+	pb.RegisterTextSimilarityServer(server, NewServer())
+
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	return pb.NewTextSimilarityClient(conn)
+}
+
+func receiveAll(t *testing.T, stream pb.TextSimilarity_AnalyzeClient) ([]*pb.Similarity, []*pb.Progress) {
+	t.Helper()
+
+	sims := []*pb.Similarity{}
+	progress := []*pb.Progress{}
+
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return sims, progress
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		switch msg := resp.GetMessage().(type) {
+		case *pb.AnalyzeResponse_Similarity:
+			sims = append(sims, msg.Similarity)
+		case *pb.AnalyzeResponse_Progress:
+			progress = append(progress, msg.Progress)
+		}
+	}
+}
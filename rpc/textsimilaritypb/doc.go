@@ -0,0 +1,5 @@
+// Package textsimilaritypb contains the protocol buffer messages and gRPC service definition of the
+// textsimilarity gRPC service.
+package textsimilaritypb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative textsimilarity.proto
@@ -0,0 +1,177 @@
+package textsimilarity
+
+import (
+	"sort"
+	"sync"
+)
+
+// taskLines is the maximum number of lines of a file checked by a single task.
+const taskLines = 1000
+
+// A task is a range of lines of a file that needs to be checked for similarities against the file's peers.
+type task struct {
+	// idx is the index of the task in the order of all tasks.
+	idx int
+
+	// f is the file to check.
+	f *File
+
+	// peers are all the files f needs to be checked against, including f itself.
+	peers []*File
+
+	// startLine is the first line to check (zero-based.)
+	startLine int
+
+	// endLine is the line to stop checking at (zero-based, exclusive.) Similarities starting before endLine
+	// may extend beyond it.
+	endLine int
+}
+
+// A workQueue is a queue of tasks of a single worker. Other workers may steal tasks from it.
+type workQueue struct {
+	// lock guards tasks.
+	lock sync.Mutex
+
+	// tasks are the tasks that are yet to be run.
+	tasks []*task
+}
+
+// canonicalFiles returns a copy of files, sorted by name. Files with equal names keep their relative order.
+// Scanning files in this order makes the occurrences that start similarities, and thus the similarities found,
+// independent of the order of files.
+func canonicalFiles(files []*File) []*File {
+	sorted := make([]*File, len(files))
+	copy(sorted, files)
+
+	sort.SliceStable(sorted, func(a int, b int) bool {
+		return sorted[a].Name < sorted[b].Name
+	})
+
+	return sorted
+}
+
+// newTasks returns the tasks to check files against peers, splitting files into ranges of at most
+// taskLines lines each.
+func newTasks(files []*File, peers [][]*File) []*task {
+	tasks := []*task{}
+
+	for fileIdx, file := range files {
+		for startLine := 0; startLine == 0 || startLine < file.lineCount; startLine += taskLines {
+			tasks = append(tasks, &task{
+				idx:       len(tasks),
+				f:         file,
+				peers:     peers[fileIdx],
+				startLine: startLine,
+				endLine:   min(startLine+taskLines, file.lineCount),
+			})
+		}
+	}
+
+	return tasks
+}
+
+// fileToCheck returns a new fileToCheck for t, with new done-markers for t's file and its peers. Lines outside
+// of the files' scopes are marked as done.
+func (t *task) fileToCheck() *fileToCheck {
+	ftc := fileToCheck{
+		f:         t.f,
+		linesDone: newBitVector(len(t.f.lines)),
+		peers:     make([]*fileToCheck, len(t.peers)),
+	}
+
+	t.f.markOutOfScope(ftc.linesDone)
+
+	for idx, peer := range t.peers {
+		ftc.peers[idx] = &fileToCheck{
+			f:         peer,
+			linesDone: newBitVector(len(peer.lines)),
+		}
+
+		peer.markOutOfScope(ftc.peers[idx].linesDone)
+	}
+
+	return &ftc
+}
+
+// runTasks runs all tasks using run, using a number of workers. Tasks are distributed evenly between workers.
+// Each worker runs its own tasks in order, and steals tasks from other workers once it has run out of tasks.
+// runTasks returns when all tasks have been run.
+func runTasks(tasks []*task, workers int, run func(t *task)) {
+	workers = max(1, min(workers, len(tasks)))
+
+	queues := make([]*workQueue, workers)
+	for idx := range queues {
+		queues[idx] = &workQueue{}
+	}
+
+	for idx, t := range tasks {
+		queue := queues[idx%workers]
+		queue.tasks = append(queue.tasks, t)
+	}
+
+	grp := sync.WaitGroup{}
+	grp.Add(workers)
+
+	for worker := 0; worker < workers; worker++ {
+		go func(worker int) {
+			defer grp.Done()
+
+			for {
+				t, ok := nextTask(queues, worker)
+				if !ok {
+					return
+				}
+
+				run(t)
+			}
+		}(worker)
+	}
+
+	grp.Wait()
+}
+
+// nextTask returns the next task for worker from its own queue, or steals one from another worker's queue.
+// It returns false if there are no tasks left in any queue.
+func nextTask(queues []*workQueue, worker int) (*task, bool) {
+	if t, ok := queues[worker].pop(); ok {
+		return t, true
+	}
+
+	for idx := 1; idx < len(queues); idx++ {
+		if t, ok := queues[(worker+idx)%len(queues)].steal(); ok {
+			return t, true
+		}
+	}
+
+	return nil, false
+}
+
+// pop removes and returns the first task of q.
+func (q *workQueue) pop() (*task, bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if len(q.tasks) == 0 {
+		return nil, false
+	}
+
+	t := q.tasks[0]
+	q.tasks = q.tasks[1:]
+
+	return t, true
+}
+
+// steal removes and returns the last task of q.
+func (q *workQueue) steal() (*task, bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if len(q.tasks) == 0 {
+		return nil, false
+	}
+
+	t := q.tasks[len(q.tasks)-1]
+	q.tasks = q.tasks[:len(q.tasks)-1]
+
+	return t, true
+}
@@ -0,0 +1,213 @@
+package textsimilarity
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestRunTasks(t *testing.T) {
+	is := is.New(t)
+
+	tasks := make([]*task, 100)
+	for idx := range tasks {
+		tasks[idx] = &task{idx: idx}
+	}
+
+	lock := sync.Mutex{}
+	runs := map[int]int{}
+
+	runTasks(tasks, 7, func(t *task) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		runs[t.idx]++
+	})
+
+	is.Equal(len(runs), len(tasks))
+
+	for _, count := range runs {
+		is.Equal(count, 1)
+	}
+}
+
+func TestSimilarities_AcrossTasks(t *testing.T) {
+	is := is.New(t)
+
+	lines := make([]string, 2500)
+	for idx := range lines {
+		lines[idx] = fmt.Sprintf("%x", sha256.Sum256([]byte(strconv.Itoa(idx))))
+	}
+
+	// duplicate a block crossing the boundary between the first and second task
+	copy(lines[2000:2010], lines[995:1005])
+
+	file := newFile("large.txt", strings.Join(lines, "\n")+"\n")
+
+	simsCh, progressCh, _ := Similarities(context.Background(), []*File{file}, &Options{
+		MaxEditDistance: 1,
+		MinSimilarLines: 5,
+	})
+
+	var sims []*Similarity
+
+	waitForAll(func() {
+		sims = readSimilaritiesChan(simsCh)
+	}, drainProgressChan(progressCh))
+
+	is.Equal(len(sims), 1)
+	is.Equal(sims[0].Level, EqualSimilarityLevel)
+	is.Equal(len(sims[0].Occurrences), 2)
+	is.Equal(sims[0].Occurrences[0].Start, 995)
+	is.Equal(sims[0].Occurrences[0].End, 1005)
+	is.Equal(sims[0].Occurrences[1].Start, 2000)
+	is.Equal(sims[0].Occurrences[1].End, 2010)
+}
+
+func TestSimilarities_Deterministic(t *testing.T) {
+	is := is.New(t)
+
+	block := make([]string, 10)
+	for idx := range block {
+		block[idx] = fmt.Sprintf("%x", sha256.Sum256([]byte(fmt.Sprintf("block:%d", idx))))
+	}
+
+	// copies of the block, some of them edited or partial, make for many ties between candidate lines
+	texts := make([]string, 3)
+	for fileIdx, starts := range [][]int{{10, 400, 995, 1050}, {5, 30}, {0, 15, 40}} {
+		lines := make([]string, starts[len(starts)-1]+20)
+		for idx := range lines {
+			lines[idx] = fmt.Sprintf("%x", sha256.Sum256([]byte(fmt.Sprintf("%d:%d", fileIdx, idx))))
+		}
+
+		for copyIdx, start := range starts {
+			copy(lines[start:start+10-copyIdx], block)
+
+			if copyIdx%2 == 1 {
+				lines[start+copyIdx] += "x"
+			}
+		}
+
+		texts[fileIdx] = strings.Join(lines, "\n") + "\n"
+	}
+
+	results := map[string]struct{}{}
+
+	for _, parallelism := range []int{1, 4, 16} {
+		files := make([]*File, len(texts))
+		for idx, text := range texts {
+			files[idx] = newFile(fmt.Sprintf("%d.txt", idx), text)
+		}
+
+		sims := similaritiesWithOptions(t, files, &Options{
+			MaxEditDistance: 2,
+			MinSimilarLines: 3,
+			Parallelism:     parallelism,
+		})
+
+		is.True(len(sims) > 0)
+
+		result := strings.Builder{}
+
+		for _, sim := range sims {
+			fmt.Fprintf(&result, "%d:", sim.Level)
+
+			for _, occ := range sim.Occurrences {
+				fmt.Fprintf(&result, " %s:%d-%d", occ.File.Name, occ.Start, occ.End)
+			}
+
+			result.WriteString("\n")
+		}
+
+		results[result.String()] = struct{}{}
+	}
+
+	is.Equal(len(results), 1)
+}
+
+func TestSimilarities_FileOrder(t *testing.T) {
+	is := is.New(t)
+
+	texts := map[string]string{
+		"a.txt": "alpha bravo charlie\ndelta echo foxtrot\ngolf hotel india\njuliet kilo lima\n",
+		"b.txt": "alpha bravo charlie\ndelta echo foxtrt\ngolf hotel india\njuliet kilo lima\n",
+		"c.txt": "unrelated line here\ndelta echo foxtrot\ngolf hotel indio\njuliet kilo lima\n",
+	}
+
+	results := map[string]struct{}{}
+
+	for _, names := range [][]string{{"a.txt", "b.txt", "c.txt"}, {"c.txt", "b.txt", "a.txt"}, {"b.txt", "c.txt", "a.txt"}} {
+		files := make([]*File, len(names))
+		for idx, name := range names {
+			files[idx] = newFile(name, texts[name])
+		}
+
+		sims := similaritiesWithOptions(t, files, &Options{MaxEditDistance: 2, MinSimilarLines: 3})
+		is.True(len(sims) > 0)
+
+		result := strings.Builder{}
+
+		for _, sim := range sims {
+			fmt.Fprintf(&result, "%d:", sim.Level)
+
+			for _, occ := range sim.Occurrences {
+				fmt.Fprintf(&result, " %s:%d-%d", occ.File.Name, occ.Start, occ.End)
+			}
+
+			result.WriteString("\n")
+		}
+
+		results[result.String()] = struct{}{}
+	}
+
+	is.Equal(len(results), 1)
+}
+
+func TestSimilarities_PairsCheckedOnce(t *testing.T) {
+	is := is.New(t)
+
+	lines := func(prefix string, start int, end int) []string {
+		lines := make([]string, 0, end-start)
+		for idx := start; idx < end; idx++ {
+			lines = append(lines, fmt.Sprintf("%x", sha256.Sum256([]byte(fmt.Sprintf("%s:%d", prefix, idx)))))
+		}
+
+		return lines
+	}
+
+	text := func(parts ...[]string) string {
+		all := []string{}
+		for _, part := range parts {
+			all = append(all, part...)
+		}
+
+		return strings.Join(all, "\n") + "\n"
+	}
+
+	// b.txt and c.txt share a block that overlaps the block shared by a.txt and b.txt
+	files := []*File{
+		newFile("a.txt", text(lines("a", 0, 5), lines("x", 0, 20), lines("a", 5, 10))),
+		newFile("b.txt", text(lines("x", 0, 15), lines("y", 0, 25))),
+		newFile("c.txt", text(lines("c", 0, 3), lines("x", 10, 15), lines("y", 0, 25))),
+	}
+
+	sims := similaritiesWithOptions(t, files, &Options{MinSimilarLines: 5})
+
+	got := make([]string, len(sims))
+	for idx, sim := range sims {
+		occs := make([]string, len(sim.Occurrences))
+		for occIdx, occ := range sim.Occurrences {
+			occs[occIdx] = fmt.Sprintf("%s:%d-%d", occ.File.Name, occ.Start, occ.End)
+		}
+
+		got[idx] = strings.Join(occs, " ")
+	}
+
+	is.Equal(got, []string{"a.txt:5-20 b.txt:0-15", "b.txt:10-40 c.txt:3-33"})
+}
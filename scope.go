@@ -0,0 +1,160 @@
+package textsimilarity
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// A Scope is a range of lines of a File that similarities are restricted to, such as the body of a function.
+type Scope struct {
+	// Name is the name of the scope, such as the name of a function.
+	Name string
+
+	// Start is the starting line number (zero-based.)
+	Start int
+
+	// End is the ending line number (zero-based, exclusive.)
+	End int
+}
+
+// Scope returns the scope of o's file that contains o, or nil if o's file has no scopes.
+func (o *FileOccurrence) Scope() *Scope {
+	for idx := range o.File.Scopes {
+		scope := &o.File.Scopes[idx]
+		if o.Start >= scope.Start && o.Start < scope.End {
+			return scope
+		}
+	}
+
+	return nil
+}
+
+// setupScopes sets f.Scopes to the bodies of functions if f is a Go file and GoFunctionsFlag is set, and sets up
+// f.scopeLines according to f.Scopes. The lines of f must be loaded.
+func (f *File) setupScopes(opts *Options) {
+	// scopes are ranges of lines, which do not correspond to other units
+	if !opts.lineUnits() {
+		f.scopeLines = nil
+		return
+	}
+
+	if f.Scopes == nil && opts.flagSet(GoFunctionsFlag) && strings.HasSuffix(f.Name, ".go") {
+		f.Scopes = f.goFunctionScopes()
+	}
+
+	if f.Scopes == nil {
+		f.scopeLines = nil
+		return
+	}
+
+	f.scopeLines = make([]int32, f.lineCount)
+	for idx := range f.scopeLines {
+		f.scopeLines[idx] = -1
+	}
+
+	for scopeIdx, scope := range f.Scopes {
+		for l := max(scope.Start, 0); l < min(scope.End, f.lineCount); l++ {
+			f.scopeLines[l] = int32(scopeIdx) //nolint:gosec // number of scopes is small
+		}
+	}
+}
+
+// inScope returns whether the line at lineIdx (zero-based) of f is within any of f's scopes, or whether f has no
+// scopes.
+func (f *File) inScope(lineIdx int) bool {
+	return f.scopeLines == nil || f.scopeLines[lineIdx] >= 0
+}
+
+// sameScope returns whether the lines at lineIdx1 and lineIdx2 (zero-based) of f are within the same scope,
+// or whether f has no scopes.
+func (f *File) sameScope(lineIdx1 int, lineIdx2 int) bool {
+	return f.scopeLines == nil || f.scopeLines[lineIdx1] == f.scopeLines[lineIdx2]
+}
+
+// markOutOfScope sets the bits of all lines of f in done that are outside of f's scopes.
+func (f *File) markOutOfScope(done *bitVector) {
+	for lineIdx, scopeIdx := range f.scopeLines {
+		if scopeIdx < 0 {
+			done.set(lineIdx, true)
+		}
+	}
+}
+
+// goFunctionScopes parses the lines of f as Go source code and returns the bodies of all functions and methods,
+// without their braces, as scopes. If f cannot be parsed, nil is returned. The lines of f must be loaded.
+func (f *File) goFunctionScopes() []Scope {
+	src := strings.Builder{}
+
+	for lineIdx := 0; lineIdx < f.lineCount; lineIdx++ {
+		src.WriteString(f.lines[lineIdx].originalText)
+		src.WriteByte('\n')
+	}
+
+	fset := token.NewFileSet()
+
+	astFile, err := parser.ParseFile(fset, f.Name, src.String(), parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+
+	scopes := []Scope{}
+
+	for _, decl := range astFile.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || funcDecl.Body == nil {
+			continue
+		}
+
+		// lines are one-based, so the line of the opening brace is the zero-based start of the body
+		start := fset.Position(funcDecl.Body.Lbrace).Line
+		end := fset.Position(funcDecl.Body.Rbrace).Line - 1
+
+		if end <= start {
+			continue
+		}
+
+		scopes = append(scopes, Scope{
+			Name:  goFunctionName(funcDecl),
+			Start: start,
+			End:   end,
+		})
+	}
+
+	return scopes
+}
+
+// goFunctionName returns the name of decl, qualified by its receiver type for methods, such as "(*T).Method".
+func goFunctionName(decl *ast.FuncDecl) string {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return decl.Name.Name
+	}
+
+	recvType := decl.Recv.List[0].Type
+	pointer := false
+
+	if star, ok := recvType.(*ast.StarExpr); ok {
+		recvType = star.X
+		pointer = true
+	}
+
+	// strip type parameters of generic types
+	switch expr := recvType.(type) {
+	case *ast.IndexExpr:
+		recvType = expr.X
+	case *ast.IndexListExpr:
+		recvType = expr.X
+	}
+
+	recvName := "?"
+	if ident, ok := recvType.(*ast.Ident); ok {
+		recvName = ident.Name
+	}
+
+	if pointer {
+		return "(*" + recvName + ")." + decl.Name.Name
+	}
+
+	return recvName + "." + decl.Name.Name
+}
@@ -0,0 +1,85 @@
+package textsimilarity
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+const scopeTestGoSource = `package foo
+
+import (
+	"fmt"
+	"strings"
+)
+
+func (t *T[K]) First() {
+	fmt.Println("aaaaaaaaaa")
+	fmt.Println("bbbbbbbbbb")
+	fmt.Println("cccccccccc")
+}
+
+func Second() {
+	fmt.Println("aaaaaaaaaa")
+	fmt.Println("bbbbbbbbbb")
+	fmt.Println("cccccccccc")
+}
+`
+
+func TestSimilarities_GoFunctions(t *testing.T) {
+	is := is.New(t)
+
+	file1 := newFile("1.go", scopeTestGoSource)
+	file2 := newFile("2.go", scopeTestGoSource)
+
+	sims := similaritiesWithOptions(t, []*File{file1, file2}, &Options{MinSimilarLines: 3, Flags: GoFunctionsFlag})
+
+	is.Equal(len(file1.Scopes), 2)
+	is.Equal(file1.Scopes[0], Scope{Name: "(*T).First", Start: 8, End: 11})
+	is.Equal(file1.Scopes[1], Scope{Name: "Second", Start: 14, End: 17})
+
+	for _, sim := range sims {
+		for _, occ := range sim.Occurrences {
+			is.True(occ.Scope() != nil)
+			is.True(occ.End <= occ.Scope().End)
+		}
+	}
+
+	is.Equal(len(sims), 1)
+	is.Equal(len(sims[0].Occurrences), 4)
+	is.Equal(sims[0].Occurrences[0].Scope().Name, "(*T).First")
+}
+
+func TestSimilarities_Scopes(t *testing.T) {
+	is := is.New(t)
+
+	text := "aaaaaaaaaa\nbbbbbbbbbb\ncccccccccc\ndddddddddd\neeeeeeeeee\nffffffffff\n"
+
+	file1 := newFile("1.txt", text)
+	file1.Scopes = []Scope{{Name: "one", Start: 0, End: 3}, {Name: "two", Start: 3, End: 5}}
+	file2 := newFile("2.txt", text)
+
+	sims := similaritiesWithOptions(t, []*File{file1, file2}, &Options{MinSimilarLines: 2})
+
+	is.Equal(len(sims), 2)
+
+	is.Equal(sims[0].Occurrences[0].File, file1)
+	is.Equal(sims[0].Occurrences[0].Start, 0)
+	is.Equal(sims[0].Occurrences[0].End, 3)
+	is.Equal(sims[0].Occurrences[0].Scope().Name, "one")
+	is.Equal(sims[0].Occurrences[1].Scope(), nil)
+
+	is.Equal(sims[1].Occurrences[0].Start, 3)
+	is.Equal(sims[1].Occurrences[0].End, 5)
+	is.Equal(sims[1].Occurrences[0].Scope().Name, "two")
+}
+
+func TestSimilarities_GoFunctions_ParseError(t *testing.T) {
+	is := is.New(t)
+
+	file := newFile("1.go", "not go\n")
+
+	_ = similaritiesWithOptions(t, []*File{file}, &Options{MinSimilarLines: 3, Flags: GoFunctionsFlag})
+
+	is.Equal(file.Scopes, nil)
+}
@@ -0,0 +1,111 @@
+package textsimilarity
+
+import (
+	"math/bits"
+	"sort"
+)
+
+// maxSimHashBands is the maximum number of bands SimHashes are split into for bucketing. Narrower bands would
+// put too many blocks into the same buckets.
+const maxSimHashBands = 16
+
+// A simHashBucket is a bucket of blocks whose SimHashes are equal in a single band.
+type simHashBucket struct {
+	band  int
+	value uint64
+}
+
+// SimHashDistance returns the Hamming distance between the SimHashes hash1 and hash2, that is, the number of bits
+// they differ in. The more similar the words of two blocks are, the smaller is the distance of their SimHashes.
+func SimHashDistance(hash1 uint64, hash2 uint64) int {
+	return bits.OnesCount64(hash1 ^ hash2)
+}
+
+// simHash returns the SimHash of words, weighted by the number of times they appear in words.
+func simHash(words map[uint64]int) uint64 {
+	weights := [64]int{}
+
+	for word, count := range words {
+		hash := mixHash(word)
+
+		for bit := 0; bit < 64; bit++ {
+			if hash&(1<<bit) != 0 {
+				weights[bit] += count
+			} else {
+				weights[bit] -= count
+			}
+		}
+	}
+
+	hash := uint64(0)
+
+	for bit, weight := range weights {
+		if weight > 0 {
+			hash |= 1 << bit
+		}
+	}
+
+	return hash
+}
+
+// setSimHash sets o.SimHash from the words of its lines that are considered for similarities, according to opts.
+// The lines of o's file must be loaded.
+func (o *FileOccurrence) setSimHash(opts *Options) {
+	words := map[uint64]int{}
+
+	for l := o.Start; l < o.End; l++ {
+		line := o.File.lines[l]
+		if !acceptLine(line, opts) {
+			continue
+		}
+
+		for _, word := range lineWords(line.text) {
+			words[word]++
+		}
+	}
+
+	o.SimHash = simHash(words)
+}
+
+// simHashCandidates returns a function that returns the indexes of the blocks after the block with a given index
+// whose SimHashes differ in at most maxDist bits from its SimHash, in ascending order. Blocks are put into buckets
+// by bands of their SimHashes, so that blocks within maxDist must share at least one bucket.
+func simHashCandidates(blocks []*cosineBlock, maxDist int) func(blockIdx int) []int {
+	bands := min(maxDist+1, maxSimHashBands)
+	buckets := map[simHashBucket][]int{}
+
+	for blockIdx, block := range blocks {
+		for band := 0; band < bands; band++ {
+			bucket := simHashBand(block.simHash, band, bands)
+			buckets[bucket] = append(buckets[bucket], blockIdx)
+		}
+	}
+
+	return func(blockIdx int) []int {
+		block := blocks[blockIdx]
+		candidates := map[int]struct{}{}
+
+		for band := 0; band < bands; band++ {
+			bucketIdxs := buckets[simHashBand(block.simHash, band, bands)]
+
+			for _, otherIdx := range bucketIdxs[sort.SearchInts(bucketIdxs, blockIdx+1):] {
+				if SimHashDistance(block.simHash, blocks[otherIdx].simHash) <= maxDist {
+					candidates[otherIdx] = struct{}{}
+				}
+			}
+		}
+
+		return sortedInts(candidates)
+	}
+}
+
+// simHashBand returns the bucket of hash in band, when splitting hashes into bands of equal width.
+func simHashBand(hash uint64, band int, bands int) simHashBucket {
+	start := band * 64 / bands
+	end := (band + 1) * 64 / bands
+
+	return simHashBucket{
+		band:  band,
+		value: (hash >> start) & (^uint64(0) >> (64 - (end - start))),
+	}
+}
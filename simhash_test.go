@@ -0,0 +1,69 @@
+package textsimilarity
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestSimHashDistance(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(SimHashDistance(0, 0), 0)
+	is.Equal(SimHashDistance(0b1011, 0b0110), 3)
+	is.Equal(SimHashDistance(0, ^uint64(0)), 64)
+}
+
+func TestSimHash(t *testing.T) {
+	is := is.New(t)
+
+	words := func(text string) map[uint64]int {
+		counts := map[uint64]int{}
+		for _, word := range lineWords(text) {
+			counts[word]++
+		}
+
+		return counts
+	}
+
+	hash := simHash(words("the quick brown fox jumps over the lazy dog"))
+	is.Equal(simHash(words("The lazy dog; the quick brown fox jumps over.")), hash)
+
+	similar := simHash(words("the quick brown fox jumps over the sleepy dog"))
+	different := simHash(words("lorem ipsum dolor sit amet consectetur adipiscing elit"))
+	is.True(SimHashDistance(hash, similar) < SimHashDistance(hash, different))
+}
+
+func TestSimilarities_SimHash(t *testing.T) {
+	is := is.New(t)
+
+	files := []*File{
+		newFile("1.txt", "foo bar baz\nqux quux corge\ngrault garply waldo\n"),
+		newFile("2.txt", "foo bar baz\nqux quux corge\ngrault garply waldo\n"),
+	}
+
+	sims := similaritiesWithOptions(t, files, &Options{MinSimilarLines: 3, ComputeSimHashes: true})
+	is.Equal(len(sims), 1)
+	is.True(sims[0].Occurrences[0].SimHash != 0)
+	is.Equal(sims[0].Occurrences[0].SimHash, sims[0].Occurrences[1].SimHash)
+}
+
+func TestSimilarities_CosineSimHash(t *testing.T) {
+	is := is.New(t)
+
+	files := []*File{
+		newFile("1.txt", "Unrelated introduction of the first document.\n"+
+			"The committee approved the annual budget on Monday,\n"+
+			"after a long debate about funding for public libraries.\n"+
+			"Critics argued that museums deserved more money.\n"),
+		newFile("2.txt", "A different opening line here.\n"+
+			"On Monday, after a long debate about funding for public libraries,\n"+
+			"the annual budget was approved by the committee.\n"+
+			"Critics argued that museums deserved more money.\n"),
+	}
+
+	sims := similaritiesWithOptions(t, files, &Options{MinSimilarLines: 3, MinCosineSimilarity: 0.8, MaxSimHashDistance: 6})
+	is.Equal(len(sims), 1)
+	is.Equal(sims[0].Occurrences[0].Start, 1)
+	is.Equal(sims[0].Occurrences[1].Start, 1)
+}
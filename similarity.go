@@ -1,12 +1,13 @@
 package textsimilarity
 
 import (
-	"bufio"
 	"bytes"
 	"context"
-	"errors"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"math"
 	"regexp"
 	"runtime"
 	"sort"
@@ -14,9 +15,9 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	slowlevenshtein "github.com/agext/levenshtein"
-	tsio "github.com/blizzy78/textsimilarity/internal/io"
 	"github.com/blizzy78/textsimilarity/levenshtein"
 	"github.com/dropbox/godropbox/container/bitvector"
 )
@@ -27,6 +28,36 @@ const (
 
 	// IgnoreBlankLinesFlag specifies that blank lines should be ignored.
 	IgnoreBlankLinesFlag
+
+	// SkipDisjointFilesFlag specifies that pairs of files that do not share any exactly equal lines should not
+	// be checked against each other. This is much faster for large numbers of unrelated files, but may miss
+	// similarities that consist of similar, but not exactly equal, lines only.
+	SkipDisjointFilesFlag
+
+	// ExactSeedingFlag specifies that similarities should only be started from exactly equal lines, which are
+	// looked up using an index instead of scanning files. Similarities may still be expanded in both directions
+	// using similar lines. This is much faster, but misses similarities that do not contain any exactly equal lines.
+	ExactSeedingFlag
+
+	// MaskLiteralsFlag specifies that numeric and string literals in lines should be replaced by placeholders
+	// before lines are compared, so that code differing only by constants is found to be equal. Literals are
+	// masked after applying Options.LineTransforms.
+	MaskLiteralsFlag
+
+	// ReorderedBlocksFlag specifies that blocks of lines containing the same lines in a different order, such as
+	// reordered struct fields or import lists, should be reported as similarities of ReorderedSimilarityLevel.
+	// Only exactly equal lines are considered, and blocks are only searched for after all other similarities.
+	ReorderedBlocksFlag
+
+	// GoFunctionsFlag specifies that files whose names end in ".go" should be parsed as Go source code, and that
+	// similarities in them should be restricted to the bodies of functions and methods, by setting File.Scopes
+	// if it is nil. Files that cannot be parsed are considered as a whole.
+	GoFunctionsFlag
+
+	// WordDistanceFlag specifies that the Levenshtein distance between lines should be calculated over
+	// whitespace-separated words instead of runes, so that Options.MaxEditDistance is a number of words. Replacing
+	// a single word in a long sentence is a distance of 1, which matches intuition for comparing prose.
+	WordDistanceFlag
 )
 
 const (
@@ -38,11 +69,66 @@ const (
 
 	// EqualSimilarityLevel is the similarity level used for lines or occurrences that are completely equal.
 	EqualSimilarityLevel
+
+	// ReorderedSimilarityLevel is the similarity level used for occurrences that contain the same lines, but in
+	// a different order. It is only used if ReorderedBlocksFlag is set.
+	ReorderedSimilarityLevel
 )
 
 // DefaultMaxEditDistance is the Levenshtein distance used when Options.MaxEditDistance <= 0.
 const DefaultMaxEditDistance = 5
 
+// DefaultMinJaroWinklerSimilarity is the Jaro-Winkler similarity used when Options.MinJaroWinklerSimilarity <= 0.
+const DefaultMinJaroWinklerSimilarity = 0.9
+
+// DefaultMinWindowSimilarity is the fraction of similar lines of windows used when Options.MinWindowSimilarity <= 0.
+const DefaultMinWindowSimilarity = 0.8
+
+const (
+	// LevenshteinLineMetric is the line metric that considers lines similar if their Levenshtein distance is
+	// at most Options.MaxEditDistance. This is the default.
+	LevenshteinLineMetric = LineMetric(iota)
+
+	// JaroWinklerLineMetric is the line metric that considers lines similar if their Jaro-Winkler similarity is
+	// at least Options.MinJaroWinklerSimilarity. It tolerates transposed characters and weighs common prefixes
+	// higher, which suits identifier-like and name-like lines.
+	JaroWinklerLineMetric
+)
+
+const (
+	// DropOverlapPolicy is the overlap policy that drops similarities that do not provide any new evidence: those
+	// overlapping a similarity found earlier between the same files, and those whose lines are all covered by
+	// similarities found earlier. This is the default.
+	DropOverlapPolicy = OverlapPolicy(iota)
+
+	// KeepAllOverlapPolicy is the overlap policy that keeps all similarities, even if they overlap.
+	KeepAllOverlapPolicy
+
+	// KeepLargestOverlapPolicy is the overlap policy that keeps the similarities covering the most lines, dropping
+	// those that overlap larger ones. Similarities are only sent once all similarities have been found.
+	KeepLargestOverlapPolicy
+
+	// SplitOverlapPolicy is the overlap policy that removes the lines covered by similarities found earlier from
+	// overlapping similarities, keeping the remaining parts that still have enough lines.
+	SplitOverlapPolicy
+)
+
+const (
+	// BlockProgressPolicy is the progress policy that sends all progress updates, waiting for the caller to receive
+	// them if the progress channel is full. A caller that is slow to receive progress slows down the scan. This is
+	// the default.
+	BlockProgressPolicy = ProgressPolicy(iota)
+
+	// DropProgressPolicy is the progress policy that drops progress updates if the caller is not ready to receive
+	// them and the progress channel is full. Errors are never dropped.
+	DropProgressPolicy
+
+	// CoalesceProgressPolicy is the progress policy that replaces progress updates that the caller has not
+	// received yet with newer ones, so that the caller always receives the latest progress. Progress.File of
+	// replaced updates is lost. Errors are never replaced.
+	CoalesceProgressPolicy
+)
+
 const (
 	// blankLineFlag is set on a fileLine when that line is blank.
 	blankLineFlag = Flag(1 << iota)
@@ -53,6 +139,10 @@ const (
 
 	// matchesIgnoreRegexLineFlag is set on a fileLine when that line's text matches Options.IgnoreLineRegex.
 	matchesIgnoreRegexLineFlag
+
+	// asciiLineFlag is set on a fileLine when that line's text only contains ASCII characters. In that case,
+	// the line's text is available as bytes instead of runes.
+	asciiLineFlag
 )
 
 // Options specifies several options for determining similarities.
@@ -67,15 +157,200 @@ type Options struct {
 	// fewer lines will not be reported.
 	MinSimilarLines int
 
+	// LineWeights, if set, specifies the weights of lines. Similarities must then have lines with a total weight
+	// of at least MinSimilarLines, instead of a number of lines. Only lines that are considered for similarities
+	// are weighed.
+	LineWeights *LineWeights
+
+	// MinSimilarChars is the minimum number of characters (in runes) a similarity between files must have, in
+	// addition to MinSimilarLines. Only the characters of lines that are considered for similarities are counted,
+	// without leading and trailing whitespace if IgnoreWhitespaceFlag is set. Setting MinSimilarLines to 1 makes
+	// this the only threshold, so that a few long lines qualify, but many short lines do not. If <= 0, there is
+	// no minimum.
+	MinSimilarChars int
+
 	// MaxEditDistance is the maximum Levenshtein distance between similar lines that will be considered "similar."
 	// Lines that have a larger distance between them will be considered different.
 	MaxEditDistance int
 
+	// MaxGapLines is the maximum number of consecutive lines that may be inserted into or deleted from one
+	// occurrence of a similarity, relative to the other. If > 0, similarities of two occurrences are expanded
+	// across such gaps by aligning the lines following them, instead of stopping at the first lines that differ.
+	// Similarities containing gaps are at most at the "similar" level.
+	MaxGapLines int
+
+	// WindowLines, if > 0, specifies that instead of expanding occurrences of single lines into blocks, a window of
+	// WindowLines lines is slid across each pair of files, and windows are reported whose fraction of similar
+	// corresponding lines is at least MinWindowSimilarity. Overlapping windows are merged, and each similarity has
+	// two occurrences. This gives more predictable results for noisy inputs, but compares all pairs of lines, and
+	// MinSimilarLines, ExactSeedingFlag, and MaxGapLines are not used.
+	WindowLines int
+
+	// MinWindowSimilarity is the minimum fraction (0-1) of corresponding lines of two windows of WindowLines lines
+	// that must be similar for the windows to be reported. Pairs of lines that are both not considered for
+	// similarities are not counted, and lines not considered are different from lines that are. If <= 0,
+	// DefaultMinWindowSimilarity is used.
+	MinWindowSimilarity float64
+
+	// Segmenter, if set, splits the text of files into the units that are compared, such as sentences or sequences
+	// of tokens, instead of lines. Similarities are found between sequences of units in the same way as between
+	// sequences of lines, so options referring to lines, such as MinSimilarLines or WindowLines, refer to units.
+	// Occurrences are reported as the ranges of lines spanned by their units, their captured texts are the texts
+	// of their units, and their column ranges are not computed. Scopes and GoFunctionsFlag are not used.
+	// If nil, each line is a unit.
+	Segmenter Segmenter
+
+	// LineMetric is the metric used to determine whether lines are similar. If it is JaroWinklerLineMetric,
+	// lines are similar if their Jaro-Winkler similarity is at least MinJaroWinklerSimilarity, and
+	// MaxEditDistance as well as WordDistanceFlag are not used.
+	LineMetric LineMetric
+
+	// OverlapPolicy determines what happens to similarities that overlap similarities found earlier. If it is
+	// SplitOverlapPolicy, the lines of all files are kept in memory, and MaxLinesMemory is not used.
+	OverlapPolicy OverlapPolicy
+
+	// MinJaroWinklerSimilarity is the minimum Jaro-Winkler similarity, between 0 and 1, of lines that will be
+	// considered "similar" if LineMetric is JaroWinklerLineMetric. If <= 0, DefaultMinJaroWinklerSimilarity
+	// is used.
+	MinJaroWinklerSimilarity float64
+
+	// MinCosineSimilarity, if > 0, specifies that blocks of MinSimilarLines consecutive lines should also be
+	// compared as bags of words, weighted by TF-IDF over all lines of all files, and that blocks whose cosine
+	// similarity is at least MinCosineSimilarity (0-1) should be reported as similarities of SimilarSimilarityLevel.
+	// This finds paraphrased prose that line-by-line comparison misses. Words are compared ignoring case and
+	// punctuation, and blocks are only searched for after all other similarities.
+	MinCosineSimilarity float64
+
+	// MaxSimHashDistance, if > 0, specifies that blocks are only compared for MinCosineSimilarity if the SimHashes
+	// of their words differ in at most MaxSimHashDistance bits, instead of if they share one of their most
+	// distinctive words. Blocks are bucketed by bands of their SimHashes, which is faster for very large numbers of
+	// blocks, but may miss blocks that are similar, but whose SimHashes are not. Small values, such as 3, are
+	// recommended.
+	MaxSimHashDistance int
+
+	// ComputeSimHashes indicates whether the SimHashes of the words of occurrences should be computed in
+	// FileOccurrence.SimHash.
+	ComputeSimHashes bool
+
+	// Tiers, if set, are tiers that similarities are assigned to in Similarity.Tier, such as "exact" with
+	// a MaxEditDistance of 0, "near-exact" with 2, and "loose" with 8. Each similarity is assigned the first tier
+	// whose MaxEditDistance is at least the largest edit distance between corresponding lines of its occurrences,
+	// so tiers should be sorted by MaxEditDistance. Tiers do not affect which lines are similar, so
+	// MaxEditDistance should be at least the largest MaxEditDistance of all tiers. Similarities of
+	// ReorderedSimilarityLevel are not assigned tiers.
+	Tiers []Tier
+
+	// ComputeFuzzyHashes indicates whether the context-triggered piecewise hashes of occurrences should be
+	// computed in FileOccurrence.FuzzyHash.
+	ComputeFuzzyHashes bool
+
 	// IgnoreLineRegex, if set, is an expression that a line must match to be ignored. Note that leading/trailing
 	// whitespace on lines as well as blank lines may be ignored by using Flags.
 	IgnoreLineRegex *regexp.Regexp
+
+	// LineTransforms are rules applied to each line in order before lines are compared, for example to normalize
+	// timestamps in log files. Transformed lines are also matched against IgnoreLineRegex, but the text of
+	// occurrences is reported as it was read.
+	LineTransforms []LineTransform
+
+	// TrailingCommentMarkers, if set, are markers that start end-of-line comments, such as "//" or "#". Comments
+	// following code on the same line are removed from lines before they are compared, after applying
+	// LineTransforms. Markers inside string literals, and comments that make up the whole line, are left alone.
+	TrailingCommentMarkers []string
+
+	// ExcludedFilePairs are pairs of files that are never compared with each other. Similarities may still
+	// have occurrences in both files of an excluded pair if both are similar to a third file.
+	ExcludedFilePairs []FilePair
+
+	// Parallelism is the maximum number of files, or ranges of lines of large files, that are processed
+	// concurrently. If <= 0, the number of logical CPUs plus 2 is used. It does not affect the similarities found.
+	Parallelism int
+
+	// MinOccurrences is the minimum number of occurrences a similarity must have. Similarities with fewer
+	// occurrences will not be reported. If <= 0, there is no minimum.
+	MinOccurrences int
+
+	// MaxOccurrences is the maximum number of occurrences a similarity may have. Similarities with more
+	// occurrences will not be reported. If <= 0, there is no maximum.
+	MaxOccurrences int
+
+	// MaxOccurrencesPerSimilarity is the maximum number of occurrences reported for a single similarity.
+	// Similarities with more occurrences, such as license headers, are reported with their occurrences truncated,
+	// and the number of omitted occurrences is reported in Similarity.OmittedOccurrences. Text is only captured
+	// for the occurrences that are reported. If <= 0, there is no maximum.
+	MaxOccurrencesPerSimilarity int
+
+	// CaptureText indicates whether the text of occurrences should be captured in FileOccurrence.Text,
+	// so that it does not need to be read from the files again.
+	CaptureText bool
+
+	// CaptureTextLines is the maximum number of lines of text captured per occurrence if CaptureText is set.
+	// If <= 0, all lines are captured.
+	CaptureTextLines int
+
+	// MaxLinesMemory is a soft limit of the estimated memory used by loaded lines, in bytes. When it is exceeded,
+	// the lines of files that have not been used recently are spilled to a temporary file, and reloaded when
+	// they are needed again. If <= 0, lines are never spilled.
+	MaxLinesMemory int64
+
+	// SpillDir is the directory to create the temporary file for spilled lines in. If empty, the default
+	// directory for temporary files is used.
+	SpillDir string
+
+	// OnCheckpoint, if set, is called with checkpoints of the scan, at most every CheckpointInterval, and once
+	// when the scan ends. It is called from the goroutine that sends similarities, so it should return quickly.
+	OnCheckpoint func(cp *Checkpoint)
+
+	// CheckpointInterval is the minimum interval between calls of OnCheckpoint. If <= 0, OnCheckpoint is only
+	// called when the scan ends.
+	CheckpointInterval time.Duration
+
+	// HeartbeatInterval is the interval between heartbeats, which are progress updates sent regardless of whether
+	// any file has been processed since the last update, so that callers can tell that a scan is still running
+	// even while processing a single large file takes a long time. If <= 0, no heartbeats are sent.
+	HeartbeatInterval time.Duration
+
+	// ResumeFrom, if set, is a checkpoint to resume the scan from. Its similarities are sent again, and the work
+	// already done is skipped.
+	ResumeFrom *Checkpoint
+
+	// Timings, if set, collects the time spent in each phase of the scan, and for each file.
+	Timings *Timings
+
+	// LineCacheSize is the maximum number of pairs of different lines whose similarity levels are cached during
+	// a scan, so that pairs of lines that occur in many pairs of files, or are compared repeatedly while expanding
+	// similarities, are only compared once. Only comparisons that are not ruled out by cheaper checks, such as
+	// the difference in length, are cached. Pairs are keyed by the hashes of their lines, and the least recently
+	// used pairs are evicted when the cache is full. Caching pays off for long lines with a large MaxEditDistance,
+	// or with JaroWinklerLineMetric, but costs more than it saves for short lines. If <= 0, no cache is used.
+	LineCacheSize int
+
+	// SimilarityBufferSize is the number of similarities that can be sent to the similarity channel returned
+	// by Similarities before the caller receives them. While the buffer is full, finished tasks wait for the
+	// caller, and workers stop taking new tasks once all of them are waiting. If <= 0, the channel is unbuffered.
+	SimilarityBufferSize int
+
+	// ProgressBufferSize is the number of progress updates that can be sent to the progress channel returned by
+	// Similarities before the caller receives them. If <= 0, the channel is unbuffered.
+	ProgressBufferSize int
+
+	// ProgressPolicy determines what happens to progress updates while the progress channel is full.
+	ProgressPolicy ProgressPolicy
+
+	// lineCache is the cache of similarity levels of pairs of lines of the current scan, if any.
+	lineCache *lineCache
 }
 
+// A LineMetric is a metric used to determine whether lines are similar.
+type LineMetric int
+
+// A ProgressPolicy determines what happens to progress updates while the caller is not ready to receive them.
+type ProgressPolicy int
+
+// An OverlapPolicy determines what happens to similarities that overlap similarities found earlier, that is,
+// that cover the same lines of a file.
+type OverlapPolicy int
+
 // A Flag is a single flag (a single set bit), or a set of flags (multiple set bits), depending on the context.
 type Flag uint8
 
@@ -84,11 +359,77 @@ type File struct {
 	// Name is an arbitrary name for the file.
 	Name string
 
-	// R is read from to get the file's contents. The contents is expected to be UTF-8 text.
+	// R is read from to get the file's contents. The contents is expected to be UTF-8 text. R is not used
+	// if Lines is not nil.
 	R io.Reader
 
+	// Lines are the file's lines, without line terminators, if they are already available. If Lines is not
+	// nil, it is used instead of R, so that lines need not be joined and split again. The lines are expected
+	// to be UTF-8 text.
+	Lines []string
+
+	// Open, if set, opens the file's contents again. The contents must be the same as those read from R. If lines
+	// need to be unloaded according to Options.MaxLinesMemory, they are read again using Open when needed, instead
+	// of being spilled to a temporary file. Open is not used if Lines is not nil.
+	Open func() (io.ReadCloser, error)
+
+	// ReferenceOnly indicates that the file is only used as a reference: Similarities are only searched for
+	// starting from files that are not reference-only, but may include occurrences in reference-only files.
+	ReferenceOnly bool
+
+	// Scopes, if not nil, are the ranges of lines that similarities are restricted to, such as the bodies of
+	// functions. Lines outside of all scopes are ignored, and occurrences never extend beyond a single scope.
+	// Scopes must not overlap.
+	Scopes []Scope
+
+	// Meta is arbitrary metadata about the file, such as its repository, language, or owner. It is not used to
+	// find similarities, but is available from the occurrences of similarities in the file, and is included in
+	// reports that support it.
+	Meta map[string]any
+
+	// loaded is the LoadedFile the file has been returned by, if any. Its processed lines are used instead of
+	// processing Lines again.
+	loaded *LoadedFile
+
 	// lines is a map of line numbers (zero-based) to line text.
 	lines map[int]*fileLine
+
+	// lineCount is the number of lines read from R or Lines, or the number of units if units are not lines.
+	lineCount int
+
+	// unitLines are the ranges of lines of all units, in order. It is only set if units are not lines.
+	unitLines []unitLines
+
+	// textLineCount is the number of lines of the text the units have been read from. It is only set if units
+	// are not lines.
+	textLineCount int
+
+	// linesByHash is an inverted index of lines considered for similarities, mapping line hashes to
+	// line numbers (zero-based, ascending.)
+	linesByHash map[uint64][]int
+
+	// lineClasses is an inverted index of all lines, mapping equality classes of lines (see fileLine.class) to
+	// line numbers (zero-based, ascending.)
+	lineClasses map[*fileLine][]int
+
+	// lineHashes are the hashes of all lines considered for similarities. It is only set if
+	// SkipDisjointFilesFlag is set.
+	lineHashes []uint64
+
+	// linesFilter is a filter of lineHashes. It is only set if SkipDisjointFilesFlag is set.
+	linesFilter *bloomFilter
+
+	// scopeLines are the indexes into Scopes of the scope of each line, or -1 for lines outside of all scopes.
+	// It is only set if Scopes is not nil.
+	scopeLines []int32
+
+	// reorderHashes are the hashes of all lines, in order, with 0 for lines not considered for similarities.
+	// It is only set if ReorderedBlocksFlag is set.
+	reorderHashes []uint64
+
+	// blockWords are the hashes of the words of all lines, in order, with nil for lines not considered for
+	// similarities. It is only set if Options.MinCosineSimilarity > 0.
+	blockWords [][]uint64
 }
 
 // A Similarity is a match of ranges of text between different Files.
@@ -98,6 +439,23 @@ type Similarity struct {
 
 	// Level is the level of similarity between Occurrences.
 	Level SimilarityLevel
+
+	// Tier is the name of the tier of Options.Tiers the similarity has been assigned to. It is empty if
+	// Options.Tiers is not set, or if the similarity does not fit any tier.
+	Tier string
+
+	// LineLevels are the levels of similarity of the corresponding lines of Occurrences, one for each line of an
+	// occurrence that is considered for similarities, in order. A similarity of SimilarSimilarityLevel may consist
+	// of mostly equal lines. LineLevels is nil for similarities whose lines do not correspond to each other, such
+	// as those of ReorderedSimilarityLevel, or those found using Options.MinCosineSimilarity.
+	LineLevels []SimilarityLevel
+
+	// OmittedOccurrences is the number of occurrences that have been omitted from Occurrences because of
+	// Options.MaxOccurrencesPerSimilarity.
+	OmittedOccurrences int
+
+	// id is a stable identifier of the similarity, computed from the occurrences' file names and texts.
+	id string
 }
 
 // A FileOccurrence is a range of text within a single File.
@@ -111,7 +469,40 @@ type FileOccurrence struct {
 	// End is the ending line number (zero-based, exclusive.)
 	End int
 
+	// StartUnit is the index of the starting unit (zero-based.) It equals Start unless Options.Segmenter splits
+	// text into units other than lines.
+	StartUnit int
+
+	// EndUnit is the index of the ending unit (zero-based, exclusive.) It equals End unless Options.Segmenter
+	// splits text into units other than lines.
+	EndUnit int
+
+	// StartColumns is the range of columns in the first line that differ from the first line of the similarity's
+	// first occurrence. For the first occurrence itself, it covers the differences to all other occurrences.
+	// It is only set for similarities of SimilarSimilarityLevel, and is nil if there are no differences.
+	StartColumns *ColumnRange
+
+	// EndColumns is the range of columns in the last line that differ, like StartColumns.
+	EndColumns *ColumnRange
+
+	// SimHash is a fingerprint of the words of the lines of the range that are considered for similarities,
+	// ignoring case and punctuation. Occurrences with similar words have SimHashes that differ in few bits,
+	// according to SimHashDistance. It is only set if Options.ComputeSimHashes is set.
+	SimHash uint64
+
+	// FuzzyHash is the context-triggered piecewise hash (CTPH, in the style of ssdeep) of the lines of the range
+	// that are considered for similarities, which can be compared to the hashes of other texts using
+	// ctph.Compare. It is only set if Options.ComputeFuzzyHashes is set.
+	FuzzyHash string
+
+	// Text is the text of the range, with each line terminated by "\n". It is only set if Options.CaptureText
+	// is set, and may be limited to the first lines according to Options.CaptureTextLines.
+	Text string
+
 	fileToCheck *fileToCheck
+
+	// block identifies the text of the range. It is only set for similarities returned by Similarities.
+	block blockKey
 }
 
 // SimilarityLevel is the level of similarity between ranges of text.
@@ -128,7 +519,13 @@ type Progress struct {
 	// ETA is an estimate of the time of completion.
 	ETA time.Time
 
+	// Err is an error that occurred while processing File. If it is set, Done and ETA are not.
 	Err error
+
+	// Heartbeat indicates that this is a heartbeat sent according to Options.HeartbeatInterval, rather than an
+	// update for a processed file. If it is set, File is nil, and Done and ETA reflect the files processed so far.
+	// ETA is the zero time if no file has been processed yet.
+	Heartbeat bool
 }
 
 // A fileToCheck is a file that needs to be processed, along with its peers.
@@ -141,32 +538,64 @@ type fileToCheck struct {
 	// iterating.
 	linesDone *bitVector
 
-	// peers are all the files this file needs to be checked against, including itself.
+	// peers are all the files this file needs to be checked against, including itself. Files that come
+	// before this file are not included unless they are reference-only, since they have already been checked
+	// against this file.
 	peers []*fileToCheck
 }
 
 // A fileLine is a single line of text in a file.
 type fileLine struct {
-	// text is the original line of text.
+	// originalText is the line of text as it was read, before applying any line transforms.
+	originalText string
+
+	// text is the original line of text, with line transforms applied.
 	text string
 
 	// textTrimmed is the line of text sans leading and trailing whitespace.
 	textTrimmed string
 
-	// textRunes is the original line of text.
+	// textRunes is the original line of text. It is nil if the line only contains ASCII characters.
 	textRunes []rune
 
-	// textTrimmedRunes is the line of text sans leading and trailing whitespace.
+	// textTrimmedRunes is the line of text sans leading and trailing whitespace. It is nil if the line only
+	// contains ASCII characters.
 	textTrimmedRunes []rune
 
+	// textBytes is the original line of text. It is only set if the line only contains ASCII characters.
+	textBytes []byte
+
+	// textTrimmedBytes is the line of text sans leading and trailing whitespace. It is only set if the line
+	// only contains ASCII characters.
+	textTrimmedBytes []byte
+
+	// hash is the hash of text, or of textTrimmed if whitespace is ignored.
+	hash uint64
+
+	// chars is the set of characters in text.
+	chars charSet
+
+	// charsTrimmed is the set of characters in textTrimmed.
+	charsTrimmed charSet
+
 	// length is the length of text (in runes.)
 	length int
 
 	// lengthTrimmed is the length of textTrimmed (in runes.)
 	lengthTrimmed int
 
+	// words are the hashes of the whitespace-separated words of text. They are only set if WordDistanceFlag is set.
+	words []rune
+
+	// weight is the weight of the line according to Options.LineWeights. It is only set if Options.LineWeights is set.
+	weight float64
+
 	// flags is a set of line flags, such as whether this line is blank.
 	flags Flag
+
+	// class is the first line of the equality class of this line, that is, of all lines interned using the same
+	// lineTable whose compared texts are equal. Lines of the same class are exactly equal.
+	class *fileLine
 }
 
 // A bitVector is a compact set of bits.
@@ -181,138 +610,735 @@ var intSlicePool = sync.Pool{
 	},
 }
 
+// runesPool is used to allocate []rune when converting ASCII lines for Levenshtein distance calculations.
+var runesPool = sync.Pool{
+	New: func() any {
+		runes := make([]rune, 0, 256)
+		return &runes
+	},
+}
+
+// An occurrenceBuffer collects occurrences for a line while looking for similarities. Occurrences that
+// do not end up in a similarity are recycled, so that they don't need to be garbage collected.
+type occurrenceBuffer struct {
+	// occs are the occurrences collected for the current line.
+	occs []*FileOccurrence
+
+	// free are occurrences that can be reused.
+	free []*FileOccurrence
+}
+
 // Similarities scans files for similarities between them, according to opts. Detected similarities
 // will be sent into the returned channel. Progress is reported via the returned progress channel.
 // Both channels must be drained by the caller.
+//
+// Both channels apply backpressure: while the caller does not receive similarities, and
+// Options.SimilarityBufferSize similarities are waiting, the scan is paused. Similarities are never dropped.
+// Progress updates are handled according to Options.ProgressPolicy once Options.ProgressBufferSize updates are
+// waiting: by default, the scan is paused as well, so a caller that only drains the progress channel after
+// receiving all similarities, or vice versa, needs buffers large enough for all updates, or must receive from
+// both channels concurrently. With DropProgressPolicy or CoalesceProgressPolicy, progress updates never pause
+// the scan, and only errors are guaranteed to be received.
+//
+// The similarities found are deterministic: identical files and options always yield identical similarities,
+// in the same order, regardless of Options.Parallelism and of the order in which workers run tasks. Each task
+// keeps its own state of lines done, ties between equally suitable lines are broken in favor of the first line,
+// and results are emitted in task order. Only scans that are canceled via ctx may yield partial results that
+// differ between runs. Files are scanned in order of their names, so the similarities found do not depend on the
+// order of files either.
+//
+// If reading a file fails, a *LoadError is returned.
 func Similarities(ctx context.Context, files []*File, opts *Options) (<-chan *Similarity, <-chan Progress, error) { //nolint:gocognit,cyclop // it's complicated
+	files = canonicalFiles(files)
+
+	if opts.LineCacheSize > 0 {
+		scanOpts := *opts
+		scanOpts.lineCache = newLineCache(opts.LineCacheSize)
+		opts = &scanOpts
+	}
+
 	totalLines := 0
 
+	// lines are interned across all files
+	lines := newLineTable()
+
+	store := newLineStore(opts)
+
 	for _, f := range files {
-		if err := f.load(opts); err != nil {
+		loadStart := opts.Timings.start()
+
+		if err := f.load(lines, opts); err != nil {
+			store.close()
+			return nil, nil, err
+		}
+
+		totalLines += f.lineCount
+
+		if opts.flagSet(SkipDisjointFilesFlag) {
+			f.buildLinesFilter(opts)
+		}
+
+		if opts.flagSet(ReorderedBlocksFlag) {
+			f.buildReorderHashes(opts)
+		}
+
+		if opts.MinCosineSimilarity > 0 {
+			f.buildBlockWords(opts)
+		}
+
+		if err := store.add(f); err != nil {
+			store.close()
 			return nil, nil, err
 		}
 
-		totalLines += len(f.lines)
+		opts.Timings.record(LoadPhase, loadStart)
+		opts.Timings.recordFile(f, loadStart)
 	}
 
-	filesToCheck := make([]*fileToCheck, len(files))
+	filesToCheck := make([]*File, 0, len(files))
+	filesPeers := make([][]*File, 0, len(files))
+	pairMatches := newFilePairMatches(files, opts)
 
-	for idx, file := range files {
-		ftc := fileToCheck{
-			f:         file,
-			linesDone: newBitVector(len(file.lines)),
+	for fileIdx, file := range files {
+		if file.ReferenceOnly {
+			continue
 		}
 
-		for _, peerFile := range files {
-			peer := fileToCheck{
-				f:         peerFile,
-				linesDone: newBitVector(len(peerFile.lines)),
+		peers := []*File{}
+
+		for peerIdx, peerFile := range files {
+			// each pair of files is only checked once, from the file that comes first
+			if peerIdx < fileIdx && !peerFile.ReferenceOnly {
+				continue
+			}
+
+			if peerFile != file && opts.flagSet(SkipDisjointFilesFlag) && disjointFiles(file, peerFile) {
+				continue
+			}
+
+			if pairMatches.excluded(fileIdx, peerIdx) {
+				continue
 			}
 
-			ftc.peers = append(ftc.peers, &peer)
+			peers = append(peers, peerFile)
 		}
 
-		filesToCheck[idx] = &ftc
+		filesToCheck = append(filesToCheck, file)
+		filesPeers = append(filesPeers, peers)
 	}
 
-	grp := sync.WaitGroup{}
-	simsCh := make(chan *Similarity)
-	progressCh := make(chan Progress)
-	filesDone := int32(0)
+	tasks := newTasks(filesToCheck, filesPeers)
+
+	tasksDone := 0
+
+	if opts.ResumeFrom != nil {
+		if err := resumeFrom(opts.ResumeFrom, files, tasks, store, opts); err != nil {
+			store.close()
+			return nil, nil, err
+		}
+
+		tasksDone = opts.ResumeFrom.TasksDone
+	}
+
+	tasksRemaining := map[*File]*int32{}
+	for _, t := range tasks[tasksDone:] {
+		if _, ok := tasksRemaining[t.f]; !ok {
+			tasksRemaining[t.f] = new(int32)
+		}
+
+		*tasksRemaining[t.f]++
+	}
+
+	resultsCh := make(chan taskResult)
+	progressCh, outProgressCh := newProgressChannels(opts)
 	startTime := time.Now()
-	semaphore := make(chan struct{}, runtime.NumCPU()+2)
+
+	// files whose tasks have all been done before resuming are done already
+	filesDone := int32(0)
+	for _, f := range filesToCheck {
+		if tasksRemaining[f] == nil {
+			filesDone++
+		}
+	}
+
+	// progressOf returns progress for flDone files done
+	progressOf := func(flDone int) Progress {
+		prog := Progress{
+			Done: float64(flDone) * 100.0 / float64(len(filesToCheck)),
+		}
+
+		if flDone > 0 {
+			elapsed := time.Since(startTime)
+			total := time.Duration(int64(float64(elapsed) * float64(len(filesToCheck)) / float64(flDone)))
+			prog.ETA = time.Now().Add(total - elapsed)
+		}
+
+		return prog
+	}
 
 	advanceAndSendProgress := func(file *File) {
 		if contextDone(ctx) {
 			return
 		}
 
-		flDone := int(atomic.AddInt32(&filesDone, 1))
-
-		elapsed := time.Since(startTime)
-		total := time.Duration(int64(float64(elapsed) * float64(len(files)) / float64(flDone)))
-		remaining := total - elapsed
+		prog := progressOf(int(atomic.AddInt32(&filesDone, 1)))
+		prog.File = file
 
-		progressCh <- Progress{
-			File: file,
-			Done: float64(flDone) * 100.0 / float64(len(files)),
-			ETA:  time.Now().Add(remaining),
-		}
+		progressCh <- prog
 	}
 
-	for _, file := range filesToCheck {
-		grp.Add(1)
+	go func() {
+		defer close(resultsCh)
+		defer close(progressCh)
 
-		go func(file *fileToCheck) {
-			defer grp.Done()
+		if opts.HeartbeatInterval > 0 {
+			stopHeartbeat := startHeartbeat(ctx, opts.HeartbeatInterval, progressCh, func() Progress {
+				return progressOf(int(atomic.LoadInt32(&filesDone)))
+			})
+
+			// progressCh must not be closed before heartbeats have stopped
+			defer stopHeartbeat()
+		}
+
+		runTasks(tasks[tasksDone:], opts.parallelism(), func(t *task) {
+			res := taskResult{
+				taskIdx: t.idx,
+			}
 
-			semaphore <- struct{}{}
 			defer func() {
-				<-semaphore
+				resultsCh <- res
 			}()
 
 			if contextDone(ctx) {
 				return
 			}
 
-			defer advanceAndSendProgress(file.f)
+			if err := store.acquire(t.peers); err != nil {
+				progressCh <- Progress{
+					File: t.f,
+					Err:  err,
+				}
 
-			sims := fileSimilarities(ctx, file, opts)
-			for _, sim := range sims {
-				simsCh <- sim
+				return
 			}
-		}(file)
-	}
 
-	go func() {
-		defer close(simsCh)
-		defer close(progressCh)
-		grp.Wait()
+			defer store.release(t.peers)
+
+			taskStart := opts.Timings.start()
+
+			if opts.WindowLines > 0 {
+				res.sims = windowSimilarities(ctx, t.fileToCheck(), t.startLine, t.endLine, opts)
+			} else {
+				res.sims = rangeSimilarities(ctx, t.fileToCheck(), t.startLine, t.endLine, opts)
+			}
+			res.complete = !contextDone(ctx)
+
+			prepareStart := opts.Timings.start()
+
+			// lines of the files are only available while they are in use
+			for _, sim := range res.sims {
+				prepareSimilarity(sim, opts)
+			}
+
+			opts.Timings.record(PreparationPhase, prepareStart)
+			opts.Timings.recordFile(t.f, taskStart)
+
+			if atomic.AddInt32(tasksRemaining[t.f], -1) == 0 {
+				advanceAndSendProgress(t.f)
+			}
+		})
+
+		// results of searches after all tasks are emitted in this order
+		taskIdx := len(tasks)
+
+		searchStart := opts.Timings.start()
+		defer opts.Timings.record(BlockSearchPhase, searchStart)
+
+		if opts.flagSet(ReorderedBlocksFlag) && !contextDone(ctx) {
+			resultsCh <- reorderedResult(ctx, files, pairMatches, taskIdx, store, progressCh, opts)
+			taskIdx++
+		}
+
+		if opts.MinCosineSimilarity > 0 && !contextDone(ctx) {
+			resultsCh <- cosineResult(ctx, files, pairMatches, taskIdx, store, progressCh, opts)
+		}
 	}()
 
-	outCh := make(chan *Similarity)
+	outCh := make(chan *Similarity, max(opts.SimilarityBufferSize, 0))
 
 	go func() {
 		defer close(outCh)
 
 		// help GC
 		defer func() {
+			store.close()
+
 			for _, f := range files {
 				f.lines = nil
+				f.linesByHash = nil
+				f.lineClasses = nil
+				f.lineHashes = nil
+				f.linesFilter = nil
+				f.reorderHashes = nil
+				f.blockWords = nil
 			}
 		}()
 
-		distinctSims := []*Similarity{}
+		emitter := newSimilarityEmitter(opts)
+		checkpointer := newCheckpointer(opts, tasksDone)
+
+		emit := func(sim *Similarity) {
+			if !opts.acceptOccurrences(len(sim.Occurrences)) {
+				return
+			}
+
+			outCh <- unitsToLines(truncateOccurrences(sim, opts), opts)
+		}
+
+		if opts.ResumeFrom != nil {
+			emitter.resume(opts.ResumeFrom, emit)
+		}
+
+		for res := range resultsCh {
+			dedupStart := opts.Timings.start()
+			emitter.add(res, emit)
+			opts.Timings.record(DedupPhase, dedupStart)
+
+			checkpointer.update(emitter, false)
+		}
+
+		dedupStart := opts.Timings.start()
+		emitter.finish(emit)
+		opts.Timings.record(DedupPhase, dedupStart)
+
+		checkpointer.update(emitter, true)
+	}()
+
+	return outCh, outProgressCh, nil
+}
+
+// prepareSimilarity computes sim's ID, column ranges, and text, according to opts. The lines of all files
+// of sim's occurrences must be loaded.
+func prepareSimilarity(sim *Similarity, opts *Options) {
+	if !opts.acceptOccurrences(len(sim.Occurrences)) {
+		return
+	}
+
+	sim.id = similarityID(sim, opts)
+
+	if sim.Level == SimilarSimilarityLevel {
+		setColumnRanges(sim, opts)
+	}
+
+	setTier(sim, opts)
+	setLineLevels(sim, opts)
+
+	if opts.ComputeSimHashes {
+		for _, occ := range sim.Occurrences {
+			occ.setSimHash(opts)
+		}
+	}
+
+	if opts.ComputeFuzzyHashes {
+		for _, occ := range sim.Occurrences {
+			occ.setFuzzyHash(opts)
+		}
+	}
+
+	if opts.CaptureText {
+		// only capture the text of occurrences that will be reported
+		sortOccurrences(sim.Occurrences)
+		captureText(sim, opts)
+	}
+}
+
+// truncateOccurrences returns sim with its occurrences truncated according to opts.MaxOccurrencesPerSimilarity.
+// If any occurrences are omitted, a copy of sim is returned, and sim is left untouched.
+func truncateOccurrences(sim *Similarity, opts *Options) *Similarity {
+	keep := opts.keptOccurrences(len(sim.Occurrences))
+	if keep == len(sim.Occurrences) {
+		return sim
+	}
+
+	truncated := *sim
+	truncated.Occurrences = sim.Occurrences[:keep:keep]
+	truncated.OmittedOccurrences = len(sim.Occurrences) - keep
+
+	return &truncated
+}
+
+// resumeFrom validates cp against files and tasks, and prepares its similarities, according to opts.
+func resumeFrom(cp *Checkpoint, files []*File, tasks []*task, store *lineStore, opts *Options) error {
+	if err := validateCheckpoint(cp, files, tasks); err != nil {
+		return err
+	}
+
+	for _, sim := range cp.Similarities {
+		if err := prepareStoredSimilarity(sim, store, opts); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// prepareStoredSimilarity prepares sim according to opts, like prepareSimilarity, loading the lines of its
+// files from store if necessary.
+func prepareStoredSimilarity(sim *Similarity, store *lineStore, opts *Options) error {
+	simFiles := make([]*File, len(sim.Occurrences))
+	for idx, occ := range sim.Occurrences {
+		simFiles[idx] = occ.File
+	}
+
+	if err := store.acquire(simFiles); err != nil {
+		return err
+	}
 
-	channel:
-		for sim := range simsCh {
-			sortOccurrences(sim.Occurrences)
+	defer store.release(simFiles)
 
-			for _, dsim := range distinctSims {
-				if equalSimilarities(sim, dsim) {
-					continue channel
+	prepareSimilarity(sim, opts)
+
+	return nil
+}
+
+// A coveredPair is a pair of files whose lines are covered by similarities, as tracked by a similarityEmitter.
+type coveredPair struct {
+	// file is the file whose lines are covered.
+	file *File
+
+	// peer is the file of other occurrences of the similarities.
+	peer *File
+}
+
+// A taskResult holds the similarities found when running a single task.
+type taskResult struct {
+	// taskIdx is the index of the task.
+	taskIdx int
+
+	// sims are the similarities found.
+	sims []*Similarity
+
+	// complete indicates whether the task has been run to completion, without being canceled.
+	complete bool
+}
+
+// A similarityEmitter emits similarities found by tasks in the order of the tasks, regardless of the order
+// in which the tasks have been run. Since each pair of files is only checked from the file that comes first,
+// and files are split into ranges of lines that are checked independently, a later task may find similarities
+// in lines that are already covered by similarities found by an earlier task. The emitter handles those according
+// to Options.OverlapPolicy.
+type similarityEmitter struct {
+	// pending maps task indexes to results that cannot be emitted yet because earlier tasks are not done yet.
+	pending map[int]taskResult
+
+	// next is the index of the next task whose results can be emitted.
+	next int
+
+	// linesCovered maps files to bit vectors of their lines. A line's bit is set if the line is covered by
+	// any similarity emitted so far.
+	linesCovered map[*File]*bitVector
+
+	// pairLinesCovered maps pairs of files to bit vectors of the lines of the first file of the pair. A line's
+	// bit is set if the line is covered by any similarity emitted so far that also has an occurrence in the second
+	// file. It is only used for DropOverlapPolicy.
+	pairLinesCovered map[coveredPair]*bitVector
+
+	// found are all similarities that passed the emitter so far, in order. It is only kept if keepFound is set.
+	found []*Similarity
+
+	// keepFound indicates whether similarities are kept in found after they have been emitted, which is only
+	// needed for checkpoints. Otherwise, similarities can be garbage collected as soon as the caller is done
+	// with them.
+	keepFound bool
+
+	// tasksDone is the number of tasks, in order, that have been run to completion and emitted.
+	tasksDone int
+
+	// tasksDoneSims is the number of similarities in found that have been found by the first tasksDone tasks.
+	tasksDoneSims int
+
+	// incomplete indicates whether any task has been emitted that has not been run to completion.
+	incomplete bool
+
+	// opts are the options used to handle overlapping similarities.
+	opts *Options
+}
+
+// newSimilarityEmitter returns a new similarityEmitter that handles overlapping similarities according to opts.
+func newSimilarityEmitter(opts *Options) *similarityEmitter {
+	return &similarityEmitter{
+		pending:          map[int]taskResult{},
+		linesCovered:     map[*File]*bitVector{},
+		pairLinesCovered: map[coveredPair]*bitVector{},
+		keepFound:        opts.OnCheckpoint != nil,
+		opts:             opts,
+	}
+}
+
+// add adds res, and emits all results to emit that can be emitted in order.
+func (e *similarityEmitter) add(res taskResult, emit func(*Similarity)) {
+	e.pending[res.taskIdx] = res
+
+	for {
+		res, ok := e.pending[e.next]
+		if !ok {
+			return
+		}
+
+		delete(e.pending, e.next)
+		e.next++
+
+		e.emitResult(res, emit)
+
+		if !res.complete {
+			e.incomplete = true
+		}
+
+		if !e.incomplete {
+			e.tasksDone = e.next
+			e.tasksDoneSims = len(e.found)
+		}
+	}
+}
+
+// emitResult emits all similarities in res to emit, handling similarities that cover lines covered by
+// similarities emitted earlier according to Options.OverlapPolicy.
+func (e *similarityEmitter) emitResult(res taskResult, emit func(*Similarity)) {
+	for _, sim := range res.sims {
+		sortOccurrences(sim.Occurrences)
+
+		switch {
+		case e.opts.OverlapPolicy == KeepLargestOverlapPolicy:
+			// similarities are selected and emitted when all have been found
+			e.found = append(e.found, sim)
+
+		case e.opts.OverlapPolicy == KeepAllOverlapPolicy || !e.covered(sim):
+			e.emitSimilarity(sim, emit)
+
+		case e.opts.OverlapPolicy == SplitOverlapPolicy:
+			for _, part := range e.split(sim) {
+				e.emitSimilarity(part, emit)
+			}
+		}
+	}
+}
+
+// emitSimilarity marks the lines of sim as covered, and emits it to emit.
+func (e *similarityEmitter) emitSimilarity(sim *Similarity, emit func(*Similarity)) {
+	e.cover(sim)
+
+	if e.keepFound {
+		e.found = append(e.found, sim)
+	}
+
+	emit(sim)
+}
+
+// cover marks the lines of all occurrences of sim as covered. If Options.OverlapPolicy is DropOverlapPolicy,
+// they are also marked as covered for the pairs of files of sim's occurrences.
+func (e *similarityEmitter) cover(sim *Similarity) {
+	if e.opts.OverlapPolicy == DropOverlapPolicy {
+		e.coverPairs(sim)
+	}
+
+	for _, occ := range sim.Occurrences {
+		covered := e.fileLinesCovered(occ.File)
+
+		for l := occ.Start; l < occ.End; l++ {
+			covered.set(l, true)
+		}
+	}
+}
+
+// covered returns whether any line of any occurrence of sim is covered by similarities emitted earlier. If
+// Options.OverlapPolicy is DropOverlapPolicy, it instead returns whether sim overlaps similarities emitted earlier
+// between the same files, or whether all lines of sim are covered by similarities emitted earlier, so that only
+// similarities that do not provide any new evidence are dropped.
+func (e *similarityEmitter) covered(sim *Similarity) bool {
+	if e.opts.OverlapPolicy == DropOverlapPolicy {
+		return e.pairsCovered(sim) || e.fullyCovered(sim)
+	}
+
+	for _, occ := range sim.Occurrences {
+		covered := e.fileLinesCovered(occ.File)
+
+		for l := occ.Start; l < occ.End; l++ {
+			if covered.isSet(l) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// fullyCovered returns whether all lines of all occurrences of sim are covered by similarities emitted earlier.
+func (e *similarityEmitter) fullyCovered(sim *Similarity) bool {
+	for _, occ := range sim.Occurrences {
+		covered := e.fileLinesCovered(occ.File)
+
+		for l := occ.Start; l < occ.End; l++ {
+			if !covered.isSet(l) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// coverPairs marks the lines of each occurrence of sim as covered for the pairs of its file and the files of
+// sim's other occurrences.
+func (e *similarityEmitter) coverPairs(sim *Similarity) {
+	for occIdx, occ := range sim.Occurrences {
+		for peerIdx, peerOcc := range sim.Occurrences {
+			if peerIdx == occIdx {
+				continue
+			}
+
+			covered := e.filePairLinesCovered(occ.File, peerOcc.File)
+
+			for l := occ.Start; l < occ.End; l++ {
+				covered.set(l, true)
+			}
+		}
+	}
+}
+
+// pairsCovered returns whether any line of any occurrence of sim is covered by similarities emitted earlier that
+// also have an occurrence in the file of another occurrence of sim.
+func (e *similarityEmitter) pairsCovered(sim *Similarity) bool {
+	for occIdx, occ := range sim.Occurrences {
+		for peerIdx, peerOcc := range sim.Occurrences {
+			if peerIdx == occIdx {
+				continue
+			}
+
+			covered, ok := e.pairLinesCovered[coveredPair{file: occ.File, peer: peerOcc.File}]
+			if !ok {
+				continue
+			}
+
+			for l := occ.Start; l < occ.End; l++ {
+				if covered.isSet(l) {
+					return true
 				}
 			}
+		}
+	}
+
+	return false
+}
+
+// filePairLinesCovered returns the bit vector of lines of file covered by similarities that also have an occurrence
+// in peer.
+func (e *similarityEmitter) filePairLinesCovered(file *File, peer *File) *bitVector {
+	key := coveredPair{file: file, peer: peer}
+
+	covered, ok := e.pairLinesCovered[key]
+	if !ok {
+		covered = newBitVector(file.lineCount)
+		e.pairLinesCovered[key] = covered
+	}
+
+	return covered
+}
+
+// fileLinesCovered returns the bit vector of covered lines of file.
+func (e *similarityEmitter) fileLinesCovered(file *File) *bitVector {
+	covered, ok := e.linesCovered[file]
+	if !ok {
+		covered = newBitVector(file.lineCount)
+		e.linesCovered[file] = covered
+	}
+
+	return covered
+}
+
+// captureText sets the text of all occurrences of sim, according to opts.
+func captureText(sim *Similarity, opts *Options) {
+	for _, occ := range sim.Occurrences[:opts.keptOccurrences(len(sim.Occurrences))] {
+		end := occ.End
+		if opts.CaptureTextLines > 0 && end-occ.Start > opts.CaptureTextLines {
+			end = occ.Start + opts.CaptureTextLines
+		}
 
-			distinctSims = append(distinctSims, sim)
+		text := strings.Builder{}
 
-			outCh <- sim
+		for l := occ.Start; l < end; l++ {
+			text.WriteString(occ.File.lines[l].originalText)
+			text.WriteString("\n")
+		}
+
+		occ.Text = text.String()
+	}
+}
+
+// acceptOccurrences returns whether a similarity with n occurrences should be reported, according to
+// o.MinOccurrences and o.MaxOccurrences.
+func (o Options) acceptOccurrences(n int) bool {
+	if o.MinOccurrences > 0 && n < o.MinOccurrences {
+		return false
+	}
+
+	if o.MaxOccurrences > 0 && n > o.MaxOccurrences {
+		return false
+	}
+
+	return true
+}
+
+// enoughChars returns whether the lines of o that are considered for similarities have at least
+// opts.MinSimilarChars characters.
+func (o *FileOccurrence) enoughChars(opts *Options) bool {
+	if opts.MinSimilarChars <= 0 {
+		return true
+	}
+
+	chars := 0
+
+	for l := o.Start; l < o.End; l++ {
+		line := o.File.lines[l]
+		if !acceptLine(line, opts) {
+			continue
 		}
-	}()
 
-	return outCh, progressCh, nil
+		chars += line.comparedLength(opts)
+		if chars >= opts.MinSimilarChars {
+			return true
+		}
+	}
+
+	return false
+}
+
+// keptOccurrences returns how many of n occurrences of a similarity are reported, according to
+// o.MaxOccurrencesPerSimilarity.
+func (o Options) keptOccurrences(n int) int {
+	if o.MaxOccurrencesPerSimilarity > 0 && n > o.MaxOccurrencesPerSimilarity {
+		return o.MaxOccurrencesPerSimilarity
+	}
+
+	return n
 }
 
 // fileSimilarities returns all similarities between file and its peers, according to opts.
-func fileSimilarities(ctx context.Context, file *fileToCheck, opts *Options) []*Similarity { //nolint:gocognit,cyclop // it's complicated
+func fileSimilarities(ctx context.Context, file *fileToCheck, opts *Options) []*Similarity {
+	return rangeSimilarities(ctx, file, 0, len(file.f.lines), opts)
+}
+
+// rangeSimilarities returns all similarities between file and its peers that start in file between startLine
+// and endLine (exclusive), according to opts.
+func rangeSimilarities(ctx context.Context, file *fileToCheck, startLine int, endLine int, opts *Options) []*Similarity { //nolint:gocognit,cyclop // it's complicated
 	sims := []*Similarity{}
+	buf := occurrenceBuffer{}
 
-	for fileLineIdx := 0; ; fileLineIdx++ {
+	for fileLineIdx := startLine; ; fileLineIdx++ {
 		if contextDone(ctx) {
 			return sims
 		}
 
-		if fileLineIdx >= len(file.f.lines) {
+		if fileLineIdx >= endLine {
 			break
 		}
 
@@ -325,7 +1351,10 @@ func fileSimilarities(ctx context.Context, file *fileToCheck, opts *Options) []*
 			continue
 		}
 
-		occurrences := []*FileOccurrence{}
+		// recycle occurrences of the previous line
+		buf.reset()
+		buf.add(file, fileLineIdx)
+
 		level := EqualSimilarityLevel
 
 		for _, peerFile := range file.peers {
@@ -338,35 +1367,25 @@ func fileSimilarities(ctx context.Context, file *fileToCheck, opts *Options) []*
 				startLine = fileLineIdx + 1
 			}
 
-			peerFileOccurrences, peerFileLevel := lineOccurrences(ctx, peerFile, line, startLine, opts)
-			if len(peerFileOccurrences) == 0 {
-				continue
-			}
-
-			occurrences = append(occurrences, peerFileOccurrences...)
-
+			peerFileLevel := lineOccurrences(ctx, peerFile, line, startLine, &buf, opts)
 			if peerFileLevel < level {
 				level = peerFileLevel
 			}
 		}
 
-		if len(occurrences) == 0 {
+		if len(buf.occs) == 1 {
 			continue
 		}
 
-		occurrences = append([]*FileOccurrence{
-			{
-				File:  file.f,
-				Start: fileLineIdx,
-				End:   fileLineIdx + 1,
-
-				fileToCheck: file,
-			},
-		}, occurrences...)
+		occurrences := buf.occs
 
+		expandStart := opts.Timings.start()
 		level = expandOccurrences(ctx, occurrences, level, opts)
+		level = alignOccurrences(ctx, occurrences, level, opts)
+		level = expandOccurrencesUp(ctx, occurrences, level, opts)
+		opts.Timings.record(ExpansionPhase, expandStart)
 
-		if occurrences[0].End-occurrences[0].Start < opts.MinSimilarLines {
+		if !occurrences[0].enoughLines(opts) || !occurrences[0].enoughChars(opts) {
 			// reset lines done
 			for _, occ := range occurrences {
 				for l := occ.Start; l < occ.End; l++ {
@@ -377,6 +1396,8 @@ func fileSimilarities(ctx context.Context, file *fileToCheck, opts *Options) []*
 			continue
 		}
 
+		occurrences = buf.take()
+
 		sims = append(sims, &Similarity{
 			Occurrences: occurrences,
 			Level:       level,
@@ -403,6 +1424,61 @@ func fileSimilarities(ctx context.Context, file *fileToCheck, opts *Options) []*
 	return sims
 }
 
+// ID returns a stable identifier of s. The identifier is computed from the file names and texts of s's occurrences,
+// but not from their line numbers, so it does not change when text is moved within files. It is only available
+// for similarities returned by Similarities, or set using SetID, and is empty otherwise.
+func (s *Similarity) ID() string {
+	return s.id
+}
+
+// SetID sets the identifier returned by ID to id. It is meant for similarities that are restored after having been
+// stored along with their identifiers, such as in a cache of results, so that they keep their identifiers.
+func (s *Similarity) SetID(id string) {
+	s.id = id
+}
+
+// similarityID returns a stable identifier of sim, computed from the file names and texts of its occurrences,
+// according to opts. Lines that are not considered for similarities are skipped. It also sets the block keys
+// of sim's occurrences.
+func similarityID(sim *Similarity, opts *Options) string {
+	occKeys := make([]string, len(sim.Occurrences))
+
+	for idx, occ := range sim.Occurrences {
+		hash := sha256.New()
+		occ.block = blockKey{}
+
+		for l := occ.Start; l < occ.End; l++ {
+			line := occ.File.lines[l]
+			if !acceptLine(line, opts) {
+				continue
+			}
+
+			occ.block = occ.block.add(line.hash)
+
+			text := line.text
+			if opts.flagSet(IgnoreWhitespaceFlag) {
+				text = line.textTrimmed
+			}
+
+			_, _ = hash.Write([]byte(text))
+			_, _ = hash.Write([]byte{'\n'})
+		}
+
+		occKeys[idx] = occ.File.Name + "\x00" + hex.EncodeToString(hash.Sum(nil))
+	}
+
+	sort.Strings(occKeys)
+
+	hash := sha256.New()
+	_, _ = fmt.Fprintf(hash, "%d\n", sim.Level)
+
+	for _, key := range occKeys {
+		_, _ = hash.Write([]byte(key + "\n"))
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
 // markOccurrencesLinesDone marks all lines as done that are referred to by occs.
 func markOccurrencesLinesDone(occs []*FileOccurrence) {
 	for _, occ := range occs {
@@ -412,36 +1488,72 @@ func markOccurrencesLinesDone(occs []*FileOccurrence) {
 	}
 }
 
-// lineOccurrences returns all occurrences of line in file, beginning with startLine, according to opts.
-// It also returns the similarity level of those occurrences.
-func lineOccurrences(ctx context.Context, file *fileToCheck, line *fileLine, startLine int, opts *Options) ([]*FileOccurrence, SimilarityLevel) {
-	occurrences := []*FileOccurrence{}
+// lineOccurrences adds all occurrences of line in file, beginning with startLine, to buf, according to opts.
+// It returns the similarity level of those occurrences. If file has an index of equality classes, line is only
+// compared with each class once (see classOccurrences), otherwise with each line.
+func lineOccurrences(ctx context.Context, file *fileToCheck, line *fileLine, startLine int, buf *occurrenceBuffer, opts *Options) SimilarityLevel {
+	if file.f.lineClasses != nil && !opts.flagSet(ExactSeedingFlag) {
+		return classOccurrences(ctx, file, line, startLine, buf, opts)
+	}
+
 	level := EqualSimilarityLevel
 
 	for {
 		if contextDone(ctx) {
-			return occurrences, level
+			return level
 		}
 
 		fileLineIdx, fileLevel := lineIndex(ctx, file, line, startLine, opts)
 		if fileLineIdx < 0 {
-			return occurrences, level
+			return level
+		}
+
+		buf.add(file, fileLineIdx)
+
+		if fileLevel < level {
+			level = fileLevel
 		}
 
-		occurrences = append(occurrences, &FileOccurrence{
-			File:  file.f,
-			Start: fileLineIdx,
-			End:   fileLineIdx + 1,
+		startLine = fileLineIdx + 1
+	}
+}
+
+// add adds a new occurrence of a single line in file to b.
+func (b *occurrenceBuffer) add(file *fileToCheck, line int) {
+	var occ *FileOccurrence
+
+	if len(b.free) > 0 {
+		occ = b.free[len(b.free)-1]
+		b.free = b.free[:len(b.free)-1]
+	} else {
+		occ = &FileOccurrence{}
+	}
+
+	*occ = FileOccurrence{
+		File:  file.f,
+		Start: line,
+		End:   line + 1,
+
+		fileToCheck: file,
+	}
+
+	b.occs = append(b.occs, occ)
+}
 
-			fileToCheck: file,
-		})
+// reset removes all occurrences from b, and recycles them.
+func (b *occurrenceBuffer) reset() {
+	b.free = append(b.free, b.occs...)
+	b.occs = b.occs[:0]
+}
 
-		if fileLevel < level {
-			level = fileLevel
-		}
+// take removes all occurrences from b without recycling them, and returns them in a new slice.
+func (b *occurrenceBuffer) take() []*FileOccurrence {
+	occs := make([]*FileOccurrence, len(b.occs))
+	copy(occs, b.occs)
 
-		startLine = fileLineIdx + 1
-	}
+	b.occs = b.occs[:0]
+
+	return occs
 }
 
 // expandOccurrences expands occurrences in occs, that is, it will try to capture as much text as possible
@@ -486,6 +1598,10 @@ func expandOccurrences(ctx context.Context, occs []*FileOccurrence, level Simila
 					return level
 				}
 
+				if !occ.fileToCheck.f.sameScope(occ.Start, ends[idx]-1) {
+					return level
+				}
+
 				line := occ.fileToCheck.f.lines[ends[idx]-1]
 				if acceptLine(line, opts) {
 					break
@@ -529,6 +1645,85 @@ func expandOccurrences(ctx context.Context, occs []*FileOccurrence, level Simila
 	}
 }
 
+// expandOccurrencesUp expands occurrences in occs upward, like expandOccurrences expands them downward, so that
+// occurrences whose first line is in the middle of a block of similar lines cover the whole block. Each occurrence's
+// Start will be modified accordingly. Occurrences never grow into other occurrences in the same file.
+func expandOccurrencesUp(ctx context.Context, occs []*FileOccurrence, level SimilarityLevel, opts *Options) SimilarityLevel {
+	starts := make([]int, len(occs))
+
+	for {
+		if contextDone(ctx) {
+			return level
+		}
+
+		for idx, occ := range occs {
+			starts[idx] = occ.Start
+
+			for {
+				starts[idx]--
+
+				if starts[idx] < 0 {
+					return level
+				}
+
+				if occ.fileToCheck.linesDone.isSet(starts[idx]) {
+					return level
+				}
+
+				if !occ.fileToCheck.f.sameScope(occ.Start, starts[idx]) {
+					return level
+				}
+
+				if lineInOccurrences(occ.fileToCheck.f, starts[idx], occs) {
+					return level
+				}
+
+				line := occ.fileToCheck.f.lines[starts[idx]]
+				if acceptLine(line, opts) {
+					break
+				}
+			}
+		}
+
+		// check if files are still similar
+		line1 := occs[0].fileToCheck.f.lines[starts[0]]
+		newLevel := level
+
+		for idx2, occ2 := range occs[1:] {
+			line2 := occ2.fileToCheck.f.lines[starts[idx2+1]]
+
+			lineLevel := linesSimilarity(line1, line2, opts)
+			if lineLevel == differentSimilarityLevel {
+				return level
+			}
+
+			newLevel = min(newLevel, lineLevel)
+		}
+
+		// commit new starts
+		for idx, occ := range occs {
+			for l := starts[idx]; l < occ.Start; l++ {
+				occ.fileToCheck.linesDone.set(l, true)
+			}
+
+			occ.Start = starts[idx]
+		}
+
+		level = newLevel
+	}
+}
+
+// lineInOccurrences returns whether the line with lineIdx of file is covered by any occurrence in occs.
+func lineInOccurrences(file *File, lineIdx int, occs []*FileOccurrence) bool {
+	for _, occ := range occs {
+		if occ.fileToCheck.f == file && lineIdx >= occ.Start && lineIdx < occ.End {
+			return true
+		}
+	}
+
+	return false
+}
+
 // acceptLine returns whether line should be considered for similarities at all, according to opts.
 func acceptLine(line *fileLine, opts *Options) bool {
 	if opts.flagSet(IgnoreBlankLinesFlag) && line.flagSet(blankLineFlag) {
@@ -547,106 +1742,129 @@ func acceptLine(line *fileLine, opts *Options) bool {
 }
 
 // lineIndex returns the line index and similarity level of needle in file, starting with startLine, according to opts.
-// If no match can be found, -1 is returned for the line index.
-func lineIndex(ctx context.Context, file *fileToCheck, needle *fileLine, startLine int, opts *Options) (int, SimilarityLevel) { //nolint:gocognit,cyclop // concurrent setup is complex
-	linesToCheck := len(file.f.lines) - startLine
+// If no match can be found, -1 is returned for the line index. The first exactly equal line is looked up using
+// file's index of line hashes, so that only the lines before it need to be scanned for similar lines.
+func lineIndex(ctx context.Context, file *fileToCheck, needle *fileLine, startLine int, opts *Options) (int, SimilarityLevel) {
+	start := opts.Timings.start()
+	equalLine := equalLineIndex(file, needle, startLine, opts)
+	start = opts.Timings.record(ExactSeedingPhase, start)
+
+	if opts.flagSet(ExactSeedingFlag) {
+		if equalLine < 0 {
+			return -1, differentSimilarityLevel
+		}
 
-	if linesToCheck <= 0 {
-		return -1, differentSimilarityLevel
+		return equalLine, EqualSimilarityLevel
 	}
 
-	const chunkSize = 10
-
-	chunks := linesToCheck / chunkSize
-	if chunks*chunkSize < linesToCheck {
-		chunks++
+	endLine := len(file.f.lines)
+	if equalLine >= 0 {
+		endLine = equalLine
 	}
 
-	if chunks == 1 {
-		return lineIndexEnd(ctx, file, needle, startLine, len(file.f.lines), opts)
-	}
+	line, level := scanLineIndex(ctx, file, needle, startLine, endLine, opts)
+	opts.Timings.record(FuzzyMatchingPhase, start)
 
-	startLines := make([]int, chunks)
-	for i := range startLines {
-		startLines[i] = chunkSize*i + startLine
+	if line >= 0 {
+		return line, level
 	}
 
-	endLines := make([]int, chunks)
-	for i := range endLines {
-		endLines[i] = chunkSize*(i+1) + startLine
+	if equalLine >= 0 {
+		return equalLine, EqualSimilarityLevel
 	}
 
-	if endLines[len(endLines)-1] > len(file.f.lines) {
-		endLines[len(endLines)-1] = len(file.f.lines)
+	return -1, differentSimilarityLevel
+}
+
+// equalLineIndex returns the index of the first line in file that is exactly equal to needle, starting with
+// startLine, according to opts. Lines that are done are skipped. If no such line can be found, or if file has
+// no index of line hashes, -1 is returned.
+func equalLineIndex(file *fileToCheck, needle *fileLine, startLine int, opts *Options) int {
+	lines := file.f.linesByHash[needle.hash]
+
+	for idx := sort.SearchInts(lines, startLine); idx < len(lines); idx++ {
+		lineIdx := lines[idx]
+
+		if file.linesDone.isSet(lineIdx) {
+			continue
+		}
+
+		if linesSimilarity(file.f.lines[lineIdx], needle, opts) == EqualSimilarityLevel {
+			return lineIdx
+		}
 	}
 
-	contexts := make([]context.Context, chunks)
-	cancels := make([]context.CancelFunc, chunks)
+	return -1
+}
+
+// scanLineIndex returns the line index and similarity level of needle in file, starting with startLine,
+// ending with endLine (excluding), according to opts. Lines are scanned concurrently in chunks.
+// If no match can be found, -1 is returned for the line index.
+func scanLineIndex(ctx context.Context, file *fileToCheck, needle *fileLine, startLine int, endLine int, opts *Options) (int, SimilarityLevel) {
+	linesToCheck := endLine - startLine
 
-	for i := range contexts {
-		contexts[i], cancels[i] = context.WithCancel(ctx)
+	if linesToCheck <= 0 {
+		return -1, differentSimilarityLevel
 	}
 
-	defer func() {
-		for _, cancel := range cancels {
-			cancel()
-		}
-	}()
+	const chunkSize = 10
+
+	chunks := linesToCheck / chunkSize
+	if chunks*chunkSize < linesToCheck {
+		chunks++
+	}
 
-	type result struct {
-		line  int
-		level SimilarityLevel
+	if chunks == 1 {
+		return lineIndexEnd(ctx, file, needle, startLine, endLine, nil, opts)
 	}
 
-	resultCh := make(chan result)
+	// found is the smallest line index found so far, chunks beyond it stop scanning - the chunk containing the
+	// first matching line never stops early, so the result does not depend on the order chunks are run in
+	found := atomic.Int64{}
+	found.Store(math.MaxInt64)
+
+	levels := make([]SimilarityLevel, chunks)
 
 	grp := sync.WaitGroup{}
 	grp.Add(chunks)
 
 	for chunkIdx := 0; chunkIdx < chunks; chunkIdx++ {
-		go func(ctx context.Context, startLine int, endLine int) {
+		go func(chunkIdx int) {
 			defer grp.Done()
 
-			line, level := lineIndexEnd(ctx, file, needle, startLine, endLine, opts)
-			resultCh <- result{line, level}
-		}(contexts[chunkIdx], startLines[chunkIdx], endLines[chunkIdx])
-	}
+			chunkStartLine := chunkSize*chunkIdx + startLine
+			chunkEndLine := min(chunkStartLine+chunkSize, endLine)
 
-	go func() {
-		defer close(resultCh)
+			line, level := lineIndexEnd(ctx, file, needle, chunkStartLine, chunkEndLine, &found, opts)
+			if line < 0 {
+				return
+			}
 
-		grp.Wait()
-	}()
+			levels[chunkIdx] = level
 
-	smallestResult := result{
-		line:  -1,
-		level: differentSimilarityLevel,
+			for {
+				smallest := found.Load()
+				if smallest <= int64(line) || found.CompareAndSwap(smallest, int64(line)) {
+					return
+				}
+			}
+		}(chunkIdx)
 	}
 
-	for res := range resultCh {
-		if res.line < 0 {
-			continue
-		}
-
-		if smallestResult.line >= 0 && smallestResult.line <= res.line {
-			continue
-		}
-
-		smallestResult = res
+	grp.Wait()
 
-		for i, startLine := range startLines {
-			if startLine > res.line {
-				cancels[i]()
-			}
-		}
+	line := found.Load()
+	if line == math.MaxInt64 {
+		return -1, differentSimilarityLevel
 	}
 
-	return smallestResult.line, smallestResult.level
+	return int(line), levels[(int(line)-startLine)/chunkSize]
 }
 
 // lineIndexEnd returns the line index and similarity level of needle in file, starting with startLine,
-// ending with endLine (excluding), according to opts. If no match can be found, -1 is returned for the line index.
-func lineIndexEnd(ctx context.Context, file *fileToCheck, needle *fileLine, startLine int, endLine int, opts *Options) (int, SimilarityLevel) {
+// ending with endLine (excluding), according to opts. If found is not nil, scanning stops as soon as
+// found refers to a line before startLine. If no match can be found, -1 is returned for the line index.
+func lineIndexEnd(ctx context.Context, file *fileToCheck, needle *fileLine, startLine int, endLine int, found *atomic.Int64, opts *Options) (int, SimilarityLevel) {
 	for lineIdx := startLine; ; lineIdx++ {
 		if contextDone(ctx) {
 			return -1, differentSimilarityLevel
@@ -656,6 +1874,10 @@ func lineIndexEnd(ctx context.Context, file *fileToCheck, needle *fileLine, star
 			return -1, differentSimilarityLevel
 		}
 
+		if found != nil && found.Load() < int64(startLine) {
+			return -1, differentSimilarityLevel
+		}
+
 		if file.linesDone.isSet(lineIdx) {
 			continue
 		}
@@ -671,6 +1893,34 @@ func lineIndexEnd(ctx context.Context, file *fileToCheck, needle *fileLine, star
 
 // linesSimilarity returns the similarity level between fileLine1 and fileLine2, according to opts.
 func linesSimilarity(fileLine1 *fileLine, fileLine2 *fileLine, opts *Options) SimilarityLevel {
+	if level, ok := quickLinesSimilarity(fileLine1, fileLine2, opts); ok {
+		return level
+	}
+
+	return distanceLinesSimilarity(fileLine1, fileLine2, opts)
+}
+
+// distanceLinesSimilarity returns the similarity level between fileLine1 and fileLine2, according to opts, by
+// calculating the Levenshtein distance between their texts. It must only be called for lines that
+// quickLinesSimilarity could not decide.
+func distanceLinesSimilarity(fileLine1 *fileLine, fileLine2 *fileLine, opts *Options) SimilarityLevel {
+	return cachedLinesSimilarity(fileLine1, fileLine2, opts, func() SimilarityLevel {
+		if !levenshteinDistanceAtMost(fileLine1, fileLine2, maxEditDistance(opts), opts) {
+			return differentSimilarityLevel
+		}
+
+		return SimilarSimilarityLevel
+	})
+}
+
+// quickLinesSimilarity returns the similarity level between fileLine1 and fileLine2, according to opts, and
+// whether it could be determined without calculating the Levenshtein distance between their texts.
+func quickLinesSimilarity(fileLine1 *fileLine, fileLine2 *fileLine, opts *Options) (SimilarityLevel, bool) {
+	// lines are interned and grouped into equality classes, so equal lines are usually of the same class
+	if fileLine1 == fileLine2 || fileLine1.class != nil && fileLine1.class == fileLine2.class {
+		return EqualSimilarityLevel, true
+	}
+
 	line1 := fileLine1.text
 	line2 := fileLine2.text
 
@@ -680,23 +1930,92 @@ func linesSimilarity(fileLine1 *fileLine, fileLine2 *fileLine, opts *Options) Si
 	}
 
 	if line1 == line2 {
-		return EqualSimilarityLevel
+		return EqualSimilarityLevel, true
+	}
+
+	if opts.LineMetric == JaroWinklerLineMetric {
+		return cachedLinesSimilarity(fileLine1, fileLine2, opts, func() SimilarityLevel {
+			return jaroWinklerLinesSimilarity(fileLine1, fileLine2, opts)
+		}), true
+	}
+
+	maxDist := maxEditDistance(opts)
+
+	if opts.flagSet(WordDistanceFlag) {
+		return cachedLinesSimilarity(fileLine1, fileLine2, opts, func() SimilarityLevel {
+			return wordsSimilarity(fileLine1, fileLine2, maxDist)
+		}), true
+	}
+
+	// the distance is at least the difference in length
+	length1 := fileLine1.length
+	length2 := fileLine2.length
+
+	if opts.flagSet(IgnoreWhitespaceFlag) {
+		length1 = fileLine1.lengthTrimmed
+		length2 = fileLine2.lengthTrimmed
+	}
+
+	if abs(length1-length2) > maxDist {
+		return differentSimilarityLevel, true
+	}
+
+	// the distance is at least the number of characters that only appear in one of the lines
+	chars1 := fileLine1.chars
+	chars2 := fileLine2.chars
+
+	if opts.flagSet(IgnoreWhitespaceFlag) {
+		chars1 = fileLine1.charsTrimmed
+		chars2 = fileLine2.charsTrimmed
+	}
+
+	if chars1.minDistance(chars2) > maxDist {
+		return differentSimilarityLevel, true
+	}
+
+	return differentSimilarityLevel, false
+}
+
+// maxEditDistance returns the maximum Levenshtein distance between similar lines, according to opts.
+func maxEditDistance(opts *Options) int {
+	if opts.MaxEditDistance <= 0 {
+		return DefaultMaxEditDistance
+	}
+
+	return opts.MaxEditDistance
+}
+
+// cachedLinesSimilarity returns the similarity level between fileLine1 and fileLine2, whose texts are known to
+// differ, as computed by level. If opts.lineCache is set, the level is looked up there first, and only computed
+// and cached if it is not found.
+func cachedLinesSimilarity(fileLine1 *fileLine, fileLine2 *fileLine, opts *Options, level func() SimilarityLevel) SimilarityLevel {
+	if opts.lineCache == nil {
+		return level()
 	}
 
-	maxDist := opts.MaxEditDistance
-	if maxDist <= 0 {
-		maxDist = DefaultMaxEditDistance
+	key := newLinePairKey(fileLine1.hash, fileLine2.hash)
+
+	if l, ok := opts.lineCache.get(key); ok {
+		return l
 	}
 
-	if levenshteinDistance(fileLine1, fileLine2, opts) > maxDist {
-		return differentSimilarityLevel
+	l := level()
+	opts.lineCache.put(key, l)
+
+	return l
+}
+
+// abs returns the absolute value of x.
+func abs(x int) int {
+	if x < 0 {
+		return -x
 	}
 
-	return SimilarSimilarityLevel
+	return x
 }
 
-// levenshteinDistance returns the Levenshtein distance between line1 and line2.
-func levenshteinDistance(fileLine1 *fileLine, fileLine2 *fileLine, opts *Options) int {
+// levenshteinDistanceAtMost returns whether the Levenshtein distance between line1 and line2 is at most maxDist.
+func levenshteinDistanceAtMost(fileLine1 *fileLine, fileLine2 *fileLine, maxDist int, opts *Options) bool {
 	slow := fileLine1.flagSet(slowLevenshteinLineFlag) || fileLine2.flagSet(slowLevenshteinLineFlag)
 
 	if slow {
@@ -708,68 +2027,220 @@ func levenshteinDistance(fileLine1 *fileLine, fileLine2 *fileLine, opts *Options
 			line2 = fileLine2.textTrimmed
 		}
 
-		return slowlevenshtein.Distance(line1, line2, nil)
+		return slowlevenshtein.Distance(line1, line2, nil) <= maxDist
+	}
+
+	if fileLine1.flagSet(asciiLineFlag) && fileLine2.flagSet(asciiLineFlag) {
+		line1 := fileLine1.textBytes
+		line2 := fileLine2.textBytes
+
+		if opts.flagSet(IgnoreWhitespaceFlag) {
+			line1 = fileLine1.textTrimmedBytes
+			line2 = fileLine2.textTrimmedBytes
+		}
+
+		_, ok := levenshtein.DistanceBytesAtMost(line1, line2, maxDist)
+
+		return ok
 	}
 
-	line1 := fileLine1.textRunes
-	line2 := fileLine2.textRunes
+	runes1 := runesPool.Get().(*[]rune) //nolint:forcetypeassert // we know what's in the pool
+	defer runesPool.Put(runes1)
+
+	runes2 := runesPool.Get().(*[]rune) //nolint:forcetypeassert // we know what's in the pool
+	defer runesPool.Put(runes2)
+
+	_, ok := levenshtein.DistanceAtMost(fileLine1.runes(runes1, opts), fileLine2.runes(runes2, opts), maxDist)
+
+	return ok
+}
+
+// runes returns the text of l as runes, according to opts. If l only contains ASCII characters,
+// the text is converted into buf, which is then returned.
+func (l *fileLine) runes(buf *[]rune, opts *Options) []rune {
+	if l.flagSet(asciiLineFlag) {
+		text := l.text
+		if opts.flagSet(IgnoreWhitespaceFlag) {
+			text = l.textTrimmed
+		}
+
+		*buf = (*buf)[:0]
+		for i := 0; i < len(text); i++ {
+			*buf = append(*buf, rune(text[i]))
+		}
+
+		return *buf
+	}
 
 	if opts.flagSet(IgnoreWhitespaceFlag) {
-		line1 = fileLine1.textTrimmedRunes
-		line2 = fileLine2.textTrimmedRunes
+		return l.textTrimmedRunes
+	}
+
+	return l.textRunes
+}
+
+// load loads all lines from f, and sets up f accordingly, such as setting flags and scopes. Lines are interned
+// using lines, if it is not nil.
+func (f *File) load(lines *lineTable, opts *Options) error {
+	if err := f.loadLines(lines, opts); err != nil {
+		return err
 	}
 
-	return levenshtein.Distance(line1, line2)
+	f.setupScopes(opts)
+
+	return nil
 }
 
-// load loads all lines from f, and sets up f accordingly, such as setting flags.
-func (f *File) load(opts *Options) error {
+// loadLines loads all lines from f's LoadedFile, Lines, or R, interning them using lines, if it is not nil.
+// If units are not lines, the units of Lines or R are loaded as lines instead.
+func (f *File) loadLines(lines *lineTable, opts *Options) error {
 	f.lines = map[int]*fileLine{}
+	f.linesByHash = map[uint64][]int{}
+	f.lineClasses = map[*fileLine][]int{}
+
+	if !opts.lineUnits() {
+		return f.loadUnits(lines, opts)
+	}
+
+	if f.loaded != nil {
+		for lineIdx, line := range f.loaded.processedLines(opts) {
+			f.setLine(lineIdx, line, opts)
+		}
 
-	reader := bufio.NewReader(f.R)
-	buf := bytes.Buffer{}
+		f.lineCount = len(f.Lines)
 
-	for lineIdx := 0; ; lineIdx++ {
-		text, err := tsio.ReadLine(reader, &buf)
-		if err != nil {
-			if errors.Is(err, io.EOF) {
-				return nil
-			}
+		return nil
+	}
 
-			return fmt.Errorf("read line: %w", err)
+	if f.Lines != nil {
+		for lineIdx, text := range f.Lines {
+			f.setLine(lineIdx, lines.line(text, opts), opts)
 		}
 
-		line := textToFileLine(text, opts)
-		f.lines[lineIdx] = line
+		f.lineCount = len(f.Lines)
+
+		return nil
+	}
+
+	err := LineSegmenter{}.Segment(f.R, func(unit Unit) error {
+		f.setLine(unit.StartLine, lines.line(unit.Text, opts), opts)
+		f.lineCount = unit.EndLine
+
+		return nil
+	})
+	if err != nil {
+		return &LoadError{File: f, Line: f.lineCount + 1, Err: err}
+	}
+
+	return nil
+}
+
+// loadUnits loads all units of the text of f's Lines or R as lines, according to opts, interning them using
+// lines, if it is not nil.
+func (f *File) loadUnits(lines *lineTable, opts *Options) error {
+	f.unitLines = []unitLines{}
+
+	err := opts.segmenter().Segment(f.segmentedText(), func(unit Unit) error {
+		f.setLine(len(f.unitLines), lines.line(unit.Text, opts), opts)
+		f.unitLines = append(f.unitLines, unitLines{start: unit.StartLine, end: unit.EndLine})
+		f.lineCount = len(f.unitLines)
+		f.textLineCount = unit.EndLine
+
+		return nil
+	})
+	if err != nil {
+		return &LoadError{File: f, Line: f.textLineCount + 1, Err: err}
+	}
+
+	return nil
+}
+
+// setLine sets the line at lineIdx (zero-based) in f to line, adds it to f's index of equality classes, and to
+// f's index of line hashes if it is considered for similarities, according to opts. Lines must be set in order.
+func (f *File) setLine(lineIdx int, line *fileLine, opts *Options) {
+	f.lines[lineIdx] = line
+	f.lineClasses[line.class] = append(f.lineClasses[line.class], lineIdx)
+
+	if acceptLine(line, opts) {
+		f.linesByHash[line.hash] = append(f.linesByHash[line.hash], lineIdx)
+	}
+}
+
+// LineCount returns the number of lines in f. It is only valid after f has been passed to Similarities. If units
+// are not lines, it is the number of lines up to the end of the last unit.
+func (f *File) LineCount() int {
+	if f.unitLines != nil {
+		return f.textLineCount
 	}
+
+	return f.lineCount
+}
+
+// UnitCount returns the number of units in f, which equals LineCount unless Options.Segmenter splits text into
+// units other than lines. It is only valid after f has been passed to Similarities.
+func (f *File) UnitCount() int {
+	return f.lineCount
 }
 
 func textToFileLine(text string, opts *Options) *fileLine {
 	line := fileLine{
-		text:        text,
-		textTrimmed: strings.TrimSpace(text),
-		textRunes:   []rune(text),
+		originalText: text,
+		text:         transformLine(text, opts),
 	}
 
-	line.length = len(line.textRunes)
+	line.textTrimmed = strings.TrimSpace(line.text)
 
-	if line.text != line.textTrimmed {
-		line.textTrimmedRunes = []rune(line.textTrimmed)
-		line.lengthTrimmed = len(line.textTrimmedRunes)
-	} else {
+	if line.text == line.textTrimmed {
 		line.textTrimmed = line.text
-		line.textTrimmedRunes = line.textRunes
-		line.lengthTrimmed = line.length
 	}
 
-	if needsSlowLevenshtein(line.text) {
-		line.flags |= slowLevenshteinLineFlag
+	if opts.flagSet(IgnoreWhitespaceFlag) {
+		line.hash = hashString(line.textTrimmed)
+	} else {
+		line.hash = hashString(line.text)
+	}
+
+	line.class = &line
+
+	line.chars = newCharSet(line.text)
+	line.charsTrimmed = newCharSet(line.textTrimmed)
+
+	if isASCII(line.text) {
+		line.flags |= asciiLineFlag
+
+		line.textBytes = []byte(line.text)
+		line.textTrimmedBytes = bytes.TrimSpace(line.textBytes)
+		line.length = len(line.textBytes)
+		line.lengthTrimmed = len(line.textTrimmedBytes)
+	} else {
+		line.textRunes = []rune(line.text)
+		line.length = len(line.textRunes)
+
+		if line.text != line.textTrimmed {
+			line.textTrimmedRunes = []rune(line.textTrimmed)
+			line.lengthTrimmed = len(line.textTrimmedRunes)
+		} else {
+			line.textTrimmedRunes = line.textRunes
+			line.lengthTrimmed = line.length
+		}
+
+		if needsSlowLevenshtein(line.text) {
+			line.flags |= slowLevenshteinLineFlag
+		}
 	}
 
 	if line.lengthTrimmed == 0 {
 		line.flags |= blankLineFlag
 	}
 
+	if opts.flagSet(WordDistanceFlag) {
+		line.words = wordHashes(line.text)
+	}
+
+	if opts.LineWeights != nil {
+		line.weight = opts.LineWeights.weight(&line)
+	}
+
 	if opts.IgnoreLineRegex == nil {
 		return &line
 	}
@@ -786,6 +2257,17 @@ func textToFileLine(text string, opts *Options) *fileLine {
 	return &line
 }
 
+// isASCII returns whether s only contains ASCII characters.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+
+	return true
+}
+
 // needsSlowLevenshtein returns whether a slower Levenshtein distance comparison must be used to compare s
 // to any other string. This is the case if s contains any rune >65535.
 func needsSlowLevenshtein(s string) bool {
@@ -798,6 +2280,15 @@ func needsSlowLevenshtein(s string) bool {
 	return false
 }
 
+// parallelism returns the maximum number of tasks that are run concurrently, according to o.
+func (o Options) parallelism() int {
+	if o.Parallelism <= 0 {
+		return runtime.NumCPU() + 2
+	}
+
+	return o.Parallelism
+}
+
 // flagSet returns whether f is set in o.
 func (o Options) flagSet(f Flag) bool {
 	return o.Flags.set(f)
@@ -840,12 +2331,16 @@ func (l *fileLine) longEnough(opts *Options) bool {
 		return true
 	}
 
-	length := l.length
+	return l.comparedLength(opts) >= opts.MinLineLength
+}
+
+// comparedLength returns the length of l (in runes) that is compared according to opts.
+func (l *fileLine) comparedLength(opts *Options) int {
 	if opts.flagSet(IgnoreWhitespaceFlag) {
-		length = l.lengthTrimmed
+		return l.lengthTrimmed
 	}
 
-	return length >= opts.MinLineLength
+	return l.length
 }
 
 // flagSet returns whether f is set in l.
@@ -858,32 +2353,16 @@ func (f Flag) set(flag Flag) bool {
 	return f&flag != 0
 }
 
-// equalSimilarities returns whether sim1 and sim2 are equal.
-func equalSimilarities(sim1 *Similarity, sim2 *Similarity) bool {
-	if len(sim1.Occurrences) != len(sim2.Occurrences) {
-		return false
-	}
-
-	occs1 := make([]*FileOccurrence, len(sim1.Occurrences))
-	copy(occs1, sim1.Occurrences)
-	sortOccurrences(occs1)
-
-	occs2 := make([]*FileOccurrence, len(sim2.Occurrences))
-	copy(occs2, sim2.Occurrences)
-	sortOccurrences(occs2)
-
-	for i := range occs1 {
-		if !equalOccurrences(occs1[i], occs2[i]) {
-			return false
-		}
+// String returns a human-readable name of l.
+func (l SimilarityLevel) String() string {
+	switch l {
+	case SimilarSimilarityLevel:
+		return "similar"
+	case ReorderedSimilarityLevel:
+		return "reordered"
+	default:
+		return "exactly equal"
 	}
-
-	return true
-}
-
-// equalOccurrences returns whether occ1 and occ2 are equal.
-func equalOccurrences(occ1 *FileOccurrence, occ2 *FileOccurrence) bool {
-	return occ1.File == occ2.File && occ1.Start == occ2.Start && occ1.End == occ2.End
 }
 
 // sortOccurrences sorts occs by their File.Name, then by their Start, and then by their End.
@@ -10,6 +10,7 @@ import (
 
 var Line int
 var Level SimilarityLevel
+var Sims []*Similarity
 
 func BenchmarkLineIndex(b *testing.B) {
 	b.StopTimer()
@@ -25,6 +26,7 @@ func BenchmarkLineIndex(b *testing.B) {
 
 	ctx := context.Background()
 
+	b.ReportAllocs()
 	b.StartTimer()
 
 	for n := 0; n < b.N; n++ {
@@ -49,9 +51,65 @@ func BenchmarkLineIndex_Large(b *testing.B) {
 
 	ctx := context.Background()
 
+	b.ReportAllocs()
 	b.StartTimer()
 
 	for n := 0; n < b.N; n++ {
 		Line, Level = lineIndex(ctx, file, needle, 0, &opts)
 	}
 }
+
+func BenchmarkRangeSimilarities(b *testing.B) {
+	b.StopTimer()
+
+	osFile, _ := os.Open("testdata/lipsum.txt")
+	defer osFile.Close() //nolint:errcheck // file is being read
+
+	data, _ := io.ReadAll(osFile)
+	texts := strings.Split(string(data), "\n")
+
+	// every other block of 5 lines is slightly changed in the peer
+	peerTexts := make([]string, len(texts))
+	for i, text := range texts {
+		peerTexts[i] = text
+		if (i/5)%2 == 1 && len(text) > 10 {
+			peerTexts[i] = text[:10] + "x" + text[10:]
+		}
+	}
+
+	opts := Options{MaxEditDistance: 2, MinSimilarLines: 3}
+
+	ctx := context.Background()
+
+	b.ReportAllocs()
+
+	for n := 0; n < b.N; n++ {
+		file := newFileToCheck(b, texts, make([]bool, len(texts)))
+		peer := newFileToCheck(b, peerTexts, make([]bool, len(peerTexts)))
+		file.peers = []*fileToCheck{peer}
+
+		b.StartTimer()
+
+		Sims = fileSimilarities(ctx, file, &opts)
+
+		b.StopTimer()
+	}
+}
+
+func BenchmarkLinesSimilarity_Runes(b *testing.B) {
+	b.StopTimer()
+
+	line1 := newFileLine("aaaaaaaaaaaaaaaaaaaa")
+	line1.flags |= asciiLineFlag
+
+	line2 := newFileLine("aaaaaaaaaäaaaaaaaaaa")
+
+	opts := Options{MaxEditDistance: 2}
+
+	b.ReportAllocs()
+	b.StartTimer()
+
+	for n := 0; n < b.N; n++ {
+		Level = linesSimilarity(line1, line2, &opts)
+	}
+}
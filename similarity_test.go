@@ -191,6 +191,134 @@ func TestSimilarities_MinLineLength(t *testing.T) {
 	is.Equal(sims[0].Occurrences[1].End, 4)
 }
 
+func TestSimilarities_ReferenceOnly(t *testing.T) {
+	is := is.New(t)
+
+	file1 := newFile("1.txt", "aaaaaaaaaa\nbbbbbbbbbb\n")
+	file2 := newFile("2.txt", "aaaaaaaaaa\nbbbbbbbbbb\ncccccccccc\ndddddddddd\n")
+	file3 := newFile("3.txt", "cccccccccc\ndddddddddd\n")
+
+	file1.ReferenceOnly = true
+	file3.ReferenceOnly = true
+
+	simsCh, progressCh, _ := Similarities(context.Background(), []*File{file1, file2, file3}, &Options{MaxEditDistance: 2})
+
+	var sims []*Similarity
+
+	waitForAll(func() {
+		sims = readSimilaritiesChan(simsCh)
+	}, drainProgressChan(progressCh))
+
+	is.Equal(len(sims), 2)
+
+	for _, sim := range sims {
+		is.Equal(len(sim.Occurrences), 2)
+	}
+
+	file1.ReferenceOnly = false
+	file2.ReferenceOnly = true
+	file1.R = strings.NewReader("aaaaaaaaaa\nbbbbbbbbbb\n")
+	file2.R = strings.NewReader("xxxxxxxxxx\n")
+	file3.R = strings.NewReader("aaaaaaaaaa\nbbbbbbbbbb\n")
+
+	simsCh, progressCh, _ = Similarities(context.Background(), []*File{file1, file2, file3}, &Options{MaxEditDistance: 2})
+
+	waitForAll(func() {
+		sims = readSimilaritiesChan(simsCh)
+	}, drainProgressChan(progressCh))
+
+	is.Equal(len(sims), 1)
+	is.Equal(sims[0].Occurrences[0].File, file1)
+	is.Equal(sims[0].Occurrences[1].File, file3)
+}
+
+func TestSimilarities_CaptureText(t *testing.T) {
+	is := is.New(t)
+
+	file1 := newFile("1.txt", "aaaaaaaaaa\nbbbbbbbbbb\ncccccccccc\nxxxxxxxxxx\n")
+	file2 := newFile("2.txt", "yyyyyyyyyy\naaaaaaaaaa\nbbbbbbbbbb\ncccccccccc\n")
+
+	simsCh, progressCh, _ := Similarities(context.Background(), []*File{file1, file2}, &Options{
+		CaptureText:      true,
+		CaptureTextLines: 2,
+	})
+
+	var sims []*Similarity
+
+	waitForAll(func() {
+		sims = readSimilaritiesChan(simsCh)
+	}, drainProgressChan(progressCh))
+
+	is.Equal(len(sims), 1)
+	is.Equal(sims[0].Occurrences[0].Text, "aaaaaaaaaa\nbbbbbbbbbb\n")
+	is.Equal(sims[0].Occurrences[1].Text, "aaaaaaaaaa\nbbbbbbbbbb\n")
+}
+
+func TestSimilarities_ExactSeeding(t *testing.T) {
+	is := is.New(t)
+
+	newFiles := func() []*File {
+		return []*File{
+			newFile("1.txt", "aaaaaaaaaa\nbbbbbbbbbb\ncccccccccc\n"),
+			newFile("2.txt", "aaaaxaaaaa\nbbbbbbbbbb\ncccccccccc\n"),
+		}
+	}
+
+	sims := similaritiesWithOptions(t, newFiles(), &Options{MaxEditDistance: 2})
+	is.Equal(len(sims), 1)
+	is.Equal(sims[0].Occurrences[0].Start, 0)
+	is.Equal(sims[0].Occurrences[0].End, 3)
+
+	// seeded from the second line, but expanded upward
+	sims = similaritiesWithOptions(t, newFiles(), &Options{Flags: ExactSeedingFlag, MaxEditDistance: 2})
+	is.Equal(len(sims), 1)
+	is.Equal(sims[0].Occurrences[0].Start, 0)
+	is.Equal(sims[0].Occurrences[0].End, 3)
+}
+
+func TestSimilarities_MinSimilarChars(t *testing.T) {
+	is := is.New(t)
+
+	newFiles := func() []*File {
+		return []*File{
+			newFile("1.txt", "aaaaaaaaaaaaaaaaaaaa\n}\n}\n}\nxxxxxxxxxx\nbbbbbbbbbbbbbbbbbbbb\ncccccccccccccccccccc\n"),
+			newFile("2.txt", "aaaaaaaaaaaaaaaaaaaa\n}\n}\n}\nyyyyyyyyyy\nbbbbbbbbbbbbbbbbbbbb\ncccccccccccccccccccc\n"),
+		}
+	}
+
+	sims := similaritiesWithOptions(t, newFiles(), &Options{MinSimilarLines: 2})
+	is.Equal(len(sims), 2)
+
+	sims = similaritiesWithOptions(t, newFiles(), &Options{MinSimilarLines: 1, MinSimilarChars: 30})
+	is.Equal(len(sims), 1)
+	is.Equal(sims[0].Occurrences[0].Start, 5)
+	is.Equal(sims[0].Occurrences[0].End, 7)
+}
+
+func TestSimilarities_MaxOccurrencesPerSimilarity(t *testing.T) {
+	is := is.New(t)
+
+	files := []*File{
+		newFile("4.txt", "aaaaaaaaaa\nbbbbbbbbbb\n"),
+		newFile("3.txt", "aaaaaaaaaa\nbbbbbbbbbb\n"),
+		newFile("2.txt", "aaaaaaaaaa\nbbbbbbbbbb\n"),
+		newFile("1.txt", "aaaaaaaaaa\nbbbbbbbbbb\n"),
+	}
+
+	sims := similaritiesWithOptions(t, files, &Options{
+		MinSimilarLines:             2,
+		MaxOccurrencesPerSimilarity: 2,
+		CaptureText:                 true,
+	})
+
+	is.Equal(len(sims), 1)
+	is.Equal(len(sims[0].Occurrences), 2)
+	is.Equal(sims[0].OmittedOccurrences, 2)
+	is.Equal(sims[0].Occurrences[0].File.Name, "1.txt")
+	is.Equal(sims[0].Occurrences[1].File.Name, "2.txt")
+	is.Equal(sims[0].Occurrences[1].Text, "aaaaaaaaaa\nbbbbbbbbbb\n")
+}
+
 func TestLinesSimilarity(t *testing.T) {
 	tests := []struct {
 		givenLine1 *fileLine
@@ -219,6 +347,17 @@ func TestLinesSimilarity(t *testing.T) {
 			givenLine2: newFileLine("aaaaxaaaaa"),
 			wantLevel:  SimilarSimilarityLevel,
 		},
+		{
+			givenLine1: newFileLine("aaaaaaaaaa"),
+			givenLine2: newFileLine("aaaaaaaaaaaaa"),
+			wantLevel:  differentSimilarityLevel,
+		},
+		{
+			givenLine1: newFileLine("aaaaaaaaaa"),
+			givenLine2: newFileLine("   aaaaaaaaaaa   "),
+			givenFlags: IgnoreWhitespaceFlag,
+			wantLevel:  SimilarSimilarityLevel,
+		},
 	}
 
 	for i, test := range tests {
@@ -346,6 +485,38 @@ func TestLineIndex(t *testing.T) {
 	}
 }
 
+func TestLineIndex_Index(t *testing.T) {
+	is := is.New(t)
+
+	file := newFile("test.txt", "aaaaaaaaaa\nbbbbbbbbbb\naaaaxaaaaa\naaaaaaaaaa\naaaaaaaaaa\n")
+	opts := Options{MaxEditDistance: 2}
+
+	is.NoErr(file.load(nil, &opts))
+
+	ftc := fileToCheck{
+		f:         file,
+		linesDone: newBitVector(len(file.lines)),
+	}
+
+	is.Equal(file.linesByHash[file.lines[0].hash], []int{0, 3, 4})
+
+	line, level := lineIndex(context.Background(), &ftc, file.lines[0], 1, &opts)
+	is.Equal(line, 2)
+	is.Equal(level, SimilarSimilarityLevel)
+
+	ftc.linesDone.set(3, true)
+
+	line, level = lineIndex(context.Background(), &ftc, file.lines[0], 3, &opts)
+	is.Equal(line, 4)
+	is.Equal(level, EqualSimilarityLevel)
+
+	opts.Flags = ExactSeedingFlag
+
+	line, level = lineIndex(context.Background(), &ftc, file.lines[0], 1, &opts)
+	is.Equal(line, 4)
+	is.Equal(level, EqualSimilarityLevel)
+}
+
 func TestLineIndex_Large(t *testing.T) {
 	is := is.New(t)
 
@@ -584,6 +755,114 @@ func TestExpandOccurrences(t *testing.T) {
 	}
 }
 
+func TestExpandOccurrencesUp(t *testing.T) {
+	tests := []struct {
+		description      string
+		givenOccurrences []*FileOccurrence
+		wantStarts       []int
+		wantLevel        SimilarityLevel
+	}{
+		{
+			description: "whole files",
+			givenOccurrences: []*FileOccurrence{
+				{
+					fileToCheck: newFileToCheck(t,
+						[]string{"aaaaaaaaaa", "bbbbbbbbbb", "cccccccccc", "dddddddddd", "eeeeeeeeee"},
+						[]bool{false, false, false, false, false},
+					),
+					Start: 3, End: 5,
+				},
+				{
+					fileToCheck: newFileToCheck(t,
+						[]string{"aaaaaaaaaa", "bbbbbbbbbb", "cccccccccc", "dddddddddd", "eeeeeeeeee"},
+						[]bool{false, false, false, false, false},
+					),
+					Start: 3, End: 5,
+				},
+			},
+			wantStarts: []int{0, 0},
+			wantLevel:  EqualSimilarityLevel,
+		},
+		{
+			description: "stop at diff",
+			givenOccurrences: []*FileOccurrence{
+				{
+					fileToCheck: newFileToCheck(t,
+						[]string{"aaaaaaaaaa", "bbbbbbbbbb", "cccccccccc", "dddddddddd", "eeeeeeeeee"},
+						[]bool{false, false, false, false, false},
+					),
+					Start: 3, End: 5,
+				},
+				{
+					fileToCheck: newFileToCheck(t,
+						[]string{"xxxxxxxxxx", "bbbbbbbbbb", "cccccccccc", "dddddddddd", "eeeeeeeeee"},
+						[]bool{false, false, false, false, false},
+					),
+					Start: 3, End: 5,
+				},
+			},
+			wantStarts: []int{1, 1},
+			wantLevel:  EqualSimilarityLevel,
+		},
+		{
+			description: "stop at line done",
+			givenOccurrences: []*FileOccurrence{
+				{
+					fileToCheck: newFileToCheck(t,
+						[]string{"aaaaaaaaaa", "bbbbbbbbbb", "cccccccccc", "dddddddddd", "eeeeeeeeee"},
+						[]bool{false, true, false, false, false},
+					),
+					Start: 3, End: 5,
+				},
+				{
+					fileToCheck: newFileToCheck(t,
+						[]string{"aaaaaaaaaa", "bbbbbbbbbb", "cccccccccc", "dddddddddd", "eeeeeeeeee"},
+						[]bool{false, false, false, false, false},
+					),
+					Start: 3, End: 5,
+				},
+			},
+			wantStarts: []int{2, 2},
+			wantLevel:  EqualSimilarityLevel,
+		},
+		{
+			description: "similar",
+			givenOccurrences: []*FileOccurrence{
+				{
+					fileToCheck: newFileToCheck(t,
+						[]string{"aaaaaaaaaa", "bbbbbbbbbb", "cccccccccc", "dddddddddd", "eeeeeeeeee"},
+						[]bool{false, false, false, false, false},
+					),
+					Start: 3, End: 5,
+				},
+				{
+					fileToCheck: newFileToCheck(t,
+						[]string{"aaaaaaaaaa", "bbbbbxbbbb", "cccccccccc", "dddddddddd", "eeeeeeeeee"},
+						[]bool{false, false, false, false, false},
+					),
+					Start: 3, End: 5,
+				},
+			},
+			wantStarts: []int{0, 0},
+			wantLevel:  SimilarSimilarityLevel,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("[%d] %s", i, test.description), func(t *testing.T) {
+			is := is.New(t)
+
+			level := expandOccurrencesUp(context.Background(), test.givenOccurrences, EqualSimilarityLevel, &Options{MaxEditDistance: 2})
+
+			for i, o := range test.givenOccurrences {
+				is.Equal(o.Start, test.wantStarts[i])
+			}
+
+			is.Equal(level, test.wantLevel)
+		})
+	}
+}
+
 func TestLineOccurrences(t *testing.T) {
 	tests := []struct {
 		description     string
@@ -628,7 +907,9 @@ func TestLineOccurrences(t *testing.T) {
 		t.Run(fmt.Sprintf("[%d] %s", i, test.description), func(t *testing.T) {
 			is := is.New(t)
 
-			occs, level := lineOccurrences(context.Background(), test.givenFile, test.givenLine, test.givenStartLine, &Options{MaxEditDistance: 2})
+			buf := occurrenceBuffer{}
+			level := lineOccurrences(context.Background(), test.givenFile, test.givenLine, test.givenStartLine, &buf, &Options{MaxEditDistance: 2})
+			occs := buf.occs
 
 			is.Equal(len(occs), len(test.wantOccurrences))
 
@@ -643,6 +924,34 @@ func TestLineOccurrences(t *testing.T) {
 	}
 }
 
+func TestOccurrenceBuffer(t *testing.T) {
+	is := is.New(t)
+
+	file := newFileToCheck(t, []string{"aaaaaaaaaa", "bbbbbbbbbb", "cccccccccc"}, []bool{false, false, false})
+
+	buf := occurrenceBuffer{}
+	buf.add(file, 0)
+	buf.add(file, 1)
+
+	occ := buf.occs[1]
+
+	buf.reset()
+	is.Equal(len(buf.occs), 0)
+
+	buf.add(file, 2)
+	is.Equal(buf.occs[0], occ) // recycled
+	is.Equal(buf.occs[0].Start, 2)
+	is.Equal(buf.occs[0].End, 3)
+
+	occs := buf.take()
+	is.Equal(len(occs), 1)
+	is.Equal(len(buf.occs), 0)
+
+	buf.reset()
+	buf.add(file, 0)
+	is.True(buf.occs[0] != occs[0]) // taken occurrences are not recycled
+}
+
 func TestFileSimilarities_SingleFile_SingleSimilarity(t *testing.T) {
 	givenFile := &File{
 		Name: "test.txt",
@@ -763,6 +1072,39 @@ func TestFileSimilarities_IgnoreBlankLines(t *testing.T) {
 	testFileSimilarities(t, givenFileToCheck, IgnoreBlankLinesFlag, 0, wantSimilarities)
 }
 
+func TestSimilarities_Occurrences(t *testing.T) {
+	is := is.New(t)
+
+	newFiles := func() []*File {
+		return []*File{
+			newFile("1.txt", "aaaaaaaaaa\nbbbbbbbbbb\nxxxxxxxxxx\nyyyyyyyyyy\n"),
+			newFile("2.txt", "aaaaaaaaaa\nbbbbbbbbbb\n"),
+			newFile("3.txt", "aaaaaaaaaa\nbbbbbbbbbb\n"),
+			newFile("4.txt", "xxxxxxxxxx\nyyyyyyyyyy\n"),
+		}
+	}
+
+	similarities := func(opts *Options) []*Similarity {
+		simsCh, progressCh, _ := Similarities(context.Background(), newFiles(), opts)
+
+		var sims []*Similarity
+
+		waitForAll(func() {
+			sims = readSimilaritiesChan(simsCh)
+		}, drainProgressChan(progressCh))
+
+		return sims
+	}
+
+	sims := similarities(&Options{MaxEditDistance: 2, MinOccurrences: 3})
+	is.Equal(len(sims), 1)
+	is.Equal(len(sims[0].Occurrences), 3)
+
+	sims = similarities(&Options{MaxEditDistance: 2, MaxOccurrences: 2})
+	is.Equal(len(sims), 1)
+	is.Equal(len(sims[0].Occurrences), 2)
+}
+
 func TestFileSimilarities_IgnoreRegex(t *testing.T) {
 	givenFile := &File{
 		Name: "test.txt",
@@ -883,11 +1225,12 @@ func TestFile_Load(t *testing.T) {
 
 	wantLines := newFileLinesMap(t, []string{"aaaaaaaaaa", "bbbbbbbbbb", "foo", "cccccccccc", "𨊂", "dddddddddd", "eeeeeeeeee"})
 
-	_ = file.load(&Options{
+	_ = file.load(nil, &Options{
 		IgnoreLineRegex: regexp.MustCompile("foo"),
 	})
 
 	is.Equal(len(file.lines), len(wantLines))
+	is.Equal(file.LineCount(), len(wantLines))
 
 	for i := 0; i < len(file.lines); i++ {
 		is.Equal(file.lines[i].text, wantLines[i].text)
@@ -899,6 +1242,58 @@ func TestFile_Load(t *testing.T) {
 	is.True(file.lines[2].flagSet(matchesIgnoreRegexLineFlag))
 
 	is.True(file.lines[4].flagSet(slowLevenshteinLineFlag))
+
+	is.True(file.lines[0].flagSet(asciiLineFlag))
+	is.True(!file.lines[4].flagSet(asciiLineFlag))
+}
+
+func TestFile_Load_Unterminated(t *testing.T) {
+	is := is.New(t)
+
+	lastLine := strings.Repeat("x", 4096)
+	file := newFile("test.txt", "aaaaaaaaaa\n"+lastLine)
+
+	_ = file.load(nil, &Options{})
+
+	is.Equal(file.LineCount(), 2)
+	is.Equal(file.lines[1].text, lastLine)
+}
+
+func TestFile_Load_Lines(t *testing.T) {
+	is := is.New(t)
+
+	file := &File{
+		Name:  "test.txt",
+		Lines: []string{"aaaaaaaaaa", "foo", "", "bbbbbbbbbb"},
+	}
+
+	_ = file.load(nil, &Options{
+		IgnoreLineRegex: regexp.MustCompile("foo"),
+	})
+
+	is.Equal(file.LineCount(), 4)
+	is.Equal(file.lines[0].text, "aaaaaaaaaa")
+	is.Equal(file.lines[2].text, "")
+	is.Equal(file.lines[3].text, "bbbbbbbbbb")
+	is.True(file.lines[1].flagSet(matchesIgnoreRegexLineFlag))
+	is.Equal(file.linesByHash[hashString("bbbbbbbbbb")], []int{3})
+}
+
+func TestSimilarities_Lines(t *testing.T) {
+	is := is.New(t)
+
+	file1 := newFile("1.txt", "aaaaaaaaaa\nbbbbbbbbbb\ncccccccccc\nxxxxxxxxxx\n")
+	file2 := &File{
+		Name:  "2.txt",
+		Lines: []string{"yyyyyyyyyy", "aaaaaaaaaa", "bbbbbbbbbb", "cccccccccc"},
+	}
+
+	sims := similaritiesWithOptions(t, []*File{file1, file2}, &Options{MinSimilarLines: 3, CaptureText: true})
+
+	is.Equal(len(sims), 1)
+	is.Equal(sims[0].Occurrences[1].File, file2)
+	is.Equal(sims[0].Occurrences[1].Start, 1)
+	is.Equal(sims[0].Occurrences[1].Text, "aaaaaaaaaa\nbbbbbbbbbb\ncccccccccc\n")
 }
 
 func TestFileLine_LongEnough(t *testing.T) {
@@ -911,6 +1306,14 @@ func TestFileLine_LongEnough(t *testing.T) {
 	is.True(newFileLine("  foo  ").longEnough(&Options{Flags: IgnoreWhitespaceFlag, MinLineLength: 3}))
 }
 
+func TestSimilarityLevel_String(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(EqualSimilarityLevel.String(), "exactly equal")
+	is.Equal(SimilarSimilarityLevel.String(), "similar")
+	is.Equal(ReorderedSimilarityLevel.String(), "reordered")
+}
+
 func newFile(name string, text string) *File {
 	return &File{
 		Name: name,
@@ -951,10 +1354,13 @@ func newFileLinesMap(t testingTOrB, texts []string) map[int]*fileLine {
 
 func newFileLine(text string) *fileLine {
 	line := fileLine{
+		originalText:     text,
 		text:             text,
 		textTrimmed:      strings.TrimSpace(text),
 		textRunes:        []rune(text),
 		textTrimmedRunes: []rune(strings.TrimSpace(text)),
+		chars:            newCharSet(text),
+		charsTrimmed:     newCharSet(strings.TrimSpace(text)),
 		length:           len([]rune(text)),
 		lengthTrimmed:    len([]rune(strings.TrimSpace(text))),
 	}
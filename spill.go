@@ -0,0 +1,347 @@
+package textsimilarity
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// lineOverheadBytes is the estimated memory used by a single loaded line, in addition to its text.
+const lineOverheadBytes = 256
+
+// errFileChanged is returned when the lines of a file read again using File.Open differ from those read before.
+var errFileChanged = errors.New("file changed during scan")
+
+// A lineStore keeps the estimated memory used by loaded lines below a soft limit. When the limit is exceeded,
+// the lines of files that have not been used recently are spilled to a temporary file, and reloaded from there
+// when they are needed again. Lines of files that can be opened again using File.Open are unloaded without being
+// spilled, and read from the files again instead. Files that are in use are never spilled, so the limit may be
+// exceeded temporarily.
+type lineStore struct {
+	// lock guards all fields.
+	lock sync.Mutex
+
+	// opts are the options used to reload lines.
+	opts *Options
+
+	// files maps files to their state in the store.
+	files map[*File]*storedFile
+
+	// used is the estimated memory used by loaded lines of all files, in bytes.
+	used int64
+
+	// clock is incremented every time a file is used.
+	clock int64
+
+	// spillFile is the temporary file lines are spilled to. It is created when lines are spilled for the first time.
+	spillFile *os.File
+
+	// spillOffset is the offset in spillFile at which to spill the next file's lines.
+	spillOffset int64
+}
+
+// A storedFile is the state of a single file in a lineStore.
+type storedFile struct {
+	// size is the estimated memory used by the file's loaded lines, in bytes.
+	size int64
+
+	// pins is the number of users of the file. The file's lines cannot be spilled while it is greater than 0.
+	pins int
+
+	// lastUsed is the value of the store's clock when the file was last used.
+	lastUsed int64
+
+	// loaded indicates whether the file's lines are currently loaded.
+	loaded bool
+
+	// spilled indicates whether the file's lines have been written to the spill file.
+	spilled bool
+
+	// offset is the offset of the file's lines in the spill file.
+	offset int64
+
+	// length is the number of bytes of the file's lines in the spill file.
+	length int64
+}
+
+// newLineStore returns a new lineStore according to opts. If opts.MaxLinesMemory <= 0, or if overlapping
+// similarities are split, nil is returned, and lines will never be spilled.
+func newLineStore(opts *Options) *lineStore {
+	if opts.MaxLinesMemory <= 0 || opts.OverlapPolicy == SplitOverlapPolicy {
+		return nil
+	}
+
+	return &lineStore{
+		opts:  opts,
+		files: map[*File]*storedFile{},
+	}
+}
+
+// add adds f, whose lines must be loaded, to s. Lines of other files may be spilled. Files returned by
+// LoadedFile.File are not added, since their lines are kept in memory by the LoadedFile anyway.
+func (s *lineStore) add(f *File) error {
+	if s == nil || f.loaded != nil {
+		return nil
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	size := int64(0)
+	for _, line := range f.lines {
+		size += line.size()
+	}
+
+	s.clock++
+
+	s.files[f] = &storedFile{
+		size:     size,
+		lastUsed: s.clock,
+		loaded:   true,
+	}
+
+	s.used += size
+
+	return s.evict()
+}
+
+// acquire marks files as being in use, reloading their lines if necessary. Lines of other files may be spilled.
+// Each call to acquire that does not return an error must be followed by a call to release.
+func (s *lineStore) acquire(files []*File) error {
+	if s == nil {
+		return nil
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.clock++
+
+	for idx, f := range files {
+		sf, ok := s.files[f]
+		if !ok {
+			continue
+		}
+
+		sf.pins++
+		sf.lastUsed = s.clock
+
+		if sf.loaded {
+			continue
+		}
+
+		if err := s.reload(f, sf); err != nil {
+			s.unpin(files[:idx+1])
+			return err
+		}
+	}
+
+	return s.evict()
+}
+
+// release marks files as no longer being in use by a previous call to acquire.
+func (s *lineStore) release(files []*File) {
+	if s == nil {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.unpin(files)
+}
+
+// unpin decrements the pins of files.
+func (s *lineStore) unpin(files []*File) {
+	for _, f := range files {
+		if sf, ok := s.files[f]; ok {
+			sf.pins--
+		}
+	}
+}
+
+// evict spills the lines of files that are not in use, least recently used first, until the estimated memory
+// used by loaded lines is below the limit, or until no more files can be spilled.
+func (s *lineStore) evict() error {
+	for s.used > s.opts.MaxLinesMemory {
+		var (
+			lruFile   *File
+			lruStored *storedFile
+		)
+
+		for f, sf := range s.files {
+			if !sf.loaded || sf.pins > 0 {
+				continue
+			}
+
+			if lruStored == nil || sf.lastUsed < lruStored.lastUsed {
+				lruFile = f
+				lruStored = sf
+			}
+		}
+
+		if lruFile == nil {
+			return nil
+		}
+
+		if err := s.spill(lruFile, lruStored); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// spill writes the lines of f to the spill file if they have not been written before, and unloads them. Lines
+// of files that can be opened again are not written to the spill file.
+func (s *lineStore) spill(f *File, sf *storedFile) error {
+	if !sf.spilled && !reopenable(f) {
+		if s.spillFile == nil {
+			file, err := os.CreateTemp(s.opts.SpillDir, "textsimilarity-spill-*")
+			if err != nil {
+				return fmt.Errorf("create spill file: %w", err)
+			}
+
+			s.spillFile = file
+		}
+
+		writer := bufio.NewWriter(io.NewOffsetWriter(s.spillFile, s.spillOffset))
+		length := int64(0)
+		lenBuf := make([]byte, binary.MaxVarintLen64)
+
+		for lineIdx := 0; lineIdx < f.lineCount; lineIdx++ {
+			text := f.lines[lineIdx].originalText
+
+			n := binary.PutUvarint(lenBuf, uint64(len(text)))
+			_, _ = writer.Write(lenBuf[:n])
+			_, _ = writer.WriteString(text)
+
+			length += int64(n + len(text))
+		}
+
+		if err := writer.Flush(); err != nil {
+			return fmt.Errorf("write spill file: %w", err)
+		}
+
+		sf.spilled = true
+		sf.offset = s.spillOffset
+		sf.length = length
+
+		s.spillOffset += length
+	}
+
+	f.lines = nil
+	f.linesByHash = nil
+	f.lineClasses = nil
+
+	sf.loaded = false
+	s.used -= sf.size
+
+	return nil
+}
+
+// reload reads the lines of f from the spill file, or from f itself if it can be opened again.
+func (s *lineStore) reload(f *File, sf *storedFile) error {
+	if reopenable(f) {
+		return s.reopen(f, sf)
+	}
+
+	reader := bufio.NewReader(io.NewSectionReader(s.spillFile, sf.offset, sf.length))
+
+	f.lines = make(map[int]*fileLine, f.lineCount)
+	f.linesByHash = map[uint64][]int{}
+	f.lineClasses = map[*fileLine][]int{}
+
+	for lineIdx := 0; lineIdx < f.lineCount; lineIdx++ {
+		length, err := binary.ReadUvarint(reader)
+		if err != nil {
+			return fmt.Errorf("read spill file: %w", err)
+		}
+
+		text := make([]byte, length)
+		if _, err := io.ReadFull(reader, text); err != nil {
+			return fmt.Errorf("read spill file: %w", err)
+		}
+
+		f.setLine(lineIdx, textToFileLine(string(text), s.opts), s.opts)
+	}
+
+	sf.loaded = true
+	s.used += sf.size
+
+	return nil
+}
+
+// reopen reads the lines of f again using f.Open.
+func (s *lineStore) reopen(f *File, sf *storedFile) error {
+	r, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("open %s: %w", f.Name, err)
+	}
+	defer r.Close() //nolint:errcheck // file is being read
+
+	f.lines = make(map[int]*fileLine, f.lineCount)
+	f.linesByHash = map[uint64][]int{}
+	f.lineClasses = map[*fileLine][]int{}
+
+	lineIdx := 0
+
+	err = s.opts.segmenter().Segment(r, func(unit Unit) error {
+		if lineIdx >= f.lineCount {
+			return fmt.Errorf("%w: %s", errFileChanged, f.Name)
+		}
+
+		f.setLine(lineIdx, textToFileLine(unit.Text, s.opts), s.opts)
+		lineIdx++
+
+		return nil
+	})
+
+	switch {
+	case errors.Is(err, errFileChanged):
+		return err
+	case err != nil:
+		return fmt.Errorf("read %s: %w", f.Name, err)
+	case lineIdx != f.lineCount:
+		return fmt.Errorf("%w: %s", errFileChanged, f.Name)
+	}
+
+	sf.loaded = true
+	s.used += sf.size
+
+	return nil
+}
+
+// reopenable returns whether the lines of f can be read again using f.Open.
+func reopenable(f *File) bool {
+	return f.Open != nil && f.Lines == nil
+}
+
+// close removes the spill file, if any.
+func (s *lineStore) close() {
+	if s == nil || s.spillFile == nil {
+		return
+	}
+
+	_ = s.spillFile.Close()
+	_ = os.Remove(s.spillFile.Name())
+}
+
+// size returns the estimated memory used by l, in bytes.
+func (l *fileLine) size() int64 {
+	size := lineOverheadBytes + len(l.text) + len(l.textBytes) + 4*len(l.textRunes) + 4*len(l.words)
+
+	if l.originalText != l.text {
+		size += len(l.originalText)
+	}
+
+	if l.textTrimmed != l.text {
+		size += 4 * len(l.textTrimmedRunes)
+	}
+
+	return int64(size)
+}
@@ -0,0 +1,226 @@
+package textsimilarity
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestLineStore(t *testing.T) {
+	is := is.New(t)
+
+	opts := Options{
+		MaxLinesMemory: 3 * lineOverheadBytes,
+		SpillDir:       t.TempDir(),
+	}
+
+	file1 := newFile("1.txt", "aaaaaaaaaa\nbbbbbbbbbb\n")
+	file2 := newFile("2.txt", "cccccccccc\ndddddddddd\n")
+
+	store := newLineStore(&opts)
+	defer store.close()
+
+	is.NoErr(file1.load(nil, &opts))
+	is.NoErr(store.add(file1))
+	is.True(file1.lines != nil)
+
+	is.NoErr(file2.load(nil, &opts))
+	is.NoErr(store.add(file2))
+	is.True(file1.lines == nil) // spilled
+	is.True(file2.lines != nil)
+
+	is.NoErr(store.acquire([]*File{file1}))
+	is.True(file2.lines == nil) // spilled
+	is.Equal(file1.lines[0].text, "aaaaaaaaaa")
+	is.Equal(file1.lines[1].text, "bbbbbbbbbb")
+	is.Equal(file1.linesByHash[file1.lines[1].hash], []int{1})
+
+	// both files are in use, so the limit is exceeded
+	is.NoErr(store.acquire([]*File{file2}))
+	is.True(file1.lines != nil)
+	is.Equal(file2.lines[1].text, "dddddddddd")
+
+	store.release([]*File{file1})
+	store.release([]*File{file2})
+
+	name := store.spillFile.Name()
+	store.close()
+
+	_, err := os.Stat(name)
+	is.True(os.IsNotExist(err))
+}
+
+func TestLineStore_Reopen(t *testing.T) {
+	is := is.New(t)
+
+	opts := Options{
+		MaxLinesMemory: 3 * lineOverheadBytes,
+		SpillDir:       t.TempDir(),
+	}
+
+	text1 := "aaaaaaaaaa\nbbbbbbbbbb\n"
+
+	file1 := newFile("1.txt", text1)
+	file1.Open = func() (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader(text1)), nil
+	}
+
+	file2 := newFile("2.txt", "cccccccccc\ndddddddddd\n")
+
+	store := newLineStore(&opts)
+	defer store.close()
+
+	is.NoErr(file1.load(nil, &opts))
+	is.NoErr(store.add(file1))
+	is.NoErr(file2.load(nil, &opts))
+	is.NoErr(store.add(file2))
+	is.True(file1.lines == nil) // unloaded
+	is.True(store.spillFile == nil)
+
+	is.NoErr(store.acquire([]*File{file1}))
+	is.Equal(file1.lines[1].text, "bbbbbbbbbb")
+	is.Equal(file1.linesByHash[file1.lines[1].hash], []int{1})
+	store.release([]*File{file1})
+
+	// file2 cannot be opened again, so it has been spilled
+	is.True(store.spillFile != nil)
+
+	is.NoErr(store.acquire([]*File{file2}))
+	store.release([]*File{file2})
+
+	text1 = "aaaaaaaaaa\n"
+
+	err := store.acquire([]*File{file1})
+	is.True(errors.Is(err, errFileChanged))
+}
+
+func TestLineStore_Disabled(t *testing.T) {
+	is := is.New(t)
+
+	store := newLineStore(&Options{})
+	is.True(store == nil)
+
+	is.NoErr(store.add(&File{}))
+	is.NoErr(store.acquire([]*File{{}}))
+	store.release([]*File{{}})
+	store.close()
+}
+
+func TestSimilarities_SpillLines(t *testing.T) {
+	is := is.New(t)
+
+	newFiles := func() []*File {
+		return []*File{
+			newFile("1.txt", "aaaaaaaaaa\nbbbbbbbbbb\ncccccccccc\nxxxxxxxxxx\n"),
+			newFile("2.txt", "yyyyyyyyyy\naaaaaaaaaa\nbbbbbbbbbb\ncccccccccc\n"),
+			newFile("3.txt", "zzzzzzzzzz\nbbbbbbbbbb\ncccccccccc\nxxxxxxxxxx\n"),
+		}
+	}
+
+	opts := Options{MinSimilarLines: 2, CaptureText: true}
+	want := similaritiesWithOptions(t, newFiles(), &opts)
+
+	opts.MaxLinesMemory = 1
+	opts.SpillDir = t.TempDir()
+	sims := similaritiesWithOptions(t, newFiles(), &opts)
+
+	is.Equal(len(sims), len(want))
+
+	for simIdx, sim := range sims {
+		is.Equal(sim.ID(), want[simIdx].ID())
+		is.Equal(len(sim.Occurrences), len(want[simIdx].Occurrences))
+
+		for occIdx, occ := range sim.Occurrences {
+			is.Equal(occ.File.Name, want[simIdx].Occurrences[occIdx].File.Name)
+			is.Equal(occ.Start, want[simIdx].Occurrences[occIdx].Start)
+			is.Equal(occ.End, want[simIdx].Occurrences[occIdx].End)
+			is.Equal(occ.Text, want[simIdx].Occurrences[occIdx].Text)
+		}
+	}
+
+	entries, err := os.ReadDir(opts.SpillDir)
+	is.NoErr(err)
+	is.Equal(len(entries), 0) // spill file removed
+}
+
+func TestLineStore_LoadedFile(t *testing.T) {
+	is := is.New(t)
+
+	opts := Options{
+		MaxLinesMemory: 1,
+		SpillDir:       t.TempDir(),
+	}
+
+	loaded, err := Load(newFile("1.txt", "aaaaaaaaaa\nbbbbbbbbbb\n"))
+	is.NoErr(err)
+
+	file1 := loaded.File()
+	file2 := newFile("2.txt", "cccccccccc\ndddddddddd\n")
+
+	store := newLineStore(&opts)
+	defer store.close()
+
+	is.NoErr(file1.load(nil, &opts))
+	is.NoErr(store.add(file1))
+	is.NoErr(file2.load(nil, &opts))
+	is.NoErr(store.add(file2))
+
+	is.True(file1.lines != nil) // not spilled
+	is.True(file2.lines == nil) // spilled
+
+	is.NoErr(store.acquire([]*File{file1, file2}))
+	is.Equal(file1.lines[0].text, "aaaaaaaaaa")
+	is.Equal(file2.lines[1].text, "dddddddddd")
+	store.release([]*File{file1, file2})
+
+	is.NoErr(store.acquire([]*File{file1}))
+	is.True(file2.lines == nil) // spilled again
+	store.release([]*File{file1})
+
+	is.Equal(len(store.files), 1)
+}
+
+func TestSimilarities_SpillLines_LoadedFile(t *testing.T) {
+	is := is.New(t)
+
+	texts := []string{
+		"aaaaaaaaaa\nbbbbbbbbbb\ncccccccccc\nxxxxxxxxxx\n",
+		"yyyyyyyyyy\naaaaaaaaaa\nbbbbbbbbbb\ncccccccccc\n",
+	}
+
+	loaded := make([]*LoadedFile, len(texts))
+
+	for idx, text := range texts {
+		l, err := Load(newFile(strconv.Itoa(idx+1)+".txt", text))
+		is.NoErr(err)
+
+		loaded[idx] = l
+	}
+
+	newFiles := func() []*File {
+		return []*File{loaded[0].File(), loaded[1].File(), newFile("3.txt", texts[0])}
+	}
+
+	opts := Options{MinSimilarLines: 2, CaptureText: true}
+	want := similaritiesWithOptions(t, newFiles(), &opts)
+
+	opts.MaxLinesMemory = 1
+	opts.SpillDir = t.TempDir()
+	sims := similaritiesWithOptions(t, newFiles(), &opts)
+
+	is.Equal(len(sims), len(want))
+
+	for simIdx, sim := range sims {
+		is.Equal(sim.ID(), want[simIdx].ID())
+
+		for occIdx, occ := range sim.Occurrences {
+			is.Equal(occ.File.Name, want[simIdx].Occurrences[occIdx].File.Name)
+			is.Equal(occ.Text, want[simIdx].Occurrences[occIdx].Text)
+		}
+	}
+}
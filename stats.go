@@ -0,0 +1,141 @@
+package textsimilarity
+
+import "sort"
+
+// FileStats are statistics about similarities found in a single File.
+type FileStats struct {
+	// File is the file the statistics are about.
+	File *File
+
+	// Lines is the total number of lines in File.
+	Lines int
+
+	// DuplicatedLines is the number of distinct lines in File that are covered by any similarity.
+	DuplicatedLines int
+
+	// Partners is the number of other files that share at least one similarity with File.
+	Partners int
+}
+
+// FilesStats returns statistics about sims for each file in files, sorted by duplication percentage, then by
+// number of duplicated lines (both descending), and then by file name. files and sims must have been passed to
+// and returned by Similarities, respectively.
+func FilesStats(files []*File, sims []*Similarity) []*FileStats {
+	covered := map[*File]map[int]struct{}{}
+	partners := map[*File]map[*File]struct{}{}
+
+	for _, sim := range sims {
+		for _, occ := range sim.Occurrences {
+			lines, ok := covered[occ.File]
+			if !ok {
+				lines = map[int]struct{}{}
+				covered[occ.File] = lines
+			}
+
+			for l := occ.Start; l < occ.End; l++ {
+				lines[l] = struct{}{}
+			}
+
+			filePartners, ok := partners[occ.File]
+			if !ok {
+				filePartners = map[*File]struct{}{}
+				partners[occ.File] = filePartners
+			}
+
+			for _, occ2 := range sim.Occurrences {
+				if occ2.File != occ.File {
+					filePartners[occ2.File] = struct{}{}
+				}
+			}
+		}
+	}
+
+	stats := make([]*FileStats, len(files))
+
+	for idx, file := range files {
+		stats[idx] = &FileStats{
+			File:            file,
+			Lines:           file.LineCount(),
+			DuplicatedLines: len(covered[file]),
+			Partners:        len(partners[file]),
+		}
+	}
+
+	sort.SliceStable(stats, func(a int, b int) bool {
+		pct1 := stats[a].DuplicationPct()
+		pct2 := stats[b].DuplicationPct()
+
+		switch {
+		case pct1 > pct2:
+			return true
+		case pct1 < pct2:
+			return false
+		}
+
+		switch {
+		case stats[a].DuplicatedLines > stats[b].DuplicatedLines:
+			return true
+		case stats[a].DuplicatedLines < stats[b].DuplicatedLines:
+			return false
+		}
+
+		return stats[a].File.Name < stats[b].File.Name
+	})
+
+	return stats
+}
+
+// DuplicationPct returns the percentage of duplicated lines in relation to all lines (0-100.)
+func (s *FileStats) DuplicationPct() float64 {
+	if s.Lines == 0 {
+		return 0
+	}
+
+	return float64(s.DuplicatedLines) * 100.0 / float64(s.Lines)
+}
+
+// A FileCoverage is the number of similarities covering each line of a single File, for example for rendering
+// heat maps or editor gutters.
+type FileCoverage struct {
+	// File is the file the coverage is about.
+	File *File
+
+	// Lines are the numbers of occurrences of similarities covering each line of File, by zero-based line index.
+	Lines []int
+}
+
+// FilesCoverage returns the coverage of each file that is covered by any of sims, sorted by file name.
+// sims must have been returned by Similarities.
+func FilesCoverage(sims []*Similarity) []*FileCoverage {
+	coverageByFile := map[*File]*FileCoverage{}
+	coverage := []*FileCoverage{}
+
+	for _, sim := range sims {
+		for _, occ := range sim.Occurrences {
+			fileCov, ok := coverageByFile[occ.File]
+			if !ok {
+				fileCov = &FileCoverage{
+					File: occ.File,
+				}
+
+				coverageByFile[occ.File] = fileCov
+				coverage = append(coverage, fileCov)
+			}
+
+			lines := max(occ.File.LineCount(), occ.End)
+			if len(fileCov.Lines) < lines {
+				fileCov.Lines = append(fileCov.Lines, make([]int, lines-len(fileCov.Lines))...)
+			}
+
+			for l := occ.Start; l < occ.End; l++ {
+				fileCov.Lines[l]++
+			}
+		}
+	}
+
+	sort.SliceStable(coverage, func(a int, b int) bool {
+		return coverage[a].File.Name < coverage[b].File.Name
+	})
+
+	return coverage
+}
@@ -0,0 +1,56 @@
+package textsimilarity
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestFilesStats(t *testing.T) {
+	is := is.New(t)
+
+	file1 := newFile("1.txt", "aaaaaaaaaa\nbbbbbbbbbb\nxxxxxxxxxx\nyyyyyyyyyy\n")
+	file2 := newFile("2.txt", "aaaaaaaaaa\nbbbbbbbbbb\n")
+	file3 := newFile("3.txt", "aaaaaaaaaa\nbbbbbbbbbb\nzzzzzzzzzz\n")
+	file4 := newFile("4.txt", "wwwwwwwwww\n")
+
+	files := []*File{file1, file2, file3, file4}
+
+	stats := FilesStats(files, similarities(t, files...))
+
+	is.Equal(len(stats), 4)
+
+	is.Equal(stats[0].File, file2)
+	is.Equal(stats[0].Lines, 2)
+	is.Equal(stats[0].DuplicatedLines, 2)
+	is.Equal(stats[0].Partners, 2)
+	is.Equal(stats[0].DuplicationPct(), 100.0)
+
+	is.Equal(stats[1].File, file3)
+	is.Equal(stats[2].File, file1)
+	is.Equal(stats[2].DuplicationPct(), 50.0)
+
+	is.Equal(stats[3].File, file4)
+	is.Equal(stats[3].DuplicatedLines, 0)
+	is.Equal(stats[3].Partners, 0)
+}
+
+func TestFilesCoverage(t *testing.T) {
+	is := is.New(t)
+
+	file1 := &File{Name: "1.txt"}
+	file2 := &File{Name: "2.txt"}
+
+	sims := []*Similarity{
+		{Occurrences: []*FileOccurrence{{File: file2, Start: 1, End: 3}, {File: file1, Start: 0, End: 2}}},
+		{Occurrences: []*FileOccurrence{{File: file2, Start: 2, End: 4}, {File: file1, Start: 3, End: 5}}},
+	}
+
+	coverage := FilesCoverage(sims)
+
+	is.Equal(len(coverage), 2)
+	is.Equal(coverage[0].File, file1)
+	is.Equal(coverage[0].Lines, []int{1, 1, 0, 1, 1})
+	is.Equal(coverage[1].File, file2)
+	is.Equal(coverage[1].Lines, []int{0, 1, 2, 1})
+}
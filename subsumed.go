@@ -0,0 +1,94 @@
+package textsimilarity
+
+import "sort"
+
+// A subsumingOccurrence is an occurrence of a similarity that may contain occurrences of other similarities.
+type subsumingOccurrence struct {
+	occ *FileOccurrence
+
+	// simIdx is the index of the occurrence's similarity.
+	simIdx int
+}
+
+// PruneSubsumed returns sims without the similarities that are subsumed by another similarity. A similarity is
+// subsumed if each of its occurrences is contained in an occurrence of another, larger similarity. This removes
+// smaller echoes of the inner parts of large similarities. If two similarities contain each other, only the first
+// one is kept. The order of sims is retained. sims must have been returned by Similarities.
+func PruneSubsumed(sims []*Similarity) []*Similarity {
+	occsByFile := map[*File][]subsumingOccurrence{}
+
+	for simIdx, sim := range sims {
+		for _, occ := range sim.Occurrences {
+			occsByFile[occ.File] = append(occsByFile[occ.File], subsumingOccurrence{
+				occ:    occ,
+				simIdx: simIdx,
+			})
+		}
+	}
+
+	for _, occs := range occsByFile {
+		sort.SliceStable(occs, func(a int, b int) bool {
+			return occs[a].occ.Start < occs[b].occ.Start
+		})
+	}
+
+	pruned := make([]*Similarity, 0, len(sims))
+
+	for simIdx, sim := range sims {
+		if !subsumed(sims, simIdx, occsByFile[sim.Occurrences[0].File]) {
+			pruned = append(pruned, sim)
+		}
+	}
+
+	return pruned
+}
+
+// subsumed returns whether the similarity at simIdx in sims is subsumed by another similarity. candidates are
+// the occurrences of all similarities in the file of the similarity's first occurrence, sorted by start line.
+func subsumed(sims []*Similarity, simIdx int, candidates []subsumingOccurrence) bool {
+	sim := sims[simIdx]
+	first := sim.Occurrences[0]
+
+	for _, cand := range candidates {
+		if cand.occ.Start > first.Start {
+			break
+		}
+
+		if cand.simIdx == simIdx || cand.occ.End < first.End {
+			continue
+		}
+
+		other := sims[cand.simIdx]
+
+		if !containsAll(other, sim) {
+			continue
+		}
+
+		// if both contain each other, only the first one is kept
+		if !containsAll(sim, other) || cand.simIdx < simIdx {
+			return true
+		}
+	}
+
+	return false
+}
+
+// containsAll returns whether each occurrence of sim2 is contained in an occurrence of sim1.
+func containsAll(sim1 *Similarity, sim2 *Similarity) bool {
+	for _, occ2 := range sim2.Occurrences {
+		found := false
+
+		for _, occ1 := range sim1.Occurrences {
+			if occ1.File == occ2.File && occ1.Start <= occ2.Start && occ1.End >= occ2.End {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
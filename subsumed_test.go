@@ -0,0 +1,28 @@
+package textsimilarity
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestPruneSubsumed(t *testing.T) {
+	is := is.New(t)
+
+	fileA := &File{Name: "a.go"}
+	fileB := &File{Name: "b.go"}
+	fileC := &File{Name: "c.go"}
+
+	sims := []*Similarity{
+		{Occurrences: []*FileOccurrence{{File: fileA, Start: 5, End: 8}, {File: fileB, Start: 25, End: 28}}},
+		{Occurrences: []*FileOccurrence{{File: fileA, Start: 0, End: 10}, {File: fileB, Start: 20, End: 30}}},
+		{Occurrences: []*FileOccurrence{{File: fileA, Start: 5, End: 8}, {File: fileC, Start: 0, End: 3}}},
+		{Occurrences: []*FileOccurrence{{File: fileA, Start: 0, End: 10}, {File: fileB, Start: 20, End: 30}}},
+	}
+
+	pruned := PruneSubsumed(sims)
+
+	is.Equal(len(pruned), 2)
+	is.Equal(pruned[0], sims[1])
+	is.Equal(pruned[1], sims[2])
+}
@@ -0,0 +1,112 @@
+package textsimilarity
+
+import "github.com/blizzy78/textsimilarity/levenshtein"
+
+// A Tier is a named tier of similarities, such as "near-exact", defined by the largest edit distance between
+// corresponding lines of their occurrences.
+type Tier struct {
+	// Name is the name of the tier.
+	Name string
+
+	// MaxEditDistance is the maximum edit distance between corresponding lines of occurrences of similarities
+	// in the tier. It is measured like Options.MaxEditDistance.
+	MaxEditDistance int
+}
+
+// setTier sets sim's tier according to opts.Tiers. The lines of all files of sim's occurrences must be loaded.
+func setTier(sim *Similarity, opts *Options) {
+	if len(opts.Tiers) == 0 || sim.Level == ReorderedSimilarityLevel {
+		return
+	}
+
+	maxDist := 0
+	for _, tier := range opts.Tiers {
+		maxDist = max(maxDist, tier.MaxEditDistance)
+	}
+
+	dist, ok := similarityEditDistance(sim, maxDist, opts)
+	if !ok {
+		return
+	}
+
+	for _, tier := range opts.Tiers {
+		if dist <= tier.MaxEditDistance {
+			sim.Tier = tier.Name
+			return
+		}
+	}
+}
+
+// similarityEditDistance returns the largest edit distance between corresponding lines of the first occurrence
+// of sim and each other occurrence, according to opts, and whether it is at most maxDist. Only lines that are
+// considered for similarities are compared. If the occurrences have different numbers of such lines, the missing
+// lines are considered empty.
+func similarityEditDistance(sim *Similarity, maxDist int, opts *Options) (int, bool) {
+	first := occurrenceLines(sim.Occurrences[0], opts)
+	dist := 0
+
+	for _, occ := range sim.Occurrences[1:] {
+		lines := occurrenceLines(occ, opts)
+
+		for idx := 0; idx < max(len(first), len(lines)); idx++ {
+			lineDist, ok := lineEditDistance(lineAt(first, idx), lineAt(lines, idx), maxDist, opts)
+			if !ok {
+				return lineDist, false
+			}
+
+			dist = max(dist, lineDist)
+		}
+	}
+
+	return dist, true
+}
+
+// occurrenceLines returns the lines of occ that are considered for similarities, according to opts and the scopes
+// of occ's file.
+func occurrenceLines(occ *FileOccurrence, opts *Options) []*fileLine {
+	lines := make([]*fileLine, 0, occ.End-occ.Start)
+
+	for lineIdx := occ.Start; lineIdx < occ.End; lineIdx++ {
+		line := occ.File.lines[lineIdx]
+		if acceptLine(line, opts) && occ.File.inScope(lineIdx) {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+// lineAt returns lines[idx], or an empty line if idx is out of range.
+func lineAt(lines []*fileLine, idx int) *fileLine {
+	if idx < len(lines) {
+		return lines[idx]
+	}
+
+	return &fileLine{}
+}
+
+// lineEditDistance returns the edit distance between line1 and line2, according to opts, and whether it is at
+// most maxDist. If WordDistanceFlag is set, the distance is measured in words.
+func lineEditDistance(line1 *fileLine, line2 *fileLine, maxDist int, opts *Options) (int, bool) {
+	if line1 == line2 {
+		return 0, true
+	}
+
+	if opts.flagSet(WordDistanceFlag) {
+		return levenshtein.DistanceAtMost(wordHashes(line1.text), wordHashes(line2.text), maxDist)
+	}
+
+	text1 := line1.text
+	text2 := line2.text
+
+	if opts.flagSet(IgnoreWhitespaceFlag) {
+		text1 = line1.textTrimmed
+		text2 = line2.textTrimmed
+	}
+
+	if text1 == text2 {
+		return 0, true
+	}
+
+	return levenshtein.DistanceAtMost([]rune(text1), []rune(text2), maxDist)
+}
@@ -0,0 +1,51 @@
+package textsimilarity
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestSimilarities_Tiers(t *testing.T) {
+	is := is.New(t)
+
+	tiers := []Tier{
+		{Name: "exact", MaxEditDistance: 0},
+		{Name: "near-exact", MaxEditDistance: 2},
+		{Name: "loose", MaxEditDistance: 8},
+	}
+
+	tests := []struct {
+		name     string
+		contents string
+		tier     string
+	}{
+		{"exact", "alpha bravo charlie\ndelta echo foxtrot\ngolf hotel india\n", "exact"},
+		{"near-exact", "alpha bravo charlie\ndelta echo foxtrt\ngolf hotel india\n", "near-exact"},
+		{"loose", "alpha bravo charlie\ndelta echo hotel\ngolf hotel india\n", "loose"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			is := is.New(t)
+
+			files := []*File{
+				newFile("1.txt", "alpha bravo charlie\ndelta echo foxtrot\ngolf hotel india\n"),
+				newFile("2.txt", test.contents),
+			}
+
+			sims := similaritiesWithOptions(t, files, &Options{MinSimilarLines: 3, MaxEditDistance: 8, Tiers: tiers})
+			is.Equal(len(sims), 1)
+			is.Equal(sims[0].Tier, test.tier)
+		})
+	}
+
+	files := []*File{
+		newFile("1.txt", "alpha bravo charlie\ndelta echo foxtrot\ngolf hotel india\n"),
+		newFile("2.txt", "alpha bravo charlie\ndelta echo foxtrot\ngolf hotel india\n"),
+	}
+
+	sims := similaritiesWithOptions(t, files, &Options{MinSimilarLines: 3})
+	is.Equal(len(sims), 1)
+	is.Equal(sims[0].Tier, "")
+}
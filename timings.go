@@ -0,0 +1,157 @@
+package textsimilarity
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// A Phase is a phase of a scan for similarities.
+type Phase int
+
+const (
+	// LoadPhase is the phase of reading files and indexing their lines.
+	LoadPhase = Phase(iota)
+
+	// ExactSeedingPhase is the phase of looking up exactly equal lines in the indexes of line hashes of files.
+	ExactSeedingPhase
+
+	// FuzzyMatchingPhase is the phase of scanning files for similar lines.
+	FuzzyMatchingPhase
+
+	// ExpansionPhase is the phase of expanding occurrences of single lines into blocks of lines.
+	ExpansionPhase
+
+	// BlockSearchPhase is the phase of searching for reordered or paraphrased blocks of lines, after all files have
+	// been scanned.
+	BlockSearchPhase
+
+	// PreparationPhase is the phase of computing IDs, column ranges, and other details of similarities.
+	PreparationPhase
+
+	// DedupPhase is the phase of dropping similarities that cover lines already covered by other similarities,
+	// and sending the remaining ones.
+	DedupPhase
+
+	// phaseCount is the number of phases.
+	phaseCount
+)
+
+// Timings collects the time spent in each phase of a scan, and for each file. Phases run concurrently by multiple
+// workers are summed up, so their times may exceed the duration of the scan. The zero value is ready to use,
+// and Timings is safe for concurrent use.
+type Timings struct {
+	phases [phaseCount]atomic.Int64
+
+	mu    sync.Mutex
+	files map[*File]time.Duration
+}
+
+// A PhaseTiming is the time spent in a single phase.
+type PhaseTiming struct {
+	Phase    Phase
+	Duration time.Duration
+}
+
+// A FileTiming is the time spent loading a single file, and finding similarities starting in it.
+type FileTiming struct {
+	File     *File
+	Duration time.Duration
+}
+
+// String returns a human-readable name of p.
+func (p Phase) String() string {
+	switch p {
+	case LoadPhase:
+		return "loading"
+	case ExactSeedingPhase:
+		return "exact seeding"
+	case FuzzyMatchingPhase:
+		return "fuzzy matching"
+	case ExpansionPhase:
+		return "expansion"
+	case BlockSearchPhase:
+		return "block search"
+	case PreparationPhase:
+		return "preparation"
+	case DedupPhase:
+		return "dedup"
+	default:
+		return "unknown"
+	}
+}
+
+// Phases returns the time spent in each phase, in the order the phases are run.
+func (t *Timings) Phases() []PhaseTiming {
+	timings := make([]PhaseTiming, phaseCount)
+	for phase := range timings {
+		timings[phase] = PhaseTiming{
+			Phase:    Phase(phase),
+			Duration: time.Duration(t.phases[phase].Load()),
+		}
+	}
+
+	return timings
+}
+
+// Files returns the time spent for each file, sorted by time (descending), and then by file name.
+func (t *Timings) Files() []*FileTiming {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	timings := make([]*FileTiming, 0, len(t.files))
+	for file, dur := range t.files {
+		timings = append(timings, &FileTiming{File: file, Duration: dur})
+	}
+
+	sort.Slice(timings, func(a int, b int) bool {
+		if timings[a].Duration != timings[b].Duration {
+			return timings[a].Duration > timings[b].Duration
+		}
+
+		return timings[a].File.Name < timings[b].File.Name
+	})
+
+	return timings
+}
+
+// start returns the current time to measure a phase from, or the zero time if t is nil.
+func (t *Timings) start() time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+
+	return time.Now()
+}
+
+// record adds the time since start to phase, and returns the current time to measure the next phase from.
+// If t is nil, it does nothing.
+func (t *Timings) record(phase Phase, start time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+
+	now := time.Now()
+	t.phases[phase].Add(int64(now.Sub(start)))
+
+	return now
+}
+
+// recordFile adds the time since start to file. If t is nil, it does nothing.
+func (t *Timings) recordFile(file *File, start time.Time) {
+	if t == nil {
+		return
+	}
+
+	dur := time.Since(start)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.files == nil {
+		t.files = map[*File]time.Duration{}
+	}
+
+	t.files[file] += dur
+}
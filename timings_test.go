@@ -0,0 +1,38 @@
+package textsimilarity
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestSimilarities_Timings(t *testing.T) {
+	is := is.New(t)
+
+	files := []*File{
+		newFile("1.txt", "alpha bravo charlie\ndelta echo foxtrot\ngolf hotel india\n"),
+		newFile("2.txt", "alpha bravo charlie\ndelta echo foxtrot\ngolf hotel india\n"),
+	}
+
+	timings := Timings{}
+
+	sims := similaritiesWithOptions(t, files, &Options{MinSimilarLines: 3, Timings: &timings})
+	is.Equal(len(sims), 1)
+
+	phases := timings.Phases()
+	is.Equal(len(phases), int(phaseCount))
+	is.Equal(phases[0].Phase, LoadPhase)
+	is.Equal(phases[len(phases)-1].Phase, DedupPhase)
+
+	fileTimings := timings.Files()
+	is.Equal(len(fileTimings), 2)
+	is.True(fileTimings[0].Duration >= fileTimings[1].Duration)
+}
+
+func TestPhase_String(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(LoadPhase.String(), "loading")
+	is.Equal(FuzzyMatchingPhase.String(), "fuzzy matching")
+	is.Equal(phaseCount.String(), "unknown")
+}
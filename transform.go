@@ -0,0 +1,100 @@
+package textsimilarity
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// literalRegex matches string literals in double quotes, single quotes, or backquotes, and numeric literals.
+var literalRegex = regexp.MustCompile("\"(?:[^\"\\\\]|\\\\.)*\"|'(?:[^'\\\\]|\\\\.)*'|`[^`]*`|" +
+	`\b(?:0[xX][0-9a-fA-F_]+|\d[\d_]*(?:\.\d+)?(?:[eE][+-]?\d+)?)\b`)
+
+// numberPlaceholder is the placeholder that numeric literals are replaced by if MaskLiteralsFlag is set.
+const numberPlaceholder = "0"
+
+// A LineTransform is a rule to transform lines of text before they are compared.
+type LineTransform struct {
+	// Regex matches the parts of lines to replace.
+	Regex *regexp.Regexp
+
+	// Replacement is the text to replace matches of Regex with. It may refer to submatches, as in
+	// regexp.Regexp.ReplaceAllString.
+	Replacement string
+}
+
+// transformLine returns text with all transforms of opts applied in order, with trailing comments removed,
+// and with literals masked if MaskLiteralsFlag is set.
+func transformLine(text string, opts *Options) string {
+	for _, transform := range opts.LineTransforms {
+		text = transform.Regex.ReplaceAllString(text, transform.Replacement)
+	}
+
+	if len(opts.TrailingCommentMarkers) != 0 {
+		text = stripTrailingComment(text, opts.TrailingCommentMarkers)
+	}
+
+	if opts.flagSet(MaskLiteralsFlag) {
+		text = maskLiterals(text)
+	}
+
+	return text
+}
+
+// maskLiterals returns text with string literals replaced by empty strings using the same quotes,
+// and numeric literals replaced by numberPlaceholder.
+func maskLiterals(text string) string {
+	return literalRegex.ReplaceAllStringFunc(text, func(literal string) string {
+		switch quote := literal[0]; quote {
+		case '"', '\'', '`':
+			return strings.Repeat(string(quote), 2)
+		default:
+			return numberPlaceholder
+		}
+	})
+}
+
+// stripTrailingComment returns text without a comment starting with any of markers, if that comment follows
+// code. Markers inside string literals are ignored. Whitespace preceding the comment is removed as well.
+func stripTrailingComment(text string, markers []string) string {
+	code := false
+	quote := byte(0)
+
+	for idx := 0; idx < len(text); idx++ {
+		char := text[idx]
+
+		if quote != 0 {
+			switch {
+			case char == '\\' && quote != '`':
+				idx++
+			case char == quote:
+				quote = 0
+			}
+
+			continue
+		}
+
+		for _, marker := range markers {
+			if marker == "" || !strings.HasPrefix(text[idx:], marker) {
+				continue
+			}
+
+			if !code {
+				return text
+			}
+
+			return strings.TrimRightFunc(text[:idx], unicode.IsSpace)
+		}
+
+		switch char {
+		case '"', '\'', '`':
+			quote = char
+			code = true
+		case ' ', '\t':
+		default:
+			code = true
+		}
+	}
+
+	return text
+}
@@ -0,0 +1,128 @@
+package textsimilarity
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestTransformLine(t *testing.T) {
+	is := is.New(t)
+
+	opts := Options{
+		LineTransforms: []LineTransform{
+			{Regex: regexp.MustCompile(`\d+`), Replacement: "N"},
+			{Regex: regexp.MustCompile(`(\w+)=N`), Replacement: "${1}"},
+		},
+	}
+
+	is.Equal(transformLine("a=1 b=22 c", &opts), "a b c")
+	is.Equal(transformLine("a=1", &Options{}), "a=1")
+}
+
+func TestSimilarities_LineTransforms(t *testing.T) {
+	is := is.New(t)
+
+	files := []*File{
+		newFile("1.txt", "12:00:01 aaaaaaaaaa\n12:00:02 bbbbbbbbbb\n12:00:03 cccccccccc\n"),
+		newFile("2.txt", "13:30:41 aaaaaaaaaa\n13:30:42 bbbbbbbbbb\n13:30:43 cccccccccc\n"),
+	}
+
+	sims := similaritiesWithOptions(t, files, &Options{
+		MaxEditDistance: 2,
+		CaptureText:     true,
+		LineTransforms: []LineTransform{
+			{Regex: regexp.MustCompile(`^[\d:]+ `), Replacement: "TIME "},
+		},
+	})
+
+	is.Equal(len(sims), 1)
+	is.Equal(sims[0].Level, EqualSimilarityLevel)
+	is.Equal(sims[0].Occurrences[0].Text, "12:00:01 aaaaaaaaaa\n12:00:02 bbbbbbbbbb\n12:00:03 cccccccccc\n")
+	is.Equal(sims[0].Occurrences[1].Text, "13:30:41 aaaaaaaaaa\n13:30:42 bbbbbbbbbb\n13:30:43 cccccccccc\n")
+}
+
+func TestMaskLiterals(t *testing.T) {
+	tests := []struct {
+		given string
+		want  string
+	}{
+		{given: "retry(3)", want: "retry(0)"},
+		{given: "x := 1.5e-3 + 0xFF + 1_000", want: "x := 0 + 0 + 0"},
+		{given: "var1 = v2", want: "var1 = v2"},
+		{given: `fmt.Println("a \"b\" c", 'x', ` + "`d`" + `)`, want: `fmt.Println("", '', ` + "``" + `)`},
+		{given: `"42"`, want: `""`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.given, func(t *testing.T) {
+			is := is.New(t)
+			is.Equal(maskLiterals(test.given), test.want)
+		})
+	}
+}
+
+func TestSimilarities_MaskLiterals(t *testing.T) {
+	is := is.New(t)
+
+	newFiles := func() []*File {
+		return []*File{
+			newFile("1.txt", "retry(3, \"first\")\nsleep(100)\nreturn nil, errFailed\n"),
+			newFile("2.txt", "retry(5, \"second\")\nsleep(2500)\nreturn nil, errFailed\n"),
+		}
+	}
+
+	sims := similaritiesWithOptions(t, newFiles(), &Options{MinSimilarLines: 3, MaxEditDistance: 1})
+	is.Equal(len(sims), 0)
+
+	sims = similaritiesWithOptions(t, newFiles(), &Options{Flags: MaskLiteralsFlag, MinSimilarLines: 3, MaxEditDistance: 1})
+	is.Equal(len(sims), 1)
+	is.Equal(sims[0].Level, EqualSimilarityLevel)
+}
+
+func TestStripTrailingComment(t *testing.T) {
+	tests := []struct {
+		given string
+		want  string
+	}{
+		{given: "x := 1 // one", want: "x := 1"},
+		{given: "x = 1  # one", want: "x = 1"},
+		{given: "// just a comment", want: "// just a comment"},
+		{given: "\t# just a comment", want: "\t# just a comment"},
+		{given: `url := "http://example.com" // link`, want: `url := "http://example.com"`},
+		{given: `s := "a \"#\" b"`, want: `s := "a \"#\" b"`},
+		{given: "s := `\\` // raw", want: "s := `\\`"},
+		{given: "x := 1", want: "x := 1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.given, func(t *testing.T) {
+			is := is.New(t)
+			is.Equal(stripTrailingComment(test.given, []string{"//", "#"}), test.want)
+		})
+	}
+}
+
+func TestSimilarities_TrailingCommentMarkers(t *testing.T) {
+	is := is.New(t)
+
+	newFiles := func() []*File {
+		return []*File{
+			newFile("1.txt", "open(file) // open the file\nread(file)\nclose(file) // done\n"),
+			newFile("2.txt", "open(file) // the file must exist\nread(file)\nclose(file)\n"),
+		}
+	}
+
+	sims := similaritiesWithOptions(t, newFiles(), &Options{MinSimilarLines: 3, MaxEditDistance: 1})
+	is.Equal(len(sims), 0)
+
+	sims = similaritiesWithOptions(t, newFiles(), &Options{
+		MinSimilarLines:        3,
+		MaxEditDistance:        1,
+		TrailingCommentMarkers: []string{"//"},
+	})
+
+	is.Equal(len(sims), 1)
+	is.Equal(sims[0].Level, EqualSimilarityLevel)
+}
@@ -0,0 +1,174 @@
+package textsimilarity
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+
+	tsio "github.com/blizzy78/textsimilarity/internal/io"
+)
+
+// A Unit is a single unit of text that is compared, such as a line, a sentence, or a sequence of tokens.
+// Similarities are found between sequences of units of files, in the same way as between sequences of lines.
+type Unit struct {
+	// Text is the text of the unit. It must not contain line breaks.
+	Text string
+
+	// StartLine is the line of the original text the unit starts on (zero-based.)
+	StartLine int
+
+	// EndLine is the line after the line of the original text the unit ends on (zero-based, exclusive.)
+	EndLine int
+}
+
+// A Segmenter splits text into units.
+type Segmenter interface {
+	// Segment reads text from r, splits it into units, and calls fn with each unit, in order of the text. The
+	// ranges of lines of consecutive units may share lines, such as for sentences. If fn returns an error, Segment
+	// must return it.
+	Segment(r io.Reader, fn func(unit Unit) error) error
+}
+
+// LineSegmenter is a Segmenter that makes each line a unit. It is the default Segmenter.
+type LineSegmenter struct{}
+
+// TokenSegmenter is a Segmenter that splits text into sequences of whitespace-separated tokens, regardless of
+// line breaks. Tokens of each unit are separated by single spaces. This finds similarities in text that has been
+// reflowed, or in minified code.
+type TokenSegmenter struct {
+	// Tokens is the number of tokens of each unit. The last unit may have fewer tokens. If <= 0, each token is
+	// a unit.
+	Tokens int
+}
+
+var (
+	_ Segmenter = LineSegmenter{}
+	_ Segmenter = TokenSegmenter{}
+)
+
+// A unitLines is the range of lines of the original text of a unit.
+type unitLines struct {
+	// start is the starting line (zero-based.)
+	start int
+
+	// end is the ending line (zero-based, exclusive.)
+	end int
+}
+
+// Segment implements Segmenter.
+func (LineSegmenter) Segment(r io.Reader, fn func(unit Unit) error) error {
+	reader := bufio.NewReader(r)
+	buf := bytes.Buffer{}
+
+	for lineIdx := 0; ; lineIdx++ {
+		text, err := tsio.ReadLine(reader, &buf)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err //nolint:wrapcheck // wrapped by callers
+		}
+
+		if err := fn(Unit{Text: text, StartLine: lineIdx, EndLine: lineIdx + 1}); err != nil {
+			return err
+		}
+	}
+}
+
+// Segment implements Segmenter.
+func (s TokenSegmenter) Segment(r io.Reader, fn func(unit Unit) error) error {
+	size := max(s.Tokens, 1)
+	tokens := make([]string, 0, size)
+	unit := Unit{}
+
+	flush := func() error {
+		if len(tokens) == 0 {
+			return nil
+		}
+
+		unit.Text = strings.Join(tokens, " ")
+		tokens = tokens[:0]
+
+		return fn(unit)
+	}
+
+	err := LineSegmenter{}.Segment(r, func(line Unit) error {
+		for _, token := range strings.Fields(line.Text) {
+			if len(tokens) == 0 {
+				unit.StartLine = line.StartLine
+			}
+
+			tokens = append(tokens, token)
+			unit.EndLine = line.EndLine
+
+			if len(tokens) == size {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return flush()
+}
+
+// segmenter returns the Segmenter to use, according to o.
+func (o Options) segmenter() Segmenter {
+	if o.Segmenter == nil {
+		return LineSegmenter{}
+	}
+
+	return o.Segmenter
+}
+
+// lineUnits returns whether each unit is a line, according to o.
+func (o Options) lineUnits() bool {
+	_, ok := o.segmenter().(LineSegmenter)
+	return ok
+}
+
+// segmentedText returns a reader of the text of f to be split into units.
+func (f *File) segmentedText() io.Reader {
+	if f.Lines != nil {
+		return strings.NewReader(strings.Join(f.Lines, "\n") + "\n")
+	}
+
+	return f.R
+}
+
+// unitsToLines returns a copy of sim whose occurrences refer to the ranges of lines of the original texts of their
+// units, if units are not lines, according to opts. Otherwise, it returns sim. The ranges of units are kept in
+// FileOccurrence.StartUnit and FileOccurrence.EndUnit.
+func unitsToLines(sim *Similarity, opts *Options) *Similarity {
+	for _, occ := range sim.Occurrences {
+		occ.StartUnit = occ.Start
+		occ.EndUnit = occ.End
+	}
+
+	if opts.lineUnits() {
+		return sim
+	}
+
+	mapped := *sim
+	mapped.Occurrences = make([]*FileOccurrence, len(sim.Occurrences))
+
+	for idx, occ := range sim.Occurrences {
+		mappedOcc := *occ
+		mappedOcc.Start = occ.File.unitLines[occ.Start].start
+		mappedOcc.End = occ.File.unitLines[occ.End-1].end
+		mappedOcc.StartColumns = nil
+		mappedOcc.EndColumns = nil
+
+		mapped.Occurrences[idx] = &mappedOcc
+	}
+
+	return &mapped
+}
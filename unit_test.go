@@ -0,0 +1,73 @@
+package textsimilarity
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestTokenSegmenter(t *testing.T) {
+	is := is.New(t)
+
+	units := []Unit{}
+
+	err := TokenSegmenter{Tokens: 3}.Segment(strings.NewReader("a b\n\nc d e f\n  g\n"), func(unit Unit) error {
+		units = append(units, unit)
+		return nil
+	})
+	is.NoErr(err)
+
+	is.Equal(units, []Unit{
+		{Text: "a b c", StartLine: 0, EndLine: 3},
+		{Text: "d e f", StartLine: 2, EndLine: 3},
+		{Text: "g", StartLine: 3, EndLine: 4},
+	})
+
+	err = TokenSegmenter{Tokens: 3}.Segment(strings.NewReader("a b c"), func(Unit) error {
+		return errTest
+	})
+	is.Equal(err, errTest)
+}
+
+func TestSimilarities_Segmenter(t *testing.T) {
+	is := is.New(t)
+
+	tokens := make([]string, 24)
+	for idx := range tokens {
+		tokens[idx] = fmt.Sprintf("%x", sha256.Sum256([]byte(fmt.Sprintf("token %d", idx))))[:8]
+	}
+
+	// the same tokens, reflowed differently, following six other tokens
+	text1 := strings.Join(tokens[:5], " ") + "\n" + strings.Join(tokens[5:], " ") + "\n"
+	text2 := "a b c d e f\n" + strings.Join(tokens[:12], " ") + "\n" + strings.Join(tokens[12:], "\n") + "\n"
+
+	sims := similaritiesWithOptions(t, []*File{newFile("a.txt", text1), newFile("b.txt", text2)}, &Options{
+		MinSimilarLines: 3,
+		CaptureText:     true,
+		Segmenter:       TokenSegmenter{Tokens: 6},
+	})
+
+	is.Equal(len(sims), 1)
+	is.Equal(sims[0].Level, EqualSimilarityLevel)
+
+	occ1 := sims[0].Occurrences[0]
+	is.Equal(occ1.File.Name, "a.txt")
+	is.Equal(occ1.StartUnit, 0)
+	is.Equal(occ1.EndUnit, 4)
+	is.Equal(occ1.Start, 0)
+	is.Equal(occ1.End, 2)
+	is.Equal(occ1.File.LineCount(), 2)
+	is.Equal(occ1.File.UnitCount(), 4)
+	is.Equal(occ1.Text, strings.Join(tokens[:6], " ")+"\n"+strings.Join(tokens[6:12], " ")+"\n"+
+		strings.Join(tokens[12:18], " ")+"\n"+strings.Join(tokens[18:], " ")+"\n")
+
+	occ2 := sims[0].Occurrences[1]
+	is.Equal(occ2.File.Name, "b.txt")
+	is.Equal(occ2.StartUnit, 1)
+	is.Equal(occ2.EndUnit, 5)
+	is.Equal(occ2.Start, 1)
+	is.Equal(occ2.End, 14)
+}
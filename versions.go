@@ -0,0 +1,190 @@
+package textsimilarity
+
+const (
+	// UnchangedSimilarityChange is used for similarities whose occurrences all existed in the same files
+	// in the old version already.
+	UnchangedSimilarityChange = SimilarityChange(iota)
+
+	// MovedSimilarityChange is used for similarities whose text existed as often in the old version already,
+	// but not in the same files, for example because code has been moved between files, or files have been renamed.
+	MovedSimilarityChange
+
+	// IntroducedSimilarityChange is used for similarities with more occurrences of their text than in the old
+	// version, that is, text that has been copied.
+	IntroducedSimilarityChange
+)
+
+// A SimilarityChange classifies a similarity found in a new version of files, compared to an old version.
+type SimilarityChange int
+
+// A ClassifiedSimilarity is a similarity found in a new version of files, along with its classification.
+type ClassifiedSimilarity struct {
+	Similarity *Similarity
+
+	Change SimilarityChange
+
+	// Introduced are the occurrences whose text did not exist in the files of the same names in the old version.
+	// It is only set for IntroducedSimilarityChange, and may be empty if text has been copied within files.
+	Introduced []*FileOccurrence
+}
+
+// A blockKey identifies the text of an occurrence, using the hashes of its lines that are considered for
+// similarities.
+type blockKey struct {
+	// lines is the number of lines considered.
+	lines int
+
+	// hash is the polynomial hash of the hashes of the lines considered.
+	hash uint64
+}
+
+// add returns k with a line of hash lineHash appended.
+func (k blockKey) add(lineHash uint64) blockKey {
+	return blockKey{
+		lines: k.lines + 1,
+		hash:  k.hash*fnvPrime64 + lineHash,
+	}
+}
+
+// ClassifyChanges classifies sims, found in a new version of files, as unchanged, moved, or introduced, by
+// comparing them with oldFiles, the old version of the files, which are matched by name. This allows reporting
+// text that has been copied, rather than text that has merely been moved. sims must have been returned by
+// Similarities, using the same opts. Occurrences are compared by the exact text of their lines considered for
+// similarities, so an occurrence whose text has been edited counts as introduced. Similarities with truncated
+// occurrences are classified using the occurrences that have been reported only.
+func ClassifyChanges(oldFiles []*File, sims []*Similarity, opts *Options) ([]*ClassifiedSimilarity, error) {
+	wanted := map[blockKey]struct{}{}
+	lengths := map[int]struct{}{}
+
+	for _, sim := range sims {
+		for _, occ := range sim.Occurrences {
+			wanted[occ.block] = struct{}{}
+			lengths[occ.block.lines] = struct{}{}
+		}
+	}
+
+	// counts are the numbers of times blocks occur in the old version
+	counts := map[blockKey]int{}
+
+	// fileBlocks are the blocks in each file of the old version, by file name
+	fileBlocks := map[string]map[blockKey]struct{}{}
+
+	for _, file := range oldFiles {
+		if err := file.load(nil, opts); err != nil {
+			return nil, err
+		}
+
+		blocks := map[blockKey]struct{}{}
+
+		file.forEachBlock(lengths, opts, func(key blockKey) {
+			if _, ok := wanted[key]; !ok {
+				return
+			}
+
+			counts[key]++
+			blocks[key] = struct{}{}
+		})
+
+		fileBlocks[file.Name] = blocks
+
+		file.lines = nil
+		file.linesByHash = nil
+		file.lineClasses = nil
+	}
+
+	classified := make([]*ClassifiedSimilarity, len(sims))
+
+	for idx, sim := range sims {
+		classified[idx] = classifySimilarity(sim, counts, fileBlocks)
+	}
+
+	return classified, nil
+}
+
+// classifySimilarity returns the classification of sim, using the counts of blocks in the old version, and
+// the blocks in each file of the old version.
+func classifySimilarity(sim *Similarity, counts map[blockKey]int, fileBlocks map[string]map[blockKey]struct{}) *ClassifiedSimilarity {
+	newCounts := map[blockKey]int{}
+	inPlace := make([]bool, len(sim.Occurrences))
+	allInPlace := true
+
+	for idx, occ := range sim.Occurrences {
+		newCounts[occ.block]++
+
+		_, inPlace[idx] = fileBlocks[occ.File.Name][occ.block]
+		allInPlace = allInPlace && inPlace[idx]
+	}
+
+	copied := false
+
+	for key, count := range newCounts {
+		if counts[key] < count {
+			copied = true
+			break
+		}
+	}
+
+	classified := ClassifiedSimilarity{
+		Similarity: sim,
+	}
+
+	switch {
+	case copied:
+		classified.Change = IntroducedSimilarityChange
+		classified.Introduced = []*FileOccurrence{}
+
+		for idx, occ := range sim.Occurrences {
+			if !inPlace[idx] {
+				classified.Introduced = append(classified.Introduced, occ)
+			}
+		}
+
+	case allInPlace:
+		classified.Change = UnchangedSimilarityChange
+
+	default:
+		classified.Change = MovedSimilarityChange
+	}
+
+	return &classified
+}
+
+// forEachBlock calls fn with the keys of all blocks of f that consist of any of lengths lines considered for
+// similarities, according to opts. The lines of f must be loaded.
+func (f *File) forEachBlock(lengths map[int]struct{}, opts *Options, fn func(key blockKey)) {
+	hashes := make([]uint64, 0, f.lineCount)
+
+	for idx := 0; idx < f.lineCount; idx++ {
+		line := f.lines[idx]
+		if acceptLine(line, opts) {
+			hashes = append(hashes, line.hash)
+		}
+	}
+
+	for length := range lengths {
+		if length <= 0 || length > len(hashes) {
+			continue
+		}
+
+		// highPower is fnvPrime64^(length-1), the factor of the first line's hash in a block
+		highPower := uint64(1)
+		for i := 1; i < length; i++ {
+			highPower *= fnvPrime64
+		}
+
+		key := blockKey{}
+		for _, hash := range hashes[:length] {
+			key = key.add(hash)
+		}
+
+		fn(key)
+
+		for idx := length; idx < len(hashes); idx++ {
+			key.hash -= hashes[idx-length] * highPower
+			key.lines--
+			key = key.add(hashes[idx])
+
+			fn(key)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package textsimilarity
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestClassifyChanges(t *testing.T) {
+	is := is.New(t)
+
+	const (
+		block = "aaaaaaaaaa\nbbbbbbbbbb\ncccccccccc\n"
+		other = "xxxxxxxxxx\nyyyyyyyyyy\nzzzzzzzzzz\n"
+	)
+
+	opts := Options{MinSimilarLines: 3}
+
+	classify := func(oldFiles []*File, newFiles []*File) []*ClassifiedSimilarity {
+		t.Helper()
+
+		sims := similaritiesWithOptions(t, newFiles, &opts)
+		is.Equal(len(sims), 1)
+
+		classified, err := ClassifyChanges(oldFiles, sims, &opts)
+		is.NoErr(err)
+		is.Equal(len(classified), 1)
+
+		return classified
+	}
+
+	classified := classify(
+		[]*File{newFile("a.go", block), newFile("b.go", block)},
+		[]*File{newFile("a.go", other+block), newFile("b.go", block)},
+	)
+
+	is.Equal(classified[0].Change, UnchangedSimilarityChange)
+	is.Equal(len(classified[0].Introduced), 0)
+
+	classified = classify(
+		[]*File{newFile("a.go", block), newFile("b.go", block), newFile("c.go", other)},
+		[]*File{newFile("a.go", block), newFile("c.go", block)},
+	)
+
+	is.Equal(classified[0].Change, MovedSimilarityChange)
+	is.Equal(len(classified[0].Introduced), 0)
+
+	classified = classify(
+		[]*File{newFile("a.go", block), newFile("b.go", other)},
+		[]*File{newFile("a.go", block), newFile("b.go", other+block)},
+	)
+
+	is.Equal(classified[0].Change, IntroducedSimilarityChange)
+	is.Equal(len(classified[0].Introduced), 1)
+	is.Equal(classified[0].Introduced[0].File.Name, "b.go")
+}
@@ -0,0 +1,72 @@
+package textsimilarity
+
+import "regexp"
+
+// LineWeights specifies the weights of lines, so that trivial lines, such as closing braces, contribute less to
+// Options.MinSimilarLines than substantive lines. The weight of a line is determined by the first of Rules that
+// matches the line, or else by Func, or else by FullLength. Lines weigh 1 if none of them apply.
+type LineWeights struct {
+	// Rules assign weights to lines matching regular expressions.
+	Rules []LineWeightRule
+
+	// Func, if set, returns the weight of a line of text that does not match any of Rules.
+	Func func(text string) float64
+
+	// FullLength, if > 0, is the length (in runes, without leading and trailing whitespace) at which lines weigh 1.
+	// Shorter lines weigh proportionally less.
+	FullLength int
+}
+
+// A LineWeightRule assigns a weight to lines matching a regular expression.
+type LineWeightRule struct {
+	// Regex matches the lines to assign Weight to.
+	Regex *regexp.Regexp
+
+	// Weight is the weight of matching lines.
+	Weight float64
+}
+
+// weight returns the weight of line according to w.
+func (w *LineWeights) weight(line *fileLine) float64 {
+	for _, rule := range w.Rules {
+		if rule.Regex.MatchString(line.text) {
+			return rule.Weight
+		}
+	}
+
+	switch {
+	case w.Func != nil:
+		return w.Func(line.text)
+
+	case w.FullLength > 0 && line.lengthTrimmed < w.FullLength:
+		return float64(line.lengthTrimmed) / float64(w.FullLength)
+
+	default:
+		return 1
+	}
+}
+
+// enoughLines returns whether o has at least opts.MinSimilarLines lines. If opts.LineWeights is set, the
+// lines of o that are considered for similarities must have a total weight of at least opts.MinSimilarLines
+// instead.
+func (o *FileOccurrence) enoughLines(opts *Options) bool {
+	if opts.LineWeights == nil {
+		return o.End-o.Start >= opts.MinSimilarLines
+	}
+
+	weight := 0.0
+
+	for l := o.Start; l < o.End; l++ {
+		line := o.File.lines[l]
+		if !acceptLine(line, opts) {
+			continue
+		}
+
+		weight += line.weight
+		if weight >= float64(opts.MinSimilarLines) {
+			return true
+		}
+	}
+
+	return false
+}
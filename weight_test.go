@@ -0,0 +1,53 @@
+package textsimilarity
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestLineWeights_Weight(t *testing.T) {
+	is := is.New(t)
+
+	weights := LineWeights{
+		Rules: []LineWeightRule{
+			{Regex: regexp.MustCompile(`^\s*}\s*$`), Weight: 0},
+		},
+		FullLength: 20,
+	}
+
+	is.Equal(weights.weight(newFileLine("\t}")), 0.0)
+	is.Equal(weights.weight(newFileLine("  aaaaaaaaaa  ")), 0.5)
+	is.Equal(weights.weight(newFileLine("aaaaaaaaaaaaaaaaaaaaaaaaa")), 1.0)
+
+	weights.Func = func(_ string) float64 { return 2 }
+	is.Equal(weights.weight(newFileLine("aaaaaaaaaa")), 2.0)
+	is.Equal(weights.weight(newFileLine("}")), 0.0)
+}
+
+func TestSimilarities_LineWeights(t *testing.T) {
+	is := is.New(t)
+
+	newFiles := func() []*File {
+		return []*File{
+			newFile("1.txt", "aaaaaaaaaaaaaaaaaaaa\n}\n}\n}\n}\nxxxxxxxxxx\nbbbbbbbbbbbbbbbbbbbb\ncccccccccccccccccccc\ndddddddddddddddddddd\n"),
+			newFile("2.txt", "aaaaaaaaaaaaaaaaaaaa\n}\n}\n}\n}\nyyyyyyyyyy\nbbbbbbbbbbbbbbbbbbbb\ncccccccccccccccccccc\ndddddddddddddddddddd\n"),
+		}
+	}
+
+	sims := similaritiesWithOptions(t, newFiles(), &Options{MinSimilarLines: 3})
+	is.Equal(len(sims), 2)
+
+	sims = similaritiesWithOptions(t, newFiles(), &Options{
+		MinSimilarLines: 3,
+		LineWeights: &LineWeights{
+			Rules: []LineWeightRule{
+				{Regex: regexp.MustCompile(`^}$`), Weight: 0.1},
+			},
+		},
+	})
+
+	is.Equal(len(sims), 1)
+	is.Equal(sims[0].Occurrences[0].Start, 6)
+}
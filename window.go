@@ -0,0 +1,270 @@
+package textsimilarity
+
+import (
+	"context"
+	"sort"
+)
+
+// A windowPair is the result of comparing two corresponding lines of windows.
+type windowPair int8
+
+const (
+	// ignoredWindowPair is a pair of lines that are both not considered for similarities. They are not counted.
+	ignoredWindowPair = windowPair(iota)
+
+	// similarWindowPair is a pair of similar lines.
+	similarWindowPair
+
+	// differentWindowPair is a pair of different lines, or of lines only one of which is considered.
+	differentWindowPair
+)
+
+// A windowRun is a run of consecutive windows of Options.WindowLines lines in a file and one of its peers, on the
+// same diagonal, that are all similar enough.
+type windowRun struct {
+	// start1 is the starting line of the first window in the file (zero-based.)
+	start1 int
+
+	// start2 is the starting line of the first window in peer (zero-based.)
+	start2 int
+
+	// windows is the number of windows in the run.
+	windows int
+}
+
+// windowSimilarities returns all similarities between file and its peers that start in file between startLine
+// and endLine (exclusive), found by sliding a window of Options.WindowLines lines across file and each peer,
+// according to opts. Windows are similar if the fraction of their corresponding pairs of lines that are similar
+// is at least Options.MinWindowSimilarity, not counting pairs of lines that are both not considered for
+// similarities. Overlapping similar windows on the same diagonal are merged into a
+// single similarity of two occurrences. Similarities are accepted in order of their starting lines, and similarities
+// overlapping those accepted earlier in the same peer are dropped.
+func windowSimilarities(ctx context.Context, file *fileToCheck, startLine int, endLine int, opts *Options) []*Similarity {
+	start := opts.Timings.start()
+	defer opts.Timings.record(FuzzyMatchingPhase, start)
+
+	sims := []*Similarity{}
+
+	for _, peer := range file.peers {
+		if contextDone(ctx) {
+			return sims
+		}
+
+		runs := windowRuns(ctx, file, peer, startLine, endLine, opts)
+
+		sort.SliceStable(runs, func(a int, b int) bool {
+			if runs[a].start1 != runs[b].start1 {
+				return runs[a].start1 < runs[b].start1
+			}
+
+			return runs[a].start2 < runs[b].start2
+		})
+
+		for _, run := range runs {
+			end1 := run.start1 + run.windows - 1 + opts.WindowLines
+			end2 := run.start2 + run.windows - 1 + opts.WindowLines
+
+			if anyLineSet(peer.linesDone, run.start2, end2) || peer.f == file.f && anyLineSet(peer.linesDone, run.start1, end1) {
+				continue
+			}
+
+			occs := []*FileOccurrence{
+				{File: file.f, Start: run.start1, End: end1, fileToCheck: file},
+				{File: peer.f, Start: run.start2, End: end2, fileToCheck: peer},
+			}
+
+			// lines of peer are only part of a single similarity with file
+			for l := run.start2; l < end2; l++ {
+				peer.linesDone.set(l, true)
+			}
+
+			if peer.f == file.f {
+				for l := run.start1; l < end1; l++ {
+					peer.linesDone.set(l, true)
+				}
+			}
+
+			sims = append(sims, &Similarity{
+				Occurrences: occs,
+				Level:       windowLevel(occs, opts),
+			})
+		}
+	}
+
+	sort.SliceStable(sims, func(a int, b int) bool {
+		return sims[a].Occurrences[0].Start < sims[b].Occurrences[0].Start
+	})
+
+	return sims
+}
+
+// windowRuns returns all runs of similar windows of file that start between startLine and endLine (exclusive),
+// and windows of peer, according to opts. Windows containing lines that are done in file or peer, or that span
+// multiple scopes, are not considered. When comparing file with itself, windows do not overlap.
+func windowRuns(ctx context.Context, file *fileToCheck, peer *fileToCheck, startLine int, endLine int, opts *Options) []windowRun {
+	size := opts.WindowLines
+
+	lines1 := file.f.lines
+	lines2 := peer.f.lines
+
+	endLine = min(endLine, len(lines1)-size+1)
+	if endLine <= startLine || len(lines2) < size {
+		return nil
+	}
+
+	valid1 := validWindows(file, size)
+	valid2 := validWindows(peer, size)
+
+	// pairs are the pairs of lines of the current window, indexed by line modulo size
+	pairs := make([]windowPair, size)
+
+	// counts are the numbers of pairs of the current window, by windowPair
+	counts := [3]int{}
+
+	runs := []windowRun{}
+
+	// each diagonal is a fixed offset between the lines of file and peer
+	for offset := -endLine + 1; offset <= len(lines2)-size; offset++ {
+		if contextDone(ctx) {
+			return runs
+		}
+
+		// when comparing file with itself, windows must not overlap
+		if peer.f == file.f && offset < size {
+			continue
+		}
+
+		first := max(startLine, -offset)
+		last := min(endLine, len(lines2)-size+1-offset)
+		if first >= last {
+			continue
+		}
+
+		counts = [3]int{}
+
+		for l := first; l < first+size; l++ {
+			pairs[l%size] = compareWindowLines(lines1[l], lines2[l+offset], opts)
+			counts[pairs[l%size]]++
+		}
+
+		run := windowRun{}
+
+		for start1 := first; start1 < last; start1++ {
+			if start1 > first {
+				// slide window by one line
+				counts[pairs[(start1-1)%size]]--
+
+				l := start1 + size - 1
+				pairs[l%size] = compareWindowLines(lines1[l], lines2[l+offset], opts)
+				counts[pairs[l%size]]++
+			}
+
+			if similarWindow(counts, opts) && valid1[start1] && valid2[start1+offset] {
+				if run.windows == 0 {
+					run.start1 = start1
+					run.start2 = start1 + offset
+				}
+
+				run.windows++
+
+				continue
+			}
+
+			if run.windows > 0 {
+				runs = append(runs, run)
+				run = windowRun{}
+			}
+		}
+
+		if run.windows > 0 {
+			runs = append(runs, run)
+		}
+	}
+
+	return runs
+}
+
+// validWindows returns whether each window of size lines in file may be compared, that is, whether it does not
+// contain any lines that are done, and does not span multiple scopes.
+func validWindows(file *fileToCheck, size int) []bool {
+	valid := make([]bool, max(len(file.f.lines)-size+1, 0))
+
+	done := 0
+
+	for l := 0; l < len(file.f.lines); l++ {
+		if file.linesDone.isSet(l) {
+			done++
+		}
+
+		if l >= size && file.linesDone.isSet(l-size) {
+			done--
+		}
+
+		start := l - size + 1
+		if start >= 0 {
+			valid[start] = done == 0 && file.f.sameScope(start, l)
+		}
+	}
+
+	return valid
+}
+
+// similarWindow returns whether a window with counts pairs of lines, by windowPair, is similar, according to opts.
+func similarWindow(counts [3]int, opts *Options) bool {
+	minSim := opts.MinWindowSimilarity
+	if minSim <= 0 {
+		minSim = DefaultMinWindowSimilarity
+	}
+
+	counted := counts[similarWindowPair] + counts[differentWindowPair]
+
+	return counted > 0 && float64(counts[similarWindowPair]) >= minSim*float64(counted)
+}
+
+// compareWindowLines compares line1 and line2 of two windows, according to opts.
+func compareWindowLines(line1 *fileLine, line2 *fileLine, opts *Options) windowPair {
+	accept1 := acceptLine(line1, opts)
+	accept2 := acceptLine(line2, opts)
+
+	switch {
+	case !accept1 && !accept2:
+		return ignoredWindowPair
+	case accept1 != accept2, linesSimilarity(line1, line2, opts) == differentSimilarityLevel:
+		return differentWindowPair
+	default:
+		return similarWindowPair
+	}
+}
+
+// windowLevel returns the level of a similarity of the two occurrences occs of the same number of lines found by
+// windowSimilarities. It is the lowest level of their corresponding lines, and at most SimilarSimilarityLevel if
+// any of them are different.
+func windowLevel(occs []*FileOccurrence, opts *Options) SimilarityLevel {
+	level := EqualSimilarityLevel
+
+	for l := 0; l < occs[0].End-occs[0].Start; l++ {
+		line1 := occs[0].File.lines[occs[0].Start+l]
+		line2 := occs[1].File.lines[occs[1].Start+l]
+
+		switch compareWindowLines(line1, line2, opts) {
+		case ignoredWindowPair:
+		case differentWindowPair:
+			level = min(level, SimilarSimilarityLevel)
+		case similarWindowPair:
+			level = min(level, linesSimilarity(line1, line2, opts))
+		}
+	}
+
+	return level
+}
+
+// anyLineSet returns whether any of the lines from start to end (exclusive) are set in lines.
+func anyLineSet(lines *bitVector, start int, end int) bool {
+	for l := start; l < end; l++ {
+		if lines.isSet(l) {
+			return true
+		}
+	}
+
+	return false
+}
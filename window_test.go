@@ -0,0 +1,89 @@
+package textsimilarity
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestSimilarities_Window(t *testing.T) {
+	lines := make([]string, 20)
+	for idx := range lines {
+		lines[idx] = fmt.Sprintf("%x", sha256.Sum256([]byte(fmt.Sprintf("line %d", idx))))
+	}
+
+	// b.txt has every fifth line replaced
+	linesB := append([]string{}, lines...)
+	for idx := 2; idx < len(linesB); idx += 5 {
+		linesB[idx] = fmt.Sprintf("%x", sha256.Sum256([]byte(fmt.Sprintf("noise %d", idx))))
+	}
+
+	newFiles := func() []*File {
+		return []*File{
+			newFile("a.txt", strings.Join(lines, "\n")+"\n"),
+			newFile("b.txt", strings.Join(linesB, "\n")+"\n"),
+		}
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		is := is.New(t)
+
+		sims := similaritiesWithOptions(t, newFiles(), &Options{MinSimilarLines: 8})
+		is.Equal(len(sims), 0)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		is := is.New(t)
+
+		sims := similaritiesWithOptions(t, newFiles(), &Options{WindowLines: 10, MinWindowSimilarity: 0.8})
+		is.Equal(len(sims), 1)
+		is.Equal(sims[0].Level, SimilarSimilarityLevel)
+		is.Equal(len(sims[0].Occurrences), 2)
+
+		for _, occ := range sims[0].Occurrences {
+			is.Equal(occ.Start, 0)
+			is.Equal(occ.End, 20)
+		}
+	})
+
+	t.Run("threshold", func(t *testing.T) {
+		is := is.New(t)
+
+		sims := similaritiesWithOptions(t, newFiles(), &Options{WindowLines: 10, MinWindowSimilarity: 0.9})
+		is.Equal(len(sims), 0)
+	})
+}
+
+func TestSimilarities_WindowSameFile(t *testing.T) {
+	is := is.New(t)
+
+	lines := make([]string, 6)
+	for idx := range lines {
+		lines[idx] = fmt.Sprintf("%x", sha256.Sum256([]byte(fmt.Sprintf("line %d", idx))))
+	}
+
+	text := strings.Join(lines, "\n") + "\nfoo\n" + strings.Join(lines, "\n") + "\n"
+
+	sims := similaritiesWithOptions(t, []*File{newFile("a.txt", text)}, &Options{WindowLines: 5, MinWindowSimilarity: 1})
+	is.Equal(len(sims), 1)
+	is.Equal(sims[0].Level, EqualSimilarityLevel)
+	is.Equal(sims[0].Occurrences[0].Start, 0)
+	is.Equal(sims[0].Occurrences[0].End, 6)
+	is.Equal(sims[0].Occurrences[1].Start, 7)
+	is.Equal(sims[0].Occurrences[1].End, 13)
+}
+
+func TestSimilarWindow(t *testing.T) {
+	is := is.New(t)
+
+	opts := Options{MinWindowSimilarity: 0.75}
+
+	is.True(similarWindow([3]int{ignoredWindowPair: 0, similarWindowPair: 3, differentWindowPair: 1}, &opts))
+	is.True(!similarWindow([3]int{ignoredWindowPair: 0, similarWindowPair: 2, differentWindowPair: 2}, &opts))
+	is.True(similarWindow([3]int{ignoredWindowPair: 2, similarWindowPair: 3, differentWindowPair: 1}, &opts))
+	is.True(!similarWindow([3]int{ignoredWindowPair: 4}, &opts))
+	is.True(!similarWindow([3]int{similarWindowPair: 3, differentWindowPair: 1}, &Options{}))
+}
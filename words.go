@@ -0,0 +1,35 @@
+package textsimilarity
+
+import (
+	"strings"
+
+	"github.com/blizzy78/textsimilarity/levenshtein"
+)
+
+// wordsSimilarity returns the similarity level between fileLine1 and fileLine2, whose texts are known to differ,
+// using the Levenshtein distance between their words.
+func wordsSimilarity(fileLine1 *fileLine, fileLine2 *fileLine, maxDist int) SimilarityLevel {
+	// the distance is at least the difference in number of words
+	if abs(len(fileLine1.words)-len(fileLine2.words)) > maxDist {
+		return differentSimilarityLevel
+	}
+
+	if _, ok := levenshtein.DistanceAtMost(fileLine1.words, fileLine2.words, maxDist); !ok {
+		return differentSimilarityLevel
+	}
+
+	return SimilarSimilarityLevel
+}
+
+// wordHashes returns the hashes of the whitespace-separated words of text. The hashes can be compared like runes
+// to calculate the Levenshtein distance between words. Distinct words may have the same hash, but that is unlikely.
+func wordHashes(text string) []rune {
+	words := strings.Fields(text)
+	hashes := make([]rune, len(words))
+
+	for idx, word := range words {
+		hashes[idx] = rune(hashString(word) >> 32) //nolint:gosec // truncation is intended
+	}
+
+	return hashes
+}
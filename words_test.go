@@ -0,0 +1,44 @@
+package textsimilarity
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestSimilarities_WordDistance(t *testing.T) {
+	is := is.New(t)
+
+	newFiles := func() []*File {
+		return []*File{
+			newFile("1.txt", "The quick brown fox jumps over the lazy dog.\n"+
+				"It was the best of times, it was the worst of times.\n"+
+				"Call me Ishmael, said the sailor to nobody in particular.\n"),
+			newFile("2.txt", "The quick brown fox jumps over the sleepy dog.\n"+
+				"It was the best of times, it was the worst of summers.\n"+
+				"Call me Ishmael, said the sailor to nobody in particular.\n"),
+		}
+	}
+
+	sims := similaritiesWithOptions(t, newFiles(), &Options{MinSimilarLines: 3, MaxEditDistance: 2})
+	is.Equal(len(sims), 0)
+
+	sims = similaritiesWithOptions(t, newFiles(), &Options{Flags: WordDistanceFlag, MinSimilarLines: 3, MaxEditDistance: 1})
+	is.Equal(len(sims), 1)
+	is.Equal(sims[0].Level, SimilarSimilarityLevel)
+	is.Equal(sims[0].Occurrences[0].End, 3)
+}
+
+func TestWordsSimilarity(t *testing.T) {
+	is := is.New(t)
+
+	line := func(text string) *fileLine {
+		return &fileLine{text: text, words: wordHashes(text)}
+	}
+
+	is.Equal(wordsSimilarity(line("a b c d"), line("a x c d"), 1), SimilarSimilarityLevel)
+	is.Equal(wordsSimilarity(line("a b c d"), line("a x c y"), 1), differentSimilarityLevel)
+	is.Equal(wordsSimilarity(line("a b c d"), line("a b c"), 1), SimilarSimilarityLevel)
+	is.Equal(wordsSimilarity(line("a b c d"), line("a b"), 1), differentSimilarityLevel)
+	is.Equal(wordsSimilarity(line("a  b\tc d"), line("a b c x"), 1), SimilarSimilarityLevel)
+}